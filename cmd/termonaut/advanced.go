@@ -8,6 +8,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/oiahoon/termonaut/internal/analytics"
 	"github.com/oiahoon/termonaut/internal/api"
 	"github.com/oiahoon/termonaut/internal/categories"
 	"github.com/oiahoon/termonaut/internal/config"
@@ -31,7 +32,7 @@ func initAdvancedDB() (*database.DB, error) {
 		logger.SetLevel(logrus.DebugLevel)
 	}
 
-	db, err := database.New(cfg.DataDir, logger)
+	db, err := openDatabase(cfg.DataDir, logger)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
@@ -264,7 +265,7 @@ func createFilterCmd() *cobra.Command {
 				}
 
 				classifier := categories.NewCommandClassifier()
-				category := classifier.ClassifyCommand(cmd.Command)
+				category := classifier.CommandCategory(cmd)
 				categoryInfo := classifier.GetCategoryInfo(category)
 
 				fmt.Printf("%s [%s] %s %s\n",
@@ -589,6 +590,13 @@ func createAPICmd() *cobra.Command {
 			defer db.Close()
 
 			server := api.NewAPIServer(db, port)
+			if cfg, err := config.Load(); err == nil && len(cfg.Metrics) > 0 {
+				engine, err := analytics.NewMetricEngine(cfg.Metrics)
+				if err != nil {
+					return fmt.Errorf("invalid metrics configuration: %w", err)
+				}
+				server.SetMetrics(engine)
+			}
 
 			fmt.Printf("🚀 Starting Termonaut API Server\n")
 			fmt.Printf("Port: %d\n", port)
@@ -622,11 +630,25 @@ func createAPICmd() *cobra.Command {
 			fmt.Printf("    GET /api/v1/stats/basic\n")
 			fmt.Printf("    GET /api/v1/stats/gamification\n")
 			fmt.Printf("    GET /api/v1/stats/productivity\n\n")
+			fmt.Printf("  Progress:\n")
+			fmt.Printf("    GET /api/v1/progress/forecast\n")
+			fmt.Printf("    GET /api/v1/progress/history?days=30&at=YYYY-MM-DD\n\n")
+			fmt.Printf("  Analytics:\n")
+			fmt.Printf("    GET /api/v1/analytics/anomalies?days=30\n")
+			fmt.Printf("    GET /api/v1/analytics/tools?tool=kubectl\n")
+			fmt.Printf("    GET /api/v1/analytics/recommendations?all=true\n")
+			fmt.Printf("    POST|DELETE /api/v1/analytics/recommendations/{id}/dismiss\n\n")
+			fmt.Printf("  Metrics:\n")
+			fmt.Printf("    GET /api/v1/metrics\n")
+			fmt.Printf("    GET /api/v1/metrics/{name}/series?days=30\n\n")
+			fmt.Printf("  Query:\n")
+			fmt.Printf("    POST /api/v1/query {\"query\": \"count() by tool where since=30d\"}\n\n")
 			fmt.Printf("  Commands:\n")
 			fmt.Printf("    GET /api/v1/commands?limit=50\n")
 			fmt.Printf("    POST /api/v1/commands/search\n\n")
 			fmt.Printf("  Categories:\n")
-			fmt.Printf("    GET /api/v1/categories\n\n")
+			fmt.Printf("    GET /api/v1/categories\n")
+			fmt.Printf("    GET /api/v1/categories/stats\n\n")
 			fmt.Printf("  Scoring Rules:\n")
 			fmt.Printf("    GET /api/v1/scoring/rules\n")
 			fmt.Printf("    POST /api/v1/scoring/rules\n\n")
@@ -653,7 +675,13 @@ func createAnalyticsCmd() *cobra.Command {
 		Long:  "Generate sophisticated analytics reports and insights about your terminal usage",
 	}
 
-	insightsCmd := &cobra.Command{
+	cmd.AddCommand(createInsightsCmd())
+	return cmd
+}
+
+// createInsightsCmd creates the usage insights command
+func createInsightsCmd() *cobra.Command {
+	return &cobra.Command{
 		Use:   "insights",
 		Short: "💡 Generate usage insights",
 		RunE: func(cmd *cobra.Command, args []string) error {
@@ -668,7 +696,7 @@ func createAnalyticsCmd() *cobra.Command {
 				Limit: 1000, // Analyze last 1000 commands
 			}
 
-			analytics, err := advancedStats.GetAdvancedAnalytics(filter)
+			report, err := advancedStats.GetAdvancedAnalytics(filter)
 			if err != nil {
 				return fmt.Errorf("failed to get analytics: %w", err)
 			}
@@ -677,19 +705,20 @@ func createAnalyticsCmd() *cobra.Command {
 			fmt.Printf("====================================\n\n")
 
 			fmt.Printf("📈 Overview:\n")
-			fmt.Printf("  Total Commands Analyzed: %d\n", analytics.TotalCommands)
+			fmt.Printf("  Total Commands Analyzed: %d\n", report.TotalCommands)
 
-			if analytics.TimeRange != nil {
+			if report.TimeRange != nil {
 				fmt.Printf("  Time Range: %s to %s\n",
-					analytics.TimeRange.Start.Format("2006-01-02"),
-					analytics.TimeRange.End.Format("2006-01-02"))
-				fmt.Printf("  Analysis Period: %v\n", analytics.TimeRange.Duration)
+					report.TimeRange.Start.Format("2006-01-02"),
+					report.TimeRange.End.Format("2006-01-02"))
+				fmt.Printf("  Analysis Period: %v\n", report.TimeRange.Duration)
 			}
 
-			fmt.Printf("\n💡 Recommendations:\n")
-			for i, rec := range analytics.Recommendations {
-				fmt.Printf("  %d. %s\n", i+1, rec)
-			}
+			fmt.Printf("\n🔎 Unusual Activity:\n")
+			fmt.Print(analytics.FormatAnomalies(report.Anomalies, 10))
+
+			fmt.Println()
+			fmt.Print(analytics.FormatRecommendations(report.Recommendations, 5))
 
 			fmt.Printf("\n🔧 Advanced features available:\n")
 			fmt.Printf("  • Custom scoring rules: termonaut advanced scoring list\n")
@@ -700,9 +729,6 @@ func createAnalyticsCmd() *cobra.Command {
 			return nil
 		},
 	}
-
-	cmd.AddCommand(insightsCmd)
-	return cmd
 }
 
 // setupBashCompletion sets up bash completion
@@ -819,7 +845,7 @@ func runGitHubSyncNowCommand(cmd *cobra.Command, args []string) error {
 	logger := logrus.New()
 	logger.SetLevel(logrus.WarnLevel)
 
-	db, err := database.New(config.GetDataDir(cfg), logger)
+	db, err := openDatabase(config.GetDataDir(cfg), logger)
 	if err != nil {
 		return fmt.Errorf("failed to initialize database: %w", err)
 	}
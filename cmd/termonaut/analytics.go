@@ -1,11 +1,17 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/oiahoon/termonaut/internal/analytics"
 	"github.com/oiahoon/termonaut/internal/config"
 	"github.com/oiahoon/termonaut/internal/database"
+	"github.com/oiahoon/termonaut/internal/privacy"
 	"github.com/spf13/cobra"
 )
 
@@ -19,6 +25,187 @@ efficiency metrics, category insights, and automation opportunities.`,
 	},
 }
 
+var analyticsTyposCmd = &cobra.Command{
+	Use:   "typos",
+	Short: "Show mistyped commands",
+	Long: `Detect commands that look like near misses of commands you use often, and
+suggest corrections. Commands that fail with "command not found" get a
+suggestion right away from the shell hook.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runAnalyticsTyposCommand(cmd, args)
+	},
+}
+
+var analyticsDurationsCmd = &cobra.Command{
+	Use:   "durations",
+	Short: "Show slow commands and duration percentiles",
+	Long: `Display per-command p50/p90/p99 execution times, the commands that consume
+the most wall time, and commands that are getting slower over time.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runAnalyticsDurationsCommand(cmd, args)
+	},
+}
+
+var analyticsFocusCmd = &cobra.Command{
+	Use:   "focus",
+	Short: "Show deep-work blocks and best focus times",
+	Long: `Detect deep-work blocks: sustained activity in a single project or category
+without long gaps or context switches. Shows how many blocks you had, their
+average length, your best times of day and a timeline of recent days.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runAnalyticsFocusCommand(cmd, args)
+	},
+}
+
+var analyticsToolsCmd = &cobra.Command{
+	Use:   "tools [tool]",
+	Short: "Show when tools were adopted and how their use matured",
+	Long: `Show when each tool (kubectl, terraform, rg, ...) first and last appeared in
+your history, along with usage trends, failure rates and flag variety.
+
+Pass a tool name to see its weekly learning curve.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runAnalyticsToolsCommand(cmd, args)
+	},
+}
+
+var analyticsToolCmd = &cobra.Command{
+	Use:   "tool <name>",
+	Short: "Show subcommand and flag usage for a tool",
+	Long: `Break down how you use a tool: subcommand frequencies, flag popularity and
+common flags you never use. Commands are normalized to their tool, subcommand
+and flags, so "git commit -m x" and "git commit -m y" count together.
+
+Examples:
+  termonaut analytics tool git
+  termonaut analytics tool kubectl --json`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runAnalyticsToolCommand(cmd, args)
+	},
+}
+
+var analyticsDirsCmd = &cobra.Command{
+	Use:   "dirs [path]",
+	Short: "Show activity hotspots by directory",
+	Long: `Aggregate activity by working directory into a tree showing commands, time
+spent and failure rate for each subtree. Directories with a single
+subdirectory are collapsed into one line.
+
+Paths under your home directory are shown as ~ when file-path sanitization is
+enabled. Pass a path to show only that subtree.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runAnalyticsDirsCommand(cmd, args)
+	},
+}
+
+var analyticsMetricsCmd = &cobra.Command{
+	Use:   "metrics [name]",
+	Short: "Show user-defined metrics from config.toml",
+	Long: `Show the metrics defined by [[metrics]] tables in ~/.termonaut/config.toml.
+Each metric counts matching commands (counter) or sums their run time (timer).
+
+Example definition:
+  [[metrics]]
+  name = "deploys"
+  description = "kubectl apply against production"
+  pattern = "^kubectl .*apply"
+  directory = "/deploy"
+  status = "success"
+  badge = true
+
+Conditions: pattern and exclude (regular expressions on the command),
+category, directory (regular expression on the working directory), status
+(any, success, failure) and exit_code. Pass a metric name to show its daily
+series.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runAnalyticsMetricsCommand(cmd, args)
+	},
+}
+
+var analyticsRecommendationsCmd = &cobra.Command{
+	Use:   "recommendations",
+	Short: "Show recommendations based on your recent activity",
+	Long: `Evaluate recommendation rules against the last 30 days of history. Each
+recommendation comes with a severity, the evidence that triggered it and an
+action to take. Dismissed recommendations are hidden until restored.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runAnalyticsRecommendationsCommand(cmd, args)
+	},
+}
+
+var analyticsRecommendationsDismissCmd = &cobra.Command{
+	Use:   "dismiss <id>",
+	Short: "Hide a recommendation",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runAnalyticsRecommendationsDismissCommand(cmd, args)
+	},
+}
+
+var analyticsRecommendationsRestoreCmd = &cobra.Command{
+	Use:   "restore <id>",
+	Short: "Show a dismissed recommendation again",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runAnalyticsRecommendationsRestoreCommand(cmd, args)
+	},
+}
+
+var didYouMeanCmd = &cobra.Command{
+	Use:    "did-you-mean [command]",
+	Short:  "Suggest a correction for a command that was not found",
+	Hidden: true,
+	Args:   cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runDidYouMeanCommand(cmd, args)
+	},
+}
+
+func init() {
+	analyticsCmd.AddCommand(createInsightsCmd())
+
+	analyticsCmd.AddCommand(analyticsTyposCmd)
+	analyticsTyposCmd.Flags().BoolP("json", "j", false, "Output in JSON format")
+	analyticsTyposCmd.Flags().Int("limit", 10, "Maximum number of corrections to show")
+
+	analyticsCmd.AddCommand(analyticsDurationsCmd)
+	analyticsDurationsCmd.Flags().BoolP("json", "j", false, "Output in JSON format")
+	analyticsDurationsCmd.Flags().Int("limit", 10, "Maximum number of commands to show per section")
+
+	analyticsCmd.AddCommand(analyticsFocusCmd)
+	analyticsFocusCmd.Flags().BoolP("json", "j", false, "Output in JSON format")
+	analyticsFocusCmd.Flags().Int("limit", 10, "Maximum number of blocks to list")
+	analyticsFocusCmd.Flags().Int("days", 7, "Number of days to show in the timeline")
+
+	analyticsCmd.AddCommand(analyticsToolsCmd)
+	analyticsToolsCmd.Flags().BoolP("json", "j", false, "Output in JSON format")
+	analyticsToolsCmd.Flags().Int("limit", 25, "Maximum number of tools to list")
+
+	analyticsCmd.AddCommand(analyticsToolCmd)
+	analyticsToolCmd.Flags().BoolP("json", "j", false, "Output in JSON format")
+	analyticsToolCmd.Flags().Int("limit", 10, "Maximum number of subcommands and flags to list")
+
+	analyticsCmd.AddCommand(analyticsDirsCmd)
+	analyticsDirsCmd.Flags().BoolP("json", "j", false, "Output in JSON format")
+	analyticsDirsCmd.Flags().Int("depth", 3, "Maximum tree depth to show (0 for unlimited)")
+	analyticsDirsCmd.Flags().Int("top", 8, "Maximum subdirectories to show per directory (0 for unlimited)")
+
+	analyticsCmd.AddCommand(analyticsMetricsCmd)
+	analyticsMetricsCmd.Flags().BoolP("json", "j", false, "Output in JSON format")
+	analyticsMetricsCmd.Flags().Int("days", 30, "Number of days in each metric's series")
+
+	analyticsCmd.AddCommand(analyticsRecommendationsCmd)
+	analyticsRecommendationsCmd.Flags().BoolP("json", "j", false, "Output in JSON format")
+	analyticsRecommendationsCmd.Flags().Int("limit", 0, "Maximum number of recommendations to show (0 for all)")
+	analyticsRecommendationsCmd.Flags().Bool("all", false, "Include dismissed recommendations")
+	analyticsRecommendationsCmd.AddCommand(analyticsRecommendationsDismissCmd)
+	analyticsRecommendationsCmd.AddCommand(analyticsRecommendationsRestoreCmd)
+}
+
 func runAnalyticsCommand(cmd *cobra.Command, args []string) error {
 	// Load configuration
 	cfg, err := config.Load()
@@ -30,7 +217,7 @@ func runAnalyticsCommand(cmd *cobra.Command, args []string) error {
 	logger := setupLogger(cfg.LogLevel)
 
 	// Initialize database
-	db, err := database.New(config.GetDataDir(cfg), logger)
+	db, err := openDatabase(config.GetDataDir(cfg), logger)
 	if err != nil {
 		return fmt.Errorf("failed to initialize database: %w", err)
 	}
@@ -68,3 +255,528 @@ func runAnalyticsCommand(cmd *cobra.Command, args []string) error {
 
 	return nil
 }
+
+func runAnalyticsTyposCommand(cmd *cobra.Command, args []string) error {
+	// Load configuration
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	// Initialize logger
+	logger := setupLogger(cfg.LogLevel)
+
+	// Initialize database
+	db, err := openDatabase(config.GetDataDir(cfg), logger)
+	if err != nil {
+		return fmt.Errorf("failed to initialize database: %w", err)
+	}
+	defer db.Close()
+
+	commands, err := db.GetAllCommands()
+	if err != nil {
+		return fmt.Errorf("failed to get commands: %w", err)
+	}
+
+	analyzer := analytics.NewTypoAnalyzer()
+	report := analyzer.AnalyzeTypos(commands)
+
+	jsonOutput, _ := cmd.Flags().GetBool("json")
+	if jsonOutput {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal typo report: %w", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	limit, _ := cmd.Flags().GetInt("limit")
+	fmt.Print(analyzer.FormatTypoReport(report, limit))
+
+	return nil
+}
+
+func runAnalyticsDurationsCommand(cmd *cobra.Command, args []string) error {
+	// Load configuration
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	// Initialize logger
+	logger := setupLogger(cfg.LogLevel)
+
+	// Initialize database
+	db, err := openDatabase(config.GetDataDir(cfg), logger)
+	if err != nil {
+		return fmt.Errorf("failed to initialize database: %w", err)
+	}
+	defer db.Close()
+
+	commands, err := db.GetAllCommands()
+	if err != nil {
+		return fmt.Errorf("failed to get commands: %w", err)
+	}
+
+	analyzer := analytics.NewDurationAnalyzer()
+	report := analyzer.AnalyzeDurations(commands)
+
+	jsonOutput, _ := cmd.Flags().GetBool("json")
+	if jsonOutput {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal duration report: %w", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	limit, _ := cmd.Flags().GetInt("limit")
+	fmt.Print(analyzer.FormatDurationReport(report, limit))
+
+	return nil
+}
+
+func runAnalyticsFocusCommand(cmd *cobra.Command, args []string) error {
+	// Load configuration
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	// Initialize logger
+	logger := setupLogger(cfg.LogLevel)
+
+	// Initialize database
+	db, err := openDatabase(config.GetDataDir(cfg), logger)
+	if err != nil {
+		return fmt.Errorf("failed to initialize database: %w", err)
+	}
+	defer db.Close()
+
+	commands, err := db.GetAllCommands()
+	if err != nil {
+		return fmt.Errorf("failed to get commands: %w", err)
+	}
+
+	analyzer := analytics.NewFocusAnalyzer()
+	report := analyzer.AnalyzeFocus(commands)
+
+	jsonOutput, _ := cmd.Flags().GetBool("json")
+	if jsonOutput {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal focus report: %w", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	limit, _ := cmd.Flags().GetInt("limit")
+	days, _ := cmd.Flags().GetInt("days")
+
+	fmt.Print(analyzer.FormatFocusReport(report, limit))
+	if report.TotalBlocks > 0 && days > 0 {
+		fmt.Printf("\n📅 Timeline (last %d days):\n", days)
+		fmt.Print(analyzer.FormatFocusTimeline(report, commands, days, time.Now()))
+	}
+
+	return nil
+}
+
+func runAnalyticsToolsCommand(cmd *cobra.Command, args []string) error {
+	// Load configuration
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	// Initialize logger
+	logger := setupLogger(cfg.LogLevel)
+
+	// Initialize database
+	db, err := openDatabase(config.GetDataDir(cfg), logger)
+	if err != nil {
+		return fmt.Errorf("failed to initialize database: %w", err)
+	}
+	defer db.Close()
+
+	commands, err := db.GetAllCommands()
+	if err != nil {
+		return fmt.Errorf("failed to get commands: %w", err)
+	}
+
+	analyzer := analytics.NewToolAnalyzer()
+	report := analyzer.AnalyzeTools(commands, time.Now())
+
+	var adoption *analytics.ToolAdoption
+	if len(args) > 0 {
+		if adoption = report.FindTool(args[0]); adoption == nil {
+			return fmt.Errorf("tool %q not found (it needs at least a few uses in your history)", args[0])
+		}
+	}
+
+	jsonOutput, _ := cmd.Flags().GetBool("json")
+	if jsonOutput {
+		var data []byte
+		if adoption != nil {
+			data, err = json.MarshalIndent(adoption, "", "  ")
+		} else {
+			data, err = json.MarshalIndent(report, "", "  ")
+		}
+		if err != nil {
+			return fmt.Errorf("failed to marshal tool report: %w", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	if adoption != nil {
+		fmt.Print(analyzer.FormatToolDetail(adoption))
+		return nil
+	}
+
+	limit, _ := cmd.Flags().GetInt("limit")
+	fmt.Print(analyzer.FormatToolReport(report, limit))
+
+	return nil
+}
+
+func runAnalyticsToolCommand(cmd *cobra.Command, args []string) error {
+	// Load configuration
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	// Initialize logger
+	logger := setupLogger(cfg.LogLevel)
+
+	// Initialize database
+	db, err := openDatabase(config.GetDataDir(cfg), logger)
+	if err != nil {
+		return fmt.Errorf("failed to initialize database: %w", err)
+	}
+	defer db.Close()
+
+	commands, err := db.GetAllCommands()
+	if err != nil {
+		return fmt.Errorf("failed to get commands: %w", err)
+	}
+
+	analyzer := analytics.NewToolAnalyzer()
+	usage := analyzer.AnalyzeToolUsage(args[0], commands)
+
+	jsonOutput, _ := cmd.Flags().GetBool("json")
+	if jsonOutput {
+		data, err := json.MarshalIndent(usage, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal tool usage: %w", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	limit, _ := cmd.Flags().GetInt("limit")
+	fmt.Print(analyzer.FormatToolUsage(usage, limit))
+
+	return nil
+}
+
+func runAnalyticsDirsCommand(cmd *cobra.Command, args []string) error {
+	// Load configuration
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	// Initialize logger
+	logger := setupLogger(cfg.LogLevel)
+
+	// Initialize database
+	db, err := openDatabase(config.GetDataDir(cfg), logger)
+	if err != nil {
+		return fmt.Errorf("failed to initialize database: %w", err)
+	}
+	defer db.Close()
+
+	commands, err := db.GetAllCommands()
+	if err != nil {
+		return fmt.Errorf("failed to get commands: %w", err)
+	}
+
+	sanitizer := privacy.NewCommandSanitizer(config.SanitizationConfig(cfg))
+
+	analyzer := analytics.NewDirectoryAnalyzer(sanitizer)
+	root := analyzer.BuildTree(commands)
+
+	if len(args) > 0 {
+		path := args[0]
+		if strings.HasPrefix(path, "~") {
+			if home, err := os.UserHomeDir(); err == nil {
+				path = home + strings.TrimPrefix(path, "~")
+			}
+		}
+		if path, err = filepath.Abs(path); err != nil {
+			return fmt.Errorf("failed to resolve path: %w", err)
+		}
+		path = sanitizer.SanitizeDirectory(path)
+
+		node := root.Find(path)
+		if node == nil {
+			return fmt.Errorf("no activity recorded in %s", path)
+		}
+		root = &analytics.DirectoryNode{Commands: node.Commands, Children: []*analytics.DirectoryNode{node}}
+	}
+
+	jsonOutput, _ := cmd.Flags().GetBool("json")
+	if jsonOutput {
+		data, err := json.MarshalIndent(root.Children, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal directory tree: %w", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	depth, _ := cmd.Flags().GetInt("depth")
+	top, _ := cmd.Flags().GetInt("top")
+	fmt.Print(analyzer.FormatDirectoryTree(root, depth, top))
+
+	return nil
+}
+
+func runAnalyticsMetricsCommand(cmd *cobra.Command, args []string) error {
+	// Load configuration
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	// Initialize logger
+	logger := setupLogger(cfg.LogLevel)
+
+	// Initialize database
+	db, err := openDatabase(config.GetDataDir(cfg), logger)
+	if err != nil {
+		return fmt.Errorf("failed to initialize database: %w", err)
+	}
+	defer db.Close()
+
+	days, _ := cmd.Flags().GetInt("days")
+	if days <= 0 {
+		return fmt.Errorf("--days must be positive")
+	}
+	values, err := computeMetricValues(cfg, db, days)
+	if err != nil {
+		return err
+	}
+
+	var output interface{} = values
+	if len(args) > 0 {
+		value := analytics.FindMetric(values, args[0])
+		if value == nil {
+			return fmt.Errorf("no metric named %q in config.toml", args[0])
+		}
+		output = value
+		values = []*analytics.MetricValue{value}
+	}
+
+	jsonOutput, _ := cmd.Flags().GetBool("json")
+	if jsonOutput {
+		data, err := json.MarshalIndent(output, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal metrics: %w", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	fmt.Print(analytics.FormatMetrics(values))
+	if len(args) > 0 {
+		fmt.Println()
+		for _, point := range values[0].Series {
+			if values[0].Type == analytics.MetricTimer {
+				fmt.Printf("  %s %9s\n", point.Date, analytics.FormatDuration(point.DurationMS))
+			} else {
+				fmt.Printf("  %s %6d\n", point.Date, point.Count)
+			}
+		}
+	}
+
+	return nil
+}
+
+// computeMetricValues evaluates the [[metrics]] defined in the configuration
+func computeMetricValues(cfg *config.Config, db *database.DB, days int) ([]*analytics.MetricValue, error) {
+	engine, err := analytics.NewMetricEngine(cfg.Metrics)
+	if err != nil {
+		return nil, fmt.Errorf("invalid metrics configuration: %w", err)
+	}
+
+	commands, err := db.GetAllCommands()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get commands: %w", err)
+	}
+
+	return engine.Compute(commands, time.Now(), days), nil
+}
+
+// runDidYouMeanCommand is invoked by the shell hooks after a command exits
+// with 127. It must stay quiet on any failure so it never disrupts the prompt.
+func runAnalyticsRecommendationsCommand(cmd *cobra.Command, args []string) error {
+	// Load configuration
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	// Initialize logger
+	logger := setupLogger(cfg.LogLevel)
+
+	// Initialize database
+	db, err := openDatabase(config.GetDataDir(cfg), logger)
+	if err != nil {
+		return fmt.Errorf("failed to initialize database: %w", err)
+	}
+	defer db.Close()
+
+	recommendations, err := evaluateRecommendations(db)
+	if err != nil {
+		return err
+	}
+
+	dismissed, err := db.GetDismissedRecommendations()
+	if err != nil {
+		return fmt.Errorf("failed to get dismissed recommendations: %w", err)
+	}
+	if all, _ := cmd.Flags().GetBool("all"); !all {
+		recommendations = analytics.FilterDismissed(recommendations, dismissed)
+	}
+
+	jsonOutput, _ := cmd.Flags().GetBool("json")
+	if jsonOutput {
+		data, err := json.MarshalIndent(recommendations, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal recommendations: %w", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	limit, _ := cmd.Flags().GetInt("limit")
+	fmt.Print(analytics.FormatRecommendations(recommendations, limit))
+	if len(recommendations) > 0 {
+		fmt.Println("\nDismiss one with: termonaut analytics recommendations dismiss <id>")
+	}
+	if len(dismissed) > 0 {
+		fmt.Printf("%d dismissed recommendation(s) hidden; use --all to include them\n", len(dismissed))
+	}
+
+	return nil
+}
+
+func runAnalyticsRecommendationsDismissCommand(cmd *cobra.Command, args []string) error {
+	// Load configuration
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	// Initialize logger
+	logger := setupLogger(cfg.LogLevel)
+
+	// Initialize database
+	db, err := openDatabase(config.GetDataDir(cfg), logger)
+	if err != nil {
+		return fmt.Errorf("failed to initialize database: %w", err)
+	}
+	defer db.Close()
+
+	recommendations, err := evaluateRecommendations(db)
+	if err != nil {
+		return err
+	}
+	rec := analytics.FindRecommendation(recommendations, args[0])
+	if rec == nil {
+		return fmt.Errorf("no current recommendation with id %q", args[0])
+	}
+
+	if err := db.DismissRecommendation(rec.ID); err != nil {
+		return err
+	}
+	fmt.Printf("🙈 Dismissed: %s\n", rec.Title)
+
+	return nil
+}
+
+func runAnalyticsRecommendationsRestoreCommand(cmd *cobra.Command, args []string) error {
+	// Load configuration
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	// Initialize logger
+	logger := setupLogger(cfg.LogLevel)
+
+	// Initialize database
+	db, err := openDatabase(config.GetDataDir(cfg), logger)
+	if err != nil {
+		return fmt.Errorf("failed to initialize database: %w", err)
+	}
+	defer db.Close()
+
+	restored, err := db.RestoreRecommendation(args[0])
+	if err != nil {
+		return err
+	}
+	if !restored {
+		return fmt.Errorf("recommendation %q is not dismissed", args[0])
+	}
+	fmt.Printf("👀 Restored recommendation %s\n", args[0])
+
+	return nil
+}
+
+// evaluateRecommendations runs the built-in rules over the full history,
+// including dismissed recommendations
+func evaluateRecommendations(db *database.DB) ([]*analytics.Recommendation, error) {
+	commands, err := db.GetAllCommands()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get commands: %w", err)
+	}
+
+	ctx := analytics.NewRecommendationContext(commands, time.Now())
+	return analytics.NewRecommendationEngine().Evaluate(ctx, nil), nil
+}
+
+func runDidYouMeanCommand(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil || !cfg.TypoSuggestions {
+		return nil
+	}
+
+	logger := setupLogger(cfg.LogLevel)
+
+	db, err := openDatabase(config.GetDataDir(cfg), logger)
+	if err != nil {
+		return nil
+	}
+	defer db.Close()
+
+	// This runs inside the prompt, so only the distinct commands are read
+	counts, err := db.GetCommandCounts()
+	if err != nil {
+		return nil
+	}
+
+	analyzer := analytics.NewTypoAnalyzer()
+	command := strings.Join(args, " ")
+	if suggestion, ok := analyzer.SuggestCorrection(command, analyzer.VocabularyFromCounts(counts)); ok {
+		fmt.Fprintf(os.Stderr, "💡 Did you mean: %s?\n", suggestion)
+	}
+
+	return nil
+}
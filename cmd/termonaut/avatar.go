@@ -7,7 +7,6 @@ import (
 
 	"github.com/oiahoon/termonaut/internal/avatar"
 	"github.com/oiahoon/termonaut/internal/config"
-	"github.com/oiahoon/termonaut/internal/database"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
@@ -365,7 +364,7 @@ func getCurrentUserStats() (username string, level int, error error) {
 	// Initialize database
 	logger := logrus.New()
 	logger.SetLevel(logrus.ErrorLevel) // Minimal logging for avatar operations
-	db, err := database.New(config.GetDataDir(cfg), logger)
+	db, err := openDatabase(config.GetDataDir(cfg), logger)
 	if err != nil {
 		// Fallback to level 1 if database fails
 		return username, 1, nil
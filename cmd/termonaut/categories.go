@@ -1,12 +1,16 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 	"sort"
+	"strings"
+	"time"
 
 	"github.com/oiahoon/termonaut/internal/categories"
 	"github.com/oiahoon/termonaut/internal/config"
 	"github.com/oiahoon/termonaut/internal/database"
+	"github.com/oiahoon/termonaut/internal/query"
 	"github.com/spf13/cobra"
 )
 
@@ -14,12 +18,296 @@ var categoriesCmd = &cobra.Command{
 	Use:   "categories",
 	Short: "Show command usage by category",
 	Long: `Display statistics about your command usage organized by categories
-such as git, development, system administration, etc.`,
+such as git, development, system administration, etc.
+
+Categories such as git are broken down into subcategories (git/remote,
+git/history, ...), and a command can carry several weighted labels, e.g.
+"docker build" is both docker/build and build.
+
+Categories can be added or changed in ~/.termonaut/categories.toml (or
+.yaml/.yml, or the file set with categories_file). Use "categories test" to
+see which rule classifies a command, and "categories reclassify" to update
+the categories stored with past commands after changing them. Tools can
+also be assigned directly with "categories assign" or "categories triage".`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		return runCategoriesCommand(cmd, args)
 	},
 }
 
+var categoriesTestCmd = &cobra.Command{
+	Use:   "test <command>",
+	Short: "Explain which category a command falls in",
+	Long: `Classify a command and show the rule that decided its category, along with
+any other rules that also match but have lower precedence.
+
+Pipelines and chains (|, &&, ||, ;) are split into segments and wrappers such
+as sudo, time and nohup are skipped. Each segment is classified; the first
+one doing the main work decides the category, while segments reading from a
+pipe and cd only decide it when nothing else matches.
+
+Tools assigned with "categories assign" come first. The other rules are
+tried by priority, highest first. At equal priority rules from the
+categories file come before built-in ones, then the order they are defined
+in.
+
+Examples:
+  termonaut categories test "git push origin main"
+  termonaut categories test "terraform plan" --json
+  termonaut categories test "sudo docker ps | grep web && kubectl get pods"`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runCategoriesTestCommand(cmd, args)
+	},
+}
+
+var categoriesReclassifyCmd = &cobra.Command{
+	Use:   "reclassify",
+	Short: "Rewrite stored categories after rule changes",
+	Long: `Classify stored commands again with the current rules and save their new
+categories, e.g. after editing the categories file or upgrading. Shows how
+many commands were in each category before and after.
+
+With --recompute-xp, total XP is adjusted by the difference the new
+categories make to each changed command's XP.
+
+Commands are rewritten in batches with a checkpoint after each one, so on a
+large database the job can be interrupted (or stopped with --limit) and
+picks up where it left off when run again. Use --restart to start over.
+
+Examples:
+  termonaut categories reclassify --dry-run
+  termonaut categories reclassify --since 30d
+  termonaut categories reclassify --recompute-xp --limit 50000`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runCategoriesReclassifyCommand(cmd, args)
+	},
+}
+
+func init() {
+	categoriesCmd.AddCommand(categoriesTestCmd)
+	categoriesTestCmd.Flags().BoolP("json", "j", false, "Output in JSON format")
+
+	categoriesCmd.AddCommand(categoriesReclassifyCmd)
+	categoriesReclassifyCmd.Flags().String("since", "", "Only commands since a date or relative time (2024-01-31, 30d, 6mo)")
+	categoriesReclassifyCmd.Flags().Bool("recompute-xp", false, "Adjust total XP for the changed categories")
+	categoriesReclassifyCmd.Flags().Bool("dry-run", false, "Show what would change without saving anything")
+	categoriesReclassifyCmd.Flags().Int("limit", 0, "Stop after about this many commands; run again to continue")
+	categoriesReclassifyCmd.Flags().Int("batch-size", 500, "Commands per batch and checkpoint")
+	categoriesReclassifyCmd.Flags().Bool("restart", false, "Discard an interrupted run instead of resuming it")
+	categoriesReclassifyCmd.Flags().BoolP("json", "j", false, "Output in JSON format")
+}
+
+func runCategoriesReclassifyCommand(cmd *cobra.Command, args []string) error {
+	opts := database.ReclassifyOptions{}
+	if since, _ := cmd.Flags().GetString("since"); since != "" {
+		var err error
+		if opts.Since, err = query.ParseTime(since, time.Now()); err != nil {
+			return err
+		}
+	}
+	opts.RecomputeXP, _ = cmd.Flags().GetBool("recompute-xp")
+	opts.DryRun, _ = cmd.Flags().GetBool("dry-run")
+	opts.Limit, _ = cmd.Flags().GetInt("limit")
+	opts.BatchSize, _ = cmd.Flags().GetInt("batch-size")
+	opts.Restart, _ = cmd.Flags().GetBool("restart")
+	jsonOutput, _ := cmd.Flags().GetBool("json")
+
+	db, err := openCategoriesDB()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	if !jsonOutput {
+		opts.Progress = func(result *database.ReclassifyResult) {
+			fmt.Printf("\r🔄 Reclassified %d commands (%d changed)", result.Processed, result.Changed)
+		}
+	}
+
+	result, err := db.ReclassifyCommands(opts)
+	if !jsonOutput && opts.Progress != nil {
+		fmt.Print("\r\033[K")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to reclassify commands: %w", err)
+	}
+
+	if jsonOutput {
+		return printJSON(result)
+	}
+	fmt.Print(formatReclassifyResult(categories.NewCommandClassifier(), result))
+	return nil
+}
+
+// formatReclassifyResult shows the commands in each category before and
+// after a reclassification
+func formatReclassifyResult(classifier *categories.CommandClassifier, result *database.ReclassifyResult) string {
+	title := "🔄 Reclassified Commands"
+	if result.DryRun {
+		title = "🔍 Reclassification Preview (dry run)"
+	}
+	output := title + "\n"
+	output += "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n"
+
+	if result.Processed == 0 {
+		return output + "\n🤷 No commands to reclassify.\n"
+	}
+
+	if !result.Since.IsZero() {
+		output += fmt.Sprintf("Since: %s\n", result.Since.Format("2006-01-02 15:04"))
+	}
+	if result.Resumed {
+		output += fmt.Sprintf("Resumed a run started %s\n", result.StartedAt.Format("2006-01-02 15:04"))
+	}
+	output += fmt.Sprintf("Commands: %d | Changed: %d\n\n", result.Processed, result.Changed)
+
+	names := make(map[string]bool)
+	for category := range result.Before {
+		names[category] = true
+	}
+	for category := range result.After {
+		names[category] = true
+	}
+	sorted := make([]string, 0, len(names))
+	for category := range names {
+		sorted = append(sorted, category)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		a, b := sorted[i], sorted[j]
+		if result.After[a] != result.After[b] {
+			return result.After[a] > result.After[b]
+		}
+		return a < b
+	})
+
+	output += fmt.Sprintf("   %-24s %8s %8s %8s\n", "Category", "Before", "After", "Change")
+	for _, category := range sorted {
+		before, after := result.Before[category], result.After[category]
+		icon := "  "
+		if category != database.UnsetCategory {
+			icon = classifier.GetCategoryInfo(categories.Category(category)).Icon
+		}
+		change := ""
+		if after != before {
+			change = fmt.Sprintf("%+d", after-before)
+		}
+		output += fmt.Sprintf("%s %-24s %8d %8d %8s\n", icon, category, before, after, change)
+	}
+
+	if result.RecomputeXP {
+		verb := "Adjusted"
+		if result.DryRun {
+			verb = "Would adjust"
+		}
+		output += fmt.Sprintf("\n⭐ %s total XP by %+d\n", verb, result.XPDelta)
+	}
+	if !result.Complete {
+		output += "\n⏸️  Stopped at the limit; run again to continue.\n"
+	}
+	return output
+}
+
+func runCategoriesTestCommand(cmd *cobra.Command, args []string) error {
+	// Opening the database loads the learned categories
+	db, err := openCategoriesDB()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	command := strings.Join(args, " ")
+	classifier := categories.NewCommandClassifier()
+	classification := classifier.Classify(command)
+
+	// The rules are explained for the segment that decided the category
+	matches := []*categories.Rule{}
+	if classification.PrimarySegment >= 0 {
+		matches = classifier.Explain(classification.Segments[classification.PrimarySegment].Command)
+	}
+
+	jsonOutput, _ := cmd.Flags().GetBool("json")
+	if jsonOutput {
+		data, err := json.MarshalIndent(map[string]interface{}{
+			"command":  command,
+			"category": classification.Primary,
+			"info":     classifier.GetCategoryInfo(classification.Primary),
+			"segments": classification.Segments,
+			"matches":  matches,
+		}, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal classification: %w", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	fmt.Print(formatCategoryExplanation(classifier, command, classification, matches))
+	return nil
+}
+
+// formatCategoryExplanation shows the segments of a command, then the
+// deciding rule and the shadowed ones
+func formatCategoryExplanation(classifier *categories.CommandClassifier, command string,
+	classification *categories.Classification, matches []*categories.Rule) string {
+	result := fmt.Sprintf("🧪 Classifying: %s\n", command)
+	result += "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n"
+
+	// Show the segments when there are several or wrappers were stripped
+	segments := classification.Segments
+	if len(segments) > 1 || len(segments) == 1 && segments[0].Command != strings.TrimSpace(command) {
+		result += "🔗 Segments:\n"
+		for i, segment := range segments {
+			marker := " "
+			if i == classification.PrimarySegment {
+				marker = "*"
+			}
+			operator := segment.Operator
+			if operator == "" {
+				operator = " "
+			}
+			info := classifier.GetCategoryInfo(segment.Category)
+			line := fmt.Sprintf(" %s %-2s %s %-12s %s", marker, operator, info.Icon, segment.Category, segment.Command)
+			if len(segment.Wrappers) > 0 {
+				line += fmt.Sprintf("  (via %s)", strings.Join(segment.Wrappers, ", "))
+			}
+			result += line + "\n"
+		}
+		result += "   * decides the category\n\n"
+	}
+
+	if len(classification.Labels) > 1 {
+		var labels []string
+		for _, label := range classification.Labels {
+			labels = append(labels, fmt.Sprintf("%s %.0f%%", label.Category, label.Weight*100))
+		}
+		result += fmt.Sprintf("🏷️  Labels: %s\n\n", strings.Join(labels, ", "))
+	}
+
+	if len(matches) == 0 {
+		info := classifier.GetCategoryInfo(categories.Unknown)
+		result += fmt.Sprintf("%s No rule matched, so the command is %s\n", info.Icon, categories.Unknown)
+		return result
+	}
+
+	winner := matches[0]
+	info := classifier.GetCategoryInfo(winner.Category)
+	result += fmt.Sprintf("✅ Category: %s %s (%s)\n", info.Icon, info.Name, winner.Category)
+	result += fmt.Sprintf("   Rule:     %s\n", winner.Pattern)
+	result += fmt.Sprintf("   Priority: %d (%s)\n", winner.Priority, winner.Source)
+	result += fmt.Sprintf("   XP bonus: %.1fx\n", info.XPBonus)
+
+	if len(matches) > 1 {
+		result += "\n🔽 Also matched, lower precedence:\n"
+		for _, rule := range matches[1:] {
+			other := classifier.GetCategoryInfo(rule.Category)
+			result += fmt.Sprintf("   %s %-12s %-32s priority %d (%s)\n",
+				other.Icon, rule.Category, rule.Pattern, rule.Priority, rule.Source)
+		}
+	}
+
+	return result
+}
+
 func runCategoriesCommand(cmd *cobra.Command, args []string) error {
 	// Load configuration
 	cfg, err := config.Load()
@@ -31,7 +319,7 @@ func runCategoriesCommand(cmd *cobra.Command, args []string) error {
 	logger := setupLogger(cfg.LogLevel)
 
 	// Initialize database
-	db, err := database.New(config.GetDataDir(cfg), logger)
+	db, err := openDatabase(config.GetDataDir(cfg), logger)
 	if err != nil {
 		return fmt.Errorf("failed to initialize database: %w", err)
 	}
@@ -49,15 +337,9 @@ func runCategoriesCommand(cmd *cobra.Command, args []string) error {
 		return nil
 	}
 
-	// Convert to string slice for analysis
-	commandStrings := make([]string, len(commands))
-	for i, cmd := range commands {
-		commandStrings[i] = cmd.Command
-	}
-
-	// Analyze categories
+	// Analyze categories, as stored with the commands
 	classifier := categories.NewCommandClassifier()
-	categoryStats := classifier.AnalyzeCategories(commandStrings)
+	categoryStats := classifier.AnalyzeCommandCategories(commands)
 
 	// Check output format
 	jsonOutput, _ := cmd.Flags().GetBool("json")
@@ -93,10 +375,10 @@ func runCategoriesCommand(cmd *cobra.Command, args []string) error {
 	}
 
 	sort.Slice(sortableStats, func(i, j int) bool {
-		return sortableStats[i].stats.Count > sortableStats[j].stats.Count
+		return sortableStats[i].stats.Share > sortableStats[j].stats.Share
 	})
 
-	fmt.Printf("\nTotal Commands Analyzed: %d\n\n", len(commandStrings))
+	fmt.Printf("\nTotal Commands Analyzed: %d\n\n", len(commands))
 
 	// Display category breakdown
 	for i, item := range sortableStats {
@@ -121,7 +403,12 @@ func runCategoriesCommand(cmd *cobra.Command, args []string) error {
 		fmt.Printf("   [%s] %.1f%%\n", bar, item.stats.Percentage)
 		fmt.Printf("   Commands: %d | XP Bonus: %.1fx | Est. XP: %d\n",
 			item.stats.Count, item.info.XPBonus, item.stats.TotalXP)
-		fmt.Printf("   %s\n\n", item.info.Description)
+		fmt.Printf("   %s\n", item.info.Description)
+		for _, sub := range sortedSubcategories(item.stats) {
+			subInfo := classifier.GetCategoryInfo(sub.Category)
+			fmt.Printf("   ↳ %-22s %5.1f%%  (%d commands)\n", subInfo.Name, sub.Percentage, sub.Count)
+		}
+		fmt.Println()
 	}
 
 	// Show category mastery
@@ -152,3 +439,18 @@ func runCategoriesCommand(cmd *cobra.Command, args []string) error {
 
 	return nil
 }
+
+// sortedSubcategories returns the subcategories of a category, most used first
+func sortedSubcategories(stats *categories.CategoryStats) []*categories.CategoryStats {
+	subs := make([]*categories.CategoryStats, 0, len(stats.Subcategories))
+	for _, sub := range stats.Subcategories {
+		subs = append(subs, sub)
+	}
+	sort.Slice(subs, func(i, j int) bool {
+		if subs[i].Share != subs[j].Share {
+			return subs[i].Share > subs[j].Share
+		}
+		return subs[i].Category < subs[j].Category
+	})
+	return subs
+}
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/oiahoon/termonaut/internal/categories"
+	"github.com/oiahoon/termonaut/internal/config"
+	"github.com/oiahoon/termonaut/internal/database"
+	"github.com/oiahoon/termonaut/internal/tui/enhanced"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+var categoriesAssignCmd = &cobra.Command{
+	Use:   "assign <tool> <category>",
+	Short: "Put every command running a tool in a category",
+	Long: `Teach Termonaut which category a tool belongs in. The assignment is stored
+in the database, wins over the categories file and the built-in rules, and
+updates the category of the stored commands that run the tool.
+
+The category can be a built-in one, one from the categories file, or a new
+one such as "infra" or "cloud/terraform".
+
+Examples:
+  termonaut categories assign terraform infra
+  termonaut categories assign just build`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runCategoriesAssignCommand(cmd, args)
+	},
+}
+
+var categoriesUnassignCmd = &cobra.Command{
+	Use:   "unassign <tool>",
+	Short: "Forget the category assigned to a tool",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runCategoriesUnassignCommand(cmd, args)
+	},
+}
+
+var categoriesLearnedCmd = &cobra.Command{
+	Use:   "learned",
+	Short: "List the categories assigned to tools",
+	Long: `List the tools assigned to a category with "categories assign" or the
+triage screen.
+
+With --export, they are written as a categories file (.toml, .yaml, .yml or
+.json) that can be shared with a team and used as their categories_file.
+
+Examples:
+  termonaut categories learned
+  termonaut categories learned --export team-categories.toml`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runCategoriesLearnedCommand(cmd, args)
+	},
+}
+
+var categoriesTriageCmd = &cobra.Command{
+	Use:   "triage",
+	Short: "Categorize the most used unknown commands interactively",
+	Long: `Walk through the tools of your most used commands that fall in no category,
+and pick a category for each one. Every choice is stored like
+"categories assign".`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runCategoriesTriageCommand(cmd, args)
+	},
+}
+
+func init() {
+	categoriesCmd.AddCommand(categoriesAssignCmd)
+	categoriesCmd.AddCommand(categoriesUnassignCmd)
+
+	categoriesCmd.AddCommand(categoriesLearnedCmd)
+	categoriesLearnedCmd.Flags().String("export", "", "Write the assignments to a categories file")
+	categoriesLearnedCmd.Flags().BoolP("json", "j", false, "Output in JSON format")
+
+	categoriesCmd.AddCommand(categoriesTriageCmd)
+	categoriesTriageCmd.Flags().Int("limit", 20, "Number of unknown tools to go through")
+}
+
+// openCategoriesDB loads the configuration and categories file, opens the
+// database and loads the learned categories
+func openCategoriesDB() (*database.DB, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+	if path := config.CategoriesFilePath(cfg); path != "" {
+		if _, err := categories.LoadDefinitions(path); err != nil {
+			return nil, fmt.Errorf("categories file %s: %w", path, err)
+		}
+	}
+
+	logger := setupLogger(cfg.LogLevel)
+	db, err := openDatabase(config.GetDataDir(cfg), logger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize database: %w", err)
+	}
+	return db, nil
+}
+
+func runCategoriesAssignCommand(cmd *cobra.Command, args []string) error {
+	db, err := openCategoriesDB()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	tool, category := args[0], categories.Category(args[1])
+	changed, err := db.AssignCategory(tool, category)
+	if err != nil {
+		return fmt.Errorf("failed to assign %s: %w", tool, err)
+	}
+
+	info := categories.NewCommandClassifier().GetCategoryInfo(category)
+	fmt.Printf("✅ %s commands are now %s %s\n", tool, info.Icon, category)
+	fmt.Printf("Updated %d stored commands\n", changed)
+	return nil
+}
+
+func runCategoriesUnassignCommand(cmd *cobra.Command, args []string) error {
+	db, err := openCategoriesDB()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	tool := args[0]
+	found, changed, err := db.UnassignCategory(tool)
+	if err != nil {
+		return fmt.Errorf("failed to unassign %s: %w", tool, err)
+	}
+	if !found {
+		return fmt.Errorf("no category is assigned to %s", tool)
+	}
+
+	fmt.Printf("🗑️  Forgot the category of %s\n", tool)
+	fmt.Printf("Updated %d stored commands\n", changed)
+	return nil
+}
+
+func runCategoriesLearnedCommand(cmd *cobra.Command, args []string) error {
+	db, err := openCategoriesDB()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	assignments, err := db.GetLearnedCategories()
+	if err != nil {
+		return err
+	}
+
+	if path, _ := cmd.Flags().GetString("export"); path != "" {
+		if len(assignments) == 0 {
+			return fmt.Errorf("no categories have been assigned yet")
+		}
+		if err := categories.WriteDefinitions(path, categories.LearnedDefinitions(assignments)); err != nil {
+			return err
+		}
+		fmt.Printf("📤 Exported %d assignments to %s\n", len(assignments), path)
+		return nil
+	}
+
+	if jsonOutput, _ := cmd.Flags().GetBool("json"); jsonOutput {
+		if assignments == nil {
+			assignments = []categories.Assignment{}
+		}
+		return printJSON(assignments)
+	}
+
+	fmt.Println("🧠 Learned Categories")
+	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+	if len(assignments) == 0 {
+		fmt.Println("\nNo categories assigned yet. Try \"termonaut categories triage\".")
+		return nil
+	}
+
+	classifier := categories.NewCommandClassifier()
+	for _, a := range assignments {
+		info := classifier.GetCategoryInfo(a.Category)
+		fmt.Printf("  %-20s %s %s\n", a.Tool, info.Icon, a.Category)
+	}
+	return nil
+}
+
+func runCategoriesTriageCommand(cmd *cobra.Command, args []string) error {
+	if !term.IsTerminal(int(os.Stdout.Fd())) {
+		return fmt.Errorf("triage needs an interactive terminal; use \"categories assign\" instead")
+	}
+
+	db, err := openCategoriesDB()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	limit, _ := cmd.Flags().GetInt("limit")
+	tools, err := db.GetUnknownTools(limit)
+	if err != nil {
+		return err
+	}
+	if len(tools) == 0 {
+		fmt.Println("🎉 No unknown commands to triage!")
+		return nil
+	}
+
+	model := enhanced.NewTriageModel(tools, categories.NewCommandClassifier(), db.AssignCategory)
+	if _, err := tea.NewProgram(model, tea.WithAltScreen()).Run(); err != nil {
+		return fmt.Errorf("failed to run triage: %w", err)
+	}
+
+	fmt.Println("🗂️  " + model.Summary())
+	return nil
+}
@@ -5,7 +5,6 @@ import (
 
 	"github.com/oiahoon/termonaut/internal/analytics"
 	"github.com/oiahoon/termonaut/internal/config"
-	"github.com/oiahoon/termonaut/internal/database"
 	"github.com/oiahoon/termonaut/internal/stats"
 	"github.com/oiahoon/termonaut/internal/visualization"
 	"github.com/spf13/cobra"
@@ -32,7 +31,7 @@ func runDashboardCommand(cmd *cobra.Command, args []string) error {
 	logger := setupLogger(cfg.LogLevel)
 
 	// Initialize database
-	db, err := database.New(config.GetDataDir(cfg), logger)
+	db, err := openDatabase(config.GetDataDir(cfg), logger)
 	if err != nil {
 		return fmt.Errorf("failed to initialize database: %w", err)
 	}
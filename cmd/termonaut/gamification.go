@@ -1,10 +1,10 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 
 	"github.com/oiahoon/termonaut/internal/config"
-	"github.com/oiahoon/termonaut/internal/database"
 	"github.com/oiahoon/termonaut/internal/gamification"
 	"github.com/oiahoon/termonaut/internal/stats"
 	"github.com/spf13/cobra"
@@ -58,7 +58,7 @@ func runProgressCommand(cmd *cobra.Command, args []string) error {
 	logger := setupLogger(cfg.LogLevel)
 
 	// Initialize database
-	db, err := database.New(config.GetDataDir(cfg), logger)
+	db, err := openDatabase(config.GetDataDir(cfg), logger)
 	if err != nil {
 		return fmt.Errorf("failed to initialize database: %w", err)
 	}
@@ -76,7 +76,11 @@ func runProgressCommand(cmd *cobra.Command, args []string) error {
 	// Check output format
 	jsonOutput, _ := cmd.Flags().GetBool("json")
 	if jsonOutput {
-		fmt.Printf("%+v\n", gamificationStats)
+		data, err := json.MarshalIndent(gamificationStats, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal progress: %w", err)
+		}
+		fmt.Println(string(data))
 	} else {
 		fmt.Print(statsCalc.FormatGamificationStats(gamificationStats))
 	}
@@ -95,7 +99,7 @@ func runAchievementsCommand(cmd *cobra.Command, args []string) error {
 	logger := setupLogger(cfg.LogLevel)
 
 	// Initialize database
-	db, err := database.New(config.GetDataDir(cfg), logger)
+	db, err := openDatabase(config.GetDataDir(cfg), logger)
 	if err != nil {
 		return fmt.Errorf("failed to initialize database: %w", err)
 	}
@@ -198,7 +202,7 @@ func runLevelCommand(cmd *cobra.Command, args []string) error {
 	logger := setupLogger(cfg.LogLevel)
 
 	// Initialize database
-	db, err := database.New(config.GetDataDir(cfg), logger)
+	db, err := openDatabase(config.GetDataDir(cfg), logger)
 	if err != nil {
 		return fmt.Errorf("failed to initialize database: %w", err)
 	}
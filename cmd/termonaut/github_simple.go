@@ -53,7 +53,7 @@ var badgesGenerateCmd = &cobra.Command{
 		logger := logrus.New()
 		logger.SetLevel(logrus.WarnLevel) // Reduce noise
 
-		db, err := database.New(config.GetDataDir(cfg), logger)
+		db, err := openDatabase(config.GetDataDir(cfg), logger)
 		if err != nil {
 			return fmt.Errorf("failed to open database: %w", err)
 		}
@@ -94,6 +94,19 @@ var badgesGenerateCmd = &cobra.Command{
 			badges["Last Active"] = badgeGen.GenerateLastActiveBadge(*userProgress.LastActivityDate)
 		}
 
+		// User-defined metrics marked badge = true
+		if len(cfg.Metrics) > 0 {
+			values, err := computeMetricValues(cfg, db, 30)
+			if err != nil {
+				return err
+			}
+			for _, value := range values {
+				if value.Badge {
+					badges[value.Name] = badgeGen.GenerateCustomBadge(value.Name, value.Display(), "blue")
+				}
+			}
+		}
+
 		// Output format
 		output, _ := cmd.Flags().GetString("output")
 		format, _ := cmd.Flags().GetString("format")
@@ -153,7 +166,7 @@ var profileGenerateCmd = &cobra.Command{
 		logger := logrus.New()
 		logger.SetLevel(logrus.WarnLevel)
 
-		db, err := database.New(config.GetDataDir(cfg), logger)
+		db, err := openDatabase(config.GetDataDir(cfg), logger)
 		if err != nil {
 			return fmt.Errorf("failed to open database: %w", err)
 		}
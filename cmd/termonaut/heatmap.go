@@ -5,7 +5,6 @@ import (
 
 	"github.com/oiahoon/termonaut/internal/analytics"
 	"github.com/oiahoon/termonaut/internal/config"
-	"github.com/oiahoon/termonaut/internal/database"
 	"github.com/spf13/cobra"
 )
 
@@ -30,7 +29,7 @@ func runHeatmapCommand(cmd *cobra.Command, args []string) error {
 	logger := setupLogger(cfg.LogLevel)
 
 	// Initialize database
-	db, err := database.New(config.GetDataDir(cfg), logger)
+	db, err := openDatabase(config.GetDataDir(cfg), logger)
 	if err != nil {
 		return fmt.Errorf("failed to initialize database: %w", err)
 	}
@@ -62,19 +61,13 @@ func runHeatmapCommand(cmd *cobra.Command, args []string) error {
 
 		// Add insights
 		insights := heatmapAnalyzer.GenerateTimeInsights(heatmapData, commands)
-		if len(insights.Recommendations) > 0 {
-			fmt.Printf("\n💡 Recommendations:\n")
-			for i, rec := range insights.Recommendations {
-				fmt.Printf("  %d. %s\n", i+1, rec)
-			}
+		dismissed, err := db.GetDismissedRecommendations()
+		if err != nil {
+			return fmt.Errorf("failed to get dismissed recommendations: %w", err)
 		}
-
-		if len(insights.ProductivityTips) > 0 {
-			fmt.Printf("\n🎯 Productivity Tips:\n")
-			for _, tip := range insights.ProductivityTips {
-				fmt.Printf("  • %s: %s (Impact: %s, Effort: %s)\n",
-					tip.Title, tip.Description, tip.Impact, tip.Effort)
-			}
+		if recommendations := analytics.FilterDismissed(insights.Recommendations, dismissed); len(recommendations) > 0 {
+			fmt.Println()
+			fmt.Print(analytics.FormatRecommendations(recommendations, 0))
 		}
 	}
 
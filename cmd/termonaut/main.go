@@ -4,10 +4,14 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/oiahoon/termonaut/internal/analytics"
 	"github.com/oiahoon/termonaut/internal/avatar"
+	"github.com/oiahoon/termonaut/internal/calendar"
+	"github.com/oiahoon/termonaut/internal/categories"
 	"github.com/oiahoon/termonaut/internal/config"
 	"github.com/oiahoon/termonaut/internal/database"
 	"github.com/oiahoon/termonaut/internal/gamification"
@@ -57,6 +61,28 @@ Track your terminal habits, earn XP, unlock achievements, and level up
 your productivity - all without leaving your CLI!`,
 	SilenceUsage: true,
 	Aliases:      []string{"tn"},
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		applyConfig(cmd)
+	},
+}
+
+// applyConfig sets the day boundaries and categories of the configuration
+// before any command runs. A bad setting is reported, except by the
+// commands shell hooks run, whose output would land in every prompt; those
+// keep logging with the defaults.
+func applyConfig(cmd *cobra.Command) {
+	cfg, err := config.Load()
+	if err != nil {
+		return // each command reports it when it loads the config
+	}
+	if err := config.Apply(cfg); err != nil && !isShellHook(cmd) {
+		fmt.Fprintf(os.Stderr, "⚠️  Ignoring invalid settings: %v\n", err)
+	}
+}
+
+// isShellHook reports whether a command is run by the shell integration
+func isShellHook(cmd *cobra.Command) bool {
+	return cmd == logCommandCmd || cmd == logExitCmd || cmd == didYouMeanCmd || cmd == promptCmd
 }
 
 var statsCmd = &cobra.Command{
@@ -132,6 +158,17 @@ var logCommandCmd = &cobra.Command{
 	},
 }
 
+var logExitCmd = &cobra.Command{
+	Use:    "log-exit <exit-code>",
+	Short:  "Log how a command ended (internal use)",
+	Long:   "Internal command used by shell hooks to record the exit code and duration of a logged command.",
+	Args:   cobra.ExactArgs(1),
+	Hidden: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runLogExitCommand(cmd, args)
+	},
+}
+
 var versionCmd = &cobra.Command{
 	Use:   "version",
 	Short: "Show version information",
@@ -169,7 +206,7 @@ Fish:
 		logger := setupLogger("error")
 
 		// Initialize database
-		db, err := database.New(config.GetDataDir(cfg), logger)
+		db, err := openDatabase(config.GetDataDir(cfg), logger)
 		if err != nil {
 			// Silent fail for prompt integration
 			return nil
@@ -389,6 +426,7 @@ func init() {
 	rootCmd.AddCommand(initCmd)
 	rootCmd.AddCommand(configCmd)
 	rootCmd.AddCommand(logCommandCmd)
+	rootCmd.AddCommand(logExitCmd)
 	rootCmd.AddCommand(versionCmd)
 	rootCmd.AddCommand(promptCmd)
 	rootCmd.AddCommand(terminalTestCmd)
@@ -403,16 +441,25 @@ func init() {
 	rootCmd.AddCommand(doctorCmd)
 	rootCmd.AddCommand(statusCmd)
 
-	// Add gamification commands (temporarily commented out)
-	// rootCmd.AddCommand(progressCmd)
+	// Add gamification commands (achievements and level temporarily commented out)
+	rootCmd.AddCommand(progressCmd)
 	// rootCmd.AddCommand(achievementsCmd)
 	// rootCmd.AddCommand(levelCmd)
 
-	// Add category analysis command (temporarily commented out)
-	// rootCmd.AddCommand(categoriesCmd)
+	// Add category analysis command
+	rootCmd.AddCommand(categoriesCmd)
+
+	// Add privacy command
+	rootCmd.AddCommand(privacyCmd)
 
-	// Add productivity analytics command (temporarily commented out)
-	// rootCmd.AddCommand(analyticsCmd)
+	// Add productivity analytics command
+	rootCmd.AddCommand(analyticsCmd)
+	rootCmd.AddCommand(didYouMeanCmd)
+
+	// Add periodic report command
+	rootCmd.AddCommand(reportCmd)
+	rootCmd.AddCommand(queryCmd)
+	rootCmd.AddCommand(wrappedCmd)
 
 	// Add advanced features
 	rootCmd.AddCommand(tuiCmd)           // Main TUI command (now enhanced)
@@ -487,20 +534,26 @@ PowerShell:
 	statsCmd.Flags().Bool("weekly", false, "Show weekly stats")
 	statsCmd.Flags().Bool("monthly", false, "Show monthly stats")
 
+	// The shell hooks number each command so log-exit can find it
+	logCommandCmd.Flags().Int("pid", 0, "Process ID of the shell (default: the parent process)")
+	logCommandCmd.Flags().Int("seq", 0, "The shell's number for the command")
+	logExitCmd.Flags().Int("pid", 0, "Process ID of the shell (default: the parent process)")
+	logExitCmd.Flags().Int("seq", 0, "The shell's number for the command")
+
 	initCmd.Flags().Bool("force", false, "Force reinstall even if already installed")
 	initCmd.Flags().String("shell", "", "Specify shell type (zsh, bash)")
 
-	// Gamification command flags (temporarily commented out)
-	// progressCmd.Flags().BoolP("json", "j", false, "Output in JSON format")
+	// Gamification command flags (achievements and level temporarily commented out)
+	progressCmd.Flags().BoolP("json", "j", false, "Output in JSON format")
 	// achievementsCmd.Flags().Bool("all", false, "Show all achievements including locked ones")
 	// achievementsCmd.Flags().BoolP("json", "j", false, "Output in JSON format")
 	// levelCmd.Flags().BoolP("json", "j", false, "Output in JSON format")
 
-	// Category command flags (temporarily commented out)
-	// categoriesCmd.Flags().BoolP("json", "j", false, "Output in JSON format")
+	// Category command flags
+	categoriesCmd.Flags().BoolP("json", "j", false, "Output in JSON format")
 
-	// Analytics command flags (temporarily commented out)
-	// analyticsCmd.Flags().BoolP("json", "j", false, "Output in JSON format")
+	// Analytics command flags
+	analyticsCmd.Flags().BoolP("json", "j", false, "Output in JSON format")
 
 	// Heatmap command flags (temporarily commented out)
 	// heatmapCmd.Flags().BoolP("json", "j", false, "Output in JSON format")
@@ -520,7 +573,7 @@ func runStatsCommand(cmd *cobra.Command, args []string) error {
 	logger := setupLogger(cfg.LogLevel)
 
 	// Initialize database
-	db, err := database.New(config.GetDataDir(cfg), logger)
+	db, err := openDatabase(config.GetDataDir(cfg), logger)
 	if err != nil {
 		return fmt.Errorf("failed to initialize database: %w", err)
 	}
@@ -569,6 +622,16 @@ func runStatsCommand(cmd *cobra.Command, args []string) error {
 			// Fallback to regular stats display if avatar fails
 			fmt.Print(statsCalc.FormatBasicStats(basicStats))
 		}
+
+		// User-defined metrics from [[metrics]] in config.toml
+		if len(cfg.Metrics) > 0 {
+			values, err := computeMetricValues(cfg, db, 30)
+			if err != nil {
+				return err
+			}
+			fmt.Println()
+			fmt.Print(analytics.FormatMetrics(values))
+		}
 	}
 
 	return nil
@@ -636,6 +699,7 @@ func runConfigGetCommand(cmd *cobra.Command, args []string) error {
 		fmt.Printf("Command Categories: %t\n", cfg.CommandCategories)
 		fmt.Printf("Easter Eggs Enabled: %t\n", cfg.EasterEggsEnabled)
 		fmt.Printf("Empty Command Stats: %t\n", cfg.EmptyCommandStats)
+		fmt.Printf("Typo Suggestions: %t\n", cfg.TypoSuggestions)
 		fmt.Printf("Avatar Enabled: %t\n", cfg.AvatarEnabled)
 		fmt.Printf("Avatar Style: %s\n", cfg.AvatarStyle)
 		fmt.Printf("Avatar Size: %s\n", cfg.AvatarSize)
@@ -643,6 +707,8 @@ func runConfigGetCommand(cmd *cobra.Command, args []string) error {
 		fmt.Printf("Avatar Cache TTL: %s\n", cfg.AvatarCacheTTL)
 		fmt.Printf("Sync Enabled: %t\n", cfg.SyncEnabled)
 		fmt.Printf("Anonymous Mode: %t\n", cfg.AnonymousMode)
+		fmt.Printf("Privacy Sanitizer: %t\n", cfg.PrivacySanitizer)
+		fmt.Printf("Opt-out Commands: %s\n", strings.Join(cfg.OptOutCommands, ", "))
 		fmt.Printf("Log Level: %s\n", cfg.LogLevel)
 		return nil
 	}
@@ -659,6 +725,8 @@ func runConfigGetCommand(cmd *cobra.Command, args []string) error {
 		fmt.Printf("%t\n", cfg.EasterEggsEnabled)
 	case "empty_command_stats":
 		fmt.Printf("%t\n", cfg.EmptyCommandStats)
+	case "typo_suggestions":
+		fmt.Printf("%t\n", cfg.TypoSuggestions)
 	case "idle_timeout_minutes":
 		fmt.Printf("%d\n", cfg.IdleTimeoutMinutes)
 	case "sync_enabled":
@@ -677,6 +745,22 @@ func runConfigGetCommand(cmd *cobra.Command, args []string) error {
 		fmt.Println(cfg.AvatarColorSupport)
 	case "avatar_cache_ttl":
 		fmt.Println(cfg.AvatarCacheTTL)
+	case "anonymous_mode":
+		fmt.Printf("%t\n", cfg.AnonymousMode)
+	case "privacy_sanitizer":
+		fmt.Printf("%t\n", cfg.PrivacySanitizer)
+	case "sanitize_passwords":
+		fmt.Printf("%t\n", cfg.SanitizePasswords)
+	case "sanitize_urls":
+		fmt.Printf("%t\n", cfg.SanitizeURLs)
+	case "sanitize_file_paths":
+		fmt.Printf("%t\n", cfg.SanitizeFilePaths)
+	case "opt_out_commands":
+		fmt.Println(strings.Join(cfg.OptOutCommands, ","))
+	case "sensitive_patterns":
+		fmt.Println(strings.Join(cfg.SensitivePatterns, ","))
+	case "preserve_prefixes":
+		fmt.Println(strings.Join(cfg.PreservePrefixes, ","))
 	case "log_level":
 		fmt.Println(cfg.LogLevel)
 	default:
@@ -721,6 +805,11 @@ func runConfigSetCommand(cmd *cobra.Command, args []string) error {
 			return fmt.Errorf("invalid boolean value. Must be: true, false")
 		}
 		cfg.EmptyCommandStats = value == "true"
+	case "typo_suggestions":
+		if value != "true" && value != "false" {
+			return fmt.Errorf("invalid boolean value. Must be: true, false")
+		}
+		cfg.TypoSuggestions = value == "true"
 	case "sync_enabled":
 		if value != "true" && value != "false" {
 			return fmt.Errorf("invalid boolean value. Must be: true, false")
@@ -755,6 +844,41 @@ func runConfigSetCommand(cmd *cobra.Command, args []string) error {
 		cfg.AvatarColorSupport = value
 	case "avatar_cache_ttl":
 		cfg.AvatarCacheTTL = value
+	case "anonymous_mode":
+		if value != "true" && value != "false" {
+			return fmt.Errorf("invalid boolean value. Must be: true, false")
+		}
+		cfg.AnonymousMode = value == "true"
+	case "privacy_sanitizer":
+		if value != "true" && value != "false" {
+			return fmt.Errorf("invalid boolean value. Must be: true, false")
+		}
+		cfg.PrivacySanitizer = value == "true"
+	case "sanitize_passwords":
+		if value != "true" && value != "false" {
+			return fmt.Errorf("invalid boolean value. Must be: true, false")
+		}
+		cfg.SanitizePasswords = value == "true"
+	case "sanitize_urls":
+		if value != "true" && value != "false" {
+			return fmt.Errorf("invalid boolean value. Must be: true, false")
+		}
+		cfg.SanitizeURLs = value == "true"
+	case "sanitize_file_paths":
+		if value != "true" && value != "false" {
+			return fmt.Errorf("invalid boolean value. Must be: true, false")
+		}
+		cfg.SanitizeFilePaths = value == "true"
+	case "opt_out_commands":
+		cfg.OptOutCommands = splitConfigList(value)
+	case "sensitive_patterns":
+		patterns := splitConfigList(value)
+		if err := config.ValidateSensitivePatterns(patterns); err != nil {
+			return err
+		}
+		cfg.SensitivePatterns = patterns
+	case "preserve_prefixes":
+		cfg.PreservePrefixes = splitConfigList(value)
 	case "log_level":
 		if value != "debug" && value != "info" && value != "warn" && value != "error" {
 			return fmt.Errorf("invalid log_level value. Must be: debug, info, warn, error")
@@ -769,9 +893,24 @@ func runConfigSetCommand(cmd *cobra.Command, args []string) error {
 	}
 
 	fmt.Printf("✅ Configuration updated: %s = %s\n", key, value)
+	if key == "anonymous_mode" && cfg.AnonymousMode {
+		fmt.Println("Run \"termonaut privacy anonymize\" to convert the commands stored so far.")
+	}
 	return nil
 }
 
+// splitConfigList splits a comma-separated list value; an empty value
+// clears the list
+func splitConfigList(value string) []string {
+	list := []string{}
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
 func runLogCommandCommand(cmd *cobra.Command, args []string) error {
 	// Debug: Check what we received
 	if len(args) == 0 {
@@ -806,20 +945,18 @@ func runLogCommandCommand(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	// Load configuration
+	// Load configuration. Without it the privacy settings are unknown, so
+	// the command is not logged rather than logged with the defaults.
 	cfg, err := config.Load()
 	if err != nil {
-		// If config fails to load, still try to log the command
-		cfg = config.DefaultConfig()
+		return nil
 	}
 
-	// Initialize command sanitizer for privacy protection
-	sanitizer := privacy.NewCommandSanitizer(privacy.DefaultSanitizationConfig())
-
-	// Sanitize command for privacy
-	sanitizedCommand, shouldIgnore := sanitizer.SanitizeCommand(command)
-	if shouldIgnore {
-		return nil // Skip logging this command entirely
+	// Commands run in an ignored directory never touch the database
+	cwd := shell.GetCurrentWorkingDir()
+	decision := config.DirectoryRules(cfg).Decide(cwd)
+	if decision.Mode == privacy.TrackIgnore {
+		return nil
 	}
 
 	// Initialize logger (with minimal output for background operation)
@@ -827,15 +964,22 @@ func runLogCommandCommand(cmd *cobra.Command, args []string) error {
 	logger.SetLevel(logrus.ErrorLevel) // Only log errors for background operation
 
 	// Initialize database
-	db, err := database.New(config.GetDataDir(cfg), logger)
+	db, err := openDatabase(config.GetDataDir(cfg), logger)
 	if err != nil {
 		// Silent fail for background operation
 		return nil
 	}
 	defer db.Close()
 
+	// Apply the privacy settings
+	logged, err := applyPrivacy(cfg, command, cwd, decision, categories.NewCommandClassifier())
+	if err != nil || logged.Ignored {
+		return nil // Skip logging this command entirely
+	}
+	sanitizedCommand := logged.Stored
+
 	// Get or create session
-	session, err := db.GetOrCreateSession(shell.GetTerminalPID(), string(shell.Zsh))
+	session, err := db.GetOrCreateSession(shellPID(cmd), string(shell.Zsh))
 	if err != nil {
 		// Silent fail for background operation
 		return nil
@@ -843,12 +987,14 @@ func runLogCommandCommand(cmd *cobra.Command, args []string) error {
 
 	// Create command record
 	commandRecord := &models.Command{
-		Timestamp: time.Now(),
-		SessionID: session.ID,
-		Command:   sanitizedCommand, // Use sanitized command
-		ExitCode:  0,                // We don't have exit code from preexec hook
-		CWD:       shell.GetCurrentWorkingDir(),
+		Timestamp:  time.Now(),
+		SessionID:  session.ID,
+		Command:    sanitizedCommand, // Use sanitized command
+		ExitCode:   0,                // set by log-exit once the command ends
+		CWD:        logged.Directory,
+		Anonymized: logged.Anonymized,
 	}
+	commandRecord.ShellSeq, _ = cmd.Flags().GetInt("seq")
 
 	// Check for Easter Eggs (only if enabled in config)
 	if cfg.ShowGamification {
@@ -880,7 +1026,12 @@ func runLogCommandCommand(cmd *cobra.Command, args []string) error {
 	}
 
 	// Store command with enhanced gamification (XP, achievements, privacy)
-	if err := db.StoreCommandWithXP(commandRecord); err != nil {
+	if logged.Classification != nil {
+		err = db.StoreClassifiedCommandWithXP(commandRecord, logged.Classification)
+	} else {
+		err = db.StoreCommandWithXP(commandRecord)
+	}
+	if err != nil {
 		// Silent fail for background operation
 		return nil
 	}
@@ -902,6 +1053,56 @@ func runLogCommandCommand(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// logExitWait is how long log-exit waits for the command to be stored,
+// since log-command stores it in the background
+const logExitWait = 2 * time.Second
+
+func runLogExitCommand(cmd *cobra.Command, args []string) error {
+	finishedAt := time.Now()
+	exitCode, err := strconv.Atoi(args[0])
+	if err != nil {
+		return nil
+	}
+	seq, _ := cmd.Flags().GetInt("seq")
+	if seq <= 0 {
+		return nil // a hook that does not number its commands
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return nil
+	}
+
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel) // Only log errors for background operation
+
+	db, err := openDatabase(config.GetDataDir(cfg), logger)
+	if err != nil {
+		// Silent fail for background operation
+		return nil
+	}
+	defer db.Close()
+
+	// Ignored commands are never stored, so stop waiting after a while
+	pid := shellPID(cmd)
+	for deadline := finishedAt.Add(logExitWait); ; {
+		finished, err := db.FinishCommand(pid, seq, exitCode, finishedAt)
+		if err != nil || finished || time.Now().After(deadline) {
+			return nil
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+// shellPID returns the process ID of the shell a hook runs in, from --pid
+// or else the parent process
+func shellPID(cmd *cobra.Command) int {
+	if pid, _ := cmd.Flags().GetInt("pid"); pid > 0 {
+		return pid
+	}
+	return shell.GetTerminalPID()
+}
+
 // showQuickStats displays a concise stats summary when empty command is executed
 func showQuickStats() error {
 	// Load configuration
@@ -919,7 +1120,7 @@ func showQuickStats() error {
 	logger := setupLogger(cfg.LogLevel)
 
 	// Initialize database
-	db, err := database.New(config.GetDataDir(cfg), logger)
+	db, err := openDatabase(config.GetDataDir(cfg), logger)
 	if err != nil {
 		return nil // Silent fail for background operation
 	}
@@ -1050,17 +1251,31 @@ func setupLogger(level string) *logrus.Logger {
 	return logger
 }
 
+// openDatabase opens the database in dataDir and loads the learned
+// categories, so every command classifies with them
+func openDatabase(dataDir string, logger *logrus.Logger) (*database.DB, error) {
+	db, err := database.New(dataDir, logger)
+	if err != nil {
+		return nil, err
+	}
+	if err := db.LoadLearnedCategories(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to load learned categories: %w", err)
+	}
+	return db, nil
+}
+
 // isFirstCommandToday checks if this is the first command executed today
 func isFirstCommandToday(recentCommands []*models.Command) bool {
 	if len(recentCommands) == 0 {
 		return true
 	}
 
-	now := time.Now()
-	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	cal := calendar.Default()
+	today := cal.Today(time.Now())
 
 	for _, cmd := range recentCommands {
-		if cmd.Timestamp.After(today) {
+		if cal.DayKey(cmd.Timestamp) == today {
 			return false // Found another command today
 		}
 	}
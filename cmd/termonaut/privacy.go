@@ -0,0 +1,508 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/oiahoon/termonaut/internal/categories"
+	"github.com/oiahoon/termonaut/internal/config"
+	"github.com/oiahoon/termonaut/internal/database"
+	"github.com/oiahoon/termonaut/internal/privacy"
+	"github.com/oiahoon/termonaut/internal/shell"
+	"github.com/spf13/cobra"
+)
+
+var privacyCmd = &cobra.Command{
+	Use:   "privacy",
+	Short: "Check how the privacy settings treat commands",
+	Long: `Check what Termonaut records with the current privacy settings.
+
+The settings are changed with "termonaut config set":
+  privacy_sanitizer      sanitize commands before storing them
+  sanitize_passwords     redact passwords
+  sanitize_urls          keep only the host of URLs
+  sanitize_file_paths    keep only the last element of paths
+  anonymous_mode         keep only tools and categories readable; commands,
+                         arguments and directories are stored as keyed
+                         hashes (see "termonaut privacy anonymize --help")
+  opt_out_commands       comma-separated words; commands containing one are
+                         not recorded
+  sensitive_patterns     comma-separated regular expressions to redact
+  preserve_prefixes      comma-separated tools whose flags are kept
+
+Directories can be left out of tracking, or tracked without arguments, with
+.termonautignore and .termonaut.toml files or directory_rules in the config;
+see "termonaut privacy explain --help".`,
+}
+
+var privacyTestCmd = &cobra.Command{
+	Use:   "test <command>",
+	Short: "Show what would be stored for a command",
+	Long: `Show exactly what log-command would store for a command run in the current
+directory, and which setting decided it. Nothing is recorded.
+
+Examples:
+  termonaut privacy test "mysql -u root --password=hunter2"
+  termonaut privacy test "curl https://api.example.com/v1/users?token=abc"`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runPrivacyTestCommand(cmd, args)
+	},
+}
+
+var privacyAuditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "Find secrets in the stored commands",
+	Long: `Scan every stored command with the current secret detectors and report what
+they find, by type and by day. Commands logged before a detector existed may
+still hold secrets in plaintext.
+
+With --redact, the commands with secrets are rewritten in one transaction,
+each secret replaced by the label of the detector that found it, and the old
+text is purged from the database file and the cached results. Add --dry-run
+to see what would be rewritten first.
+
+Examples:
+  termonaut privacy audit
+  termonaut privacy audit --list
+  termonaut privacy audit --redact --dry-run
+  termonaut privacy audit --redact`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runPrivacyAuditCommand(cmd, args)
+	},
+}
+
+var privacyExplainCmd = &cobra.Command{
+	Use:   "explain [dir]",
+	Short: "Show which tracking rule applies to a directory",
+	Long: `Show how commands run in a directory are tracked and which rule decides it.
+
+The most restrictive of these wins, looking upward from the directory:
+  .termonautignore    the directory tree is not recorded
+  .termonaut.toml     mode = "ignore", "base-command-only" or "full"
+  directory_rules     [[directory_rules]] tables in the config, with a path
+                      (~ and glob patterns allowed) and a mode
+
+In base-command-only mode only the programs a command runs are recorded,
+without their arguments. A rule file can tighten the mode set above it or
+by the config but never loosen it. Without a directory, the current one is
+explained.
+
+Examples:
+  termonaut privacy explain
+  termonaut privacy explain ~/work/client-repo`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runPrivacyExplainCommand(cmd, args)
+	},
+}
+
+var privacyAnonymizeCmd = &cobra.Command{
+	Use:   "anonymize",
+	Short: "Convert the stored commands to anonymous mode",
+	Long: `Rewrite the commands stored before anonymous mode was turned on the way it
+stores new ones: only the tool and category of each command stay readable,
+and the command, its arguments and its working directory become keyed hashes.
+The same command always gets the same hash, so repetition, unique commands,
+streaks and top tools are counted as before.
+
+The hashes are made with a secret key created in the data directory
+(anonymous.key). They cannot be turned back into commands; keep the key to
+keep new commands matching the stored ones.
+
+Commands are rewritten in batches, each in one transaction, so an
+interrupted run continues where it stopped. The old text is purged from the
+database file and the cached results. Anonymized commands are left alone by
+"termonaut categories reclassify".
+
+Examples:
+  termonaut config set anonymous_mode true
+  termonaut privacy anonymize --dry-run
+  termonaut privacy anonymize`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runPrivacyAnonymizeCommand(cmd, args)
+	},
+}
+
+func init() {
+	privacyCmd.AddCommand(privacyTestCmd)
+	privacyTestCmd.Flags().BoolP("json", "j", false, "Output in JSON format")
+
+	privacyCmd.AddCommand(privacyAuditCmd)
+	privacyAuditCmd.Flags().Bool("redact", false, "Rewrite the commands with secrets, redacted")
+	privacyAuditCmd.Flags().Bool("dry-run", false, "With --redact, show what would be rewritten without writing anything")
+	privacyAuditCmd.Flags().Bool("list", false, "List each command with secrets, redacted")
+	privacyAuditCmd.Flags().BoolP("json", "j", false, "Output in JSON format")
+
+	privacyCmd.AddCommand(privacyExplainCmd)
+	privacyExplainCmd.Flags().BoolP("json", "j", false, "Output in JSON format")
+
+	privacyCmd.AddCommand(privacyAnonymizeCmd)
+	privacyAnonymizeCmd.Flags().Bool("dry-run", false, "Show how many commands would be rewritten without writing anything")
+	privacyAnonymizeCmd.Flags().BoolP("json", "j", false, "Output in JSON format")
+}
+
+// loggedCommand is what log-command stores for a command
+type loggedCommand struct {
+	*privacy.Result
+	Directory  string            `json:"directory,omitempty"`
+	Tracking   *privacy.Decision `json:"tracking"`
+	Anonymized bool              `json:"anonymized"`
+	Category   string            `json:"category,omitempty"` // in anonymous mode, where it cannot be read back
+
+	// Classification is made before anonymizing, in anonymous mode
+	Classification *categories.Classification `json:"-"`
+}
+
+// applyPrivacy applies the tracking decision of cwd and the privacy
+// settings to a command about to be logged from it. In anonymous mode, the
+// command and directory are replaced with keyed hashes after classifier
+// classifies the command.
+func applyPrivacy(cfg *config.Config, command, cwd string, decision *privacy.Decision, classifier *categories.CommandClassifier) (*loggedCommand, error) {
+	if decision.Mode == privacy.TrackIgnore {
+		result := &privacy.Result{Command: command, Ignored: true, IgnoredBy: decision.Source}
+		return &loggedCommand{Result: result, Tracking: decision}, nil
+	}
+
+	sanitizer := privacy.NewCommandSanitizer(config.SanitizationConfig(cfg))
+	result := sanitizer.Inspect(command)
+	if cfg.AnonymousMode && !result.Ignored {
+		// The hash hides the command, so it is made from the command as
+		// typed, as "privacy anonymize" does with stored ones; sanitizing
+		// first would merge commands that differ in their arguments
+		result.Stored = strings.TrimSpace(command)
+		result.Redactions = nil
+		result.Preserved = false
+	}
+	if !result.Ignored && decision.Mode == privacy.TrackBaseCommand {
+		result.Stored = categories.StripArguments(result.Stored)
+		result.Ignored = result.Stored == ""
+	}
+	logged := &loggedCommand{Result: result, Directory: cwd, Tracking: decision}
+	if result.Ignored {
+		logged.Directory = ""
+		return logged, nil
+	}
+
+	if cfg.AnonymousMode {
+		anonymizer, err := config.Anonymizer(cfg)
+		if err != nil {
+			return nil, err
+		}
+		result.Stored, logged.Classification = anonymizer.AnonymizeCommand(result.Stored, classifier)
+		logged.Category = string(logged.Classification.Primary)
+		logged.Directory = anonymizer.AnonymizeDirectory(cwd)
+		logged.Anonymized = true
+	}
+	return logged, nil
+}
+
+// describeDecision says which rule decides the tracking mode of a directory
+func describeDecision(decision *privacy.Decision) string {
+	switch decision.Source {
+	case "default":
+		return fmt.Sprintf("%s (no rule applies)", decision.Mode)
+	case "config":
+		return fmt.Sprintf("%s (directory_rules path %q, matching %s)", decision.Mode, decision.Rule, decision.Directory)
+	}
+	return fmt.Sprintf("%s (%s)", decision.Mode, decision.Source)
+}
+
+func runPrivacyTestCommand(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	// Anonymous mode classifies with the learned categories
+	db, err := openCategoriesDB()
+	if err != nil {
+		return err
+	}
+	db.Close()
+
+	cwd := shell.GetCurrentWorkingDir()
+	decision := config.DirectoryRules(cfg).Decide(cwd)
+	logged, err := applyPrivacy(cfg, args[0], cwd, decision, categories.NewCommandClassifier())
+	if err != nil {
+		return err
+	}
+	result := logged.Result
+
+	if jsonOutput, _ := cmd.Flags().GetBool("json"); jsonOutput {
+		return printJSON(logged)
+	}
+
+	fmt.Println("🔒 Privacy Test")
+	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+	fmt.Printf("Command:   %s\n", result.Command)
+	fmt.Printf("Tracking:  %s\n", describeDecision(decision))
+
+	switch {
+	case decision.Mode == privacy.TrackIgnore:
+		fmt.Println("Stored:    nothing (commands in this directory are not recorded)")
+	case result.Ignored && result.IgnoredBy == "":
+		fmt.Println("Stored:    nothing (empty command)")
+	case result.OptedOut:
+		fmt.Printf("Stored:    nothing (opt_out_commands contains %q)\n", result.IgnoredBy)
+	case result.Ignored:
+		fmt.Printf("Stored:    nothing (%s is never recorded)\n", result.IgnoredBy)
+	default:
+		fmt.Printf("Stored:    %s\n", result.Stored)
+		fmt.Printf("Directory: %s\n", logged.Directory)
+		if logged.Anonymized {
+			fmt.Printf("Category:  %s\n", logged.Category)
+		}
+	}
+	if result.Ignored {
+		return nil
+	}
+
+	if len(result.Redactions) > 0 {
+		fmt.Println("\nRedacted:")
+		for _, finding := range result.Redactions {
+			fmt.Printf("  %-20s found by %s (%d characters)\n",
+				privacy.Label(finding.Detector), finding.Detector, finding.End-finding.Start)
+		}
+	}
+
+	var notes []string
+	switch {
+	case decision.Mode == privacy.TrackBaseCommand:
+		notes = append(notes, "Only the programs are recorded in this directory, without arguments.")
+	case !cfg.PrivacySanitizer && !cfg.AnonymousMode:
+		notes = append(notes, "The privacy sanitizer is off (privacy_sanitizer = false).")
+	case logged.Anonymized:
+	case result.Stored == strings.TrimSpace(result.Command):
+		notes = append(notes, "Nothing sensitive was found.")
+	case result.Preserved:
+		notes = append(notes, "Flags are kept as they are (a preserved tool); the other arguments are sanitized one by one.")
+	}
+	if cfg.AnonymousMode {
+		notes = append(notes, "Anonymous mode is on: only the tools and the category are readable; the command and",
+			"directory are stored as keyed hashes.")
+	}
+	if len(notes) > 0 {
+		fmt.Println("\n" + strings.Join(notes, "\n"))
+	}
+	return nil
+}
+
+func runPrivacyExplainCommand(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	dir := shell.GetCurrentWorkingDir()
+	if len(args) > 0 {
+		dir = args[0]
+		if strings.HasPrefix(dir, "~") {
+			if home, err := os.UserHomeDir(); err == nil {
+				dir = home + strings.TrimPrefix(dir, "~")
+			}
+		}
+	}
+	if dir, err = filepath.Abs(dir); err != nil {
+		return fmt.Errorf("failed to resolve %s: %w", dir, err)
+	}
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", dir)
+	}
+
+	rules := config.DirectoryRules(cfg)
+	decisions := rules.Explain(dir)
+	decision := rules.Decide(dir)
+
+	if jsonOutput, _ := cmd.Flags().GetBool("json"); jsonOutput {
+		return printJSON(struct {
+			Directory string              `json:"directory"`
+			Decision  *privacy.Decision   `json:"decision"`
+			Rules     []*privacy.Decision `json:"rules"`
+		}{dir, decision, decisions})
+	}
+
+	fmt.Println("📂 Directory Tracking")
+	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+	fmt.Printf("Directory: %s\n", dir)
+	fmt.Printf("Mode:      %s\n", describeDecision(decision))
+	if decision.Cached {
+		fmt.Println("           (cached decision; still up to date)")
+	}
+	if decision.Error != "" {
+		fmt.Printf("⚠️  %s; commands are not recorded until it is fixed\n", decision.Error)
+	}
+
+	if len(decisions) > 1 {
+		fmt.Println("\nRules that apply, the first (most restrictive) one decides:")
+		for _, d := range decisions {
+			fmt.Printf("  %-18s %s\n", d.Mode, describeSource(d))
+		}
+	}
+	return nil
+}
+
+// describeSource says where a directory rule comes from
+func describeSource(decision *privacy.Decision) string {
+	switch decision.Source {
+	case "default":
+		return "default"
+	case "config":
+		return fmt.Sprintf("config: directory_rules path %q", decision.Rule)
+	}
+	return decision.Source
+}
+
+func runPrivacyAuditCommand(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	logger := setupLogger(cfg.LogLevel)
+	db, err := openDatabase(config.GetDataDir(cfg), logger)
+	if err != nil {
+		return fmt.Errorf("failed to initialize database: %w", err)
+	}
+	defer db.Close()
+
+	redact, _ := cmd.Flags().GetBool("redact")
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	jsonOutput, _ := cmd.Flags().GetBool("json")
+	list, _ := cmd.Flags().GetBool("list")
+
+	detectors := privacy.DefaultDetectors(config.SanitizationConfig(cfg))
+	report, err := db.AuditCommands(detectors, database.AuditOptions{Redact: redact, DryRun: dryRun})
+	if err != nil {
+		return fmt.Errorf("failed to audit commands: %w", err)
+	}
+
+	if jsonOutput {
+		return printJSON(report)
+	}
+
+	fmt.Println("🔍 Privacy Audit")
+	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+	fmt.Printf("Scanned %d stored commands: %d contain %d secrets\n",
+		report.Scanned, report.Commands, report.Secrets)
+	if report.Commands == 0 {
+		fmt.Println("\n✅ No secrets found.")
+		return nil
+	}
+
+	fmt.Println("\nBy type:")
+	detectorNames := make([]string, 0, len(report.ByDetector))
+	for name := range report.ByDetector {
+		detectorNames = append(detectorNames, name)
+	}
+	sort.Slice(detectorNames, func(i, j int) bool {
+		a, b := detectorNames[i], detectorNames[j]
+		if report.ByDetector[a] != report.ByDetector[b] {
+			return report.ByDetector[a] > report.ByDetector[b]
+		}
+		return a < b
+	})
+	for _, name := range detectorNames {
+		fmt.Printf("  %-22s %5d\n", privacy.Label(name), report.ByDetector[name])
+	}
+
+	fmt.Println("\nBy date:")
+	dates := make([]string, 0, len(report.ByDate))
+	for date := range report.ByDate {
+		dates = append(dates, date)
+	}
+	sort.Strings(dates)
+	for _, date := range dates {
+		fmt.Printf("  %-22s %5d\n", date, report.ByDate[date])
+	}
+
+	if list {
+		fmt.Println("\nCommands:")
+		for _, finding := range report.Findings {
+			fmt.Printf("  #%-6d %s  %s\n", finding.CommandID,
+				finding.Timestamp.Local().Format("2006-01-02 15:04"), finding.Redacted)
+		}
+	}
+
+	fmt.Println()
+	switch {
+	case redact && dryRun:
+		fmt.Printf("Dry run: %d commands would be rewritten with %d secrets redacted.\n",
+			report.Commands, report.Secrets)
+	case redact:
+		fmt.Printf("🧹 Redacted %d secrets in %d commands.\n", report.Secrets, report.Redacted)
+	default:
+		fmt.Println("Run \"termonaut privacy audit --redact\" to rewrite them.")
+	}
+	return nil
+}
+
+func runPrivacyAnonymizeCommand(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	jsonOutput, _ := cmd.Flags().GetBool("json")
+
+	// Converting the history while new commands are still stored in
+	// plaintext would leave it half anonymous
+	if !cfg.AnonymousMode && !dryRun {
+		return fmt.Errorf("anonymous mode is off; turn it on first with \"termonaut config set anonymous_mode true\"")
+	}
+
+	anonymizer, err := config.Anonymizer(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to load anonymous mode key: %w", err)
+	}
+
+	logger := setupLogger(cfg.LogLevel)
+	db, err := openDatabase(config.GetDataDir(cfg), logger)
+	if err != nil {
+		return fmt.Errorf("failed to initialize database: %w", err)
+	}
+	defer db.Close()
+
+	opts := database.AnonymizeOptions{DryRun: dryRun}
+	if !jsonOutput {
+		opts.Progress = func(report *database.AnonymizeReport) {
+			fmt.Printf("\r  %d/%d commands anonymized", report.Anonymized, report.Pending)
+		}
+	}
+	report, err := db.AnonymizeCommands(anonymizer, opts)
+	if err != nil {
+		if !jsonOutput {
+			fmt.Println()
+		}
+		return fmt.Errorf("failed to anonymize commands: %w", err)
+	}
+
+	if jsonOutput {
+		return printJSON(report)
+	}
+	if report.Anonymized > 0 {
+		fmt.Println()
+	}
+
+	fmt.Println("🕶️  Anonymize Commands")
+	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+	switch {
+	case report.Pending == 0:
+		fmt.Println("✅ Every stored command is already anonymized.")
+	case dryRun:
+		fmt.Printf("Dry run: %d commands from %d directories would be anonymized.\n",
+			report.Pending, report.Directories)
+		if !cfg.AnonymousMode {
+			fmt.Println("Turn on anonymous mode first: termonaut config set anonymous_mode true")
+		}
+	default:
+		fmt.Printf("🧹 Anonymized %d commands and %d segments from %d directories.\n",
+			report.Anonymized, report.Segments, report.Directories)
+		fmt.Printf("Keep %s: without it new commands no longer match the stored ones.\n",
+			filepath.Join(config.GetDataDir(cfg), privacy.AnonymousKeyFileName))
+	}
+	return nil
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/oiahoon/termonaut/internal/calendar"
+	"github.com/oiahoon/termonaut/internal/config"
+	"github.com/oiahoon/termonaut/internal/gamification"
+	"github.com/oiahoon/termonaut/internal/visualization"
+	"github.com/oiahoon/termonaut/pkg/models"
+	"github.com/spf13/cobra"
+)
+
+// maxChartPoints keeps the line chart within a terminal's width
+const maxChartPoints = 40
+
+// progressMetric is a value charted by progress history
+type progressMetric struct {
+	title string
+	value func(*models.ProgressSnapshot) int
+}
+
+var progressMetrics = map[string]progressMetric{
+	"xp":       {"Total XP", func(s *models.ProgressSnapshot) int { return s.TotalXP }},
+	"level":    {"Level", func(s *models.ProgressSnapshot) int { return s.Level }},
+	"commands": {"Commands", func(s *models.ProgressSnapshot) int { return s.CommandsCount }},
+	"unique":   {"Unique Commands", func(s *models.ProgressSnapshot) int { return s.UniqueCommandsCount }},
+	"streak":   {"Current Streak", func(s *models.ProgressSnapshot) int { return s.CurrentStreak }},
+}
+
+var progressHistoryCmd = &cobra.Command{
+	Use:   "history",
+	Short: "Chart your progress over time",
+	Long: `Chart XP, level, commands, unique commands or streak over the last days.
+A snapshot of your progress is saved every day you use the terminal. History
+from before snapshots were recorded is estimated from your command history.
+
+Examples:
+  termonaut progress history                    # XP over the last 30 days
+  termonaut progress history --metric level --days 90
+  termonaut progress history --at 2026-03-01    # what level was I on March 1`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runProgressHistoryCommand(cmd, args)
+	},
+}
+
+func init() {
+	progressCmd.AddCommand(progressHistoryCmd)
+	progressHistoryCmd.Flags().Int("days", 30, "Number of days to chart")
+	progressHistoryCmd.Flags().String("metric", "xp", "Metric to chart: "+strings.Join(progressMetricNames(), ", "))
+	progressHistoryCmd.Flags().String("at", "", "Show progress as of a day (YYYY-MM-DD)")
+	progressHistoryCmd.Flags().BoolP("json", "j", false, "Output in JSON format")
+}
+
+func runProgressHistoryCommand(cmd *cobra.Command, args []string) error {
+	days, _ := cmd.Flags().GetInt("days")
+	metricName, _ := cmd.Flags().GetString("metric")
+	at, _ := cmd.Flags().GetString("at")
+	jsonOutput, _ := cmd.Flags().GetBool("json")
+
+	metric, exists := progressMetrics[metricName]
+	if !exists {
+		return fmt.Errorf("unknown metric %q, expected one of: %s", metricName, strings.Join(progressMetricNames(), ", "))
+	}
+	if days < 1 {
+		return fmt.Errorf("invalid days %d: must be at least 1", days)
+	}
+	if at != "" {
+		if _, err := time.Parse(calendar.DateFormat, at); err != nil {
+			return fmt.Errorf("invalid date %q, expected YYYY-MM-DD", at)
+		}
+	}
+
+	// Load configuration
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	// Initialize logger
+	logger := setupLogger(cfg.LogLevel)
+
+	// Initialize database
+	db, err := openDatabase(config.GetDataDir(cfg), logger)
+	if err != nil {
+		return fmt.Errorf("failed to initialize database: %w", err)
+	}
+	defer db.Close()
+
+	// Estimates the days logged before snapshots were recorded
+	if _, err := db.BackfillProgressSnapshots(); err != nil {
+		return fmt.Errorf("failed to backfill progress history: %w", err)
+	}
+	snapshots, err := db.GetProgressHistory()
+	if err != nil {
+		return fmt.Errorf("failed to get progress history: %w", err)
+	}
+
+	if at != "" {
+		snapshot := gamification.SnapshotAt(snapshots, at)
+		if jsonOutput {
+			return printJSON(snapshot)
+		}
+		fmt.Print(formatProgressSnapshot(snapshot, at))
+		return nil
+	}
+
+	series := gamification.ProgressSeries(snapshots, calendar.Default().LastDays(time.Now(), days))
+	if jsonOutput {
+		return printJSON(series)
+	}
+	fmt.Print(formatProgressHistory(series, metric))
+	return nil
+}
+
+func printJSON(v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal JSON: %w", err)
+	}
+	fmt.Println(string(data))
+	return nil
+}
+
+func progressMetricNames() []string {
+	names := make([]string, 0, len(progressMetrics))
+	for name := range progressMetrics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// formatProgressHistory charts one metric of a progress series
+func formatProgressHistory(series []*models.ProgressSnapshot, metric progressMetric) string {
+	first, last := series[0], series[len(series)-1]
+
+	// Sample long ranges down to what fits, always keeping the last day
+	step := (len(series) + maxChartPoints - 1) / maxChartPoints
+	var data []float64
+	var labels []string
+	for i := (len(series) - 1) % step; i < len(series); i += step {
+		data = append(data, float64(metric.value(series[i])))
+		labels = append(labels, series[i].Date[8:]) // day of the month
+	}
+
+	chart := &visualization.LineChart{
+		Title:  fmt.Sprintf("%s (%s to %s)", metric.title, first.Date, last.Date),
+		Data:   data,
+		Labels: labels,
+	}
+	minVal, maxVal := data[0], data[0]
+	for _, v := range data {
+		if v < minVal {
+			minVal = v
+		}
+		if v > maxVal {
+			maxVal = v
+		}
+	}
+	if minVal == maxVal {
+		chart.YAxis = visualization.YAxisConfig{Min: minVal - 1, Max: maxVal + 1}
+	}
+
+	result := visualization.NewChartRenderer(60, 10).RenderLineChart(chart)
+
+	from, to := metric.value(first), metric.value(last)
+	result += fmt.Sprintf("%s: %d → %d (%+d)\n", metric.title, from, to, to-from)
+
+	estimatedUntil := ""
+	for _, point := range series {
+		if point.Estimated {
+			estimatedUntil = point.Date
+		}
+	}
+	if estimatedUntil != "" {
+		result += fmt.Sprintf("ℹ️  Values up to %s are estimated from command history\n", estimatedUntil)
+	}
+
+	return result
+}
+
+// formatProgressSnapshot shows progress as of a day
+func formatProgressSnapshot(snapshot *models.ProgressSnapshot, date string) string {
+	if snapshot == nil {
+		return fmt.Sprintf("📭 No progress recorded on or before %s\n", date)
+	}
+
+	levelCalc := gamification.NewLevelCalculator()
+	result := fmt.Sprintf("🕰️  Progress on %s\n", date)
+	result += "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n"
+	result += fmt.Sprintf("🚀 Level:           %d %s\n", snapshot.Level, levelCalc.GetLevelTitle(snapshot.Level))
+	result += fmt.Sprintf("💎 Total XP:        %d\n", snapshot.TotalXP)
+	result += fmt.Sprintf("⌨️  Commands:        %d (%d unique)\n", snapshot.CommandsCount, snapshot.UniqueCommandsCount)
+	result += fmt.Sprintf("🔥 Streak:          %d days (longest %d)\n", snapshot.CurrentStreak, snapshot.LongestStreak)
+	result += fmt.Sprintf("🏆 Achievements:    %d\n", snapshot.AchievementsCount)
+	if snapshot.Estimated {
+		result += "ℹ️  Estimated from command history\n"
+	}
+	return result
+}
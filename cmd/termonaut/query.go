@@ -0,0 +1,126 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/oiahoon/termonaut/internal/config"
+	"github.com/oiahoon/termonaut/internal/query"
+	"github.com/spf13/cobra"
+)
+
+var queryCmd = &cobra.Command{
+	Use:   "query '<query>'",
+	Short: "Run an ad-hoc query over your command history",
+	Long: `Run an ad-hoc query over your command history.
+
+Syntax:
+  [select] [by group, ...] [where filter and ...] [order by column [asc|desc]] [limit n]
+
+The select list holds fields or aggregates: count(), distinct(field),
+sum(field), avg(field), min(field), max(field). It defaults to count().
+Without aggregates or by, matching commands are listed.
+
+Group by any field or a time bucket: hour, day, week, month, year, weekday,
+hour_of_day. Filters use =, !=, >, >=, <, <=, ~ (contains) and !~ (does not
+contain); since= and until= take a date or a relative time (12h, 30d, 4w,
+6mo, 1y). Quote values containing spaces.
+
+Examples:
+  termonaut query 'count() by tool where category=git and since=30d order by count desc limit 10'
+  termonaut query 'count(), avg(duration_ms) by week where since=3mo'
+  termonaut query 'timestamp, command, exit_code where failed=1 and cwd~termonaut limit 20'
+  termonaut query 'sum(failed), count() by subcommand where tool=git' --format csv
+
+Run 'termonaut query --fields' to list the available fields.`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if listFields, _ := cmd.Flags().GetBool("fields"); listFields {
+			return nil
+		}
+		if len(args) == 0 {
+			return fmt.Errorf("a query is required, e.g. termonaut query 'count() by tool'")
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runQueryCommand(cmd, args)
+	},
+}
+
+func init() {
+	queryCmd.Flags().String("format", "table", "Output format (table, json, csv)")
+	queryCmd.Flags().Bool("fields", false, "List the fields available to queries")
+	queryCmd.Flags().Bool("explain", false, "Print the generated SQL instead of running it")
+}
+
+func runQueryCommand(cmd *cobra.Command, args []string) error {
+	format, _ := cmd.Flags().GetString("format")
+	listFields, _ := cmd.Flags().GetBool("fields")
+	explain, _ := cmd.Flags().GetBool("explain")
+
+	if listFields {
+		for _, field := range query.Fields() {
+			fmt.Printf("  %-12s %s\n", field.Name, field.Description)
+		}
+		return nil
+	}
+
+	input := strings.Join(args, " ")
+	if explain {
+		q, err := query.Parse(input)
+		if err != nil {
+			return fmt.Errorf("invalid query: %w", err)
+		}
+		compiled, err := query.Compile(q, time.Now())
+		if err != nil {
+			return fmt.Errorf("invalid query: %w", err)
+		}
+		fmt.Println(compiled.SQL)
+		fmt.Printf("-- args: %v\n", compiled.Args)
+		return nil
+	}
+
+	switch format {
+	case "table", "json", "csv":
+	default:
+		return fmt.Errorf("unknown format %q (use table, json or csv)", format)
+	}
+
+	// Load configuration
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	// Initialize logger
+	logger := setupLogger(cfg.LogLevel)
+
+	// Initialize database
+	db, err := openDatabase(config.GetDataDir(cfg), logger)
+	if err != nil {
+		return fmt.Errorf("failed to initialize database: %w", err)
+	}
+	defer db.Close()
+
+	result, err := query.Run(db, input, time.Now())
+	if err != nil {
+		return err
+	}
+
+	var output string
+	switch format {
+	case "json":
+		output, err = query.FormatJSON(result)
+	case "csv":
+		output, err = query.FormatCSV(result)
+	default:
+		output = query.FormatTable(result)
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Print(output)
+	return nil
+}
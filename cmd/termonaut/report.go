@@ -0,0 +1,116 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/oiahoon/termonaut/internal/config"
+	"github.com/oiahoon/termonaut/internal/report"
+	"github.com/spf13/cobra"
+)
+
+var reportCmd = &cobra.Command{
+	Use:   "report",
+	Short: "Generate a weekly, monthly or yearly activity report",
+	Long: `Generate a local activity report covering stats, categories, the activity
+heatmap, achievements earned and your top workflows.
+
+Reports render through Go templates. The built-in Markdown and HTML templates
+are used by default; pass --template to use your own. HTML output uses inline
+styles so it can be sent as an email.
+
+Examples:
+  termonaut report                                # this week, Markdown to stdout
+  termonaut report --period month --out report.html
+  termonaut report --period year --template my-report.tmpl --out year.md
+
+See docs/REPORTS.md for the data model available to templates.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runReportCommand(cmd, args)
+	},
+}
+
+func init() {
+	reportCmd.Flags().String("period", "week", "Report period (week, month, year)")
+	reportCmd.Flags().String("template", "", "Path to a custom text/template or html/template file")
+	reportCmd.Flags().String("out", "", "Write the report to a file instead of stdout")
+	reportCmd.Flags().String("format", "", "Output format (text, html); defaults to the --out or --template extension")
+}
+
+func runReportCommand(cmd *cobra.Command, args []string) error {
+	periodName, _ := cmd.Flags().GetString("period")
+	templatePath, _ := cmd.Flags().GetString("template")
+	outPath, _ := cmd.Flags().GetString("out")
+	formatName, _ := cmd.Flags().GetString("format")
+
+	period, err := report.ParsePeriod(periodName)
+	if err != nil {
+		return err
+	}
+
+	format := report.FormatFromPath(outPath)
+	if outPath == "" && templatePath != "" {
+		format = report.FormatFromPath(templatePath)
+	}
+	if formatName != "" {
+		if format, err = report.ParseFormat(formatName); err != nil {
+			return err
+		}
+	}
+
+	source, err := report.LoadTemplate(templatePath, format)
+	if err != nil {
+		return err
+	}
+
+	// Load configuration
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	// Initialize logger
+	logger := setupLogger(cfg.LogLevel)
+
+	// Initialize database
+	db, err := openDatabase(config.GetDataDir(cfg), logger)
+	if err != nil {
+		return fmt.Errorf("failed to initialize database: %w", err)
+	}
+	defer db.Close()
+
+	commands, err := db.GetAllCommands()
+	if err != nil {
+		return fmt.Errorf("failed to get commands: %w", err)
+	}
+
+	achievements, err := db.GetUserAchievements()
+	if err != nil {
+		return fmt.Errorf("failed to get achievements: %w", err)
+	}
+
+	progress, err := db.GetUserProgress()
+	if err != nil {
+		return fmt.Errorf("failed to get user progress: %w", err)
+	}
+
+	data := report.NewBuilder().Build(period, time.Now(), commands, achievements, progress)
+
+	output, err := report.Render(data, source, format)
+	if err != nil {
+		return err
+	}
+
+	if outPath == "" {
+		fmt.Print(output)
+		return nil
+	}
+
+	if err := os.WriteFile(outPath, []byte(output), 0644); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+	fmt.Printf("📄 %s written to %s\n", data.Title, outPath)
+
+	return nil
+}
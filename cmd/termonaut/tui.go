@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/oiahoon/termonaut/internal/analytics"
 	"github.com/oiahoon/termonaut/internal/config"
 	"github.com/oiahoon/termonaut/internal/database"
 	"github.com/oiahoon/termonaut/internal/tui"
@@ -70,7 +71,7 @@ func runTUICommand(cmd *cobra.Command, args []string) error {
 	logger := setupLogger(cfg.LogLevel)
 
 	// Initialize database
-	db, err := database.New(config.GetDataDir(cfg), logger)
+	db, err := openDatabase(config.GetDataDir(cfg), logger)
 	if err != nil {
 		return fmt.Errorf("failed to initialize database: %w", err)
 	}
@@ -81,7 +82,13 @@ func runTUICommand(cmd *cobra.Command, args []string) error {
 	case "classic":
 		return runClassicTUI(db)
 	case "compact", "full", "smart":
-		return runEnhancedTUI(db, mode)
+		var metrics *analytics.MetricEngine
+		if len(cfg.Metrics) > 0 {
+			if metrics, err = analytics.NewMetricEngine(cfg.Metrics); err != nil {
+				return fmt.Errorf("invalid metrics configuration: %w", err)
+			}
+		}
+		return runEnhancedTUI(db, mode, metrics)
 	default:
 		return fmt.Errorf("unknown mode: %s. Available modes: smart, compact, full, classic, minimal", mode)
 	}
@@ -100,12 +107,15 @@ func runClassicTUI(db *database.DB) error {
 	return nil
 }
 
-func runEnhancedTUI(db *database.DB, mode string) error {
+func runEnhancedTUI(db *database.DB, mode string, metrics *analytics.MetricEngine) error {
 	// Create enhanced dashboard
 	dashboard := enhanced.NewEnhancedDashboard(db)
 	
 	// Set mode preference (the dashboard will adapt accordingly)
 	dashboard.SetModePreference(mode)
+	if metrics != nil {
+		dashboard.SetMetrics(metrics)
+	}
 
 	// Run the TUI
 	program := tea.NewProgram(
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/oiahoon/termonaut/internal/config"
+	"github.com/oiahoon/termonaut/internal/report"
+	"github.com/oiahoon/termonaut/internal/tui/enhanced"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+var wrappedCmd = &cobra.Command{
+	Use:   "wrapped",
+	Short: "Play your year in review",
+	Long: `Play an animated recap of a calendar year: top tools, busiest day, longest
+streak, biggest time sinks, new tools learned, achievements earned and your
+terminal personality.
+
+Use --compare to contrast the year with another one, and --out to write
+shareable Markdown, HTML and SVG files.
+
+Examples:
+  termonaut wrapped                       # this year so far
+  termonaut wrapped --year 2026 --compare 2025
+  termonaut wrapped --out ~/wrapped       # writes wrapped-<year>.md/.html/.svg`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runWrappedCommand(cmd, args)
+	},
+}
+
+func init() {
+	wrappedCmd.Flags().Int("year", time.Now().Year(), "Calendar year to recap")
+	wrappedCmd.Flags().Int("compare", 0, "Compare with another year")
+	wrappedCmd.Flags().String("out", "", "Directory to write wrapped-<year>.md, .html and .svg to")
+	wrappedCmd.Flags().Bool("no-animation", false, "Print the recap as Markdown instead of animating it")
+}
+
+func runWrappedCommand(cmd *cobra.Command, args []string) error {
+	year, _ := cmd.Flags().GetInt("year")
+	compare, _ := cmd.Flags().GetInt("compare")
+	outDir, _ := cmd.Flags().GetString("out")
+	noAnimation, _ := cmd.Flags().GetBool("no-animation")
+
+	now := time.Now()
+	if year < 1970 || year > now.Year() {
+		return fmt.Errorf("invalid year %d: must be between 1970 and %d", year, now.Year())
+	}
+	if cmd.Flags().Changed("compare") {
+		if compare < 1970 || compare > now.Year() {
+			return fmt.Errorf("invalid compare year %d: must be between 1970 and %d", compare, now.Year())
+		}
+		if compare == year {
+			return fmt.Errorf("cannot compare %d with itself", year)
+		}
+	}
+
+	// Load configuration
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	// Initialize logger
+	logger := setupLogger(cfg.LogLevel)
+
+	// Initialize database
+	db, err := openDatabase(config.GetDataDir(cfg), logger)
+	if err != nil {
+		return fmt.Errorf("failed to initialize database: %w", err)
+	}
+	defer db.Close()
+
+	commands, err := db.GetAllCommands()
+	if err != nil {
+		return fmt.Errorf("failed to get commands: %w", err)
+	}
+
+	achievements, err := db.GetUserAchievements()
+	if err != nil {
+		return fmt.Errorf("failed to get achievements: %w", err)
+	}
+
+	builder := report.NewBuilder()
+	wrapped := builder.BuildWrapped(year, now, commands, achievements)
+	if cmd.Flags().Changed("compare") {
+		wrapped.CompareTo(builder.BuildWrapped(compare, now, commands, achievements))
+	}
+
+	if outDir != "" {
+		if err := writeWrappedFiles(wrapped, outDir); err != nil {
+			return err
+		}
+	}
+
+	if noAnimation || !term.IsTerminal(int(os.Stdout.Fd())) {
+		if outDir != "" {
+			return nil
+		}
+		output, err := report.RenderWrapped(wrapped, report.FormatText)
+		if err != nil {
+			return err
+		}
+		fmt.Print(output)
+		return nil
+	}
+
+	program := tea.NewProgram(enhanced.NewWrappedModel(wrapped), tea.WithAltScreen())
+	if _, err := program.Run(); err != nil {
+		return fmt.Errorf("failed to run wrapped animation: %w", err)
+	}
+
+	return nil
+}
+
+// writeWrappedFiles writes the Markdown, HTML and SVG versions of a recap
+func writeWrappedFiles(wrapped *report.Wrapped, dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	formats := []struct {
+		format report.Format
+		ext    string
+	}{
+		{report.FormatText, "md"},
+		{report.FormatHTML, "html"},
+		{report.FormatSVG, "svg"},
+	}
+	for _, f := range formats {
+		output, err := report.RenderWrapped(wrapped, f.format)
+		if err != nil {
+			return err
+		}
+		path := filepath.Join(dir, fmt.Sprintf("wrapped-%d.%s", wrapped.Year, f.ext))
+		if err := os.WriteFile(path, []byte(output), 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", path, err)
+		}
+		fmt.Printf("📄 Wrapped %d written to %s\n", wrapped.Year, path)
+	}
+
+	return nil
+}
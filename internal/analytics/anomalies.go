@@ -0,0 +1,310 @@
+package analytics
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/oiahoon/termonaut/internal/calendar"
+	"github.com/oiahoon/termonaut/internal/categories"
+	"github.com/oiahoon/termonaut/pkg/models"
+)
+
+const (
+	// anomalyThreshold is the robust z-score above which a day is flagged
+	anomalyThreshold = 3.5
+
+	// minBaselineDays is the number of days of history needed before flagging anything
+	minBaselineDays = 7
+
+	// minAnomalyValue keeps tiny absolute numbers (2 failures instead of 0) from
+	// being reported just because the baseline is flat
+	minAnomalyValue = 10
+
+	// lateNightEndHour marks the end of the all-nighter window (00:00-05:59)
+	lateNightEndHour = 6
+)
+
+// AnomalyType identifies what kind of unusual activity was detected
+type AnomalyType string
+
+const (
+	AnomalyActivitySpike AnomalyType = "activity_spike"
+	AnomalyFailureSpike  AnomalyType = "failure_spike"
+	AnomalyAllNighter    AnomalyType = "all_nighter"
+	AnomalyToolSurge     AnomalyType = "tool_surge"
+)
+
+// Anomaly represents a day that stands out from the user's own baseline
+type Anomaly struct {
+	Date        string      `json:"date"`
+	Type        AnomalyType `json:"type"`
+	Subject     string      `json:"subject,omitempty"` // tool name for tool surges
+	Value       int         `json:"value"`
+	Baseline    float64     `json:"baseline"` // median of the daily values
+	Score       float64     `json:"score"`    // robust z-score
+	Description string      `json:"description"`
+}
+
+// DailyRollup aggregates a single day of activity
+type DailyRollup struct {
+	Date      string         `json:"date"`
+	Commands  int            `json:"commands"`
+	Failures  int            `json:"failures"`
+	LateNight int            `json:"late_night"`
+	Tools     map[string]int `json:"tools"`
+}
+
+// AnomalyDetector flags unusual days using a robust z-score over daily rollups
+type AnomalyDetector struct {
+	classifier *categories.CommandClassifier
+}
+
+// NewAnomalyDetector creates a new anomaly detector
+func NewAnomalyDetector() *AnomalyDetector {
+	return &AnomalyDetector{
+		classifier: categories.NewCommandClassifier(),
+	}
+}
+
+// BuildDailyRollups groups commands by local calendar day, filling days with
+// no activity so quiet days count towards the baseline
+func (ad *AnomalyDetector) BuildDailyRollups(commands []*models.Command) []*DailyRollup {
+	if len(commands) == 0 {
+		return []*DailyRollup{}
+	}
+
+	cal := calendar.Default()
+	byDate := make(map[string]*DailyRollup)
+	first, last := cal.DayKey(commands[0].Timestamp), cal.DayKey(commands[0].Timestamp)
+	for _, cmd := range commands {
+		date := cal.DayKey(cmd.Timestamp)
+		rollup, exists := byDate[date]
+		if !exists {
+			rollup = &DailyRollup{Date: date, Tools: make(map[string]int)}
+			byDate[date] = rollup
+		}
+
+		rollup.Commands++
+		if cmd.ExitCode != 0 {
+			rollup.Failures++
+		}
+		if cal.Clock(cmd.Timestamp).Hour() < lateNightEndHour {
+			rollup.LateNight++
+		}
+		if base := ad.classifier.BaseCommand(cmd.Command); base != "" {
+			rollup.Tools[base]++
+		}
+
+		if date < first {
+			first = date
+		}
+		if date > last {
+			last = date
+		}
+	}
+
+	var rollups []*DailyRollup
+	for date := first; date <= last; date = calendar.AddDays(date, 1) {
+		if rollup, exists := byDate[date]; exists {
+			rollups = append(rollups, rollup)
+		} else {
+			rollups = append(rollups, &DailyRollup{Date: date, Tools: make(map[string]int)})
+		}
+	}
+
+	return rollups
+}
+
+// DetectAnomalies returns unusual days, most recent and most severe first
+func (ad *AnomalyDetector) DetectAnomalies(commands []*models.Command) []*Anomaly {
+	rollups := ad.BuildDailyRollups(commands)
+	anomalies := []*Anomaly{}
+	if len(rollups) < minBaselineDays {
+		return anomalies
+	}
+
+	series := func(value func(*DailyRollup) int) []float64 {
+		values := make([]float64, len(rollups))
+		for i, rollup := range rollups {
+			values[i] = float64(value(rollup))
+		}
+		return values
+	}
+
+	checks := []struct {
+		anomalyType AnomalyType
+		value       func(*DailyRollup) int
+		describe    func(value int, baseline float64) string
+	}{
+		{
+			AnomalyActivitySpike,
+			func(r *DailyRollup) int { return r.Commands },
+			func(value int, baseline float64) string {
+				return fmt.Sprintf("%d commands vs. a typical %.0f", value, baseline)
+			},
+		},
+		{
+			AnomalyFailureSpike,
+			func(r *DailyRollup) int { return r.Failures },
+			func(value int, baseline float64) string {
+				return fmt.Sprintf("%d failed commands vs. a typical %.0f", value, baseline)
+			},
+		},
+		{
+			AnomalyAllNighter,
+			func(r *DailyRollup) int { return r.LateNight },
+			func(value int, baseline float64) string {
+				return fmt.Sprintf("%d commands between midnight and 6am", value)
+			},
+		},
+	}
+
+	for _, check := range checks {
+		values := series(check.value)
+		median, scale := robustStats(values)
+		for i, rollup := range rollups {
+			value := check.value(rollup)
+			score := robustZScore(values[i], median, scale)
+			if value < minAnomalyValue || score < anomalyThreshold {
+				continue
+			}
+			anomalies = append(anomalies, &Anomaly{
+				Date:        rollup.Date,
+				Type:        check.anomalyType,
+				Value:       value,
+				Baseline:    median,
+				Score:       score,
+				Description: check.describe(value, median),
+			})
+		}
+	}
+
+	// Tool surges compare each tool with its own daily history
+	tools := make(map[string]bool)
+	for _, rollup := range rollups {
+		for tool := range rollup.Tools {
+			tools[tool] = true
+		}
+	}
+	for tool := range tools {
+		values := series(func(r *DailyRollup) int { return r.Tools[tool] })
+		median, scale := robustStats(values)
+		for i, rollup := range rollups {
+			value := rollup.Tools[tool]
+			score := robustZScore(values[i], median, scale)
+			if value < minAnomalyValue || score < anomalyThreshold {
+				continue
+			}
+			anomalies = append(anomalies, &Anomaly{
+				Date:        rollup.Date,
+				Type:        AnomalyToolSurge,
+				Subject:     tool,
+				Value:       value,
+				Baseline:    median,
+				Score:       score,
+				Description: fmt.Sprintf("%s used %d times vs. a typical %.0f", tool, value, median),
+			})
+		}
+	}
+
+	sort.Slice(anomalies, func(i, j int) bool {
+		if anomalies[i].Date != anomalies[j].Date {
+			return anomalies[i].Date > anomalies[j].Date
+		}
+		if anomalies[i].Score != anomalies[j].Score {
+			return anomalies[i].Score > anomalies[j].Score
+		}
+		return anomalies[i].Subject < anomalies[j].Subject
+	})
+
+	return anomalies
+}
+
+// RecentAnomalies filters anomalies to those within the last n days of now
+func RecentAnomalies(anomalies []*Anomaly, now time.Time, days int) []*Anomaly {
+	cutoff := calendar.AddDays(calendar.Default().Today(now), -days)
+	recent := []*Anomaly{}
+	for _, anomaly := range anomalies {
+		if anomaly.Date > cutoff {
+			recent = append(recent, anomaly)
+		}
+	}
+	return recent
+}
+
+// robustStats returns the median and a robust scale estimate. The scale is the
+// median absolute deviation, falling back to the mean absolute deviation when
+// more than half of the days share the same value.
+func robustStats(values []float64) (float64, float64) {
+	median := medianFloat(values)
+
+	deviations := make([]float64, len(values))
+	total := 0.0
+	for i, value := range values {
+		deviations[i] = math.Abs(value - median)
+		total += deviations[i]
+	}
+
+	if mad := medianFloat(deviations); mad > 0 {
+		return median, 1.4826 * mad
+	}
+	return median, 1.2533 * total / float64(len(values))
+}
+
+// robustZScore scores how far a value sits above the median
+func robustZScore(value, median, scale float64) float64 {
+	if scale == 0 {
+		return 0
+	}
+	return (value - median) / scale
+}
+
+func medianFloat(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := make([]float64, len(values))
+	copy(sorted, values)
+	sort.Float64s(sorted)
+
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
+
+// AnomalyIcon returns a display icon for an anomaly type
+func AnomalyIcon(anomalyType AnomalyType) string {
+	switch anomalyType {
+	case AnomalyActivitySpike:
+		return "📈"
+	case AnomalyFailureSpike:
+		return "❌"
+	case AnomalyAllNighter:
+		return "🌙"
+	case AnomalyToolSurge:
+		return "🔧"
+	default:
+		return "⚠️"
+	}
+}
+
+// FormatAnomalies renders anomalies as a list of lines for reports
+func FormatAnomalies(anomalies []*Anomaly, limit int) string {
+	if len(anomalies) == 0 {
+		return "  ✅ Nothing unusual — activity is in line with your baseline.\n"
+	}
+
+	result := ""
+	for i, anomaly := range anomalies {
+		if limit > 0 && i >= limit {
+			break
+		}
+		result += fmt.Sprintf("  %s %s  %s (z=%.1f)\n",
+			AnomalyIcon(anomaly.Type), anomaly.Date, anomaly.Description, anomaly.Score)
+	}
+	return result
+}
@@ -0,0 +1,259 @@
+package analytics
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/oiahoon/termonaut/internal/privacy"
+	"github.com/oiahoon/termonaut/pkg/models"
+)
+
+// DirectoryAnalyzer aggregates activity by working directory
+type DirectoryAnalyzer struct {
+	sanitizer *privacy.CommandSanitizer
+}
+
+// NewDirectoryAnalyzer creates a new directory analyzer. Paths are anonymized
+// with the sanitizer's file-path settings; a nil sanitizer uses the defaults.
+func NewDirectoryAnalyzer(sanitizer *privacy.CommandSanitizer) *DirectoryAnalyzer {
+	if sanitizer == nil {
+		sanitizer = privacy.NewCommandSanitizer(nil)
+	}
+	return &DirectoryAnalyzer{
+		sanitizer: sanitizer,
+	}
+}
+
+// DirectoryNode is a directory with activity totals for its whole subtree
+type DirectoryNode struct {
+	Name            string           `json:"name"`
+	Path            string           `json:"path"`
+	Commands        int              `json:"commands"`     // commands run in this directory or below
+	OwnCommands     int              `json:"own_commands"` // commands run in this directory itself
+	Failures        int              `json:"failures"`
+	FailureRate     float64          `json:"failure_rate"`
+	TotalDurationMS int64            `json:"total_duration_ms"`
+	LastUsed        time.Time        `json:"last_used"`
+	Children        []*DirectoryNode `json:"children,omitempty"`
+
+	children map[string]*DirectoryNode
+}
+
+// DirectoryRow is one visible line of a rendered directory tree. Rows with a
+// nil Node summarize subdirectories left out by the per-directory limit.
+type DirectoryRow struct {
+	Node       *DirectoryNode
+	Label      string // directory name, with single-child chains joined ("code/termonaut")
+	Prefix     string // tree-drawing characters before the label
+	Depth      int
+	Expandable bool // the directory has subdirectories
+	Expanded   bool
+}
+
+// BuildTree aggregates commands into a directory tree. The returned root is
+// unnamed; its children are the top-level directories ("~" and "/").
+func (da *DirectoryAnalyzer) BuildTree(commands []*models.Command) *DirectoryNode {
+	root := newDirectoryNode("", "")
+
+	for _, cmd := range commands {
+		if cmd.CWD == "" {
+			continue
+		}
+
+		node := root
+		node.add(cmd)
+		for _, part := range splitDirectory(da.sanitizer.SanitizeDirectory(cmd.CWD)) {
+			child, exists := node.children[part]
+			if !exists {
+				child = newDirectoryNode(part, joinDirectory(node.Path, part))
+				node.children[part] = child
+			}
+			node = child
+			node.add(cmd)
+		}
+		node.OwnCommands++
+	}
+
+	root.finalize()
+	return root
+}
+
+func newDirectoryNode(name, path string) *DirectoryNode {
+	return &DirectoryNode{
+		Name:     name,
+		Path:     path,
+		children: make(map[string]*DirectoryNode),
+	}
+}
+
+func (n *DirectoryNode) add(cmd *models.Command) {
+	n.Commands++
+	n.TotalDurationMS += cmd.DurationMS
+	if cmd.ExitCode != 0 {
+		n.Failures++
+	}
+	if cmd.Timestamp.After(n.LastUsed) {
+		n.LastUsed = cmd.Timestamp
+	}
+}
+
+// finalize computes rates and sorts children by activity, busiest first
+func (n *DirectoryNode) finalize() {
+	if n.Commands > 0 {
+		n.FailureRate = float64(n.Failures) / float64(n.Commands) * 100
+	}
+
+	n.Children = make([]*DirectoryNode, 0, len(n.children))
+	for _, child := range n.children {
+		child.finalize()
+		n.Children = append(n.Children, child)
+	}
+	sort.Slice(n.Children, func(i, j int) bool {
+		if n.Children[i].Commands != n.Children[j].Commands {
+			return n.Children[i].Commands > n.Children[j].Commands
+		}
+		return n.Children[i].Name < n.Children[j].Name
+	})
+}
+
+// Find returns the node at path, or nil
+func (n *DirectoryNode) Find(path string) *DirectoryNode {
+	if n.Path == path {
+		return n
+	}
+	for _, child := range n.Children {
+		if path == child.Path || strings.HasPrefix(path, strings.TrimSuffix(child.Path, "/")+"/") {
+			return child.Find(path)
+		}
+	}
+	return nil
+}
+
+// splitDirectory splits a path into components, keeping "~" or "/" as the first
+func splitDirectory(path string) []string {
+	var parts []string
+	if strings.HasPrefix(path, "/") {
+		parts = append(parts, "/")
+	}
+	for _, part := range strings.Split(path, "/") {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return parts
+}
+
+func joinDirectory(parent, name string) string {
+	switch {
+	case parent == "":
+		return name
+	case strings.HasSuffix(parent, "/"):
+		return parent + name
+	default:
+		return parent + "/" + name
+	}
+}
+
+// FlattenTree lists the visible rows of the tree. isExpanded decides whether
+// a directory's children are shown; maxChildren limits the subdirectories
+// listed per directory (0 for no limit). Chains of directories with a single
+// subdirectory and no commands of their own are joined into one row.
+func (da *DirectoryAnalyzer) FlattenTree(root *DirectoryNode, isExpanded func(node *DirectoryNode, depth int) bool, maxChildren int) []DirectoryRow {
+	var rows []DirectoryRow
+
+	var walk func(node *DirectoryNode, prefix string, depth int)
+	walk = func(node *DirectoryNode, prefix string, depth int) {
+		children := node.Children
+		hidden := 0
+		if maxChildren > 0 && len(children) > maxChildren {
+			hidden = len(children) - maxChildren
+			children = children[:maxChildren]
+		}
+
+		for i, child := range children {
+			last := i == len(children)-1
+
+			label := child.Name
+			for len(child.Children) == 1 && child.OwnCommands == 0 {
+				child = child.Children[0]
+				label = joinDirectory(label, child.Name)
+			}
+
+			branch, indent := "├── ", "│   "
+			if last {
+				branch, indent = "└── ", "    "
+			}
+
+			row := DirectoryRow{
+				Node:       child,
+				Label:      label,
+				Prefix:     prefix + branch,
+				Depth:      depth,
+				Expandable: len(child.Children) > 0,
+			}
+			row.Expanded = row.Expandable && isExpanded(child, depth)
+			rows = append(rows, row)
+
+			if row.Expanded {
+				walk(child, prefix+indent, depth+1)
+			}
+		}
+
+		if hidden > 0 {
+			rows = append(rows, DirectoryRow{
+				Label:  fmt.Sprintf("… %d more", hidden),
+				Prefix: prefix + "    ",
+				Depth:  depth,
+			})
+		}
+	}
+	walk(root, "", 0)
+
+	return rows
+}
+
+// FormatDirectoryRow renders a tree row with its activity columns
+func FormatDirectoryRow(row DirectoryRow, width int) string {
+	if row.Node == nil {
+		return row.Prefix + row.Label
+	}
+
+	marker := ""
+	if row.Expandable && !row.Expanded {
+		marker = " ▸"
+	}
+	name := truncateCommand(row.Prefix+row.Label+marker, width)
+	padding := width - len([]rune(name))
+	if padding < 0 {
+		padding = 0
+	}
+
+	return fmt.Sprintf("%s%s %7d %9s %6.1f%%", name, strings.Repeat(" ", padding),
+		row.Node.Commands, FormatDuration(row.Node.TotalDurationMS), row.Node.FailureRate)
+}
+
+// FormatDirectoryTree generates a formatted directory hotspot tree, showing
+// at most depth levels and maxChildren subdirectories per directory
+func (da *DirectoryAnalyzer) FormatDirectoryTree(root *DirectoryNode, depth, maxChildren int) string {
+	if root.Commands == 0 {
+		return "📁 No directory information recorded yet.\n"
+	}
+
+	result := fmt.Sprintf("📁 Directory Hotspots\n")
+	result += fmt.Sprintf("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n\n")
+	result += fmt.Sprintf("%d commands across %d top-level directories\n\n", root.Commands, len(root.Children))
+
+	const nameWidth = 40
+	result += fmt.Sprintf("%-*s %7s %9s %7s\n", nameWidth, "Directory", "Commands", "Time", "Fail%")
+
+	rows := da.FlattenTree(root, func(node *DirectoryNode, d int) bool {
+		return depth <= 0 || d+1 < depth
+	}, maxChildren)
+	for _, row := range rows {
+		result += FormatDirectoryRow(row, nameWidth) + "\n"
+	}
+
+	return result
+}
@@ -0,0 +1,284 @@
+package analytics
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/oiahoon/termonaut/internal/calendar"
+	"github.com/oiahoon/termonaut/pkg/models"
+)
+
+const (
+	// minTrendSamples is the number of timed runs needed before a trend is reported
+	minTrendSamples = 6
+
+	// regressionThreshold is the median slowdown (in percent) flagged as a regression
+	regressionThreshold = 20.0
+)
+
+// DurationAnalyzer analyzes how long commands take to run
+type DurationAnalyzer struct{}
+
+// NewDurationAnalyzer creates a new duration analyzer
+func NewDurationAnalyzer() *DurationAnalyzer {
+	return &DurationAnalyzer{}
+}
+
+// DurationReport summarizes command execution times
+type DurationReport struct {
+	TotalCommands int                     `json:"total_commands"`
+	TimedCommands int                     `json:"timed_commands"`
+	TotalTimeMS   int64                   `json:"total_time_ms"`
+	Overall       *DurationPercentiles    `json:"overall"`
+	Commands      []*CommandDurationStats `json:"commands"`
+	TimeSinks     []*CommandDurationStats `json:"time_sinks"`
+	Regressions   []*CommandDurationStats `json:"regressions"`
+}
+
+// DurationPercentiles holds percentile durations in milliseconds
+type DurationPercentiles struct {
+	P50 int64 `json:"p50_ms"`
+	P90 int64 `json:"p90_ms"`
+	P99 int64 `json:"p99_ms"`
+	Max int64 `json:"max_ms"`
+}
+
+// CommandDurationStats holds duration statistics for a single command line
+type CommandDurationStats struct {
+	Command       string               `json:"command"`
+	Count         int                  `json:"count"`
+	TotalMS       int64                `json:"total_ms"`
+	AverageMS     float64              `json:"average_ms"`
+	Percentiles   *DurationPercentiles `json:"percentiles"`
+	Trend         []DurationTrendPoint `json:"trend"`
+	ChangePercent float64              `json:"change_percent"` // median change, later runs vs earlier runs
+}
+
+// DurationTrendPoint is the median duration of a command on a given day
+type DurationTrendPoint struct {
+	Date     string `json:"date"`
+	MedianMS int64  `json:"median_ms"`
+	Runs     int    `json:"runs"`
+}
+
+// AnalyzeDurations computes percentiles, time sinks and regressions from
+// commands that have a recorded duration
+func (da *DurationAnalyzer) AnalyzeDurations(commands []*models.Command) *DurationReport {
+	report := &DurationReport{
+		TotalCommands: len(commands),
+		Overall:       &DurationPercentiles{},
+		Commands:      []*CommandDurationStats{},
+		TimeSinks:     []*CommandDurationStats{},
+		Regressions:   []*CommandDurationStats{},
+	}
+
+	grouped := make(map[string][]*models.Command)
+	var all []int64
+	for _, cmd := range commands {
+		if cmd.DurationMS <= 0 {
+			continue
+		}
+		key := normalizeCommandLine(cmd.Command)
+		if key == "" {
+			continue
+		}
+		grouped[key] = append(grouped[key], cmd)
+		all = append(all, cmd.DurationMS)
+		report.TotalTimeMS += cmd.DurationMS
+	}
+
+	report.TimedCommands = len(all)
+	if len(all) == 0 {
+		return report
+	}
+	report.Overall = calculatePercentiles(all)
+
+	for command, runs := range grouped {
+		report.Commands = append(report.Commands, da.analyzeCommand(command, runs))
+	}
+
+	// Sort by p90 so the consistently slow commands come first
+	sort.Slice(report.Commands, func(i, j int) bool {
+		if report.Commands[i].Percentiles.P90 != report.Commands[j].Percentiles.P90 {
+			return report.Commands[i].Percentiles.P90 > report.Commands[j].Percentiles.P90
+		}
+		return report.Commands[i].Command < report.Commands[j].Command
+	})
+
+	report.TimeSinks = append(report.TimeSinks, report.Commands...)
+	sort.Slice(report.TimeSinks, func(i, j int) bool {
+		if report.TimeSinks[i].TotalMS != report.TimeSinks[j].TotalMS {
+			return report.TimeSinks[i].TotalMS > report.TimeSinks[j].TotalMS
+		}
+		return report.TimeSinks[i].Command < report.TimeSinks[j].Command
+	})
+
+	for _, stats := range report.Commands {
+		if stats.Count >= minTrendSamples && stats.ChangePercent >= regressionThreshold {
+			report.Regressions = append(report.Regressions, stats)
+		}
+	}
+	sort.Slice(report.Regressions, func(i, j int) bool {
+		return report.Regressions[i].ChangePercent > report.Regressions[j].ChangePercent
+	})
+
+	return report
+}
+
+// analyzeCommand computes statistics for all runs of one command line
+func (da *DurationAnalyzer) analyzeCommand(command string, runs []*models.Command) *CommandDurationStats {
+	sort.Slice(runs, func(i, j int) bool {
+		return runs[i].Timestamp.Before(runs[j].Timestamp)
+	})
+
+	durations := make([]int64, len(runs))
+	stats := &CommandDurationStats{
+		Command: command,
+		Count:   len(runs),
+		Trend:   []DurationTrendPoint{},
+	}
+
+	daily := make(map[string][]int64)
+	var days []string
+	for i, run := range runs {
+		durations[i] = run.DurationMS
+		stats.TotalMS += run.DurationMS
+
+		day := calendar.Default().DayKey(run.Timestamp)
+		if _, exists := daily[day]; !exists {
+			days = append(days, day)
+		}
+		daily[day] = append(daily[day], run.DurationMS)
+	}
+
+	stats.AverageMS = float64(stats.TotalMS) / float64(stats.Count)
+	stats.Percentiles = calculatePercentiles(durations)
+
+	for _, day := range days {
+		stats.Trend = append(stats.Trend, DurationTrendPoint{
+			Date:     day,
+			MedianMS: percentile(sortedCopy(daily[day]), 50),
+			Runs:     len(daily[day]),
+		})
+	}
+
+	// Compare the median of the earlier half of runs with the later half
+	if stats.Count >= minTrendSamples {
+		half := stats.Count / 2
+		before := percentile(sortedCopy(durations[:half]), 50)
+		after := percentile(sortedCopy(durations[stats.Count-half:]), 50)
+		if before > 0 {
+			stats.ChangePercent = float64(after-before) / float64(before) * 100
+		}
+	}
+
+	return stats
+}
+
+// calculatePercentiles returns p50/p90/p99/max for the given durations
+func calculatePercentiles(durations []int64) *DurationPercentiles {
+	sorted := sortedCopy(durations)
+	return &DurationPercentiles{
+		P50: percentile(sorted, 50),
+		P90: percentile(sorted, 90),
+		P99: percentile(sorted, 99),
+		Max: sorted[len(sorted)-1],
+	}
+}
+
+// percentile returns the nearest-rank percentile of sorted values
+func percentile(sorted []int64, p float64) int64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+func sortedCopy(values []int64) []int64 {
+	sorted := make([]int64, len(values))
+	copy(sorted, values)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return sorted
+}
+
+// normalizeCommandLine collapses whitespace so identical invocations group together
+func normalizeCommandLine(command string) string {
+	return strings.Join(strings.Fields(command), " ")
+}
+
+// FormatDuration renders milliseconds in a compact human-readable form
+func FormatDuration(ms int64) string {
+	d := time.Duration(ms) * time.Millisecond
+	switch {
+	case d < time.Second:
+		return fmt.Sprintf("%dms", ms)
+	case d < time.Minute:
+		return fmt.Sprintf("%.1fs", d.Seconds())
+	case d < time.Hour:
+		return fmt.Sprintf("%dm%02ds", int(d.Minutes()), int(d.Seconds())%60)
+	default:
+		return fmt.Sprintf("%dh%02dm", int(d.Hours()), int(d.Minutes())%60)
+	}
+}
+
+// FormatDurationReport generates a formatted slow command report
+func (da *DurationAnalyzer) FormatDurationReport(report *DurationReport, limit int) string {
+	if report.TimedCommands == 0 {
+		return "⏱️  No command durations recorded yet.\n"
+	}
+
+	result := fmt.Sprintf("⏱️  Command Duration Report\n")
+	result += fmt.Sprintf("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n\n")
+
+	result += fmt.Sprintf("📊 Timed commands: %d of %d | Total time: %s\n",
+		report.TimedCommands, report.TotalCommands, FormatDuration(report.TotalTimeMS))
+	result += fmt.Sprintf("   p50 %s | p90 %s | p99 %s | max %s\n",
+		FormatDuration(report.Overall.P50), FormatDuration(report.Overall.P90),
+		FormatDuration(report.Overall.P99), FormatDuration(report.Overall.Max))
+
+	result += fmt.Sprintf("\n🐢 Slowest Commands (by p90):\n")
+	for i, stats := range report.Commands {
+		if limit > 0 && i >= limit {
+			break
+		}
+		result += fmt.Sprintf("  %-32s p50 %-8s p90 %-8s p99 %-8s (%dx)\n",
+			truncateCommand(stats.Command, 32),
+			FormatDuration(stats.Percentiles.P50), FormatDuration(stats.Percentiles.P90),
+			FormatDuration(stats.Percentiles.P99), stats.Count)
+	}
+
+	result += fmt.Sprintf("\n⌛ Top Time Sinks (total wall time):\n")
+	for i, stats := range report.TimeSinks {
+		if limit > 0 && i >= limit {
+			break
+		}
+		share := float64(stats.TotalMS) / float64(report.TotalTimeMS) * 100
+		result += fmt.Sprintf("  %-32s %-8s %5.1f%%  (%dx)\n",
+			truncateCommand(stats.Command, 32), FormatDuration(stats.TotalMS), share, stats.Count)
+	}
+
+	if len(report.Regressions) > 0 {
+		result += fmt.Sprintf("\n📈 Getting Slower:\n")
+		for _, stats := range report.Regressions {
+			result += fmt.Sprintf("  %-32s +%.0f%% median over %d runs\n",
+				truncateCommand(stats.Command, 32), stats.ChangePercent, stats.Count)
+		}
+	}
+
+	return result
+}
+
+func truncateCommand(command string, width int) string {
+	runes := []rune(command)
+	if len(runes) <= width {
+		return command
+	}
+	return string(runes[:width-1]) + "…"
+}
@@ -0,0 +1,345 @@
+package analytics
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/oiahoon/termonaut/internal/calendar"
+	"github.com/oiahoon/termonaut/internal/categories"
+	"github.com/oiahoon/termonaut/pkg/models"
+)
+
+const (
+	// maxFocusGap is the longest pause that still counts as sustained activity
+	maxFocusGap = 10 * time.Minute
+
+	// minFocusDuration is the shortest stretch reported as a deep-work block
+	minFocusDuration = 25 * time.Minute
+
+	// minFocusCommands keeps a couple of widely spaced commands from forming a block
+	minFocusCommands = 10
+
+	// maxExcursion is how many consecutive off-context commands are tolerated
+	// (checking a log, a quick ls elsewhere) before the block counts as switched
+	maxExcursion = 2
+)
+
+// FocusAnalyzer detects deep-work blocks from command timing and context
+type FocusAnalyzer struct {
+	classifier *categories.CommandClassifier
+}
+
+// NewFocusAnalyzer creates a new focus analyzer
+func NewFocusAnalyzer() *FocusAnalyzer {
+	return &FocusAnalyzer{
+		classifier: categories.NewCommandClassifier(),
+	}
+}
+
+// FocusBlock is a sustained stretch of activity in a single project or category
+type FocusBlock struct {
+	Start           time.Time `json:"start"`
+	End             time.Time `json:"end"`
+	DurationMinutes float64   `json:"duration_minutes"`
+	Commands        int       `json:"commands"`
+	Project         string    `json:"project"`
+	Category        string    `json:"category"`
+	Excursions      int       `json:"excursions"` // brief off-context commands inside the block
+}
+
+// FocusReport summarizes deep-work blocks
+type FocusReport struct {
+	Blocks             []*FocusBlock `json:"blocks"`
+	TotalBlocks        int           `json:"total_blocks"`
+	AverageMinutes     float64       `json:"average_minutes"`
+	LongestMinutes     float64       `json:"longest_minutes"`
+	TotalFocusMinutes  float64       `json:"total_focus_minutes"`
+	ActiveMinutes      float64       `json:"active_minutes"`
+	FocusScore         float64       `json:"focus_score"` // share of active time spent in deep work
+	BestHours          []int         `json:"best_hours"`
+	HourlyFocusMinutes [24]float64   `json:"hourly_focus_minutes"`
+}
+
+// focusContext identifies what a command was working on
+type focusContext struct {
+	project  string
+	category string
+}
+
+// AnalyzeFocus finds deep-work blocks in the command history
+func (fa *FocusAnalyzer) AnalyzeFocus(commands []*models.Command) *FocusReport {
+	report := &FocusReport{
+		Blocks:    []*FocusBlock{},
+		BestHours: []int{},
+	}
+	if len(commands) == 0 {
+		return report
+	}
+
+	sorted := make([]*models.Command, len(commands))
+	copy(sorted, commands)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Timestamp.Before(sorted[j].Timestamp)
+	})
+
+	// Split into stretches of continuous activity, then split those on context switches
+	start := 0
+	for i := 1; i <= len(sorted); i++ {
+		if i < len(sorted) && sorted[i].Timestamp.Sub(sorted[i-1].Timestamp) <= maxFocusGap {
+			continue
+		}
+		stretch := sorted[start:i]
+		report.ActiveMinutes += stretch[len(stretch)-1].Timestamp.Sub(stretch[0].Timestamp).Minutes()
+		report.Blocks = append(report.Blocks, fa.splitOnContextSwitches(stretch)...)
+		start = i
+	}
+
+	report.TotalBlocks = len(report.Blocks)
+	for _, block := range report.Blocks {
+		report.TotalFocusMinutes += block.DurationMinutes
+		if block.DurationMinutes > report.LongestMinutes {
+			report.LongestMinutes = block.DurationMinutes
+		}
+		distributeFocusMinutes(block, &report.HourlyFocusMinutes)
+	}
+
+	if report.TotalBlocks > 0 {
+		report.AverageMinutes = report.TotalFocusMinutes / float64(report.TotalBlocks)
+	}
+	if report.ActiveMinutes > 0 {
+		report.FocusScore = report.TotalFocusMinutes / report.ActiveMinutes * 100
+	}
+	report.BestHours = bestFocusHours(report.HourlyFocusMinutes, 3)
+
+	return report
+}
+
+// splitOnContextSwitches turns one stretch of continuous activity into the
+// deep-work blocks it contains
+func (fa *FocusAnalyzer) splitOnContextSwitches(stretch []*models.Command) []*FocusBlock {
+	var blocks []*FocusBlock
+
+	blockStart := 0
+	current := fa.contextOf(stretch[0])
+	excursionStart, excursions := -1, 0
+
+	for i := 1; i < len(stretch); i++ {
+		ctx := fa.contextOf(stretch[i])
+		if current.matches(ctx) {
+			if excursionStart >= 0 {
+				excursions++
+			}
+			excursionStart = -1
+			continue
+		}
+
+		if excursionStart < 0 {
+			excursionStart = i
+		}
+		if i-excursionStart+1 <= maxExcursion {
+			continue
+		}
+
+		// A real switch: close the block before the excursion began
+		if block := fa.newBlock(stretch[blockStart:excursionStart], current, excursions); block != nil {
+			blocks = append(blocks, block)
+		}
+		blockStart = excursionStart
+		current = fa.contextOf(stretch[excursionStart])
+		excursionStart, excursions = -1, 0
+		i = blockStart
+	}
+
+	end := len(stretch)
+	if excursionStart >= 0 {
+		end = excursionStart
+	}
+	if block := fa.newBlock(stretch[blockStart:end], current, excursions); block != nil {
+		blocks = append(blocks, block)
+	}
+
+	return blocks
+}
+
+// newBlock builds a focus block if the commands qualify as deep work
+func (fa *FocusAnalyzer) newBlock(commands []*models.Command, ctx focusContext, excursions int) *FocusBlock {
+	if len(commands) < minFocusCommands {
+		return nil
+	}
+	start, end := commands[0].Timestamp, commands[len(commands)-1].Timestamp
+	if end.Sub(start) < minFocusDuration {
+		return nil
+	}
+
+	return &FocusBlock{
+		Start:           start,
+		End:             end,
+		DurationMinutes: end.Sub(start).Minutes(),
+		Commands:        len(commands),
+		Project:         ctx.project,
+		Category:        ctx.category,
+		Excursions:      excursions,
+	}
+}
+
+// contextOf returns the project and category a command belongs to
+func (fa *FocusAnalyzer) contextOf(cmd *models.Command) focusContext {
+	category := fa.classifier.CommandCategory(cmd).Root()
+	return focusContext{
+		project:  projectKey(cmd.CWD),
+		category: fa.classifier.GetCategoryInfo(category).Name,
+	}
+}
+
+// matches reports whether a command stays within the block's context: the
+// same project, or the same category when no project is known
+func (c focusContext) matches(other focusContext) bool {
+	if c.project != "" && other.project != "" {
+		return c.project == other.project
+	}
+	return c.category == other.category
+}
+
+// projectKey reduces a working directory to the project it belongs to, taken
+// as the first two directories below the home directory
+func projectKey(cwd string) string {
+	if cwd == "" {
+		return ""
+	}
+
+	parts := strings.Split(strings.Trim(cwd, "/"), "/")
+	if len(parts) >= 2 && (parts[0] == "home" || parts[0] == "Users") {
+		parts = parts[2:]
+	} else if len(parts) >= 1 && parts[0] == "root" {
+		parts = parts[1:]
+	}
+	if len(parts) == 0 {
+		return "~"
+	}
+	if len(parts) > 2 {
+		parts = parts[:2]
+	}
+	return strings.Join(parts, "/")
+}
+
+// distributeFocusMinutes spreads a block's minutes over the hours it covers
+func distributeFocusMinutes(block *FocusBlock, hourly *[24]float64) {
+	cursor := block.Start
+	for cursor.Before(block.End) {
+		next := cursor.Truncate(time.Hour).Add(time.Hour)
+		if next.After(block.End) {
+			next = block.End
+		}
+		hourly[calendar.Default().Clock(cursor).Hour()] += next.Sub(cursor).Minutes()
+		cursor = next
+	}
+}
+
+// bestFocusHours returns the hours with the most deep-work minutes
+func bestFocusHours(hourly [24]float64, limit int) []int {
+	hours := []int{}
+	for hour, minutes := range hourly {
+		if minutes > 0 {
+			hours = append(hours, hour)
+		}
+	}
+	sort.Slice(hours, func(i, j int) bool {
+		if hourly[hours[i]] != hourly[hours[j]] {
+			return hourly[hours[i]] > hourly[hours[j]]
+		}
+		return hours[i] < hours[j]
+	})
+	if len(hours) > limit {
+		hours = hours[:limit]
+	}
+	sort.Ints(hours)
+	return hours
+}
+
+// FormatFocusTimeline renders one row per day with deep-work blocks marked
+// in half-hour cells: █ focus, ░ other activity, · idle
+func (fa *FocusAnalyzer) FormatFocusTimeline(report *FocusReport, commands []*models.Command, days int, now time.Time) string {
+	const cellsPerDay = 48
+
+	cal := calendar.Default()
+	clock := cal.Clock(now)
+	today := time.Date(clock.Year(), clock.Month(), clock.Day(), 0, 0, 0, 0, clock.Location())
+	first := today.AddDate(0, 0, -(days - 1))
+
+	rows := make(map[string][]rune)
+	for d := 0; d < days; d++ {
+		rows[first.AddDate(0, 0, d).Format("2006-01-02")] = []rune(strings.Repeat("·", cellsPerDay))
+	}
+
+	// Rows follow the wall clock from midnight, regardless of day start
+	cellOf := func(t time.Time) (string, int) {
+		t = cal.Clock(t)
+		return t.Format(calendar.DateFormat), t.Hour()*2 + t.Minute()/30
+	}
+
+	for _, cmd := range commands {
+		date, cell := cellOf(cmd.Timestamp)
+		if row, exists := rows[date]; exists && row[cell] == '·' {
+			row[cell] = '░'
+		}
+	}
+	for _, block := range report.Blocks {
+		for t := block.Start; !t.After(block.End); t = t.Add(30 * time.Minute) {
+			if date, cell := cellOf(t); rows[date] != nil {
+				rows[date][cell] = '█'
+			}
+		}
+	}
+
+	result := "    0     3     6     9     12    15    18    21\n"
+	for d := 0; d < days; d++ {
+		day := first.AddDate(0, 0, d)
+		result += fmt.Sprintf("%s %s\n", day.Format("Mon"), string(rows[day.Format("2006-01-02")]))
+	}
+	result += "Legend: █ deep work  ░ activity  · idle\n"
+
+	return result
+}
+
+// FormatFocusReport generates a formatted deep-work report
+func (fa *FocusAnalyzer) FormatFocusReport(report *FocusReport, limit int) string {
+	if report.TotalBlocks == 0 {
+		return "🎯 No deep-work blocks detected yet.\n" +
+			"A block is at least 25 minutes of steady work in one project without long breaks.\n"
+	}
+
+	result := fmt.Sprintf("🎯 Deep Work Report\n")
+	result += fmt.Sprintf("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n\n")
+
+	result += fmt.Sprintf("🧠 Focus blocks: %d | Average: %.0f min | Longest: %.0f min\n",
+		report.TotalBlocks, report.AverageMinutes, report.LongestMinutes)
+	result += fmt.Sprintf("⏱️  Deep work: %.1fh of %.1fh active (%.0f%%)\n",
+		report.TotalFocusMinutes/60, report.ActiveMinutes/60, report.FocusScore)
+
+	if len(report.BestHours) > 0 {
+		hours := make([]string, len(report.BestHours))
+		for i, hour := range report.BestHours {
+			hours[i] = fmt.Sprintf("%02d:00", hour)
+		}
+		result += fmt.Sprintf("🌟 Best focus hours: %s\n", strings.Join(hours, ", "))
+	}
+
+	result += fmt.Sprintf("\n📋 Recent Blocks:\n")
+	for i := len(report.Blocks) - 1; i >= 0; i-- {
+		if limit > 0 && len(report.Blocks)-1-i >= limit {
+			break
+		}
+		block := report.Blocks[i]
+		context := block.Project
+		if context == "" {
+			context = block.Category
+		}
+		result += fmt.Sprintf("  %s %s-%s  %3.0f min  %-24s %d commands\n",
+			block.Start.Format("Jan 02"), block.Start.Format("15:04"), block.End.Format("15:04"),
+			block.DurationMinutes, context, block.Commands)
+	}
+
+	return result
+}
@@ -6,6 +6,7 @@ import (
 	"sort"
 	"time"
 
+	"github.com/oiahoon/termonaut/internal/calendar"
 	"github.com/oiahoon/termonaut/pkg/models"
 )
 
@@ -25,6 +26,7 @@ type HeatmapData struct {
 	PeakDays         []time.Weekday                   `json:"peak_days"`
 	WorkingPatterns  []WorkingPattern                 `json:"working_patterns"`
 	FocusScore       float64                          `json:"focus_score"`
+	DeepWorkScore    float64                          `json:"deep_work_score"` // share of active time in focus blocks
 	DistributionType string                           `json:"distribution_type"`
 }
 
@@ -41,21 +43,10 @@ type WorkingPattern struct {
 
 // TimeInsights provides detailed time-based insights
 type TimeInsights struct {
-	Recommendations  []string             `json:"recommendations"`
-	OptimalSchedule  map[string]string    `json:"optimal_schedule"`
-	ProductivityTips []ProductivityTip    `json:"productivity_tips"`
-	SeasonalTrends   SeasonalTrends       `json:"seasonal_trends"`
-	WorkLifeBalance  WorkLifeBalanceScore `json:"work_life_balance"`
-}
-
-// ProductivityTip provides actionable productivity advice
-type ProductivityTip struct {
-	Category    string `json:"category"`
-	Title       string `json:"title"`
-	Description string `json:"description"`
-	Impact      string `json:"impact"`   // high, medium, low
-	Effort      string `json:"effort"`   // high, medium, low
-	Priority    int    `json:"priority"` // 1-10
+	Recommendations []*Recommendation    `json:"recommendations"`
+	OptimalSchedule map[string]string    `json:"optimal_schedule"`
+	SeasonalTrends  SeasonalTrends       `json:"seasonal_trends"`
+	WorkLifeBalance WorkLifeBalanceScore `json:"work_life_balance"`
 }
 
 // SeasonalTrends analyzes productivity trends over time
@@ -99,10 +90,11 @@ func (ha *HeatmapAnalyzer) GenerateHeatmap(commands []*models.Command) *HeatmapD
 	dayActivity := make(map[time.Weekday]int)
 	dailyHourlyActivity := make(map[int]map[int]int) // day of year -> hour -> count
 
+	cal := calendar.Default()
 	for _, cmd := range commands {
-		hour := cmd.Timestamp.Hour()
-		day := cmd.Timestamp.Weekday()
-		dayOfYear := cmd.Timestamp.YearDay()
+		hour := cal.Clock(cmd.Timestamp).Hour()
+		day := cal.Weekday(cmd.Timestamp)
+		dayOfYear := cal.Day(cmd.Timestamp).YearDay()
 
 		// Count activities
 		hourlyActivity[hour]++
@@ -198,6 +190,9 @@ func (ha *HeatmapAnalyzer) GenerateHeatmap(commands []*models.Command) *HeatmapD
 	// Calculate focus score (consistency of activity)
 	focusScore := ha.calculateFocusScore(weeklyHeatmap)
 
+	// Session-based counterpart: time actually spent in deep-work blocks
+	deepWorkScore := NewFocusAnalyzer().AnalyzeFocus(commands).FocusScore
+
 	// Determine distribution type
 	distributionType := ha.determineDistributionType(weeklyHeatmap)
 
@@ -208,6 +203,7 @@ func (ha *HeatmapAnalyzer) GenerateHeatmap(commands []*models.Command) *HeatmapD
 		PeakDays:         peakDays,
 		WorkingPatterns:  workingPatterns,
 		FocusScore:       focusScore,
+		DeepWorkScore:    deepWorkScore,
 		DistributionType: distributionType,
 	}
 }
@@ -418,18 +414,13 @@ func (ha *HeatmapAnalyzer) determineDistributionType(heatmap map[time.Weekday]ma
 	}
 }
 
+// timeRecommendationRules are the recommendation rules about when you work
+var timeRecommendationRules = []string{"late-night", "peak-hours", "focus-blocks"}
+
 // GenerateTimeInsights creates actionable time management insights
 func (ha *HeatmapAnalyzer) GenerateTimeInsights(heatmapData *HeatmapData, commands []*models.Command) *TimeInsights {
 	insights := &TimeInsights{
-		Recommendations:  []string{},
-		OptimalSchedule:  make(map[string]string),
-		ProductivityTips: []ProductivityTip{},
-	}
-
-	// Generate recommendations based on patterns
-	if heatmapData.FocusScore < 50 {
-		insights.Recommendations = append(insights.Recommendations,
-			"Consider consolidating your work into specific time blocks for better focus")
+		OptimalSchedule: make(map[string]string),
 	}
 
 	// Generate optimal schedule
@@ -444,26 +435,9 @@ func (ha *HeatmapAnalyzer) GenerateTimeInsights(heatmapData *HeatmapData, comman
 		insights.OptimalSchedule["peak_duration"] = fmt.Sprintf("%d hours", len(heatmapData.OptimalHours))
 	}
 
-	// Generate productivity tips
-	insights.ProductivityTips = append(insights.ProductivityTips, ProductivityTip{
-		Category:    "Time Management",
-		Title:       "Optimize Your Peak Hours",
-		Description: "Schedule your most important tasks during your identified peak productivity hours",
-		Impact:      "high",
-		Effort:      "low",
-		Priority:    8,
-	})
-
-	if heatmapData.FocusScore > 70 {
-		insights.ProductivityTips = append(insights.ProductivityTips, ProductivityTip{
-			Category:    "Focus",
-			Title:       "Maintain Your Focus Pattern",
-			Description: "Your current time management shows excellent focus. Keep it up!",
-			Impact:      "medium",
-			Effort:      "low",
-			Priority:    5,
-		})
-	}
+	// Time-related recommendations come from the recommendation engine
+	ctx := NewRecommendationContext(commands, time.Now())
+	insights.Recommendations = NewRecommendationEngine().Only(timeRecommendationRules...).Evaluate(ctx, nil)
 
 	return insights
 }
@@ -502,6 +476,7 @@ func (ha *HeatmapAnalyzer) FormatHeatmapVisualization(heatmapData *HeatmapData)
 	result += fmt.Sprintf("\n")
 	result += fmt.Sprintf("Legend: ░ Light  ▓ Medium  █ High  🔥 Peak\n")
 	result += fmt.Sprintf("Focus Score: %.1f/100 (%s)\n", heatmapData.FocusScore, ha.getFocusDescription(heatmapData.FocusScore))
+	result += fmt.Sprintf("Deep Work: %.1f%% of active time in focus blocks\n", heatmapData.DeepWorkScore)
 	result += fmt.Sprintf("Distribution: %s\n", heatmapData.DistributionType)
 
 	// Optimal hours
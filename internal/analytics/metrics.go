@@ -0,0 +1,246 @@
+package analytics
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/oiahoon/termonaut/internal/calendar"
+	"github.com/oiahoon/termonaut/internal/categories"
+	"github.com/oiahoon/termonaut/internal/config"
+	"github.com/oiahoon/termonaut/pkg/models"
+)
+
+// Metric types
+const (
+	MetricCounter = "counter" // number of matching commands
+	MetricTimer   = "timer"   // total run time of matching commands
+)
+
+// MetricEngine computes user-defined metrics from command history
+type MetricEngine struct {
+	metrics    []*compiledMetric
+	classifier *categories.CommandClassifier
+}
+
+type compiledMetric struct {
+	def       config.MetricConfig
+	pattern   *regexp.Regexp
+	exclude   *regexp.Regexp
+	directory *regexp.Regexp
+}
+
+// MetricPoint is a metric's value on one day
+type MetricPoint struct {
+	Date       string `json:"date"` // YYYY-MM-DD, local time
+	Count      int    `json:"count"`
+	DurationMS int64  `json:"duration_ms"`
+}
+
+// MetricValue is a user-defined metric computed over the history
+type MetricValue struct {
+	Name            string         `json:"name"`
+	Description     string         `json:"description,omitempty"`
+	Type            string         `json:"type"`
+	Badge           bool           `json:"badge"`
+	Total           int            `json:"total"`
+	TotalDurationMS int64          `json:"total_duration_ms"`
+	AvgDurationMS   int64          `json:"avg_duration_ms"`
+	Today           int            `json:"today"`
+	Last7Days       int            `json:"last_7_days"`
+	Last30Days      int            `json:"last_30_days"`
+	LastSeen        *time.Time     `json:"last_seen,omitempty"`
+	Series          []*MetricPoint `json:"series"` // one point per day, oldest first
+}
+
+// NewMetricEngine compiles metric definitions from the configuration
+func NewMetricEngine(defs []config.MetricConfig) (*MetricEngine, error) {
+	if err := config.ValidateMetrics(defs); err != nil {
+		return nil, err
+	}
+
+	engine := &MetricEngine{classifier: categories.NewCommandClassifier()}
+	for _, def := range defs {
+		metric := &compiledMetric{def: def}
+		if def.Type == "" {
+			metric.def.Type = MetricCounter
+		}
+		if def.Pattern != "" {
+			metric.pattern = regexp.MustCompile(def.Pattern)
+		}
+		if def.Exclude != "" {
+			metric.exclude = regexp.MustCompile(def.Exclude)
+		}
+		if def.Directory != "" {
+			metric.directory = regexp.MustCompile(def.Directory)
+		}
+		engine.metrics = append(engine.metrics, metric)
+	}
+
+	return engine, nil
+}
+
+// Len returns the number of metrics defined
+func (me *MetricEngine) Len() int {
+	return len(me.metrics)
+}
+
+// matches reports whether a command meets every condition of the metric
+func (me *MetricEngine) matches(metric *compiledMetric, cmd *models.Command) bool {
+	def := metric.def
+	if metric.pattern != nil && !metric.pattern.MatchString(cmd.Command) {
+		return false
+	}
+	if metric.exclude != nil && metric.exclude.MatchString(cmd.Command) {
+		return false
+	}
+	if metric.directory != nil && !metric.directory.MatchString(cmd.CWD) {
+		return false
+	}
+	// Without a reported exit status, a command matches no status condition
+	if (def.Status == "success" || def.Status == "failure" || def.ExitCode != nil) && !cmd.Finished {
+		return false
+	}
+	switch def.Status {
+	case "success":
+		if cmd.ExitCode != 0 {
+			return false
+		}
+	case "failure":
+		if cmd.ExitCode == 0 {
+			return false
+		}
+	}
+	if def.ExitCode != nil && cmd.ExitCode != *def.ExitCode {
+		return false
+	}
+	if def.Category != "" && !me.classifier.CommandCategory(cmd).Within(categories.Category(def.Category)) {
+		return false
+	}
+	return true
+}
+
+// Compute evaluates every metric over the commands. The series covers the
+// last days days up to now, one zero-filled point per day.
+func (me *MetricEngine) Compute(commands []*models.Command, now time.Time, days int) []*MetricValue {
+	cal := calendar.Default()
+	today, _ := time.Parse(calendar.DateFormat, cal.Today(now))
+	seriesDates := cal.LastDays(now, days)
+
+	values := make([]*MetricValue, 0, len(me.metrics))
+	for _, metric := range me.metrics {
+		value := &MetricValue{
+			Name:        metric.def.Name,
+			Description: metric.def.Description,
+			Type:        metric.def.Type,
+			Badge:       metric.def.Badge,
+			Series:      make([]*MetricPoint, 0, days),
+		}
+		for _, date := range seriesDates {
+			value.Series = append(value.Series, &MetricPoint{Date: date})
+		}
+
+		for _, cmd := range commands {
+			if !me.matches(metric, cmd) {
+				continue
+			}
+
+			value.Total++
+			value.TotalDurationMS += cmd.DurationMS
+			if value.LastSeen == nil || cmd.Timestamp.After(*value.LastSeen) {
+				timestamp := cmd.Timestamp
+				value.LastSeen = &timestamp
+			}
+
+			day, _ := time.Parse(calendar.DateFormat, cal.DayKey(cmd.Timestamp))
+			age := calendar.DaysBetween(day, today)
+			if age < 0 {
+				continue
+			}
+			if age == 0 {
+				value.Today++
+			}
+			if age < 7 {
+				value.Last7Days++
+			}
+			if age < 30 {
+				value.Last30Days++
+			}
+			if age < days {
+				point := value.Series[days-1-age]
+				point.Count++
+				point.DurationMS += cmd.DurationMS
+			}
+		}
+
+		if value.Total > 0 {
+			value.AvgDurationMS = value.TotalDurationMS / int64(value.Total)
+		}
+		values = append(values, value)
+	}
+
+	return values
+}
+
+// Display renders the metric's headline value: a count for counters, the
+// total run time for timers
+func (mv *MetricValue) Display() string {
+	if mv.Type == MetricTimer {
+		return FormatDuration(mv.TotalDurationMS)
+	}
+	return fmt.Sprintf("%d", mv.Total)
+}
+
+// Sparkline renders the metric's daily series as block characters
+func (mv *MetricValue) Sparkline() string {
+	blocks := []rune("▁▂▃▄▅▆▇█")
+
+	var max int64
+	points := make([]int64, len(mv.Series))
+	for i, point := range mv.Series {
+		points[i] = int64(point.Count)
+		if mv.Type == MetricTimer {
+			points[i] = point.DurationMS
+		}
+		if points[i] > max {
+			max = points[i]
+		}
+	}
+
+	line := make([]rune, len(points))
+	for i, v := range points {
+		if max == 0 || v == 0 {
+			line[i] = ' '
+			continue
+		}
+		line[i] = blocks[int(v*int64(len(blocks)-1)/max)]
+	}
+	return string(line)
+}
+
+// FormatMetrics generates a formatted summary of user-defined metrics
+func FormatMetrics(values []*MetricValue) string {
+	if len(values) == 0 {
+		return "📏 No metrics defined. Add [[metrics]] tables to ~/.termonaut/config.toml.\n"
+	}
+
+	result := fmt.Sprintf("📏 Custom Metrics\n")
+	result += fmt.Sprintf("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n")
+	result += fmt.Sprintf("%-20s %8s %6s %6s %6s  %s\n", "Metric", "Total", "Today", "7d", "30d", "Last 30 days")
+	for _, value := range values {
+		result += fmt.Sprintf("%-20s %8s %6d %6d %6d  %s\n",
+			truncateCommand(value.Name, 20), value.Display(), value.Today, value.Last7Days, value.Last30Days, value.Sparkline())
+	}
+
+	return result
+}
+
+// FindMetric returns the named metric, or nil
+func FindMetric(values []*MetricValue, name string) *MetricValue {
+	for _, value := range values {
+		if value.Name == name {
+			return value
+		}
+	}
+	return nil
+}
@@ -6,6 +6,7 @@ import (
 	"sort"
 	"time"
 
+	"github.com/oiahoon/termonaut/internal/calendar"
 	"github.com/oiahoon/termonaut/internal/categories"
 	"github.com/oiahoon/termonaut/pkg/models"
 )
@@ -127,7 +128,7 @@ func (pa *ProductivityAnalyzer) analyzeDailyPattern(commands []*models.Command)
 
 	// Group commands by hour
 	for _, cmd := range commands {
-		hour := cmd.Timestamp.Hour()
+		hour := calendar.Default().Clock(cmd.Timestamp).Hour()
 		if _, exists := hourlyStats[hour]; !exists {
 			hourlyStats[hour] = &HourStat{Hour: hour}
 		}
@@ -208,7 +209,7 @@ func (pa *ProductivityAnalyzer) analyzeWeeklyPattern(commands []*models.Command)
 
 	// Group commands by weekday
 	for _, cmd := range commands {
-		day := cmd.Timestamp.Weekday()
+		day := calendar.Default().Weekday(cmd.Timestamp)
 		dailyStats[day].CommandCount++
 		dailyCommands[day] = append(dailyCommands[day], cmd)
 	}
@@ -229,7 +230,7 @@ func (pa *ProductivityAnalyzer) analyzeWeeklyPattern(commands []*models.Command)
 		// Calculate category spread
 		categories := make(map[categories.Category]bool)
 		for _, cmd := range dailyCommands[day] {
-			category := pa.classifier.ClassifyCommand(cmd.Command)
+			category := pa.classifier.CommandCategory(cmd).Root()
 			categories[category] = true
 		}
 		stat.CategorySpread = len(categories)
@@ -273,9 +274,9 @@ func (pa *ProductivityAnalyzer) analyzeCategoryProductivity(commands []*models.C
 	categoryCount := make(map[categories.Category]int)
 	categoryCommands := make(map[categories.Category][]*models.Command)
 
-	// Group commands by category
+	// Group commands by top-level category
 	for _, cmd := range commands {
-		category := pa.classifier.ClassifyCommand(cmd.Command)
+		category := pa.classifier.CommandCategory(cmd).Root()
 		categoryCount[category]++
 		categoryCommands[category] = append(categoryCommands[category], cmd)
 	}
@@ -337,11 +338,15 @@ func (pa *ProductivityAnalyzer) analyzeStreaks(commands []*models.Command) Strea
 	dailyActivity := make(map[string]bool)
 	dates := make([]time.Time, 0)
 
+	// Dates are kept as UTC midnights of the calendar days so differences
+	// are whole days regardless of offsets and DST
+	cal := calendar.Default()
 	for _, cmd := range commands {
-		dateStr := cmd.Timestamp.Format("2006-01-02")
+		dateStr := cal.DayKey(cmd.Timestamp)
 		if !dailyActivity[dateStr] {
 			dailyActivity[dateStr] = true
-			dates = append(dates, cmd.Timestamp.Truncate(24*time.Hour))
+			date, _ := time.Parse(calendar.DateFormat, dateStr)
+			dates = append(dates, date)
 		}
 	}
 
@@ -356,7 +361,7 @@ func (pa *ProductivityAnalyzer) analyzeStreaks(commands []*models.Command) Strea
 	tempStreak := 0
 	gaps := make([]int, 0)
 
-	today := time.Now().Truncate(24 * time.Hour)
+	today, _ := time.Parse(calendar.DateFormat, cal.Today(time.Now()))
 
 	for i, date := range dates {
 		if i == 0 {
@@ -488,7 +493,7 @@ func (pa *ProductivityAnalyzer) analyzeEfficiency(commands []*models.Command, se
 
 	complexCommandCount := 0
 	for _, cmd := range commands {
-		category := pa.classifier.ClassifyCommand(cmd.Command)
+		category := pa.classifier.CommandCategory(cmd).Root()
 		for _, complex := range complexCategories {
 			if category == complex {
 				complexCommandCount++
@@ -518,7 +523,7 @@ func (pa *ProductivityAnalyzer) analyzeTimeDistribution(commands []*models.Comma
 	totalCommands := len(commands)
 
 	for _, cmd := range commands {
-		category := pa.classifier.ClassifyCommand(cmd.Command)
+		category := pa.classifier.CommandCategory(cmd).Root()
 		info := pa.classifier.GetCategoryInfo(category)
 		categoryTime[info.Name]++
 	}
@@ -0,0 +1,524 @@
+package analytics
+
+import (
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/oiahoon/termonaut/internal/calendar"
+	"github.com/oiahoon/termonaut/internal/categories"
+	"github.com/oiahoon/termonaut/pkg/models"
+)
+
+const (
+	// RecommendationWindowDays is how much recent history rules look at
+	RecommendationWindowDays = 30
+
+	// minRecommendationCommands is the sample size below which rules based
+	// on rates and distributions stay quiet
+	minRecommendationCommands = 50
+)
+
+// Severity ranks how urgently a recommendation should be acted on
+type Severity string
+
+// Recommendation severities, most urgent first
+const (
+	SeverityCritical Severity = "critical"
+	SeverityWarning  Severity = "warning"
+	SeverityInfo     Severity = "info"
+)
+
+var severityRank = map[Severity]int{
+	SeverityCritical: 0,
+	SeverityWarning:  1,
+	SeverityInfo:     2,
+}
+
+// Recommendation is an actionable suggestion produced by a rule
+type Recommendation struct {
+	ID       string   `json:"id"` // stable identifier, used to dismiss it
+	Rule     string   `json:"rule"`
+	Severity Severity `json:"severity"`
+	Title    string   `json:"title"`
+	Evidence []string `json:"evidence"` // observations that triggered the rule
+	Action   string   `json:"action"`   // what to do about it
+}
+
+// RecommendationContext holds the analytics rules are evaluated against,
+// computed once and shared by every rule
+type RecommendationContext struct {
+	Now       time.Time
+	Commands  []*models.Command // commands in the recommendation window
+	Heatmap   *HeatmapData
+	Durations *DurationReport
+	Typos     *TypoReport
+	Anomalies []*Anomaly // anomalies from the last week
+}
+
+// NewRecommendationContext analyzes the history for rule evaluation.
+// Anomalies are detected over the whole history so they have a baseline;
+// everything else covers the last RecommendationWindowDays days.
+func NewRecommendationContext(commands []*models.Command, now time.Time) *RecommendationContext {
+	since := now.AddDate(0, 0, -RecommendationWindowDays)
+	var recent []*models.Command
+	for _, cmd := range commands {
+		if cmd.Timestamp.After(since) && !cmd.Timestamp.After(now) {
+			recent = append(recent, cmd)
+		}
+	}
+
+	return &RecommendationContext{
+		Now:       now,
+		Commands:  recent,
+		Heatmap:   NewHeatmapAnalyzer().GenerateHeatmap(recent),
+		Durations: NewDurationAnalyzer().AnalyzeDurations(recent),
+		Typos:     NewTypoAnalyzer().AnalyzeTypos(recent),
+		Anomalies: RecentAnomalies(NewAnomalyDetector().DetectAnomalies(commands), now, 7),
+	}
+}
+
+// RecommendationRule inspects the analytics and returns recommendations for
+// whatever it finds worth acting on
+type RecommendationRule interface {
+	ID() string
+	Evaluate(ctx *RecommendationContext) []*Recommendation
+}
+
+type funcRule struct {
+	id       string
+	evaluate func(ctx *RecommendationContext) []*Recommendation
+}
+
+func (r *funcRule) ID() string { return r.id }
+
+func (r *funcRule) Evaluate(ctx *RecommendationContext) []*Recommendation { return r.evaluate(ctx) }
+
+// NewRecommendationRule creates a rule from an evaluation function
+func NewRecommendationRule(id string, evaluate func(ctx *RecommendationContext) []*Recommendation) RecommendationRule {
+	return &funcRule{id: id, evaluate: evaluate}
+}
+
+// RecommendationEngine evaluates a set of rules
+type RecommendationEngine struct {
+	rules []RecommendationRule
+}
+
+// NewRecommendationEngine creates an engine with the built-in rules
+func NewRecommendationEngine() *RecommendationEngine {
+	engine := &RecommendationEngine{}
+	for _, rule := range DefaultRecommendationRules() {
+		engine.Register(rule)
+	}
+	return engine
+}
+
+// Register adds a rule, replacing any rule with the same ID
+func (re *RecommendationEngine) Register(rule RecommendationRule) {
+	for i, existing := range re.rules {
+		if existing.ID() == rule.ID() {
+			re.rules[i] = rule
+			return
+		}
+	}
+	re.rules = append(re.rules, rule)
+}
+
+// Only returns an engine restricted to the given rules
+func (re *RecommendationEngine) Only(ids ...string) *RecommendationEngine {
+	wanted := make(map[string]bool)
+	for _, id := range ids {
+		wanted[id] = true
+	}
+
+	subset := &RecommendationEngine{}
+	for _, rule := range re.rules {
+		if wanted[rule.ID()] {
+			subset.rules = append(subset.rules, rule)
+		}
+	}
+	return subset
+}
+
+// RuleIDs lists the registered rules in evaluation order
+func (re *RecommendationEngine) RuleIDs() []string {
+	ids := make([]string, len(re.rules))
+	for i, rule := range re.rules {
+		ids[i] = rule.ID()
+	}
+	return ids
+}
+
+// Evaluate runs every rule and returns the recommendations that have not
+// been dismissed, most severe first
+func (re *RecommendationEngine) Evaluate(ctx *RecommendationContext, dismissed map[string]time.Time) []*Recommendation {
+	recommendations := []*Recommendation{}
+	for _, rule := range re.rules {
+		for _, rec := range rule.Evaluate(ctx) {
+			if rec.Rule == "" {
+				rec.Rule = rule.ID()
+			}
+			if rec.ID == "" {
+				rec.ID = rec.Rule
+			}
+			if rec.Severity == "" {
+				rec.Severity = SeverityInfo
+			}
+			if _, isDismissed := dismissed[rec.ID]; isDismissed {
+				continue
+			}
+			recommendations = append(recommendations, rec)
+		}
+	}
+
+	sort.SliceStable(recommendations, func(i, j int) bool {
+		return severityRank[recommendations[i].Severity] < severityRank[recommendations[j].Severity]
+	})
+	return recommendations
+}
+
+// FilterDismissed drops dismissed recommendations
+func FilterDismissed(recommendations []*Recommendation, dismissed map[string]time.Time) []*Recommendation {
+	kept := []*Recommendation{}
+	for _, rec := range recommendations {
+		if _, isDismissed := dismissed[rec.ID]; !isDismissed {
+			kept = append(kept, rec)
+		}
+	}
+	return kept
+}
+
+// FindRecommendation returns the recommendation with the given ID, or nil
+func FindRecommendation(recommendations []*Recommendation, id string) *Recommendation {
+	for _, rec := range recommendations {
+		if rec.ID == id {
+			return rec
+		}
+	}
+	return nil
+}
+
+// recommendationID builds a stable ID for a rule firing on a subject, so
+// dismissing one alias suggestion does not silence the others
+func recommendationID(rule, subject string) string {
+	h := fnv.New32a()
+	h.Write([]byte(subject))
+	return fmt.Sprintf("%s:%08x", rule, h.Sum32())
+}
+
+// DefaultRecommendationRules returns the built-in rules
+func DefaultRecommendationRules() []RecommendationRule {
+	return []RecommendationRule{
+		NewRecommendationRule("failure-spike", failureSpikeRule),
+		NewRecommendationRule("git-push-failures", gitPushFailuresRule),
+		NewRecommendationRule("late-night", lateNightRule),
+		NewRecommendationRule("low-success-rate", lowSuccessRateRule),
+		NewRecommendationRule("typo-alias", typoAliasRule),
+		NewRecommendationRule("jump-tool", jumpToolRule),
+		NewRecommendationRule("repeated-command", repeatedCommandRule),
+		NewRecommendationRule("time-sink", timeSinkRule),
+		NewRecommendationRule("peak-hours", peakHoursRule),
+		NewRecommendationRule("focus-blocks", focusBlocksRule),
+	}
+}
+
+// failureSpikeRule flags days from the last week with unusually many failures
+func failureSpikeRule(ctx *RecommendationContext) []*Recommendation {
+	var recs []*Recommendation
+	for _, anomaly := range ctx.Anomalies {
+		if anomaly.Type != AnomalyFailureSpike {
+			continue
+		}
+		recs = append(recs, &Recommendation{
+			ID:       "failure-spike:" + anomaly.Date,
+			Severity: SeverityCritical,
+			Title:    fmt.Sprintf("Failures spiked on %s", anomaly.Date),
+			Evidence: []string{anomaly.Description},
+			Action:   "Check for a broken tool, expired credentials or a changed environment on that day",
+		})
+	}
+	return recs
+}
+
+// gitPushFailuresRule suggests pulling first when pushes are often rejected,
+// among the pushes whose exit status the shell reported
+func gitPushFailuresRule(ctx *RecommendationContext) []*Recommendation {
+	pushes, failed := 0, 0
+	for _, cmd := range ctx.Commands {
+		normalized := categories.NormalizeCommand(cmd.Command)
+		if !cmd.Finished || normalized.Tool != "git" || normalized.Subcommand != "push" {
+			continue
+		}
+		pushes++
+		if cmd.ExitCode != 0 {
+			failed++
+		}
+	}
+
+	rate := float64(failed) / float64(maxInt(pushes, 1))
+	if pushes < 5 || rate < 0.2 {
+		return nil
+	}
+	return []*Recommendation{{
+		Severity: SeverityWarning,
+		Title:    "Pull before you push",
+		Evidence: []string{fmt.Sprintf("%d of %d `git push` runs failed in the last %d days (%.0f%%)",
+			failed, pushes, RecommendationWindowDays, rate*100)},
+		Action: "Run `git pull --rebase` before pushing, or set `git config --global pull.rebase true`",
+	}}
+}
+
+// lateNightRule warns when work regularly runs past midnight
+func lateNightRule(ctx *RecommendationContext) []*Recommendation {
+	days := make(map[string]bool)
+	count := 0
+	var last time.Time
+	for _, cmd := range ctx.Commands {
+		if calendar.Default().Clock(cmd.Timestamp).Hour() >= 5 {
+			continue
+		}
+		count++
+		days[calendar.Default().DayKey(cmd.Timestamp)] = true
+		if cmd.Timestamp.After(last) {
+			last = cmd.Timestamp
+		}
+	}
+	if len(days) < 3 {
+		return nil
+	}
+
+	severity := SeverityWarning
+	if len(days) >= 10 {
+		severity = SeverityCritical
+	}
+	return []*Recommendation{{
+		Severity: severity,
+		Title:    "Late nights are adding up",
+		Evidence: []string{
+			fmt.Sprintf("Active between 00:00 and 05:00 on %d of the last %d days", len(days), RecommendationWindowDays),
+			fmt.Sprintf("%d late-night commands, the latest on %s", count, last.Format("Jan 02 at 15:04")),
+		},
+		Action: "Pick a cut-off time and wrap up before midnight; rested sessions have fewer failures",
+	}}
+}
+
+// lowSuccessRateRule points at typos when many commands fail, among the
+// commands whose exit status the shell reported
+func lowSuccessRateRule(ctx *RecommendationContext) []*Recommendation {
+	finished, successful := 0, 0
+	for _, cmd := range ctx.Commands {
+		if !cmd.Finished {
+			continue
+		}
+		finished++
+		if cmd.ExitCode == 0 {
+			successful++
+		}
+	}
+	if finished < minRecommendationCommands {
+		return nil
+	}
+	rate := float64(successful) / float64(finished) * 100
+	if rate >= 80 {
+		return nil
+	}
+
+	return []*Recommendation{{
+		Severity: SeverityWarning,
+		Title:    "Many commands fail",
+		Evidence: []string{fmt.Sprintf("Only %.0f%% of %d commands in the last %d days succeeded",
+			rate, finished, RecommendationWindowDays)},
+		Action: "Run `termonaut analytics typos` and `termonaut analytics durations` to find the usual suspects",
+	}}
+}
+
+// typoAliasRule suggests aliasing frequently mistyped commands
+func typoAliasRule(ctx *RecommendationContext) []*Recommendation {
+	var recs []*Recommendation
+	for _, correction := range ctx.Typos.Corrections {
+		if correction.Count < 3 || len(recs) == 3 {
+			continue
+		}
+		recs = append(recs, &Recommendation{
+			ID:       "typo-alias:" + correction.Typo,
+			Severity: SeverityInfo,
+			Title:    fmt.Sprintf("You often type `%s` for `%s`", correction.Typo, correction.Correction),
+			Evidence: []string{fmt.Sprintf("Mistyped %d times in the last %d days", correction.Count, RecommendationWindowDays)},
+			Action:   fmt.Sprintf("Add `alias %s=%s` to your shell profile", correction.Typo, correction.Correction),
+		})
+	}
+	return recs
+}
+
+// jumpToolRule suggests a directory jumper for repeated upward navigation
+func jumpToolRule(ctx *RecommendationContext) []*Recommendation {
+	jumpTools := map[string]bool{"z": true, "zoxide": true, "autojump": true, "j": true, "fasd": true}
+	climbs := 0
+	targets := make(map[string]int)
+	for _, cmd := range ctx.Commands {
+		fields := strings.Fields(cmd.Command)
+		if len(fields) == 0 {
+			continue
+		}
+		if jumpTools[fields[0]] {
+			return nil
+		}
+		if fields[0] != "cd" || len(fields) < 2 {
+			continue
+		}
+		if strings.Count(fields[1], "..") >= 2 {
+			climbs++
+			targets[fields[1]]++
+		}
+	}
+	if climbs < 10 {
+		return nil
+	}
+
+	top, topCount := "", 0
+	for target, count := range targets {
+		if count > topCount || (count == topCount && target < top) {
+			top, topCount = target, count
+		}
+	}
+	return []*Recommendation{{
+		Severity: SeverityInfo,
+		Title:    "Jump instead of climbing directories",
+		Evidence: []string{
+			fmt.Sprintf("%d `cd ../..`-style commands in the last %d days", climbs, RecommendationWindowDays),
+			fmt.Sprintf("Most frequent: `cd %s` (%d times)", top, topCount),
+		},
+		Action: "Install a directory jumper such as zoxide or autojump to reach frequent directories by name",
+	}}
+}
+
+// repeatedCommandRule suggests aliases for long commands typed over and over
+func repeatedCommandRule(ctx *RecommendationContext) []*Recommendation {
+	frequency := make(map[string]int)
+	for _, cmd := range ctx.Commands {
+		if len(cmd.Command) > 20 {
+			frequency[cmd.Command]++
+		}
+	}
+	repeated, repeatedCount := "", 0
+	for command, count := range frequency {
+		if count > repeatedCount || (count == repeatedCount && command < repeated) {
+			repeated, repeatedCount = command, count
+		}
+	}
+	if repeatedCount < 5 {
+		return nil
+	}
+
+	return []*Recommendation{{
+		ID:       recommendationID("repeated-command", repeated),
+		Severity: SeverityInfo,
+		Title:    "Alias a command you keep retyping",
+		Evidence: []string{fmt.Sprintf("`%s` was typed %d times in the last %d days", repeated, repeatedCount, RecommendationWindowDays)},
+		Action:   "Turn it into a shell alias, function or script",
+	}}
+}
+
+// timeSinkRule highlights the command with the most total run time
+func timeSinkRule(ctx *RecommendationContext) []*Recommendation {
+	if len(ctx.Durations.TimeSinks) == 0 {
+		return nil
+	}
+	top := ctx.Durations.TimeSinks[0]
+	if top.TotalMS < int64(10*time.Minute/time.Millisecond) {
+		return nil
+	}
+
+	evidence := []string{fmt.Sprintf("`%s` ran %d times for %s in total", top.Command, top.Count, FormatDuration(top.TotalMS))}
+	if top.ChangePercent >= 20 {
+		evidence = append(evidence, fmt.Sprintf("It has become %.0f%% slower", top.ChangePercent))
+	}
+	return []*Recommendation{{
+		ID:       recommendationID("time-sink", top.Command),
+		Severity: SeverityInfo,
+		Title:    "Speed up your biggest time sink",
+		Evidence: evidence,
+		Action:   "Look for caching, incremental builds or parallelism; `termonaut analytics durations` shows its trend",
+	}}
+}
+
+// peakHoursRule recommends protecting the most active hours
+func peakHoursRule(ctx *RecommendationContext) []*Recommendation {
+	if len(ctx.Commands) < minRecommendationCommands || len(ctx.Heatmap.OptimalHours) == 0 {
+		return nil
+	}
+	hours := append([]int(nil), ctx.Heatmap.OptimalHours...)
+	sort.Ints(hours)
+	labels := make([]string, len(hours))
+	for i, hour := range hours {
+		labels[i] = fmt.Sprintf("%02d:00", hour)
+	}
+
+	return []*Recommendation{{
+		Severity: SeverityInfo,
+		Title:    "Protect your peak hours",
+		Evidence: []string{fmt.Sprintf("You are most active at %s", strings.Join(labels, ", "))},
+		Action:   "Keep meetings and interruptions out of these hours and schedule focused work in them",
+	}}
+}
+
+// focusBlocksRule suggests time blocking when activity is scattered
+func focusBlocksRule(ctx *RecommendationContext) []*Recommendation {
+	if len(ctx.Commands) < minRecommendationCommands || ctx.Heatmap.FocusScore >= 50 {
+		return nil
+	}
+
+	return []*Recommendation{{
+		Severity: SeverityInfo,
+		Title:    "Consolidate your work into time blocks",
+		Evidence: []string{
+			fmt.Sprintf("Focus score %.0f/100: activity is %s across the week", ctx.Heatmap.FocusScore, ctx.Heatmap.DistributionType),
+		},
+		Action: "Group terminal work into a few longer blocks instead of many short bursts",
+	}}
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// RecommendationIcon returns the icon for a severity
+func RecommendationIcon(severity Severity) string {
+	switch severity {
+	case SeverityCritical:
+		return "🚨"
+	case SeverityWarning:
+		return "⚠️ "
+	default:
+		return "💡"
+	}
+}
+
+// FormatRecommendations generates a formatted list of recommendations
+func FormatRecommendations(recommendations []*Recommendation, limit int) string {
+	if len(recommendations) == 0 {
+		return "✨ No recommendations right now. Keep it up!\n"
+	}
+
+	result := fmt.Sprintf("💡 Recommendations\n")
+	result += fmt.Sprintf("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n")
+	for i, rec := range recommendations {
+		if limit > 0 && i >= limit {
+			result += fmt.Sprintf("\n… and %d more\n", len(recommendations)-limit)
+			break
+		}
+		result += fmt.Sprintf("\n%s %s\n", RecommendationIcon(rec.Severity), rec.Title)
+		for _, evidence := range rec.Evidence {
+			result += fmt.Sprintf("   • %s\n", evidence)
+		}
+		result += fmt.Sprintf("   → %s\n", rec.Action)
+		result += fmt.Sprintf("   id: %s\n", rec.ID)
+	}
+
+	return result
+}
@@ -0,0 +1,256 @@
+package analytics
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/oiahoon/termonaut/internal/categories"
+	"github.com/oiahoon/termonaut/pkg/models"
+)
+
+// minSubcommandUses is how often a subcommand must be used before flags it
+// is missing are suggested
+const minSubcommandUses = 3
+
+// FlagHint is a commonly useful flag worth suggesting when it is never used
+type FlagHint struct {
+	Subcommand  string   `json:"subcommand,omitempty"`
+	Flags       []string `json:"flags"` // equivalent spellings, e.g. -p and --patch
+	Description string   `json:"description"`
+}
+
+// commonFlags lists well-known flags per tool
+var commonFlags = map[string][]FlagHint{
+	"git": {
+		{"commit", []string{"--amend"}, "fix up the last commit instead of adding a new one"},
+		{"commit", []string{"-v", "--verbose"}, "show the diff in the commit message editor"},
+		{"add", []string{"-p", "--patch"}, "stage changes hunk by hunk"},
+		{"log", []string{"--oneline"}, "one line per commit"},
+		{"log", []string{"--graph"}, "draw the branch graph"},
+		{"diff", []string{"--staged", "--cached"}, "show what is staged for commit"},
+		{"diff", []string{"--stat"}, "summarize changed files"},
+		{"pull", []string{"--rebase"}, "rebase local commits instead of merging"},
+		{"push", []string{"--force-with-lease"}, "force-push without clobbering others' work"},
+		{"status", []string{"-s", "--short"}, "compact status output"},
+		{"stash", []string{"-u", "--include-untracked"}, "stash untracked files too"},
+		{"checkout", []string{"-b"}, "create and switch to a new branch"},
+		{"switch", []string{"-c", "--create"}, "create and switch to a new branch"},
+	},
+	"docker": {
+		{"run", []string{"--rm"}, "remove the container when it exits"},
+		{"run", []string{"-i", "--interactive"}, "keep stdin open (with -t for an interactive shell)"},
+		{"ps", []string{"-a", "--all"}, "include stopped containers"},
+		{"logs", []string{"-f", "--follow"}, "stream new log lines"},
+		{"build", []string{"-t", "--tag"}, "tag the built image"},
+		{"system", []string{"--volumes"}, "also prune volumes"},
+	},
+	"kubectl": {
+		{"get", []string{"-o", "--output"}, "choose output format (wide, yaml, json)"},
+		{"get", []string{"-w", "--watch"}, "watch for changes"},
+		{"get", []string{"-A", "--all-namespaces"}, "list across all namespaces"},
+		{"logs", []string{"-f", "--follow"}, "stream new log lines"},
+		{"logs", []string{"--previous", "-p"}, "logs from the previous container instance"},
+		{"apply", []string{"--dry-run"}, "preview changes without applying them"},
+		{"delete", []string{"--dry-run"}, "preview what would be deleted"},
+	},
+	"ls": {
+		{"", []string{"-l"}, "long listing"},
+		{"", []string{"-a"}, "include hidden files"},
+		{"", []string{"-h"}, "human-readable sizes"},
+		{"", []string{"-t"}, "sort by modification time"},
+	},
+	"grep": {
+		{"", []string{"-r", "-R", "--recursive"}, "search directories recursively"},
+		{"", []string{"-i", "--ignore-case"}, "case-insensitive search"},
+		{"", []string{"-n", "--line-number"}, "show line numbers"},
+		{"", []string{"-v", "--invert-match"}, "show non-matching lines"},
+	},
+	"rg": {
+		{"", []string{"-i", "--ignore-case"}, "case-insensitive search"},
+		{"", []string{"-t", "--type"}, "limit to a file type"},
+		{"", []string{"-g", "--glob"}, "include or exclude paths by glob"},
+		{"", []string{"-l", "--files-with-matches"}, "list matching files only"},
+	},
+	"npm": {
+		{"install", []string{"-D", "--save-dev"}, "add as a dev dependency"},
+		{"ci", []string{"--ignore-scripts"}, "skip lifecycle scripts"},
+	},
+	"go": {
+		{"test", []string{"-race"}, "enable the race detector"},
+		{"test", []string{"-run"}, "run only matching tests"},
+		{"test", []string{"-cover"}, "report test coverage"},
+		{"build", []string{"-o"}, "choose the output file"},
+	},
+	"curl": {
+		{"", []string{"-L", "--location"}, "follow redirects"},
+		{"", []string{"-f", "--fail"}, "fail on HTTP errors"},
+		{"", []string{"-s", "--silent"}, "hide the progress meter"},
+	},
+}
+
+// FlagUsage is how often a flag is used with a subcommand
+type FlagUsage struct {
+	Subcommand string  `json:"subcommand,omitempty"`
+	Flag       string  `json:"flag"`
+	Count      int     `json:"count"`
+	Percentage float64 `json:"percentage"` // share of the subcommand's uses
+}
+
+// SubcommandUsage is how often a subcommand is used
+type SubcommandUsage struct {
+	Name        string  `json:"name"`
+	Count       int     `json:"count"`
+	Percentage  float64 `json:"percentage"`
+	FailureRate float64 `json:"failure_rate"`
+}
+
+// ToolUsage breaks down how a tool is used
+type ToolUsage struct {
+	Tool        string             `json:"tool"`
+	TotalUses   int                `json:"total_uses"`
+	Subcommands []*SubcommandUsage `json:"subcommands"`
+	Flags       []*FlagUsage       `json:"flags"`
+	UnusedFlags []*FlagHint        `json:"unused_flags"` // common flags never used with subcommands you use
+}
+
+// AnalyzeToolUsage breaks a tool's usage down by subcommand and flag
+func (ta *ToolAnalyzer) AnalyzeToolUsage(tool string, commands []*models.Command) *ToolUsage {
+	usage := &ToolUsage{
+		Tool:        tool,
+		Subcommands: []*SubcommandUsage{},
+		Flags:       []*FlagUsage{},
+		UnusedFlags: []*FlagHint{},
+	}
+
+	subcommands := make(map[string]*SubcommandUsage)
+	failures := make(map[string]int)
+	flags := make(map[string]*FlagUsage)
+	for _, cmd := range commands {
+		normalized := categories.NormalizeCommand(cmd.Command)
+		if normalized.Tool != tool {
+			continue
+		}
+		usage.TotalUses++
+
+		sub, exists := subcommands[normalized.Subcommand]
+		if !exists {
+			sub = &SubcommandUsage{Name: normalized.Subcommand}
+			subcommands[normalized.Subcommand] = sub
+		}
+		sub.Count++
+		if cmd.ExitCode != 0 {
+			failures[normalized.Subcommand]++
+		}
+
+		for _, flag := range normalized.Flags {
+			key := normalized.Subcommand + " " + flag
+			if flags[key] == nil {
+				flags[key] = &FlagUsage{Subcommand: normalized.Subcommand, Flag: flag}
+			}
+			flags[key].Count++
+		}
+	}
+	if usage.TotalUses == 0 {
+		return usage
+	}
+
+	for name, sub := range subcommands {
+		sub.Percentage = float64(sub.Count) / float64(usage.TotalUses) * 100
+		sub.FailureRate = float64(failures[name]) / float64(sub.Count) * 100
+		usage.Subcommands = append(usage.Subcommands, sub)
+	}
+	sort.Slice(usage.Subcommands, func(i, j int) bool {
+		if usage.Subcommands[i].Count != usage.Subcommands[j].Count {
+			return usage.Subcommands[i].Count > usage.Subcommands[j].Count
+		}
+		return usage.Subcommands[i].Name < usage.Subcommands[j].Name
+	})
+
+	for _, flag := range flags {
+		flag.Percentage = float64(flag.Count) / float64(subcommands[flag.Subcommand].Count) * 100
+		usage.Flags = append(usage.Flags, flag)
+	}
+	sort.Slice(usage.Flags, func(i, j int) bool {
+		if usage.Flags[i].Count != usage.Flags[j].Count {
+			return usage.Flags[i].Count > usage.Flags[j].Count
+		}
+		if usage.Flags[i].Subcommand != usage.Flags[j].Subcommand {
+			return usage.Flags[i].Subcommand < usage.Flags[j].Subcommand
+		}
+		return usage.Flags[i].Flag < usage.Flags[j].Flag
+	})
+
+	// Suggest common flags for subcommands used regularly but never with them
+	for i := range commonFlags[tool] {
+		hint := &commonFlags[tool][i]
+		sub := subcommands[hint.Subcommand]
+		if sub == nil || sub.Count < minSubcommandUses {
+			continue
+		}
+		used := false
+		for _, flag := range hint.Flags {
+			if flags[hint.Subcommand+" "+flag] != nil {
+				used = true
+				break
+			}
+		}
+		if !used {
+			usage.UnusedFlags = append(usage.UnusedFlags, hint)
+		}
+	}
+
+	return usage
+}
+
+// FormatToolUsage generates a formatted subcommand and flag breakdown
+func (ta *ToolAnalyzer) FormatToolUsage(usage *ToolUsage, limit int) string {
+	if usage.TotalUses == 0 {
+		return fmt.Sprintf("🔧 No uses of %s recorded yet.\n", usage.Tool)
+	}
+
+	result := fmt.Sprintf("🔧 %s Usage Breakdown\n", usage.Tool)
+	result += fmt.Sprintf("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n\n")
+	result += fmt.Sprintf("📊 Total uses: %d | Subcommands: %d | Distinct flags: %d\n\n",
+		usage.TotalUses, len(usage.Subcommands), len(usage.Flags))
+
+	hasSubcommands := len(usage.Subcommands) > 1 || usage.Subcommands[0].Name != ""
+	if hasSubcommands {
+		result += fmt.Sprintf("📋 Subcommands:\n")
+		for i, sub := range usage.Subcommands {
+			if limit > 0 && i >= limit {
+				break
+			}
+			name := sub.Name
+			if name == "" {
+				name = "(none)"
+			}
+			barLen := int(sub.Percentage / 5)
+			result += fmt.Sprintf("  %-16s %-20s %5d (%4.1f%%)  ❌ %.0f%%\n",
+				truncateCommand(name, 16), strings.Repeat("█", barLen), sub.Count, sub.Percentage, sub.FailureRate)
+		}
+		result += "\n"
+	}
+
+	if len(usage.Flags) > 0 {
+		result += fmt.Sprintf("🏳️  Popular Flags:\n")
+		for i, flag := range usage.Flags {
+			if limit > 0 && i >= limit {
+				break
+			}
+			result += fmt.Sprintf("  %-28s %5d  (%.0f%% of uses)\n",
+				truncateCommand(strings.TrimSpace(flag.Subcommand+" "+flag.Flag), 28), flag.Count, flag.Percentage)
+		}
+		result += "\n"
+	}
+
+	if len(usage.UnusedFlags) > 0 {
+		result += fmt.Sprintf("💡 Flags You Might Like:\n")
+		for _, hint := range usage.UnusedFlags {
+			result += fmt.Sprintf("  %s %s — %s\n",
+				strings.TrimSpace(usage.Tool+" "+hint.Subcommand), strings.Join(hint.Flags, "/"), hint.Description)
+		}
+	}
+
+	return result
+}
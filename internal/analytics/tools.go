@@ -0,0 +1,379 @@
+package analytics
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/oiahoon/termonaut/internal/calendar"
+	"github.com/oiahoon/termonaut/internal/categories"
+	"github.com/oiahoon/termonaut/pkg/models"
+)
+
+const (
+	// minToolUses keeps one-off commands out of the adoption timeline
+	minToolUses = 3
+
+	// newToolDays is how recently a tool must first appear to count as newly adopted
+	newToolDays = 14
+
+	// dormantToolDays is how long a tool can go unused before it counts as dormant
+	dormantToolDays = 30
+
+	// trendWeeks is the window compared against the one before it for usage trends
+	trendWeeks = 4
+)
+
+// Tool learning stages
+const (
+	ToolStageNew        = "new"
+	ToolStageRampingUp  = "ramping up"
+	ToolStageProficient = "proficient"
+	ToolStageDormant    = "dormant"
+)
+
+// ToolAnalyzer tracks when tools were adopted and how their use matured
+type ToolAnalyzer struct {
+	classifier *categories.CommandClassifier
+}
+
+// NewToolAnalyzer creates a new tool adoption analyzer
+func NewToolAnalyzer() *ToolAnalyzer {
+	return &ToolAnalyzer{
+		classifier: categories.NewCommandClassifier(),
+	}
+}
+
+// ToolWeek is one week of usage for a tool
+type ToolWeek struct {
+	WeekStart time.Time `json:"week_start"`
+	Uses      int       `json:"uses"`
+	Failures  int       `json:"failures"`
+	NewFlags  int       `json:"new_flags"` // flags used for the first time this week
+}
+
+// ToolAdoption describes one tool's adoption and learning curve
+type ToolAdoption struct {
+	Tool              string      `json:"tool"`
+	Category          string      `json:"category"`
+	FirstSeen         time.Time   `json:"first_seen"`
+	LastSeen          time.Time   `json:"last_seen"`
+	TotalUses         int         `json:"total_uses"`
+	ActiveDays        int         `json:"active_days"`
+	FailureRate       float64     `json:"failure_rate"`
+	EarlyFailureRate  float64     `json:"early_failure_rate"`  // first third of uses
+	RecentFailureRate float64     `json:"recent_failure_rate"` // last third of uses
+	UniqueFlags       int         `json:"unique_flags"`
+	Trend             string      `json:"trend"` // rising, falling or stable
+	Stage             string      `json:"stage"`
+	Summary           string      `json:"summary"`
+	Weekly            []*ToolWeek `json:"weekly"`
+}
+
+// ToolReport lists tool adoption, most recently adopted first
+type ToolReport struct {
+	Tools      []*ToolAdoption `json:"tools"`
+	TotalTools int             `json:"total_tools"`
+	NewTools   []string        `json:"new_tools"` // first seen within the last two weeks
+}
+
+// AnalyzeTools builds the adoption timeline for every tool in the history
+func (ta *ToolAnalyzer) AnalyzeTools(commands []*models.Command, now time.Time) *ToolReport {
+	report := &ToolReport{
+		Tools:    []*ToolAdoption{},
+		NewTools: []string{},
+	}
+
+	sorted := make([]*models.Command, len(commands))
+	copy(sorted, commands)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Timestamp.Before(sorted[j].Timestamp)
+	})
+
+	byTool := make(map[string][]*models.Command)
+	for _, cmd := range sorted {
+		if tool := ta.classifier.BaseCommand(cmd.Command); tool != "" {
+			byTool[tool] = append(byTool[tool], cmd)
+		}
+	}
+
+	for tool, uses := range byTool {
+		if len(uses) < minToolUses {
+			continue
+		}
+		adoption := ta.analyzeTool(tool, uses, now)
+		report.Tools = append(report.Tools, adoption)
+		if adoption.Stage == ToolStageNew {
+			report.NewTools = append(report.NewTools, tool)
+		}
+	}
+
+	sort.Slice(report.Tools, func(i, j int) bool {
+		if !report.Tools[i].FirstSeen.Equal(report.Tools[j].FirstSeen) {
+			return report.Tools[i].FirstSeen.After(report.Tools[j].FirstSeen)
+		}
+		return report.Tools[i].Tool < report.Tools[j].Tool
+	})
+	sort.Strings(report.NewTools)
+	report.TotalTools = len(report.Tools)
+
+	return report
+}
+
+// analyzeTool builds the adoption record for one tool's uses, oldest first
+func (ta *ToolAnalyzer) analyzeTool(tool string, uses []*models.Command, now time.Time) *ToolAdoption {
+	adoption := &ToolAdoption{
+		Tool:      tool,
+		Category:  ta.classifier.GetCategoryInfo(ta.classifier.CommandCategory(uses[0]).Root()).Name,
+		FirstSeen: uses[0].Timestamp,
+		LastSeen:  uses[len(uses)-1].Timestamp,
+		TotalUses: len(uses),
+		Weekly:    []*ToolWeek{},
+	}
+
+	days := make(map[string]bool)
+	flags := make(map[string]bool)
+	failures := 0
+	var week *ToolWeek
+	for _, cmd := range uses {
+		days[calendar.Default().DayKey(cmd.Timestamp)] = true
+
+		start := weekStart(cmd.Timestamp)
+		if week == nil || !week.WeekStart.Equal(start) {
+			week = &ToolWeek{WeekStart: start}
+			adoption.Weekly = append(adoption.Weekly, week)
+		}
+		week.Uses++
+		if cmd.ExitCode != 0 {
+			week.Failures++
+			failures++
+		}
+		for _, flag := range commandFlags(cmd.Command) {
+			if !flags[flag] {
+				flags[flag] = true
+				week.NewFlags++
+			}
+		}
+	}
+
+	adoption.ActiveDays = len(days)
+	adoption.UniqueFlags = len(flags)
+	adoption.FailureRate = float64(failures) / float64(len(uses)) * 100
+
+	third := len(uses) / 3
+	if third == 0 {
+		third = 1
+	}
+	adoption.EarlyFailureRate = failureRate(uses[:third])
+	adoption.RecentFailureRate = failureRate(uses[len(uses)-third:])
+	adoption.Trend = usageTrend(uses, now)
+	adoption.Stage = toolStage(adoption, now)
+	adoption.Summary = learningSummary(adoption)
+
+	return adoption
+}
+
+// weekStart returns midnight on the Monday of t's week
+func weekStart(t time.Time) time.Time {
+	day := calendar.Default().Day(t)
+	return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+}
+
+// commandFlags returns the flags in a command line, without their values
+func commandFlags(command string) []string {
+	var flags []string
+	for _, field := range strings.Fields(command) {
+		if !strings.HasPrefix(field, "-") || field == "-" || field == "--" {
+			continue
+		}
+		if eq := strings.Index(field, "="); eq > 0 {
+			field = field[:eq]
+		}
+		flags = append(flags, field)
+	}
+	return flags
+}
+
+func failureRate(commands []*models.Command) float64 {
+	if len(commands) == 0 {
+		return 0
+	}
+	failures := 0
+	for _, cmd := range commands {
+		if cmd.ExitCode != 0 {
+			failures++
+		}
+	}
+	return float64(failures) / float64(len(commands)) * 100
+}
+
+// usageTrend compares use over the last four weeks with the four before
+func usageTrend(uses []*models.Command, now time.Time) string {
+	window := time.Duration(trendWeeks) * 7 * 24 * time.Hour
+	recent, previous := 0, 0
+	for _, cmd := range uses {
+		age := now.Sub(cmd.Timestamp)
+		switch {
+		case age < window:
+			recent++
+		case age < 2*window:
+			previous++
+		}
+	}
+
+	switch {
+	case recent > previous*3/2 && recent-previous >= minToolUses:
+		return "rising"
+	case previous > recent*3/2 && previous-recent >= minToolUses:
+		return "falling"
+	default:
+		return "stable"
+	}
+}
+
+// toolStage places a tool on its learning curve
+func toolStage(adoption *ToolAdoption, now time.Time) string {
+	switch {
+	case now.Sub(adoption.LastSeen) > dormantToolDays*24*time.Hour:
+		return ToolStageDormant
+	case now.Sub(adoption.FirstSeen) <= newToolDays*24*time.Hour:
+		return ToolStageNew
+	case adoption.RecentFailureRate >= adoption.EarlyFailureRate && adoption.RecentFailureRate > 20:
+		return ToolStageRampingUp
+	default:
+		// Still discovering flags in the last month counts as ramping up
+		cutoff := weekStart(now).AddDate(0, 0, -7*trendWeeks)
+		for _, week := range adoption.Weekly {
+			if !week.WeekStart.Before(cutoff) && week.NewFlags > 0 && adoption.UniqueFlags > 0 &&
+				float64(week.NewFlags)/float64(adoption.UniqueFlags) >= 0.25 {
+				return ToolStageRampingUp
+			}
+		}
+		return ToolStageProficient
+	}
+}
+
+// learningSummary describes how a tool's use changed since adoption
+func learningSummary(adoption *ToolAdoption) string {
+	parts := []string{}
+
+	switch {
+	case adoption.TotalUses < 2*minToolUses:
+		parts = append(parts, "too few uses to judge a learning curve")
+	case adoption.RecentFailureRate < adoption.EarlyFailureRate:
+		parts = append(parts, fmt.Sprintf("failure rate fell from %.0f%% to %.0f%%",
+			adoption.EarlyFailureRate, adoption.RecentFailureRate))
+	case adoption.RecentFailureRate > adoption.EarlyFailureRate:
+		parts = append(parts, fmt.Sprintf("failure rate rose from %.0f%% to %.0f%%",
+			adoption.EarlyFailureRate, adoption.RecentFailureRate))
+	default:
+		parts = append(parts, fmt.Sprintf("failure rate steady at %.0f%%", adoption.RecentFailureRate))
+	}
+
+	if adoption.UniqueFlags > 0 {
+		parts = append(parts, fmt.Sprintf("%d flags learned", adoption.UniqueFlags))
+	}
+	if adoption.Trend != "stable" {
+		parts = append(parts, "usage "+adoption.Trend)
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+// FindTool returns the adoption record for a tool, or nil
+func (r *ToolReport) FindTool(tool string) *ToolAdoption {
+	for _, adoption := range r.Tools {
+		if adoption.Tool == tool {
+			return adoption
+		}
+	}
+	return nil
+}
+
+// FormatToolReport generates a formatted tool adoption report
+func (ta *ToolAnalyzer) FormatToolReport(report *ToolReport, limit int) string {
+	if report.TotalTools == 0 {
+		return "🧰 No tools used often enough to chart adoption yet.\n"
+	}
+
+	result := fmt.Sprintf("🧰 Tool Adoption Timeline\n")
+	result += fmt.Sprintf("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n\n")
+
+	result += fmt.Sprintf("📦 Tools tracked: %d", report.TotalTools)
+	if len(report.NewTools) > 0 {
+		result += fmt.Sprintf(" | 🆕 New: %s", strings.Join(report.NewTools, ", "))
+	}
+	result += "\n\n"
+
+	result += fmt.Sprintf("  %-14s %-11s %-11s %6s %6s %6s  %s\n",
+		"Tool", "First seen", "Last seen", "Uses", "Fail%", "Flags", "Stage")
+	for i, adoption := range report.Tools {
+		if limit > 0 && i >= limit {
+			break
+		}
+		result += fmt.Sprintf("  %-14s %-11s %-11s %6d %5.0f%% %6d  %s %s\n",
+			truncateCommand(adoption.Tool, 14),
+			adoption.FirstSeen.Format("2006-01-02"),
+			adoption.LastSeen.Format("2006-01-02"),
+			adoption.TotalUses,
+			adoption.FailureRate,
+			adoption.UniqueFlags,
+			toolStageIcon(adoption.Stage),
+			adoption.Stage)
+	}
+
+	return result
+}
+
+// FormatToolDetail generates a detailed learning curve for one tool
+func (ta *ToolAnalyzer) FormatToolDetail(adoption *ToolAdoption) string {
+	result := fmt.Sprintf("🧰 %s (%s)\n", adoption.Tool, adoption.Category)
+	result += fmt.Sprintf("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n\n")
+
+	result += fmt.Sprintf("📅 First seen: %s | Last seen: %s | Active days: %d\n",
+		adoption.FirstSeen.Format("2006-01-02"), adoption.LastSeen.Format("2006-01-02"), adoption.ActiveDays)
+	result += fmt.Sprintf("🔢 Uses: %d | Failure rate: %.0f%% | Flags: %d | Trend: %s\n",
+		adoption.TotalUses, adoption.FailureRate, adoption.UniqueFlags, adoption.Trend)
+	result += fmt.Sprintf("%s Stage: %s — %s\n\n", toolStageIcon(adoption.Stage), adoption.Stage, adoption.Summary)
+
+	maxUses := 0
+	for _, week := range adoption.Weekly {
+		if week.Uses > maxUses {
+			maxUses = week.Uses
+		}
+	}
+
+	result += fmt.Sprintf("📈 Weekly Usage:\n")
+	for _, week := range adoption.Weekly {
+		barLen := week.Uses * 30 / maxUses
+		if barLen == 0 {
+			barLen = 1
+		}
+		line := fmt.Sprintf("  %s %-30s %4d", week.WeekStart.Format("2006-01-02"), strings.Repeat("█", barLen), week.Uses)
+		if week.Failures > 0 {
+			line += fmt.Sprintf("  ❌ %d", week.Failures)
+		}
+		if week.NewFlags > 0 {
+			line += fmt.Sprintf("  🆕 %d flags", week.NewFlags)
+		}
+		result += line + "\n"
+	}
+
+	return result
+}
+
+func toolStageIcon(stage string) string {
+	switch stage {
+	case ToolStageNew:
+		return "🆕"
+	case ToolStageRampingUp:
+		return "📈"
+	case ToolStageProficient:
+		return "✅"
+	case ToolStageDormant:
+		return "💤"
+	default:
+		return "•"
+	}
+}
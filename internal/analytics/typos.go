@@ -0,0 +1,305 @@
+package analytics
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/oiahoon/termonaut/internal/categories"
+	"github.com/oiahoon/termonaut/pkg/models"
+)
+
+const (
+	// minVocabularyCount is how often a base command must appear before it
+	// is trusted as a correction target
+	minVocabularyCount = 3
+
+	// minCorrectionRatio is how much more frequent a correction must be than
+	// the suspected typo
+	minCorrectionRatio = 3
+)
+
+// TypoAnalyzer detects mistyped commands using the user's own command
+// vocabulary. The exit status of a stored command is only known once the
+// shell reports it, and never for commands logged by older hooks, so typos
+// are not found from "command not found" exits; the shell hooks call
+// did-you-mean for those live.
+type TypoAnalyzer struct {
+	classifier *categories.CommandClassifier
+}
+
+// NewTypoAnalyzer creates a new typo analyzer
+func NewTypoAnalyzer() *TypoAnalyzer {
+	return &TypoAnalyzer{
+		classifier: categories.NewCommandClassifier(),
+	}
+}
+
+// TypoReport summarizes detected typos and their most likely corrections
+type TypoReport struct {
+	TotalCommands int               `json:"total_commands"`
+	TypoCount     int               `json:"typo_count"`
+	TypoRate      float64           `json:"typo_rate"`
+	Corrections   []*TypoCorrection `json:"corrections"`
+}
+
+// TypoCorrection represents a mistyped base command and its suggested fix
+type TypoCorrection struct {
+	Typo       string    `json:"typo"`
+	Correction string    `json:"correction"`
+	Count      int       `json:"count"`
+	Distance   int       `json:"distance"`
+	LastSeen   time.Time `json:"last_seen"`
+}
+
+// Vocabulary maps base commands to how often they were used
+type Vocabulary map[string]int
+
+// BuildVocabulary collects base command frequencies from history
+func (ta *TypoAnalyzer) BuildVocabulary(commands []*models.Command) Vocabulary {
+	vocabulary := make(Vocabulary)
+	for _, cmd := range commands {
+		if base := ta.classifier.BaseCommand(cmd.Command); base != "" {
+			vocabulary[base]++
+		}
+	}
+	return vocabulary
+}
+
+// VocabularyFromCounts collects base command frequencies from how often
+// each distinct command was run
+func (ta *TypoAnalyzer) VocabularyFromCounts(counts map[string]int) Vocabulary {
+	vocabulary := make(Vocabulary)
+	for command, count := range counts {
+		if base := ta.classifier.BaseCommand(command); base != "" {
+			vocabulary[base] += count
+		}
+	}
+	return vocabulary
+}
+
+// AnalyzeTypos finds commands that look like near misses of frequently used
+// commands
+func (ta *TypoAnalyzer) AnalyzeTypos(commands []*models.Command) *TypoReport {
+	report := &TypoReport{
+		TotalCommands: len(commands),
+		Corrections:   []*TypoCorrection{},
+	}
+	if len(commands) == 0 {
+		return report
+	}
+
+	vocabulary := ta.BuildVocabulary(commands)
+	corrections := make(map[string]*TypoCorrection)
+
+	for _, cmd := range commands {
+		base := ta.classifier.BaseCommand(cmd.Command)
+		if base == "" {
+			continue
+		}
+
+		if ta.isKnownCommand(cmd.Command, base, vocabulary) {
+			continue
+		}
+
+		correction, distance := ta.nearestCommand(base, vocabulary)
+		if correction == "" {
+			continue
+		}
+
+		// Short names collide easily (fd vs cd), so only swapped letters
+		// count as typos
+		if len(base) <= 3 && !sameLetters(base, correction) {
+			continue
+		}
+
+		entry, exists := corrections[base]
+		if !exists {
+			entry = &TypoCorrection{
+				Typo:       base,
+				Correction: correction,
+				Distance:   distance,
+			}
+			corrections[base] = entry
+		}
+		entry.Count++
+		if cmd.Timestamp.After(entry.LastSeen) {
+			entry.LastSeen = cmd.Timestamp
+		}
+		report.TypoCount++
+	}
+
+	for _, entry := range corrections {
+		report.Corrections = append(report.Corrections, entry)
+	}
+	sort.Slice(report.Corrections, func(i, j int) bool {
+		if report.Corrections[i].Count != report.Corrections[j].Count {
+			return report.Corrections[i].Count > report.Corrections[j].Count
+		}
+		return report.Corrections[i].Typo < report.Corrections[j].Typo
+	})
+
+	report.TypoRate = float64(report.TypoCount) / float64(report.TotalCommands) * 100
+
+	return report
+}
+
+// SuggestCorrection returns the command with its base command replaced by the
+// closest match from the vocabulary
+func (ta *TypoAnalyzer) SuggestCorrection(command string, vocabulary Vocabulary) (string, bool) {
+	trimmed := strings.TrimSpace(command)
+	classification := ta.classifier.Classify(trimmed)
+	base := classification.Tool()
+	if base == "" {
+		return "", false
+	}
+
+	correction, _ := ta.nearestCommand(base, vocabulary)
+	if correction == "" || correction == base {
+		return "", false
+	}
+
+	// Only the program is replaced, wherever wrappers and other commands
+	// put it
+	segment := classification.Segments[classification.PrimarySegment]
+	start := strings.Index(trimmed, segment.Command)
+	offset := strings.Index(segment.Command, base)
+	if start < 0 || offset < 0 {
+		return "", false
+	}
+	start += offset
+	return trimmed[:start] + correction + trimmed[start+len(base):], true
+}
+
+// isKnownCommand reports whether a base command is trusted as valid input
+func (ta *TypoAnalyzer) isKnownCommand(command, base string, vocabulary Vocabulary) bool {
+	if vocabulary[base] >= minVocabularyCount {
+		return true
+	}
+	return ta.classifier.ClassifyCommand(command) != categories.Unknown
+}
+
+// nearestCommand finds the closest frequently used command within the
+// allowed edit distance for the word's length
+func (ta *TypoAnalyzer) nearestCommand(word string, vocabulary Vocabulary) (string, int) {
+	maxDistance := 1
+	if len(word) > 4 {
+		maxDistance = 2
+	}
+
+	best := ""
+	bestDistance := maxDistance + 1
+	bestCount := 0
+
+	for candidate, count := range vocabulary {
+		if candidate == word || count < minVocabularyCount {
+			continue
+		}
+		if count < vocabulary[word]*minCorrectionRatio {
+			continue
+		}
+
+		distance := editDistance(word, candidate)
+		if distance > maxDistance {
+			continue
+		}
+
+		// Prefer closer matches, then more frequent ones, then alphabetical order
+		if distance < bestDistance ||
+			(distance == bestDistance && count > bestCount) ||
+			(distance == bestDistance && count == bestCount && candidate < best) {
+			best = candidate
+			bestDistance = distance
+			bestCount = count
+		}
+	}
+
+	if best == "" {
+		return "", 0
+	}
+	return best, bestDistance
+}
+
+// editDistance computes the optimal string alignment distance, which counts
+// adjacent transpositions (gti -> git) as a single edit
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	rows, cols := len(ra)+1, len(rb)+1
+
+	d := make([][]int, rows)
+	for i := range d {
+		d[i] = make([]int, cols)
+		d[i][0] = i
+	}
+	for j := 0; j < cols; j++ {
+		d[0][j] = j
+	}
+
+	for i := 1; i < rows; i++ {
+		for j := 1; j < cols; j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+
+			d[i][j] = minInt(d[i-1][j]+1, minInt(d[i][j-1]+1, d[i-1][j-1]+cost))
+
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				d[i][j] = minInt(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+
+	return d[rows-1][cols-1]
+}
+
+// sameLetters reports whether two words are permutations of each other
+func sameLetters(a, b string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	ra, rb := []rune(a), []rune(b)
+	sort.Slice(ra, func(i, j int) bool { return ra[i] < ra[j] })
+	sort.Slice(rb, func(i, j int) bool { return rb[i] < rb[j] })
+	return string(ra) == string(rb)
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// FormatTypoReport generates a formatted typo report
+func (ta *TypoAnalyzer) FormatTypoReport(report *TypoReport, limit int) string {
+	if report.TypoCount == 0 {
+		return "✨ No typos detected. Your fingers are on point!\n"
+	}
+
+	result := fmt.Sprintf("⌨️  Typo Report\n")
+	result += fmt.Sprintf("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n\n")
+
+	result += fmt.Sprintf("🔍 Likely typos: %d of %d commands (%.1f%%)\n",
+		report.TypoCount, report.TotalCommands, report.TypoRate)
+
+	if len(report.Corrections) > 0 {
+		result += fmt.Sprintf("\n💡 Most Common Corrections:\n")
+		for i, correction := range report.Corrections {
+			if limit > 0 && i >= limit {
+				break
+			}
+			result += fmt.Sprintf("  %-12s → %-12s %3dx  (last: %s)\n",
+				correction.Typo, correction.Correction, correction.Count,
+				correction.LastSeen.Format("2006-01-02"))
+		}
+	}
+
+	if len(report.Corrections) > 0 {
+		top := report.Corrections[0]
+		result += fmt.Sprintf("\n🤖 Tip: alias %s='%s' if this keeps happening.\n", top.Typo, top.Correction)
+	}
+
+	return result
+}
@@ -2,6 +2,7 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
@@ -9,8 +10,11 @@ import (
 
 	"github.com/gorilla/mux"
 	"github.com/oiahoon/termonaut/internal/analytics"
+	"github.com/oiahoon/termonaut/internal/calendar"
 	"github.com/oiahoon/termonaut/internal/categories"
 	"github.com/oiahoon/termonaut/internal/database"
+	"github.com/oiahoon/termonaut/internal/gamification"
+	"github.com/oiahoon/termonaut/internal/query"
 	"github.com/oiahoon/termonaut/internal/stats"
 	"github.com/oiahoon/termonaut/pkg/models"
 )
@@ -21,6 +25,7 @@ type APIServer struct {
 	db             *database.DB
 	statsManager   *stats.AdvancedStatsManager
 	analytics      *analytics.ProductivityAnalyzer
+	metrics        *analytics.MetricEngine
 	port           int
 	enableCORS     bool
 	authenticator  Authenticator
@@ -75,6 +80,11 @@ func (s *APIServer) SetAuthenticator(auth Authenticator) {
 	s.authenticator = auth
 }
 
+// SetMetrics sets the user-defined metrics served under /metrics
+func (s *APIServer) SetMetrics(engine *analytics.MetricEngine) {
+	s.metrics = engine
+}
+
 // setupRoutes configures all API routes
 func (s *APIServer) setupRoutes() {
 	api := s.router.PathPrefix("/api/v1").Subrouter()
@@ -93,6 +103,24 @@ func (s *APIServer) setupRoutes() {
 	api.HandleFunc("/stats/gamification", s.handleGetGamificationStats).Methods("GET")
 	api.HandleFunc("/stats/productivity", s.handleGetProductivityStats).Methods("GET")
 
+	// Progress endpoints
+	api.HandleFunc("/progress/forecast", s.handleGetForecast).Methods("GET")
+	api.HandleFunc("/progress/history", s.handleGetProgressHistory).Methods("GET")
+
+	// Analytics endpoints
+	api.HandleFunc("/analytics/anomalies", s.handleGetAnomalies).Methods("GET")
+	api.HandleFunc("/analytics/tools", s.handleGetTools).Methods("GET")
+	api.HandleFunc("/analytics/recommendations", s.handleGetRecommendations).Methods("GET")
+	api.HandleFunc("/analytics/recommendations/{id}/dismiss", s.handleDismissRecommendation).Methods("POST")
+	api.HandleFunc("/analytics/recommendations/{id}/dismiss", s.handleRestoreRecommendation).Methods("DELETE")
+
+	// User-defined metrics endpoints
+	api.HandleFunc("/metrics", s.handleGetMetrics).Methods("GET")
+	api.HandleFunc("/metrics/{name}/series", s.handleGetMetricSeries).Methods("GET")
+
+	// Query endpoint
+	api.HandleFunc("/query", s.handleQuery).Methods("POST")
+
 	// Commands endpoints
 	api.HandleFunc("/commands", s.handleGetCommands).Methods("GET")
 	api.HandleFunc("/commands/{id}", s.handleGetCommand).Methods("GET")
@@ -241,6 +269,218 @@ func (s *APIServer) handleGetProductivityStats(w http.ResponseWriter, r *http.Re
 	s.writeSuccess(w, productivity)
 }
 
+func (s *APIServer) handleGetForecast(w http.ResponseWriter, r *http.Request) {
+	statsCalc := stats.New(s.db)
+	gamificationStats, err := statsCalc.GetGamificationStats()
+	if err != nil || gamificationStats.Forecast == nil {
+		s.writeError(w, http.StatusInternalServerError, "Failed to get forecast")
+		return
+	}
+
+	s.writeSuccess(w, gamificationStats.Forecast)
+}
+
+const (
+	// defaultDays is the period of the endpoints taking a days parameter
+	defaultDays = 30
+	// maxDays caps the days parameter at ten years, so a request cannot
+	// make the server build an arbitrarily long day-by-day series
+	maxDays = 3660
+)
+
+// parseDays reads the days parameter of a request: defaultDays when it is
+// missing or not a positive number, and at most maxDays
+func parseDays(r *http.Request) int {
+	days, err := strconv.Atoi(r.URL.Query().Get("days"))
+	if err != nil || days <= 0 {
+		return defaultDays
+	}
+	if days > maxDays {
+		return maxDays
+	}
+	return days
+}
+
+func (s *APIServer) handleGetProgressHistory(w http.ResponseWriter, r *http.Request) {
+	days := parseDays(r)
+
+	snapshots, err := s.db.GetProgressHistory()
+	if err != nil {
+		s.writeError(w, http.StatusInternalServerError, "Failed to get progress history")
+		return
+	}
+
+	if at := r.URL.Query().Get("at"); at != "" {
+		if _, err := time.Parse(calendar.DateFormat, at); err != nil {
+			s.writeError(w, http.StatusBadRequest, "Invalid date, expected YYYY-MM-DD")
+			return
+		}
+		snapshot := gamification.SnapshotAt(snapshots, at)
+		if snapshot == nil {
+			s.writeError(w, http.StatusNotFound, "No progress recorded on or before that date")
+			return
+		}
+		s.writeSuccess(w, snapshot)
+		return
+	}
+
+	s.writeSuccess(w, gamification.ProgressSeries(snapshots, calendar.Default().LastDays(time.Now(), days)))
+}
+
+func (s *APIServer) handleGetAnomalies(w http.ResponseWriter, r *http.Request) {
+	days := parseDays(r)
+
+	// The full history is needed to establish the baseline
+	commands, err := s.db.GetAllCommands()
+	if err != nil {
+		s.writeError(w, http.StatusInternalServerError, "Failed to get commands")
+		return
+	}
+
+	anomalies := analytics.NewAnomalyDetector().DetectAnomalies(commands)
+	s.writeSuccess(w, analytics.RecentAnomalies(anomalies, time.Now(), days))
+}
+
+func (s *APIServer) handleGetTools(w http.ResponseWriter, r *http.Request) {
+	commands, err := s.db.GetAllCommands()
+	if err != nil {
+		s.writeError(w, http.StatusInternalServerError, "Failed to get commands")
+		return
+	}
+
+	report := analytics.NewToolAnalyzer().AnalyzeTools(commands, time.Now())
+
+	if tool := r.URL.Query().Get("tool"); tool != "" {
+		adoption := report.FindTool(tool)
+		if adoption == nil {
+			s.writeError(w, http.StatusNotFound, "Tool not found")
+			return
+		}
+		s.writeSuccess(w, adoption)
+		return
+	}
+
+	s.writeSuccess(w, report)
+}
+
+// computeMetrics evaluates the user-defined metrics over the last days days
+func (s *APIServer) computeMetrics(days int) ([]*analytics.MetricValue, error) {
+	if s.metrics == nil {
+		return []*analytics.MetricValue{}, nil
+	}
+
+	commands, err := s.db.GetAllCommands()
+	if err != nil {
+		return nil, err
+	}
+	return s.metrics.Compute(commands, time.Now(), days), nil
+}
+
+func (s *APIServer) handleGetRecommendations(w http.ResponseWriter, r *http.Request) {
+	commands, err := s.db.GetAllCommands()
+	if err != nil {
+		s.writeError(w, http.StatusInternalServerError, "Failed to get commands")
+		return
+	}
+
+	var dismissed map[string]time.Time
+	if r.URL.Query().Get("all") != "true" {
+		if dismissed, err = s.db.GetDismissedRecommendations(); err != nil {
+			s.writeError(w, http.StatusInternalServerError, "Failed to get dismissed recommendations")
+			return
+		}
+	}
+
+	ctx := analytics.NewRecommendationContext(commands, time.Now())
+	s.writeSuccess(w, analytics.NewRecommendationEngine().Evaluate(ctx, dismissed))
+}
+
+func (s *APIServer) handleDismissRecommendation(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	if err := s.db.DismissRecommendation(id); err != nil {
+		s.writeError(w, http.StatusInternalServerError, "Failed to dismiss recommendation")
+		return
+	}
+
+	s.writeSuccess(w, map[string]string{"message": "Recommendation dismissed"})
+}
+
+func (s *APIServer) handleRestoreRecommendation(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	restored, err := s.db.RestoreRecommendation(id)
+	if err != nil {
+		s.writeError(w, http.StatusInternalServerError, "Failed to restore recommendation")
+		return
+	}
+	if !restored {
+		s.writeError(w, http.StatusNotFound, "Recommendation is not dismissed")
+		return
+	}
+
+	s.writeSuccess(w, map[string]string{"message": "Recommendation restored"})
+}
+
+func (s *APIServer) handleGetMetrics(w http.ResponseWriter, r *http.Request) {
+	values, err := s.computeMetrics(30)
+	if err != nil {
+		s.writeError(w, http.StatusInternalServerError, "Failed to get commands")
+		return
+	}
+
+	s.writeSuccess(w, values)
+}
+
+func (s *APIServer) handleGetMetricSeries(w http.ResponseWriter, r *http.Request) {
+	days := parseDays(r)
+
+	values, err := s.computeMetrics(days)
+	if err != nil {
+		s.writeError(w, http.StatusInternalServerError, "Failed to get commands")
+		return
+	}
+
+	value := analytics.FindMetric(values, mux.Vars(r)["name"])
+	if value == nil {
+		s.writeError(w, http.StatusNotFound, "Metric not found")
+		return
+	}
+
+	s.writeSuccess(w, value.Series)
+}
+
+func (s *APIServer) handleQuery(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		Query string `json:"query"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		s.writeError(w, http.StatusBadRequest, "Invalid JSON")
+		return
+	}
+	if request.Query == "" {
+		s.writeError(w, http.StatusBadRequest, "Query is required")
+		return
+	}
+
+	result, err := query.Run(s.db, request.Query, time.Now())
+	var invalid *query.InvalidQueryError
+	if errors.As(err, &invalid) {
+		s.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		s.writeError(w, http.StatusInternalServerError, "Failed to run query")
+		return
+	}
+
+	s.writeSuccess(w, map[string]interface{}{
+		"query":   result.Query,
+		"columns": result.Columns,
+		"rows":    result.Records(),
+	})
+}
+
 func (s *APIServer) handleGetCommands(w http.ResponseWriter, r *http.Request) {
 	// Parse query parameters
 	limitStr := r.URL.Query().Get("limit")
@@ -325,8 +565,15 @@ func (s *APIServer) handleGetCategories(w http.ResponseWriter, r *http.Request)
 }
 
 func (s *APIServer) handleGetCategoryStats(w http.ResponseWriter, r *http.Request) {
-	// This would calculate category statistics from the database
-	s.writeError(w, http.StatusNotImplemented, "Category stats not implemented yet")
+	// Commands count once by their primary category, segments of pipelines
+	// and chains each count in their own
+	usage, err := s.db.GetCategoryUsage()
+	if err != nil {
+		s.writeError(w, http.StatusInternalServerError, "Failed to get category stats")
+		return
+	}
+
+	s.writeSuccess(w, usage)
 }
 
 func (s *APIServer) handleGetScoringRules(w http.ResponseWriter, r *http.Request) {
@@ -0,0 +1,192 @@
+// Package calendar maps command timestamps to the clock time and day they
+// belong to. Every query and analyzer that buckets commands by hour or day
+// goes through the default calendar, so streaks, heatmaps and daily counts
+// agree with each other.
+//
+// Timestamps are stored with the UTC offset of the machine that recorded
+// them. By default a command keeps that recorded wall-clock time, so history
+// recorded while traveling stays on the day it happened where it happened.
+// Setting a timezone converts every timestamp into that zone instead.
+//
+// A day may start later than midnight: with a day start of 4, a command at
+// 01:30 still counts towards the previous day, so night owls keep their
+// streaks.
+package calendar
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DateFormat is the format of day keys
+const DateFormat = "2006-01-02"
+
+// RecordedZone is the timezone setting that keeps each timestamp's recorded
+// offset
+const RecordedZone = "recorded"
+
+// Calendar maps instants to wall-clock times and days
+type Calendar struct {
+	location *time.Location // nil keeps each timestamp's recorded zone
+	dayStart int            // hour at which a new day begins
+}
+
+var (
+	defaultMu       sync.RWMutex
+	defaultCalendar = &Calendar{}
+)
+
+// New creates a calendar. timezone is an IANA name such as
+// "Europe/Berlin", "local" for the system timezone, or "" / "recorded" to
+// keep each timestamp's recorded offset. dayStartHour is 0-23.
+func New(timezone string, dayStartHour int) (*Calendar, error) {
+	if dayStartHour < 0 || dayStartHour > 23 {
+		return nil, fmt.Errorf("invalid day_start_hour %d, must be between 0 and 23", dayStartHour)
+	}
+
+	c := &Calendar{dayStart: dayStartHour}
+	switch strings.ToLower(timezone) {
+	case "", RecordedZone:
+	case "local":
+		c.location = time.Local
+	default:
+		location, err := time.LoadLocation(timezone)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timezone %q: %w", timezone, err)
+		}
+		c.location = location
+	}
+
+	return c, nil
+}
+
+// Default returns the calendar set from the configuration
+func Default() *Calendar {
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+	return defaultCalendar
+}
+
+// SetDefault replaces the default calendar
+func SetDefault(c *Calendar) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultCalendar = c
+}
+
+// Location returns the configured timezone, or nil when recorded offsets
+// are kept
+func (c *Calendar) Location() *time.Location {
+	return c.location
+}
+
+// DayStartHour returns the hour at which a day begins
+func (c *Calendar) DayStartHour() int {
+	return c.dayStart
+}
+
+// Clock returns the wall-clock time of an instant
+func (c *Calendar) Clock(t time.Time) time.Time {
+	if c.location == nil {
+		return t
+	}
+	return t.In(c.location)
+}
+
+// Day returns midnight of the day an instant belongs to, in the zone of its
+// wall-clock time
+func (c *Calendar) Day(t time.Time) time.Time {
+	clock := c.Clock(t)
+	year, month, day := clock.Date()
+	if clock.Hour() < c.dayStart {
+		day--
+	}
+	return time.Date(year, month, day, 0, 0, 0, 0, clock.Location())
+}
+
+// DayKey returns the YYYY-MM-DD day an instant belongs to
+func (c *Calendar) DayKey(t time.Time) string {
+	return c.Day(t).Format(DateFormat)
+}
+
+// Weekday returns the weekday of the day an instant belongs to
+func (c *Calendar) Weekday(t time.Time) time.Weekday {
+	return c.Day(t).Weekday()
+}
+
+// Today returns the day key of now. In recorded mode now is taken in the
+// system timezone.
+func (c *Calendar) Today(now time.Time) string {
+	return c.DayKey(now)
+}
+
+// DayStart returns the instant a day begins. Wall-clock arithmetic keeps it
+// right across DST changes.
+func (c *Calendar) DayStart(t time.Time) time.Time {
+	day := c.Day(t)
+	return time.Date(day.Year(), day.Month(), day.Day(), c.dayStart, 0, 0, 0, day.Location())
+}
+
+// LastDays returns the keys of the n days up to and including today, oldest
+// first
+func (c *Calendar) LastDays(now time.Time, n int) []string {
+	today := c.Day(now)
+	keys := make([]string, 0, n)
+	for i := n - 1; i >= 0; i-- {
+		keys = append(keys, today.AddDate(0, 0, -i).Format(DateFormat))
+	}
+	return keys
+}
+
+// DaysBetween returns the number of calendar days from one day to another,
+// ignoring how many hours either day had
+func DaysBetween(from, to time.Time) int {
+	a := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	b := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.UTC)
+	return int(b.Sub(a).Hours() / 24)
+}
+
+// AddDays shifts a day key by n days
+func AddDays(key string, n int) string {
+	day, err := time.Parse(DateFormat, key)
+	if err != nil {
+		return key
+	}
+	return day.AddDate(0, 0, n).Format(DateFormat)
+}
+
+// Streaks computes the current and longest runs of consecutive active days.
+// The current streak counts if the last active day is today or yesterday,
+// so it is not lost before the first command of the day.
+func Streaks(dayKeys []string, today string) (current, longest int) {
+	active := make(map[string]bool, len(dayKeys))
+	for _, key := range dayKeys {
+		active[key] = true
+	}
+
+	for key := range active {
+		if active[AddDays(key, -1)] {
+			continue // not the first day of a run
+		}
+		length := 1
+		for active[AddDays(key, length)] {
+			length++
+		}
+		if length > longest {
+			longest = length
+		}
+	}
+
+	start := today
+	if !active[start] {
+		start = AddDays(today, -1)
+	}
+	for active[start] {
+		current++
+		start = AddDays(start, -1)
+	}
+
+	return current, longest
+}
@@ -1,8 +1,12 @@
 package categories
 
 import (
+	"math"
 	"regexp"
+	"sort"
 	"strings"
+
+	"github.com/oiahoon/termonaut/pkg/models"
 )
 
 // Category represents a command category
@@ -28,6 +32,54 @@ const (
 	Unknown     Category = "unknown"
 )
 
+// Built-in subcategories. A subcategory is its parent's ID, a slash and a
+// name, and rolls up into the parent in aggregations.
+const (
+	GitRemote         Category = "git/remote"
+	GitHistory        Category = "git/history"
+	GitBranch         Category = "git/branch"
+	GitCommit         Category = "git/commit"
+	DockerBuild       Category = "docker/build"
+	DockerRun         Category = "docker/run"
+	DockerInspect     Category = "docker/inspect"
+	KubernetesDeploy  Category = "kubernetes/deploy"
+	KubernetesInspect Category = "kubernetes/inspect"
+	KubernetesDebug   Category = "kubernetes/debug"
+)
+
+// Parent returns the category a subcategory belongs to, e.g. git for
+// git/remote, or "" for a top-level category
+func (c Category) Parent() Category {
+	if slash := strings.LastIndex(string(c), "/"); slash >= 0 {
+		return c[:slash]
+	}
+	return ""
+}
+
+// Root returns the top-level category, e.g. git for git/remote
+func (c Category) Root() Category {
+	if slash := strings.Index(string(c), "/"); slash >= 0 {
+		return c[:slash]
+	}
+	return c
+}
+
+// Within reports whether c is ancestor itself or one of its subcategories
+func (c Category) Within(ancestor Category) bool {
+	return c == ancestor || strings.HasPrefix(string(c), string(ancestor)+"/")
+}
+
+// Label is one of the categories a command belongs to, with its share of
+// the command. The weights of a command's labels add up to 1.
+type Label struct {
+	Category Category `json:"category"`
+	Weight   float64  `json:"weight"`
+}
+
+// secondaryLabelWeight is how much a category matched by a lower-precedence
+// rule counts, relative to the deciding one, before weights are normalized
+const secondaryLabelWeight = 0.5
+
 // CategoryInfo holds category metadata
 type CategoryInfo struct {
 	Name        string  `json:"name"`
@@ -37,25 +89,83 @@ type CategoryInfo struct {
 	XPBonus     float64 `json:"xp_bonus"`
 }
 
+// BuiltinSource is the source of the rules that ship with Termonaut
+const BuiltinSource = "built-in"
+
+// Rule is one pattern that puts matching commands in a category
+type Rule struct {
+	Category Category `json:"category"`
+	Pattern  string   `json:"pattern"`
+	Priority int      `json:"priority"`
+	Source   string   `json:"source"` // built-in, learned, or the categories file
+
+	regex *regexp.Regexp
+}
+
 // CommandClassifier classifies commands into categories
 type CommandClassifier struct {
-	patterns map[Category][]*regexp.Regexp
+	rules    []*Rule // in precedence order, the first match wins
 	metadata map[Category]*CategoryInfo
+	xpBonus  map[Category]float64 // XP bonuses set in the categories file
 }
 
-// NewCommandClassifier creates a new command classifier
+// NewCommandClassifier creates a classifier with the built-in categories,
+// those from the categories file and the learned assignments
 func NewCommandClassifier() *CommandClassifier {
+	return newCommandClassifier(currentDefinitions(), currentLearned())
+}
+
+// NewBuiltinClassifier creates a classifier with only the built-in categories
+func NewBuiltinClassifier() *CommandClassifier {
+	return NewCommandClassifierWith(nil)
+}
+
+// NewCommandClassifierWith creates a classifier with the built-in categories
+// changed and extended by defs, which may be nil
+func NewCommandClassifierWith(defs *Definitions) *CommandClassifier {
+	return newCommandClassifier(defs, nil)
+}
+
+func newCommandClassifier(defs *Definitions, assignments []Assignment) *CommandClassifier {
 	cc := &CommandClassifier{
-		patterns: make(map[Category][]*regexp.Regexp),
 		metadata: make(map[Category]*CategoryInfo),
+		xpBonus:  make(map[Category]float64),
 	}
 	cc.initializePatterns()
 	cc.initializeMetadata()
+	cc.initializeSubcategories()
+	if defs != nil {
+		cc.applyDefinitions(defs)
+	}
+	cc.applyLearned(assignments)
+	cc.sortRules()
 	return cc
 }
 
-// initializePatterns sets up regex patterns for command classification
+// initializePatterns sets up regex patterns for command classification.
+// Built-in rules are tried in the order they are added here.
 func (cc *CommandClassifier) initializePatterns() {
+	// Subcategories come first so they win over their parent's general rules
+	cc.addPatterns(GitRemote, []string{`^git\s+(push|pull|fetch|clone|remote)(\s|$)`})
+	cc.addPatterns(GitHistory, []string{`^git\s+(log|show|blame|reflog|shortlog|diff)(\s|$)`})
+	cc.addPatterns(GitBranch, []string{`^git\s+(branch|checkout|switch|merge|rebase|cherry-pick|tag)(\s|$)`})
+	cc.addPatterns(GitCommit, []string{`^git\s+(add|commit|stash|reset|restore|rm|mv|status)(\s|$)`})
+	cc.addPatterns(DockerBuild, []string{
+		`^(docker|podman)\s+(build|buildx)(\s|$)`,
+		`^docker(-compose|\s+compose)\s+build(\s|$)`,
+	})
+	cc.addPatterns(DockerRun, []string{
+		`^(docker|podman)\s+(run|exec|start|stop|restart|rm|kill)(\s|$)`,
+		`^docker(-compose|\s+compose)\s+(up|down|run|exec|start|stop|restart)(\s|$)`,
+	})
+	cc.addPatterns(DockerInspect, []string{`^(docker|podman)\s+(ps|logs|inspect|images|stats|top)(\s|$)`})
+	cc.addPatterns(KubernetesDeploy, []string{
+		`^kubectl\s+(apply|create|delete|rollout|scale|set|patch|replace|edit)(\s|$)`,
+		`^helm\s+(install|upgrade|uninstall|rollback)(\s|$)`,
+	})
+	cc.addPatterns(KubernetesInspect, []string{`^kubectl\s+(get|describe|logs|top|events|explain)(\s|$)`})
+	cc.addPatterns(KubernetesDebug, []string{`^kubectl\s+(exec|port-forward|cp|attach|debug)(\s|$)`})
+
 	// Git commands
 	cc.addPatterns(Git, []string{
 		`^git\s+`,
@@ -86,29 +196,29 @@ func (cc *CommandClassifier) initializePatterns() {
 
 	// System commands
 	cc.addPatterns(System, []string{
-		`^(ls|ll|la|dir)\s*`,
-		`^(ps|top|htop|btop)\s*`,
+		`^(ls|ll|la|dir)(\s|$)`,
+		`^(ps|top|htop|btop)(\s|$)`,
 		`^(kill|killall|pkill)\s+`,
 		`^(sudo|su)\s+`,
 		`^(systemctl|service)\s+`,
 		`^(mount|umount)\s+`,
-		`^(df|du|free)\s*`,
-		`^(uname|whoami|id)\s*`,
-		`^(date|uptime|w)\s*`,
+		`^(df|du|free)(\s|$)`,
+		`^(uname|whoami|id)(\s|$)`,
+		`^(date|uptime|w)(\s|$)`,
 		`^(chmod|chown|chgrp)\s+`,
 		`^(ln|cp|mv|rm|mkdir|rmdir)\s+`,
 		`^(find|locate|which|whereis)\s+`,
-		`^(crontab|at|jobs)\s*`,
-		`^(env|export|set)\s*`,
+		`^(crontab|at|jobs)(\s|$)`,
+		`^(env|export|set)(\s|$)`,
 	})
 
 	// Navigation commands
 	cc.addPatterns(Navigation, []string{
-		`^cd\s*`,
-		`^pwd\s*`,
+		`^cd(\s|$)`,
+		`^pwd(\s|$)`,
 		`^pushd\s+`,
-		`^popd\s*`,
-		`^dirs\s*`,
+		`^popd(\s|$)`,
+		`^dirs(\s|$)`,
 	})
 
 	// Network commands
@@ -116,7 +226,7 @@ func (cc *CommandClassifier) initializePatterns() {
 		`^(curl|wget|http)\s+`,
 		`^(ping|traceroute|nslookup|dig)\s+`,
 		`^(ssh|scp|rsync|sftp)\s+`,
-		`^(netstat|ss|lsof)\s*`,
+		`^(netstat|ss|lsof)(\s|$)`,
 		`^(tcpdump|wireshark)\s+`,
 		`^(nc|netcat|telnet)\s+`,
 		`^(iptables|ufw|firewall-cmd)\s+`,
@@ -132,7 +242,7 @@ func (cc *CommandClassifier) initializePatterns() {
 	// Kubernetes commands
 	cc.addPatterns(Kubernetes, []string{
 		`^kubectl\s+`,
-		`^k9s\s*`,
+		`^k9s(\s|$)`,
 		`^helm\s+`,
 		`^minikube\s+`,
 		`^kind\s+`,
@@ -168,6 +278,7 @@ func (cc *CommandClassifier) initializePatterns() {
 		`^(webpack|rollup|vite)\s+`,
 		`^(gulp|grunt)\s+`,
 		`^(bazel|buck)\s+`,
+		`^(docker|podman)\s+(build|buildx)(\s|$)`, // also docker/build
 	})
 
 	// Testing
@@ -212,13 +323,95 @@ func (cc *CommandClassifier) initializePatterns() {
 
 // addPatterns compiles and adds regex patterns for a category
 func (cc *CommandClassifier) addPatterns(category Category, patterns []string) {
+	cc.addRules(category, patterns, 0, BuiltinSource)
+}
+
+// addRules compiles and adds rules for a category, skipping invalid patterns
+func (cc *CommandClassifier) addRules(category Category, patterns []string, priority int, source string) {
 	for _, pattern := range patterns {
 		if regex, err := regexp.Compile(pattern); err == nil {
-			cc.patterns[category] = append(cc.patterns[category], regex)
+			cc.rules = append(cc.rules, &Rule{
+				Category: category,
+				Pattern:  pattern,
+				Priority: priority,
+				Source:   source,
+				regex:    regex,
+			})
+		}
+	}
+}
+
+// applyDefinitions adds the categories and rules of a categories file
+func (cc *CommandClassifier) applyDefinitions(defs *Definitions) {
+	for _, def := range defs.Categories {
+		category := Category(def.ID)
+		if def.Replace {
+			kept := cc.rules[:0]
+			for _, rule := range cc.rules {
+				if !rule.Category.Within(category) {
+					kept = append(kept, rule)
+				}
+			}
+			cc.rules = kept
+		}
+		cc.addRules(category, def.Patterns, def.Priority, defs.Source)
+
+		info, exists := cc.metadata[category]
+		if !exists {
+			info = cc.newCategoryInfo(category)
+		} else {
+			copied := *info
+			info = &copied
+		}
+		if def.Name != "" {
+			info.Name = def.Name
+		}
+		if def.Icon != "" {
+			info.Icon = def.Icon
+		}
+		if def.Color != "" {
+			info.Color = def.Color
+		}
+		if def.Description != "" {
+			info.Description = def.Description
+		}
+		if def.XPBonus != nil {
+			info.XPBonus = *def.XPBonus
+			cc.xpBonus[category] = *def.XPBonus
 		}
+		cc.metadata[category] = info
 	}
 }
 
+// newCategoryInfo returns the metadata of a category that has none yet. A
+// new subcategory looks like its parent until told otherwise.
+func (cc *CommandClassifier) newCategoryInfo(category Category) *CategoryInfo {
+	if parent, ok := cc.metadata[category.Parent()]; ok {
+		return &CategoryInfo{Name: string(category), Icon: parent.Icon, Color: parent.Color,
+			Description: parent.Description, XPBonus: parent.XPBonus}
+	}
+	return &CategoryInfo{Name: string(category), Icon: "🏷️", Color: "white", XPBonus: 1.0}
+}
+
+// sortRules orders rules by precedence: learned rules first, then higher
+// priority first, then rules from the categories file before built-in ones,
+// then subcategories before top-level categories, then definition order
+func (cc *CommandClassifier) sortRules() {
+	sort.SliceStable(cc.rules, func(i, j int) bool {
+		a, b := cc.rules[i], cc.rules[j]
+		if (a.Source == LearnedSource) != (b.Source == LearnedSource) {
+			return a.Source == LearnedSource
+		}
+		if a.Priority != b.Priority {
+			return a.Priority > b.Priority
+		}
+		if (a.Source == BuiltinSource) != (b.Source == BuiltinSource) {
+			return b.Source == BuiltinSource
+		}
+		return strings.Count(string(a.Category), "/") > strings.Count(string(b.Category), "/")
+	})
+}
+
 // initializeMetadata sets up category metadata
 func (cc *CommandClassifier) initializeMetadata() {
 	cc.metadata[Git] = &CategoryInfo{
@@ -358,29 +551,210 @@ func (cc *CommandClassifier) initializeMetadata() {
 	}
 }
 
-// ClassifyCommand determines the category of a command
+// initializeSubcategories sets up the built-in subcategories, which share
+// their parent's icon, color and XP bonus
+func (cc *CommandClassifier) initializeSubcategories() {
+	subcategories := []struct {
+		category    Category
+		name        string
+		description string
+	}{
+		{GitRemote, "Git Remotes", "Pushing, pulling and cloning"},
+		{GitHistory, "Git History", "Logs, diffs and blame"},
+		{GitBranch, "Git Branching", "Branches, merges and rebases"},
+		{GitCommit, "Git Commits", "Staging and committing changes"},
+		{DockerBuild, "Image Builds", "Building container images"},
+		{DockerRun, "Containers", "Running and managing containers"},
+		{DockerInspect, "Container Inspection", "Listing, logs and inspection"},
+		{KubernetesDeploy, "Deployments", "Applying and rolling out changes"},
+		{KubernetesInspect, "Cluster Inspection", "Getting and describing resources"},
+		{KubernetesDebug, "Cluster Debugging", "Exec, port forwarding and debugging"},
+	}
+
+	for _, sub := range subcategories {
+		parent := cc.metadata[sub.category.Parent()]
+		cc.metadata[sub.category] = &CategoryInfo{
+			Name:        sub.name,
+			Icon:        parent.Icon,
+			Color:       parent.Color,
+			Description: sub.description,
+			XPBonus:     parent.XPBonus,
+		}
+	}
+}
+
+// Classification is the category of a command line and of each of its segments
+type Classification struct {
+	Primary        Category   `json:"primary"`
+	PrimarySegment int        `json:"primary_segment"` // index of the segment that decided it, -1 without segments
+	Labels         []Label    `json:"labels"`          // all categories of the primary segment, weighted
+	Segments       []*Segment `json:"segments"`
+}
+
+// Tool returns the program of the primary segment, or "" without segments
+func (c *Classification) Tool() string {
+	if c.PrimarySegment < 0 || c.PrimarySegment >= len(c.Segments) {
+		return ""
+	}
+	return c.Segments[c.PrimarySegment].Tool
+}
+
+// BaseCommand returns the program a command line runs: that of its primary
+// segment, see Classify. Wrappers, environment assignments and directory
+// prefixes are skipped, so "sudo FOO=1 /usr/bin/make" gives "make".
+func (cc *CommandClassifier) BaseCommand(command string) string {
+	return cc.Classify(command).Tool()
+}
+
+// ClassifyCommand determines the most specific category of a command, e.g.
+// git/remote. For a pipeline or chain it is the primary category, see Classify.
 func (cc *CommandClassifier) ClassifyCommand(command string) Category {
+	return cc.Classify(command).Primary
+}
+
+// CommandCategory returns the category stored with a command, which
+// "categories reclassify" and learned categories keep up to date, and
+// classifies commands stored without one
+func (cc *CommandClassifier) CommandCategory(cmd *models.Command) Category {
+	if cmd.Category != "" {
+		return Category(cmd.Category)
+	}
+	return cc.ClassifyCommand(cmd.Command)
+}
+
+// CommandLabels returns the weighted categories of a stored command. They
+// come from the classifier while it agrees with the stored category;
+// otherwise, and for anonymized commands, the stored category is the only
+// label.
+func (cc *CommandClassifier) CommandLabels(cmd *models.Command) []Label {
+	if cmd.Category == "" {
+		return cc.Classify(cmd.Command).Labels
+	}
+	stored := Category(cmd.Category)
+	if !cmd.Anonymized {
+		if classification := cc.Classify(cmd.Command); classification.Primary == stored {
+			return classification.Labels
+		}
+	}
+	return []Label{{Category: stored, Weight: 1}}
+}
+
+// Classify splits a command line into segments and classifies each one.
+// The primary category is that of the first classified segment doing the
+// main work: segments reading from a pipe ("| grep", "| less") and
+// navigation ("cd repo &&") only decide it when nothing else is classified.
+func (cc *CommandClassifier) Classify(command string) *Classification {
+	classification := &Classification{
+		Primary:        Unknown,
+		PrimarySegment: -1,
+		Labels:         []Label{{Category: Unknown, Weight: 1}},
+		Segments:       SplitCommand(command),
+	}
+	if len(classification.Segments) == 0 {
+		return classification
+	}
+
+	primary, fallback := -1, -1
+	for i, segment := range classification.Segments {
+		segment.Labels = cc.labels(segment.Command)
+		segment.Category = segment.Labels[0].Category
+		switch {
+		case segment.Category == Unknown:
+		case segment.Piped() || segment.Category.Root() == Navigation:
+			if fallback < 0 {
+				fallback = i
+			}
+		case primary < 0:
+			primary = i
+		}
+	}
+	if primary < 0 {
+		primary = fallback
+	}
+	if primary < 0 {
+		primary = 0 // nothing classified, the first segment stands for the command
+	}
+
+	classification.PrimarySegment = primary
+	classification.Primary = classification.Segments[primary].Category
+	classification.Labels = classification.Segments[primary].Labels
+	return classification
+}
+
+// labels classifies a simple command. The first matching rule decides its
+// category; every other category with a matching rule is a secondary label,
+// unless it is an ancestor or subcategory of one already found.
+func (cc *CommandClassifier) labels(command string) []Label {
+	var labels []Label
+	for _, rule := range cc.rules {
+		if !rule.regex.MatchString(command) {
+			continue
+		}
+		related := false
+		for _, label := range labels {
+			if rule.Category.Within(label.Category) || label.Category.Within(rule.Category) {
+				related = true
+				break
+			}
+		}
+		if related {
+			continue
+		}
+		weight := 1.0
+		if len(labels) > 0 {
+			weight = secondaryLabelWeight
+		}
+		labels = append(labels, Label{Category: rule.Category, Weight: weight})
+	}
+	if len(labels) == 0 {
+		return []Label{{Category: Unknown, Weight: 1}}
+	}
+
+	total := 0.0
+	for _, label := range labels {
+		total += label.Weight
+	}
+	for i := range labels {
+		labels[i].Weight /= total
+	}
+	return labels
+}
+
+// Explain returns every rule that matches a simple command, such as a
+// segment from Classify, in precedence order. The first rule decides the
+// category; the rest are shadowed by it.
+func (cc *CommandClassifier) Explain(command string) []*Rule {
 	command = strings.TrimSpace(command)
+	matches := []*Rule{}
 	if command == "" {
-		return Unknown
+		return matches
 	}
 
-	// Check each category's patterns
-	for category, patterns := range cc.patterns {
-		for _, pattern := range patterns {
-			if pattern.MatchString(command) {
-				return category
-			}
+	for _, rule := range cc.rules {
+		if rule.regex.MatchString(command) {
+			matches = append(matches, rule)
 		}
 	}
+	return matches
+}
 
-	return Unknown
+// Rules returns all rules in precedence order
+func (cc *CommandClassifier) Rules() []*Rule {
+	return cc.rules
 }
 
-// GetCategoryInfo returns metadata for a category
+// XPBonusOverrides returns the XP bonuses set in the categories file
+func (cc *CommandClassifier) XPBonusOverrides() map[Category]float64 {
+	return cc.xpBonus
+}
+
+// GetCategoryInfo returns metadata for a category. A subcategory without
+// metadata of its own gets its parent's.
 func (cc *CommandClassifier) GetCategoryInfo(category Category) *CategoryInfo {
-	if info, exists := cc.metadata[category]; exists {
-		return info
+	for c := category; c != ""; c = c.Parent() {
+		if info, exists := cc.metadata[c]; exists {
+			return info
+		}
 	}
 	return cc.metadata[Unknown]
 }
@@ -400,41 +774,78 @@ func (cc *CommandClassifier) GetXPMultiplier(category Category) float64 {
 
 // GetCategoryStats calculates statistics for each category
 type CategoryStats struct {
-	Category   Category `json:"category"`
-	Count      int      `json:"count"`
-	Percentage float64  `json:"percentage"`
-	TotalXP    int      `json:"total_xp"`
-	LastUsed   string   `json:"last_used"`
+	Category      Category                    `json:"category"`
+	Count         int                         `json:"count"`      // commands labelled with it or a subcategory
+	Share         float64                     `json:"share"`      // commands weighted by label; shares add up to the total
+	Percentage    float64                     `json:"percentage"` // share of all commands
+	TotalXP       int                         `json:"total_xp"`
+	LastUsed      string                      `json:"last_used"`
+	Subcategories map[Category]*CategoryStats `json:"subcategories,omitempty"`
 }
 
-// AnalyzeCategories analyzes command usage by category
+// AnalyzeCategories analyzes command usage by top-level category. Each
+// command is split across its labels by weight, and subcategories roll up
+// into their top-level category while keeping their own breakdown.
 func (cc *CommandClassifier) AnalyzeCategories(commands []string) map[Category]*CategoryStats {
-	categoryCount := make(map[Category]int)
-	totalCommands := len(commands)
+	labels := make([][]Label, len(commands))
+	for i, command := range commands {
+		labels[i] = cc.Classify(command).Labels
+	}
+	return cc.analyzeLabels(labels)
+}
 
-	// Count commands by category
-	for _, command := range commands {
-		category := cc.ClassifyCommand(command)
-		categoryCount[category]++
+// AnalyzeCommandCategories analyzes stored commands like AnalyzeCategories,
+// using their stored categories, see CommandLabels
+func (cc *CommandClassifier) AnalyzeCommandCategories(commands []*models.Command) map[Category]*CategoryStats {
+	labels := make([][]Label, len(commands))
+	for i, cmd := range commands {
+		labels[i] = cc.CommandLabels(cmd)
 	}
+	return cc.analyzeLabels(labels)
+}
 
-	// Calculate statistics
+// analyzeLabels rolls up the labels of each command by category
+func (cc *CommandClassifier) analyzeLabels(commands [][]Label) map[Category]*CategoryStats {
 	stats := make(map[Category]*CategoryStats)
-	for category, count := range categoryCount {
-		if count > 0 {
-			percentage := float64(count) / float64(totalCommands) * 100
-			info := cc.GetCategoryInfo(category)
-			totalXP := int(float64(count) * info.XPBonus)
-
-			stats[category] = &CategoryStats{
-				Category:   category,
-				Count:      count,
-				Percentage: percentage,
-				TotalXP:    totalXP,
-				LastUsed:   "", // TODO: Implement last used tracking
+	if len(commands) == 0 {
+		return stats
+	}
+
+	xp := make(map[*CategoryStats]float64)
+	add := func(into map[Category]*CategoryStats, category Category, counted map[*CategoryStats]bool, label Label) {
+		s, exists := into[category]
+		if !exists {
+			s = &CategoryStats{Category: category}
+			into[category] = s
+		}
+		if !counted[s] {
+			counted[s] = true
+			s.Count++
+		}
+		s.Share += label.Weight
+		xp[s] += label.Weight * cc.GetXPMultiplier(label.Category)
+	}
+
+	for _, labels := range commands {
+		// A command with two labels in one category counts once in it
+		counted := make(map[*CategoryStats]bool)
+		for _, label := range labels {
+			root := label.Category.Root()
+			add(stats, root, counted, label)
+			if label.Category != root {
+				if stats[root].Subcategories == nil {
+					stats[root].Subcategories = make(map[Category]*CategoryStats)
+				}
+				add(stats[root].Subcategories, label.Category, counted, label)
 			}
 		}
 	}
 
+	total := float64(len(commands))
+	for s, earned := range xp {
+		s.Percentage = s.Share / total * 100
+		s.TotalXP = int(math.Round(earned))
+	}
+
 	return stats
 }
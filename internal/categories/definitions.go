@@ -0,0 +1,112 @@
+package categories
+
+import (
+	"fmt"
+	"regexp"
+	"sync"
+
+	"github.com/spf13/viper"
+)
+
+// Definition adds a category or changes a built-in one. It is read from the
+// categories file, e.g. in TOML:
+//
+//	[[categories]]
+//	id = "infra"
+//	name = "Infrastructure as Code"
+//	icon = "🏗️"
+//	xp_bonus = 1.6
+//	priority = 10
+//	patterns = ['^(terraform|tofu)\s+', '^pulumi\s+']
+type Definition struct {
+	ID          string   `mapstructure:"id"` // new category, a built-in one such as git, or a subcategory such as git/remote
+	Name        string   `mapstructure:"name"`
+	Icon        string   `mapstructure:"icon"`
+	Color       string   `mapstructure:"color"`
+	Description string   `mapstructure:"description"`
+	XPBonus     *float64 `mapstructure:"xp_bonus"`
+	Priority    int      `mapstructure:"priority"` // higher priorities are tried first
+	Patterns    []string `mapstructure:"patterns"` // regular expressions matched against the command
+	Replace     bool     `mapstructure:"replace"`  // drop the built-in patterns of the category
+}
+
+// Definitions are the categories read from one file
+type Definitions struct {
+	Source     string       `mapstructure:"-"` // path of the file, shown when explaining matches
+	Categories []Definition `mapstructure:"categories"`
+}
+
+var (
+	definitionsMu sync.RWMutex
+	definitions   *Definitions
+)
+
+// idPattern is what a category ID may look like: a name, or a parent ID,
+// a slash and a name for a subcategory
+var idPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*(/[a-z0-9][a-z0-9_-]*)*$`)
+
+// LoadDefinitions reads a categories file. The format follows the extension:
+// .toml, .yaml, .yml or .json.
+func LoadDefinitions(path string) (*Definitions, error) {
+	v := viper.New()
+	v.SetConfigFile(path)
+	if err := v.ReadInConfig(); err != nil {
+		return nil, fmt.Errorf("failed to read categories file: %w", err)
+	}
+
+	defs := &Definitions{Source: path}
+	if err := v.Unmarshal(defs); err != nil {
+		return nil, fmt.Errorf("failed to parse categories file: %w", err)
+	}
+	if err := ValidateDefinitions(defs.Categories); err != nil {
+		return nil, err
+	}
+	return defs, nil
+}
+
+// ValidateDefinitions checks category definitions
+func ValidateDefinitions(defs []Definition) error {
+	builtin := NewBuiltinClassifier().metadata
+	seen := make(map[string]bool)
+	for i, def := range defs {
+		if !idPattern.MatchString(def.ID) {
+			return fmt.Errorf("categories[%d]: invalid id %q, use lowercase letters, digits, - and _, and / before a subcategory", i, def.ID)
+		}
+		if seen[def.ID] {
+			return fmt.Errorf("categories[%d]: duplicate category %q", i, def.ID)
+		}
+		if parent := Category(def.ID).Parent(); parent != "" {
+			if _, exists := builtin[parent]; !exists && !seen[string(parent)] {
+				return fmt.Errorf("category %q: parent category %q must be built in or defined before it", def.ID, parent)
+			}
+		}
+		seen[def.ID] = true
+
+		if _, exists := builtin[Category(def.ID)]; !exists && len(def.Patterns) == 0 {
+			return fmt.Errorf("category %q: a new category needs at least one pattern", def.ID)
+		}
+		if def.XPBonus != nil && *def.XPBonus < 0 {
+			return fmt.Errorf("category %q: xp_bonus must not be negative", def.ID)
+		}
+		for _, pattern := range def.Patterns {
+			if _, err := regexp.Compile(pattern); err != nil {
+				return fmt.Errorf("category %q: invalid pattern %q: %w", def.ID, pattern, err)
+			}
+		}
+	}
+	return nil
+}
+
+// SetDefinitions sets the categories every new classifier includes
+func SetDefinitions(defs *Definitions) {
+	definitionsMu.Lock()
+	defer definitionsMu.Unlock()
+	definitions = defs
+}
+
+// currentDefinitions returns the categories set from the configuration
+func currentDefinitions() *Definitions {
+	definitionsMu.RLock()
+	defer definitionsMu.RUnlock()
+	return definitions
+}
@@ -0,0 +1,143 @@
+package categories
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/spf13/viper"
+)
+
+// LearnedSource is the source of rules learned from "categories assign"
+const LearnedSource = "learned"
+
+// Assignment puts every command running a tool in a category. Assignments
+// are corrections made by the user and win over every other rule.
+type Assignment struct {
+	Tool     string   `json:"tool"`
+	Category Category `json:"category"`
+}
+
+var (
+	learnedMu sync.RWMutex
+	learned   []Assignment
+)
+
+// SetLearned sets the assignments every new classifier includes
+func SetLearned(assignments []Assignment) {
+	learnedMu.Lock()
+	defer learnedMu.Unlock()
+	learned = append([]Assignment(nil), assignments...)
+}
+
+// currentLearned returns the assignments set from the database
+func currentLearned() []Assignment {
+	learnedMu.RLock()
+	defer learnedMu.RUnlock()
+	return learned
+}
+
+// ValidateAssignment checks that a tool can be assigned to a category. A new
+// subcategory needs a parent the classifier knows.
+func (cc *CommandClassifier) ValidateAssignment(tool string, category Category) error {
+	if tool == "" || strings.ContainsAny(tool, " \t/") {
+		return fmt.Errorf("invalid tool %q, use the program name such as terraform", tool)
+	}
+	if !idPattern.MatchString(string(category)) {
+		return fmt.Errorf("invalid category %q, use lowercase letters, digits, - and _, and / before a subcategory", category)
+	}
+	if parent := category.Parent(); parent != "" {
+		if _, exists := cc.metadata[parent]; !exists {
+			return fmt.Errorf("parent category %q does not exist", parent)
+		}
+	}
+	return nil
+}
+
+// learnedPattern matches commands running a tool, with or without its path
+func learnedPattern(tool string) string {
+	return `^(\S*/)?` + regexp.QuoteMeta(tool) + `(\s|$)`
+}
+
+// applyLearned adds a rule for each assignment
+func (cc *CommandClassifier) applyLearned(assignments []Assignment) {
+	for _, a := range assignments {
+		cc.addRules(a.Category, []string{learnedPattern(a.Tool)}, 0, LearnedSource)
+		if _, exists := cc.metadata[a.Category]; !exists {
+			cc.metadata[a.Category] = cc.newCategoryInfo(a.Category)
+		}
+	}
+}
+
+// LearnedDefinitions turns assignments into categories file definitions, so
+// they can be shared with a team as a categories file. Parents come before
+// their subcategories.
+func LearnedDefinitions(assignments []Assignment) *Definitions {
+	tools := make(map[Category][]string)
+	for _, a := range assignments {
+		tools[a.Category] = append(tools[a.Category], a.Tool)
+	}
+
+	ids := make([]Category, 0, len(tools))
+	for category := range tools {
+		ids = append(ids, category)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		di, dj := strings.Count(string(ids[i]), "/"), strings.Count(string(ids[j]), "/")
+		if di != dj {
+			return di < dj
+		}
+		return ids[i] < ids[j]
+	})
+
+	defs := &Definitions{Source: LearnedSource}
+	for _, category := range ids {
+		sort.Strings(tools[category])
+		patterns := make([]string, 0, len(tools[category]))
+		for _, tool := range tools[category] {
+			patterns = append(patterns, learnedPattern(tool))
+		}
+		defs.Categories = append(defs.Categories, Definition{ID: string(category), Patterns: patterns})
+	}
+	return defs
+}
+
+// WriteDefinitions writes definitions as a categories file. The format
+// follows the extension, as for LoadDefinitions.
+func WriteDefinitions(path string, defs *Definitions) error {
+	entries := make([]map[string]interface{}, 0, len(defs.Categories))
+	for _, def := range defs.Categories {
+		entry := map[string]interface{}{"id": def.ID, "patterns": def.Patterns}
+		if def.Name != "" {
+			entry["name"] = def.Name
+		}
+		if def.Icon != "" {
+			entry["icon"] = def.Icon
+		}
+		if def.Color != "" {
+			entry["color"] = def.Color
+		}
+		if def.Description != "" {
+			entry["description"] = def.Description
+		}
+		if def.XPBonus != nil {
+			entry["xp_bonus"] = *def.XPBonus
+		}
+		if def.Priority != 0 {
+			entry["priority"] = def.Priority
+		}
+		if def.Replace {
+			entry["replace"] = true
+		}
+		entries = append(entries, entry)
+	}
+
+	v := viper.New()
+	v.Set("categories", entries)
+	if err := v.WriteConfigAs(path); err != nil {
+		return fmt.Errorf("failed to write categories file: %w", err)
+	}
+	return nil
+}
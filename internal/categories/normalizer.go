@@ -0,0 +1,133 @@
+package categories
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// subcommandTools are tools whose first argument selects a subcommand
+var subcommandTools = map[string]bool{
+	"git": true, "docker": true, "docker-compose": true, "kubectl": true, "helm": true,
+	"npm": true, "yarn": true, "pnpm": true, "go": true, "cargo": true, "pip": true,
+	"pip3": true, "brew": true, "apt": true, "apt-get": true, "terraform": true,
+	"gh": true, "systemctl": true, "gcloud": true, "aws": true, "az": true,
+	"poetry": true, "bundle": true, "rails": true, "mix": true, "dotnet": true,
+	"podman": true, "minikube": true, "kind": true, "vagrant": true, "conda": true,
+	"rustup": true, "nix": true, "bun": true, "deno": true,
+}
+
+// globalValueFlags are flags given before the subcommand that take a value,
+// so the value is not mistaken for the subcommand ("git -C dir status")
+var globalValueFlags = map[string]map[string]bool{
+	"git":     {"-C": true, "-c": true, "--git-dir": true, "--work-tree": true},
+	"kubectl": {"-n": true, "--namespace": true, "--context": true, "--kubeconfig": true},
+	"docker":  {"-H": true, "--host": true, "--context": true, "--config": true},
+	"helm":    {"-n": true, "--namespace": true, "--kube-context": true},
+	"aws":     {"--profile": true, "--region": true},
+}
+
+// singleDashLongFlagTools use single-dash long flags ("go test -race",
+// "find -name"), so their short flags are never split
+var singleDashLongFlagTools = map[string]bool{
+	"go": true, "find": true, "java": true, "terraform": true, "ffmpeg": true,
+}
+
+// subcommandPattern matches words that look like subcommands rather than
+// paths, URLs or values
+var subcommandPattern = regexp.MustCompile(`^[a-z][a-z0-9_:-]*$`)
+
+// NormalizedCommand is a command reduced to its tool, subcommand and flags,
+// with all values and arguments dropped
+type NormalizedCommand struct {
+	Tool       string   `json:"tool"`
+	Subcommand string   `json:"subcommand,omitempty"`
+	Flags      []string `json:"flags,omitempty"`
+}
+
+// Key returns the tool and subcommand, e.g. "git commit"
+func (n *NormalizedCommand) Key() string {
+	if n.Subcommand == "" {
+		return n.Tool
+	}
+	return n.Tool + " " + n.Subcommand
+}
+
+// NormalizeCommand extracts the tool, subcommand and flags from a command
+// line, or from the first command of a pipeline or chain. Flag values are
+// dropped ("--format=json" becomes "--format") and combined short flags are
+// split ("-la" becomes "-l" and "-a"), so `git commit -m "x"` and
+// `git commit -m "y"` normalize identically.
+func NormalizeCommand(command string) *NormalizedCommand {
+	normalized := &NormalizedCommand{}
+	segments := SplitCommand(command)
+	if len(segments) == 0 {
+		return normalized
+	}
+	normalized.Tool = segments[0].Tool
+
+	seen := make(map[string]bool)
+	addFlag := func(flag string) {
+		if !seen[flag] {
+			seen[flag] = true
+			normalized.Flags = append(normalized.Flags, flag)
+		}
+	}
+
+	wantSubcommand := subcommandTools[normalized.Tool]
+	skipValue := false
+	for _, w := range splitWords(segments[0].Command)[1:] {
+		field := w.value
+		if field == "--" {
+			break
+		}
+		if skipValue {
+			skipValue = false
+			continue
+		}
+
+		if strings.HasPrefix(field, "-") && len(field) > 1 {
+			for _, flag := range splitFlag(field, singleDashLongFlagTools[normalized.Tool]) {
+				addFlag(flag)
+			}
+			if wantSubcommand && normalized.Subcommand == "" && globalValueFlags[normalized.Tool][field] {
+				skipValue = true
+			}
+			continue
+		}
+
+		if wantSubcommand && normalized.Subcommand == "" {
+			if subcommandPattern.MatchString(field) {
+				normalized.Subcommand = field
+			}
+			wantSubcommand = false
+		}
+	}
+
+	sort.Strings(normalized.Flags)
+	return normalized
+}
+
+// splitFlag drops a flag's value and splits combined short flags
+func splitFlag(field string, singleDashLong bool) []string {
+	if eq := strings.Index(field, "="); eq > 0 {
+		field = field[:eq]
+	}
+
+	if strings.HasPrefix(field, "--") || singleDashLong {
+		return []string{field}
+	}
+
+	// Short flags: "-la" is "-l -a", but "-n10" and "-9" carry values
+	letters := field[1:]
+	for _, r := range letters {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z') {
+			return []string{field[:2]}
+		}
+	}
+	flags := make([]string, 0, len(letters))
+	for _, r := range letters {
+		flags = append(flags, "-"+string(r))
+	}
+	return flags
+}
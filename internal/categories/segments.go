@@ -0,0 +1,284 @@
+package categories
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Segment is one simple command of a command line. A pipeline or a chain
+// such as "make && ./app | tee log" has a segment per command.
+type Segment struct {
+	Operator string   `json:"operator,omitempty"` // what joins it to the previous segment: |, |&, &&, ||, ; or &
+	Command  string   `json:"command"`            // the command without wrappers and environment assignments
+	Tool     string   `json:"tool"`               // the program it runs, e.g. "kubectl"
+	Wrappers []string `json:"wrappers,omitempty"` // wrappers it ran under, e.g. sudo and time
+	Category Category `json:"category,omitempty"` // set once the segment is classified
+	Labels   []Label  `json:"labels,omitempty"`   // every category it belongs to, weighted
+}
+
+// Piped reports whether the segment reads the output of the previous one
+func (s *Segment) Piped() bool {
+	return s.Operator == "|" || s.Operator == "|&"
+}
+
+// commandWrapper describes a command that runs another command
+type commandWrapper struct {
+	valueFlags  map[string]bool // flags that take the next word as their value
+	positionals int             // arguments before the command, e.g. the duration of timeout
+}
+
+// commandWrappers run another command and are skipped when finding the base command
+var commandWrappers = map[string]commandWrapper{
+	"sudo":    {valueFlags: map[string]bool{"-u": true, "-g": true, "-C": true, "-D": true, "-h": true, "-p": true, "-U": true, "--user": true, "--group": true}},
+	"doas":    {valueFlags: map[string]bool{"-u": true, "-C": true}},
+	"time":    {valueFlags: map[string]bool{"-f": true, "-o": true}},
+	"nohup":   {},
+	"env":     {valueFlags: map[string]bool{"-u": true, "-C": true, "--unset": true, "--chdir": true}},
+	"command": {},
+	"exec":    {valueFlags: map[string]bool{"-a": true}},
+	"nice":    {valueFlags: map[string]bool{"-n": true, "--adjustment": true}},
+	"timeout": {valueFlags: map[string]bool{"-s": true, "-k": true, "--signal": true, "--kill-after": true}, positionals: 1},
+}
+
+// shellKeywords start a compound command and are skipped before the
+// command they introduce, as in "for f in *; do rm $f; done"
+var shellKeywords = map[string]bool{
+	"!": true, "{": true, "}": true, "if": true, "then": true, "elif": true, "else": true,
+	"fi": true, "while": true, "until": true, "do": true, "done": true,
+}
+
+// assignmentPattern matches an environment assignment such as FOO=1
+var assignmentPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*=`)
+
+// word is a shell word with quotes and escapes removed, and where it starts
+type word struct {
+	value string
+	start int
+}
+
+// SplitCommand splits a command line into its simple commands at pipes
+// (|, |&), lists (&&, ||, ;, &) and newlines. Quotes, escapes, command
+// substitutions and redirections such as 2>&1 are respected. Each segment
+// has environment assignments and wrappers such as sudo, time and nohup
+// removed, so "sudo FOO=1 docker ps | grep web" gives "docker ps" and
+// "grep web".
+func SplitCommand(command string) []*Segment {
+	var segments []*Segment
+	for _, piece := range splitPieces(command) {
+		if segment := newSegment(piece.text, piece.operator); segment != nil {
+			segments = append(segments, segment)
+		}
+	}
+	return segments
+}
+
+// piece is the text between two control operators
+type piece struct {
+	operator string
+	text     string
+}
+
+// splitPieces cuts a command line at its top-level control operators
+func splitPieces(command string) []piece {
+	var pieces []piece
+	var current strings.Builder
+	operator := ""
+	flush := func(next string) {
+		pieces = append(pieces, piece{operator: operator, text: current.String()})
+		current.Reset()
+		operator = next
+	}
+
+	var quote byte       // the quote we are inside, if any
+	depth := 0           // nesting of $( )
+	inBackticks := false // inside a `command substitution`
+	for i := 0; i < len(command); i++ {
+		c := command[i]
+		switch {
+		case c == '\\' && quote != '\'' && i+1 < len(command):
+			current.WriteByte(c)
+			current.WriteByte(command[i+1])
+			i++
+			continue
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '$' && i+1 < len(command) && command[i+1] == '(':
+			depth++
+			current.WriteString("$(")
+			i++
+			continue
+		case c == '`':
+			inBackticks = !inBackticks
+		case inBackticks:
+		case depth > 0:
+			if c == '(' {
+				depth++
+			} else if c == ')' {
+				depth--
+			}
+		case c == '(' || c == ')':
+			// Subshell grouping: the commands inside are segments of their own
+			current.WriteByte(' ')
+			continue
+		case c == '\n' || c == ';':
+			flush(";")
+			continue
+		case c == '|':
+			switch {
+			case strings.HasPrefix(command[i:], "||"):
+				flush("||")
+				i++
+			case strings.HasPrefix(command[i:], "|&"):
+				flush("|&")
+				i++
+			case i > 0 && command[i-1] == '>':
+				current.WriteByte(c) // >| overrides noclobber
+			default:
+				flush("|")
+			}
+			continue
+		case c == '&':
+			switch {
+			case strings.HasPrefix(command[i:], "&&"):
+				flush("&&")
+				i++
+				continue
+			case i > 0 && (command[i-1] == '>' || command[i-1] == '<'), i+1 < len(command) && command[i+1] == '>':
+				// 2>&1, <&3 and &> are redirections
+			default:
+				flush("&")
+				continue
+			}
+		}
+		current.WriteByte(c)
+	}
+	flush("")
+
+	return pieces
+}
+
+// newSegment strips a piece down to the command it runs
+func newSegment(text, operator string) *Segment {
+	words := splitWords(text)
+
+	segment := &Segment{Operator: operator}
+	i, lastWrapper := 0, -1
+	for i < len(words) {
+		value := words[i].value
+		if shellKeywords[value] || assignmentPattern.MatchString(value) {
+			i++
+			continue
+		}
+		spec, isWrapper := commandWrappers[value]
+		if !isWrapper {
+			break
+		}
+		segment.Wrappers = append(segment.Wrappers, value)
+		lastWrapper = i
+		i = skipWrapperArgs(words, i+1, spec)
+	}
+
+	if i >= len(words) {
+		if lastWrapper < 0 {
+			return nil // only keywords and assignments
+		}
+		// A wrapper that runs nothing ("sudo -i", "env") is the command itself
+		i = lastWrapper
+		segment.Wrappers = segment.Wrappers[:len(segment.Wrappers)-1]
+	}
+
+	segment.Command = strings.TrimSpace(text[words[i].start:])
+	segment.Tool = words[i].value
+	if slash := strings.LastIndex(segment.Tool, "/"); slash >= 0 && slash < len(segment.Tool)-1 {
+		segment.Tool = segment.Tool[slash+1:]
+	}
+	return segment
+}
+
+// skipWrapperArgs returns the index of the first word after a wrapper's
+// own flags and arguments
+func skipWrapperArgs(words []word, i int, spec commandWrapper) int {
+	positionals := spec.positionals
+	for i < len(words) {
+		value := words[i].value
+		switch {
+		case value == "--":
+			return i + 1
+		case strings.HasPrefix(value, "-") && len(value) > 1:
+			if spec.valueFlags[value] {
+				i++
+			}
+		case positionals > 0:
+			positionals--
+		default:
+			return i
+		}
+		i++
+	}
+	return i
+}
+
+// splitWords splits a simple command into words, removing quotes and escapes
+func splitWords(text string) []word {
+	var words []word
+	var current strings.Builder
+	start := -1
+	var quote byte
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		if quote == 0 && (c == ' ' || c == '\t') {
+			if start >= 0 {
+				words = append(words, word{value: current.String(), start: start})
+				current.Reset()
+				start = -1
+			}
+			continue
+		}
+		if start < 0 {
+			start = i
+		}
+		switch {
+		case c == '\\' && quote != '\'' && i+1 < len(text):
+			i++
+			current.WriteByte(text[i])
+		case quote != 0 && c == quote:
+			quote = 0
+		case quote == 0 && (c == '\'' || c == '"'):
+			quote = c
+		default:
+			current.WriteByte(c)
+		}
+	}
+	if start >= 0 {
+		words = append(words, word{value: current.String(), start: start})
+	}
+	return words
+}
+
+// BaseCommand returns the program a command line runs, skipping environment
+// assignments, wrappers such as sudo and time, and any directory prefix.
+// "sudo FOO=1 /usr/local/bin/kubectl get pods" becomes "kubectl". For a
+// pipeline or chain it is the program of the primary segment, so
+// "cd src && make" gives "make". Going through many commands, use the
+// BaseCommand of one classifier instead.
+func BaseCommand(command string) string {
+	return NewCommandClassifier().BaseCommand(command)
+}
+
+// StripArguments keeps only the programs of a command line, joined by
+// their operators, so "sudo git commit -m wip && make test | tee log"
+// becomes "git && make | tee"
+func StripArguments(command string) string {
+	var stripped strings.Builder
+	for i, segment := range SplitCommand(command) {
+		if i > 0 {
+			stripped.WriteString(" " + segment.Operator + " ")
+		}
+		stripped.WriteString(segment.Tool)
+	}
+	return stripped.String()
+}
@@ -1,10 +1,15 @@
 package config
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
 
+	"github.com/oiahoon/termonaut/internal/calendar"
+	"github.com/oiahoon/termonaut/internal/categories"
+	"github.com/oiahoon/termonaut/internal/privacy"
 	"github.com/spf13/viper"
 )
 
@@ -29,6 +34,21 @@ type UIConfig struct {
 	AnimationsEnabled bool `mapstructure:"animations_enabled"` // Enable animations
 }
 
+// MetricConfig defines a user metric computed from command history. A
+// command counts towards the metric when it matches every condition set.
+type MetricConfig struct {
+	Name        string `mapstructure:"name"`
+	Description string `mapstructure:"description"`
+	Type        string `mapstructure:"type"`      // counter (default) or timer
+	Pattern     string `mapstructure:"pattern"`   // regular expression the command must match
+	Exclude     string `mapstructure:"exclude"`   // regular expression the command must not match
+	Category    string `mapstructure:"category"`  // command category, e.g. git, including its subcategories
+	Directory   string `mapstructure:"directory"` // regular expression the working directory must match
+	Status      string `mapstructure:"status"`    // any (default), success or failure
+	ExitCode    *int   `mapstructure:"exit_code"` // exact exit code
+	Badge       bool   `mapstructure:"badge"`     // include in generated badges
+}
+
 // Config represents the application configuration
 type Config struct {
 	// Display and Theme
@@ -44,6 +64,11 @@ type Config struct {
 	TrackGitRepos      bool `mapstructure:"track_git_repos"`
 	CommandCategories  bool `mapstructure:"command_categories"`
 
+	// Day boundaries: timezone is an IANA name, "local", or "recorded" to
+	// keep each command's recorded offset; days start at day_start_hour
+	Timezone     string `mapstructure:"timezone"`
+	DayStartHour int    `mapstructure:"day_start_hour"`
+
 	// GitHub Integration (Optional)
 	SyncEnabled          bool   `mapstructure:"sync_enabled"`
 	SyncRepo             string `mapstructure:"sync_repo"`
@@ -56,6 +81,15 @@ type Config struct {
 	SanitizePasswords bool     `mapstructure:"sanitize_passwords"`
 	SanitizeURLs      bool     `mapstructure:"sanitize_urls"`
 	SanitizeFilePaths bool     `mapstructure:"sanitize_file_paths"`
+
+	// Extra regexes to redact and commands whose arguments are kept as
+	// they are, on top of the built-in ones
+	SensitivePatterns []string `mapstructure:"sensitive_patterns"`
+	PreservePrefixes  []string `mapstructure:"preserve_prefixes"`
+
+	// Tracking modes by directory ([[directory_rules]] tables), on top of
+	// .termonautignore and .termonaut.toml files
+	DirectoryRules []privacy.DirectoryRule `mapstructure:"directory_rules"`
 	
 	// Easter Eggs
 	EasterEggsEnabled bool `mapstructure:"easter_eggs_enabled"`
@@ -63,6 +97,9 @@ type Config struct {
 	// Quick Stats on Empty Command
 	EmptyCommandStats bool `mapstructure:"empty_command_stats"`
 
+	// Did-you-mean suggestions after "command not found"
+	TypoSuggestions bool `mapstructure:"typo_suggestions"`
+
 	// Avatar System
 	AvatarEnabled      bool   `mapstructure:"avatar_enabled"`
 	AvatarStyle        string `mapstructure:"avatar_style"`
@@ -70,6 +107,13 @@ type Config struct {
 	AvatarColorSupport string `mapstructure:"avatar_color_support"`
 	AvatarCacheTTL     string `mapstructure:"avatar_cache_ttl"`
 
+	// User-defined metrics ([[metrics]] tables)
+	Metrics []MetricConfig `mapstructure:"metrics"`
+
+	// Categories file with custom categories and rules; empty looks for
+	// categories.toml, .yaml or .yml in the config directory
+	CategoriesFile string `mapstructure:"categories_file"`
+
 	// Internal
 	DataDir  string `mapstructure:"data_dir"`
 	LogLevel string `mapstructure:"log_level"`
@@ -100,6 +144,8 @@ func DefaultConfig() *Config {
 		IdleTimeoutMinutes: 10,
 		TrackGitRepos:      true,
 		CommandCategories:  true,
+		Timezone:           calendar.RecordedZone,
+		DayStartHour:       0,
 
 		// GitHub Integration
 		SyncEnabled:          false,
@@ -113,6 +159,8 @@ func DefaultConfig() *Config {
 		SanitizePasswords: true,
 		SanitizeURLs:      true,
 		SanitizeFilePaths: true,
+		SensitivePatterns: []string{},
+		PreservePrefixes:  []string{},
 
 		// Easter Eggs
 		EasterEggsEnabled: true,
@@ -120,6 +168,9 @@ func DefaultConfig() *Config {
 		// Quick Stats on Empty Command
 		EmptyCommandStats: true,
 
+		// Did-you-mean suggestions
+		TypoSuggestions: true,
+
 		// Avatar System
 		AvatarEnabled:      true,
 		AvatarStyle:        "pixel-art",
@@ -133,7 +184,8 @@ func DefaultConfig() *Config {
 	}
 }
 
-// Load loads configuration from file or creates default config
+// Load loads configuration from file or creates default config. The
+// calendar and the categories are left alone; see Apply.
 func Load() (*Config, error) {
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
@@ -177,6 +229,28 @@ func Load() (*Config, error) {
 	return &config, nil
 }
 
+// Apply makes the day boundaries and categories file of a configuration
+// the ones the calendar and new classifiers use. A bad setting is returned
+// and leaves the previous one, recorded offsets and midnight or the
+// built-in categories, so command logging keeps working until it is fixed.
+func Apply(cfg *Config) error {
+	var errs []error
+	if cal, err := calendar.New(cfg.Timezone, cfg.DayStartHour); err != nil {
+		errs = append(errs, err)
+	} else {
+		calendar.SetDefault(cal)
+	}
+
+	if path := CategoriesFilePath(cfg); path != "" {
+		if defs, err := categories.LoadDefinitions(path); err != nil {
+			errs = append(errs, fmt.Errorf("categories file %s: %w", path, err))
+		} else {
+			categories.SetDefinitions(defs)
+		}
+	}
+	return errors.Join(errs...)
+}
+
 // Save saves configuration to file
 func Save(config *Config) error {
 	homeDir, err := os.UserHomeDir()
@@ -198,6 +272,8 @@ func Save(config *Config) error {
 	viper.Set("idle_timeout_minutes", config.IdleTimeoutMinutes)
 	viper.Set("track_git_repos", config.TrackGitRepos)
 	viper.Set("command_categories", config.CommandCategories)
+	viper.Set("timezone", config.Timezone)
+	viper.Set("day_start_hour", config.DayStartHour)
 	viper.Set("sync_enabled", config.SyncEnabled)
 	viper.Set("sync_repo", config.SyncRepo)
 	viper.Set("badge_update_frequency", config.BadgeUpdateFrequency)
@@ -207,8 +283,12 @@ func Save(config *Config) error {
 	viper.Set("sanitize_passwords", config.SanitizePasswords)
 	viper.Set("sanitize_urls", config.SanitizeURLs)
 	viper.Set("sanitize_file_paths", config.SanitizeFilePaths)
+	viper.Set("sensitive_patterns", config.SensitivePatterns)
+	viper.Set("preserve_prefixes", config.PreservePrefixes)
+	viper.Set("directory_rules", directoryRulesToMaps(config.DirectoryRules))
 	viper.Set("easter_eggs_enabled", config.EasterEggsEnabled)
 	viper.Set("empty_command_stats", config.EmptyCommandStats)
+	viper.Set("typo_suggestions", config.TypoSuggestions)
 	viper.Set("avatar_enabled", config.AvatarEnabled)
 	viper.Set("avatar_style", config.AvatarStyle)
 	viper.Set("avatar_size", config.AvatarSize)
@@ -216,6 +296,10 @@ func Save(config *Config) error {
 	viper.Set("avatar_cache_ttl", config.AvatarCacheTTL)
 	viper.Set("data_dir", config.DataDir)
 	viper.Set("log_level", config.LogLevel)
+	viper.Set("categories_file", config.CategoriesFile)
+	if len(config.Metrics) > 0 {
+		viper.Set("metrics", metricsToMaps(config.Metrics))
+	}
 
 	// Write config file
 	configPath := filepath.Join(configDir, ConfigFileName+"."+ConfigFileType)
@@ -226,6 +310,74 @@ func Save(config *Config) error {
 	return nil
 }
 
+// metricsToMaps converts metric definitions to tables viper can write
+func metricsToMaps(metrics []MetricConfig) []map[string]interface{} {
+	tables := make([]map[string]interface{}, 0, len(metrics))
+	for _, metric := range metrics {
+		table := map[string]interface{}{"name": metric.Name}
+		for key, value := range map[string]string{
+			"description": metric.Description,
+			"type":        metric.Type,
+			"pattern":     metric.Pattern,
+			"exclude":     metric.Exclude,
+			"category":    metric.Category,
+			"directory":   metric.Directory,
+			"status":      metric.Status,
+		} {
+			if value != "" {
+				table[key] = value
+			}
+		}
+		if metric.ExitCode != nil {
+			table["exit_code"] = *metric.ExitCode
+		}
+		if metric.Badge {
+			table["badge"] = true
+		}
+		tables = append(tables, table)
+	}
+	return tables
+}
+
+// directoryRulesToMaps converts directory rules to tables for saving
+func directoryRulesToMaps(rules []privacy.DirectoryRule) []map[string]interface{} {
+	tables := make([]map[string]interface{}, 0, len(rules))
+	for _, rule := range rules {
+		tables = append(tables, map[string]interface{}{"path": rule.Path, "mode": string(rule.Mode)})
+	}
+	return tables
+}
+
+// ValidateMetrics checks user-defined metric definitions
+func ValidateMetrics(metrics []MetricConfig) error {
+	seen := make(map[string]bool)
+	for i, metric := range metrics {
+		if metric.Name == "" {
+			return fmt.Errorf("metrics[%d]: name is required", i)
+		}
+		if seen[metric.Name] {
+			return fmt.Errorf("metrics[%d]: duplicate metric name %q", i, metric.Name)
+		}
+		seen[metric.Name] = true
+
+		if metric.Type != "" && metric.Type != "counter" && metric.Type != "timer" {
+			return fmt.Errorf("metric %q: invalid type %q, must be counter or timer", metric.Name, metric.Type)
+		}
+		if metric.Status != "" && !contains([]string{"any", "success", "failure"}, metric.Status) {
+			return fmt.Errorf("metric %q: invalid status %q, must be any, success or failure", metric.Name, metric.Status)
+		}
+		for field, expr := range map[string]string{"pattern": metric.Pattern, "exclude": metric.Exclude, "directory": metric.Directory} {
+			if _, err := regexp.Compile(expr); err != nil {
+				return fmt.Errorf("metric %q: invalid %s: %w", metric.Name, field, err)
+			}
+		}
+		if metric.Pattern == "" && metric.Category == "" && metric.Directory == "" {
+			return fmt.Errorf("metric %q: needs at least one of pattern, category or directory", metric.Name)
+		}
+	}
+	return nil
+}
+
 // setDefaults sets default configuration values
 func setDefaults() {
 	homeDir, _ := os.UserHomeDir()
@@ -237,6 +389,8 @@ func setDefaults() {
 	viper.SetDefault("idle_timeout_minutes", 10)
 	viper.SetDefault("track_git_repos", true)
 	viper.SetDefault("command_categories", true)
+	viper.SetDefault("timezone", calendar.RecordedZone)
+	viper.SetDefault("day_start_hour", 0)
 	viper.SetDefault("sync_enabled", false)
 	viper.SetDefault("sync_repo", "")
 	viper.SetDefault("badge_update_frequency", "daily")
@@ -246,8 +400,11 @@ func setDefaults() {
 	viper.SetDefault("sanitize_passwords", true)
 	viper.SetDefault("sanitize_urls", true)
 	viper.SetDefault("sanitize_file_paths", true)
+	viper.SetDefault("sensitive_patterns", []string{})
+	viper.SetDefault("preserve_prefixes", []string{})
 	viper.SetDefault("easter_eggs_enabled", true)
 	viper.SetDefault("empty_command_stats", true)
+	viper.SetDefault("typo_suggestions", true)
 	viper.SetDefault("avatar_enabled", true)
 	viper.SetDefault("avatar_style", "pixel-art")
 	viper.SetDefault("avatar_size", "small")
@@ -255,6 +412,7 @@ func setDefaults() {
 	viper.SetDefault("avatar_cache_ttl", "7d")
 	viper.SetDefault("data_dir", dataDir)
 	viper.SetDefault("log_level", "info")
+	viper.SetDefault("categories_file", "")
 }
 
 // GetConfigDir returns the configuration directory path
@@ -271,6 +429,21 @@ func GetDataDir(config *Config) string {
 	return GetConfigDir()
 }
 
+// CategoriesFilePath returns the categories file in use, or "" if there is
+// none
+func CategoriesFilePath(config *Config) string {
+	if config != nil && config.CategoriesFile != "" {
+		return config.CategoriesFile
+	}
+	for _, ext := range []string{"toml", "yaml", "yml"} {
+		path := filepath.Join(GetConfigDir(), "categories."+ext)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return ""
+}
+
 // Validate validates the configuration values
 func Validate(cfg *Config) error {
 	// Validate display mode
@@ -302,6 +475,33 @@ func Validate(cfg *Config) error {
 		return fmt.Errorf("invalid avatar_style: %s, must be one of %v", cfg.AvatarStyle, validAvatarStyles)
 	}
 
+	// Validate day boundaries
+	if _, err := calendar.New(cfg.Timezone, cfg.DayStartHour); err != nil {
+		return err
+	}
+
+	// Validate user-defined metrics
+	if err := ValidateMetrics(cfg.Metrics); err != nil {
+		return err
+	}
+
+	// Validate the custom sensitive patterns
+	if err := ValidateSensitivePatterns(cfg.SensitivePatterns); err != nil {
+		return err
+	}
+
+	// Validate the directory rules
+	if err := privacy.ValidateDirectoryRules(cfg.DirectoryRules); err != nil {
+		return err
+	}
+
+	// Validate the categories file
+	if path := CategoriesFilePath(cfg); path != "" {
+		if _, err := categories.LoadDefinitions(path); err != nil {
+			return fmt.Errorf("categories file %s: %w", path, err)
+		}
+	}
+
 	return nil
 }
 
@@ -0,0 +1,72 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+
+	"github.com/oiahoon/termonaut/internal/privacy"
+)
+
+// SanitizationConfig maps the privacy settings to the sanitizer
+// configuration. Opt-out commands, sensitive patterns and preserved
+// prefixes extend the built-in lists; anonymous mode turns on every kind of
+// sanitization, for what is still shown or audited in plaintext.
+func SanitizationConfig(cfg *Config) *privacy.SanitizationConfig {
+	sc := privacy.DefaultSanitizationConfig()
+	if cfg == nil {
+		return sc
+	}
+
+	sc.Enabled = cfg.PrivacySanitizer
+	sc.SanitizePasswords = cfg.SanitizePasswords
+	sc.SanitizeURLs = cfg.SanitizeURLs
+	sc.SanitizeFilePaths = cfg.SanitizeFilePaths
+	sc.OptOutWords = append([]string(nil), cfg.OptOutCommands...)
+	sc.SensitivePatterns = append(sc.SensitivePatterns, cfg.SensitivePatterns...)
+	sc.PreservePrefixes = append(sc.PreservePrefixes, cfg.PreservePrefixes...)
+
+	if cfg.AnonymousMode {
+		sc.Enabled = true
+		sc.SanitizePasswords = true
+		sc.SanitizeURLs = true
+		sc.SanitizeFilePaths = true
+		sc.SanitizeTokens = true
+		sc.SanitizeEmails = true
+	}
+	return sc
+}
+
+// ValidateSensitivePatterns checks that custom sensitive patterns are valid
+// regular expressions
+func ValidateSensitivePatterns(patterns []string) error {
+	for _, pattern := range patterns {
+		if pattern == "" {
+			return fmt.Errorf("sensitive_patterns: empty pattern")
+		}
+		if _, err := regexp.Compile(`(?i)` + pattern); err != nil {
+			return fmt.Errorf("sensitive_patterns: invalid pattern %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// DirectoryRules returns the tracking rules by directory, caching their
+// decisions in the data directory
+func DirectoryRules(cfg *Config) *privacy.DirectoryRules {
+	var rules []privacy.DirectoryRule
+	if cfg != nil {
+		rules = cfg.DirectoryRules
+	}
+	return privacy.NewDirectoryRules(rules, filepath.Join(GetDataDir(cfg), "directory_rules_cache"))
+}
+
+// Anonymizer returns the anonymizer of anonymous mode, with its secret key
+// kept in the data directory
+func Anonymizer(cfg *Config) (*privacy.Anonymizer, error) {
+	key, err := privacy.LoadOrCreateKey(filepath.Join(GetDataDir(cfg), privacy.AnonymousKeyFileName))
+	if err != nil {
+		return nil, err
+	}
+	return privacy.NewAnonymizer(key), nil
+}
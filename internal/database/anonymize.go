@@ -0,0 +1,151 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/oiahoon/termonaut/internal/categories"
+	"github.com/oiahoon/termonaut/internal/privacy"
+)
+
+// defaultAnonymizeBatchSize is how many commands are anonymized per transaction
+const defaultAnonymizeBatchSize = 500
+
+// AnonymizeOptions controls the anonymization of the stored commands
+type AnonymizeOptions struct {
+	DryRun    bool // report what would be anonymized without writing anything
+	BatchSize int  // commands anonymized per transaction
+
+	// Progress, if set, is called after each batch
+	Progress func(report *AnonymizeReport)
+}
+
+// AnonymizeReport describes an anonymization of the stored commands
+type AnonymizeReport struct {
+	DryRun      bool `json:"dry_run"`
+	Pending     int  `json:"pending"`     // commands not anonymized yet when it started
+	Directories int  `json:"directories"` // distinct directories among them
+	Anonymized  int  `json:"anonymized"`  // commands rewritten
+	Segments    int  `json:"segments"`    // segments rewritten
+}
+
+// AnonymizeCommands rewrites the stored commands that are not anonymized
+// yet the way anonymous mode stores new ones, with privacy.AnonymizeCommand:
+// each command and segment becomes its tool and a hash, and the working
+// directory a hash. Stored categories are kept; commands stored without one
+// get the category they are classified in.
+//
+// Each batch is a transaction and marks its commands as anonymized, so an
+// interrupted run continues where it stopped. The old text is then
+// overwritten in the database file and its write-ahead log, and the cached
+// results are cleared.
+func (db *DB) AnonymizeCommands(anonymizer *privacy.Anonymizer, opts AnonymizeOptions) (*AnonymizeReport, error) {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = defaultAnonymizeBatchSize
+	}
+
+	report := &AnonymizeReport{DryRun: opts.DryRun}
+	err := db.conn.QueryRow(`
+		SELECT COUNT(*), COUNT(DISTINCT NULLIF(cwd, ''))
+		FROM commands
+		WHERE anonymized = 0
+	`).Scan(&report.Pending, &report.Directories)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count commands to anonymize: %w", err)
+	}
+	if opts.DryRun || report.Pending == 0 {
+		return report, nil
+	}
+
+	if err := db.LoadLearnedCategories(); err != nil {
+		return nil, err
+	}
+	classifier := categories.NewCommandClassifier()
+	err = db.rewriteSecurely(func(ctx context.Context, conn *sql.Conn) error {
+		for {
+			done, err := anonymizeBatch(ctx, conn, anonymizer, classifier, report, opts.BatchSize)
+			if err != nil {
+				return err
+			}
+			if done == 0 {
+				return nil
+			}
+			if opts.Progress != nil {
+				opts.Progress(report)
+			}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// pendingCommand is a stored command about to be anonymized
+type pendingCommand struct {
+	id       int64
+	command  string
+	cwd      string
+	category string
+}
+
+// anonymizeBatch anonymizes the next commands in one transaction and
+// returns how many it rewrote, 0 when none are left
+func anonymizeBatch(ctx context.Context, conn *sql.Conn, anonymizer *privacy.Anonymizer,
+	classifier *categories.CommandClassifier, report *AnonymizeReport, size int) (int, error) {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(`
+		SELECT id, command, COALESCE(cwd, ''), COALESCE(category, '')
+		FROM commands
+		WHERE anonymized = 0
+		ORDER BY id LIMIT ?
+	`, size)
+	if err != nil {
+		return 0, fmt.Errorf("failed to query commands to anonymize: %w", err)
+	}
+	var pending []*pendingCommand
+	for rows.Next() {
+		var cmd pendingCommand
+		if err := rows.Scan(&cmd.id, &cmd.command, &cmd.cwd, &cmd.category); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan command: %w", err)
+		}
+		pending = append(pending, &cmd)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for _, cmd := range pending {
+		stored, classification := anonymizer.AnonymizeCommand(cmd.command, classifier)
+		if cmd.category == "" {
+			cmd.category = string(classification.Primary)
+		}
+
+		_, err := tx.Exec("UPDATE commands SET command = ?, cwd = ?, category = ?, anonymized = 1 WHERE id = ?",
+			stored, anonymizer.AnonymizeDirectory(cmd.cwd), cmd.category, cmd.id)
+		if err != nil {
+			return 0, fmt.Errorf("failed to anonymize command %d: %w", cmd.id, err)
+		}
+		if _, err := tx.Exec("DELETE FROM command_segments WHERE command_id = ?", cmd.id); err != nil {
+			return 0, fmt.Errorf("failed to clear command segments: %w", err)
+		}
+		if err := storeSegments(tx, cmd.id, classification); err != nil {
+			return 0, err
+		}
+		report.Segments += len(classification.Segments)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit anonymized commands: %w", err)
+	}
+	report.Anonymized += len(pending)
+	return len(pending), nil
+}
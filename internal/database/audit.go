@@ -0,0 +1,217 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/oiahoon/termonaut/internal/calendar"
+	"github.com/oiahoon/termonaut/internal/privacy"
+)
+
+// defaultAuditBatchSize is how many stored commands are scanned per query
+const defaultAuditBatchSize = 1000
+
+// AuditOptions controls a privacy audit of the stored commands
+type AuditOptions struct {
+	Redact    bool // rewrite the commands with secrets, redacted
+	DryRun    bool // with Redact, report what would be rewritten without writing anything
+	BatchSize int  // commands scanned per query
+
+	// Progress, if set, is called after each batch
+	Progress func(report *AuditReport)
+}
+
+// AuditFinding is a stored command in which secrets were found. It only
+// holds the redacted command, never the secrets.
+type AuditFinding struct {
+	CommandID int64     `json:"command_id"`
+	Timestamp time.Time `json:"timestamp"`
+	Detectors []string  `json:"detectors"` // detector of each secret, in order
+	Redacted  string    `json:"redacted"`
+}
+
+// AuditReport describes a privacy audit
+type AuditReport struct {
+	DryRun     bool            `json:"dry_run"`
+	Scanned    int             `json:"scanned"`     // stored commands scanned
+	Commands   int             `json:"commands"`    // commands with secrets
+	Secrets    int             `json:"secrets"`     // secrets found in them
+	ByDetector map[string]int  `json:"by_detector"` // secrets by detector
+	ByDate     map[string]int  `json:"by_date"`     // commands with secrets by day
+	Redacted   int             `json:"redacted"`    // commands rewritten
+	Findings   []*AuditFinding `json:"findings"`
+}
+
+// AuditCommands scans the stored commands with detectors and reports the
+// secrets they contain. With Redact, the commands with secrets and their
+// segments are rewritten in one transaction, each secret replaced by the
+// label of its detector. The old text is then overwritten in the database
+// file and its write-ahead log, and the cached results are cleared.
+func (db *DB) AuditCommands(detectors *privacy.DetectorRegistry, opts AuditOptions) (*AuditReport, error) {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = defaultAuditBatchSize
+	}
+
+	report := &AuditReport{
+		DryRun:     opts.DryRun,
+		ByDetector: make(map[string]int),
+		ByDate:     make(map[string]int),
+		Findings:   []*AuditFinding{},
+	}
+
+	var lastID int64
+	for {
+		next, err := db.auditBatch(detectors, report, lastID, opts.BatchSize)
+		if err != nil {
+			return nil, err
+		}
+		if next == lastID {
+			break
+		}
+		lastID = next
+		if opts.Progress != nil {
+			opts.Progress(report)
+		}
+	}
+
+	if opts.Redact && !opts.DryRun && len(report.Findings) > 0 {
+		if err := db.redactFindings(detectors, report.Findings); err != nil {
+			return nil, err
+		}
+		report.Redacted = len(report.Findings)
+	}
+	return report, nil
+}
+
+// auditBatch scans the commands after lastID and returns the ID of the last
+// one scanned, or lastID when there are none left
+func (db *DB) auditBatch(detectors *privacy.DetectorRegistry, report *AuditReport, lastID int64, size int) (int64, error) {
+	rows, err := db.conn.Query(`
+		SELECT id, timestamp, command
+		FROM commands
+		WHERE id > ?
+		ORDER BY id LIMIT ?
+	`, lastID, size)
+	if err != nil {
+		return lastID, fmt.Errorf("failed to query commands to audit: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int64
+		var timestamp time.Time
+		var command string
+		if err := rows.Scan(&id, &timestamp, &command); err != nil {
+			return lastID, fmt.Errorf("failed to scan command: %w", err)
+		}
+		lastID = id
+		report.Scanned++
+
+		redacted, findings := detectors.Redact(command)
+		if len(findings) == 0 {
+			continue
+		}
+
+		finding := &AuditFinding{CommandID: id, Timestamp: timestamp, Redacted: redacted}
+		for _, f := range findings {
+			finding.Detectors = append(finding.Detectors, f.Detector)
+			report.ByDetector[f.Detector]++
+		}
+		report.Commands++
+		report.Secrets += len(findings)
+		report.ByDate[calendar.Default().DayKey(timestamp)]++
+		report.Findings = append(report.Findings, finding)
+	}
+	return lastID, rows.Err()
+}
+
+// redactFindings rewrites the commands with secrets and their segments in
+// one transaction, without leaving the old text behind
+func (db *DB) redactFindings(detectors *privacy.DetectorRegistry, findings []*AuditFinding) error {
+	return db.rewriteSecurely(func(ctx context.Context, conn *sql.Conn) error {
+		tx, err := conn.BeginTx(ctx, nil)
+		if err != nil {
+			return fmt.Errorf("failed to begin transaction: %w", err)
+		}
+		defer tx.Rollback()
+
+		for _, finding := range findings {
+			if _, err := tx.Exec("UPDATE commands SET command = ? WHERE id = ?", finding.Redacted, finding.CommandID); err != nil {
+				return fmt.Errorf("failed to redact command %d: %w", finding.CommandID, err)
+			}
+			if err := redactSegments(tx, detectors, finding.CommandID); err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("failed to commit redacted commands: %w", err)
+		}
+		return nil
+	})
+}
+
+// rewriteSecurely runs fn on a connection that overwrites freed content,
+// then clears the cached results and checkpoints the write-ahead log so the
+// old text leaves it too
+func (db *DB) rewriteSecurely(fn func(ctx context.Context, conn *sql.Conn) error) error {
+	ctx := context.Background()
+	conn, err := db.conn.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get database connection: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "PRAGMA secure_delete = ON"); err != nil {
+		return fmt.Errorf("failed to enable secure delete: %w", err)
+	}
+	defer conn.ExecContext(ctx, "PRAGMA secure_delete = OFF")
+
+	err = fn(ctx, conn)
+	db.clearCache()
+	db.invalidateCache("commands")
+	if err != nil {
+		return err
+	}
+
+	if _, err := conn.ExecContext(ctx, "PRAGMA wal_checkpoint(TRUNCATE)"); err != nil {
+		return fmt.Errorf("failed to checkpoint the write-ahead log: %w", err)
+	}
+	return nil
+}
+
+// redactSegments rewrites the segments of a command that contain secrets
+func redactSegments(tx *sql.Tx, detectors *privacy.DetectorRegistry, commandID int64) error {
+	rows, err := tx.Query("SELECT position, command FROM command_segments WHERE command_id = ?", commandID)
+	if err != nil {
+		return fmt.Errorf("failed to query command segments: %w", err)
+	}
+
+	redacted := make(map[int]string)
+	for rows.Next() {
+		var position int
+		var command string
+		if err := rows.Scan(&position, &command); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan command segment: %w", err)
+		}
+		if text, findings := detectors.Redact(command); len(findings) > 0 {
+			redacted[position] = text
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for position, command := range redacted {
+		_, err := tx.Exec("UPDATE command_segments SET command = ? WHERE command_id = ? AND position = ?",
+			command, commandID, position)
+		if err != nil {
+			return fmt.Errorf("failed to redact command segment: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package database
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+	"github.com/oiahoon/termonaut/internal/calendar"
+)
+
+// driverName is the SQLite driver with the calendar functions registered
+const driverName = "sqlite3_termonaut"
+
+// localTimeFormat is how local_time renders wall-clock times, so SQLite's
+// date functions can work on them
+const localTimeFormat = "2006-01-02 15:04:05"
+
+func init() {
+	// SQLite's own DATE() and strftime() convert to UTC. Queries bucket by
+	// local_time(timestamp) and local_day(timestamp) instead, which follow
+	// the configured timezone and day start.
+	sql.Register(driverName, &sqlite3.SQLiteDriver{
+		ConnectHook: func(conn *sqlite3.SQLiteConn) error {
+			if err := conn.RegisterFunc("local_time", sqlLocalTime, false); err != nil {
+				return err
+			}
+			return conn.RegisterFunc("local_day", sqlLocalDay, false)
+		},
+	})
+}
+
+// parseTimestamp parses a timestamp the way the sqlite3 driver stores it
+func parseTimestamp(value interface{}) (time.Time, bool) {
+	var text string
+	switch v := value.(type) {
+	case string:
+		text = v
+	case []byte:
+		text = string(v)
+	default:
+		return time.Time{}, false
+	}
+
+	text = strings.TrimSuffix(text, "Z")
+	for _, format := range sqlite3.SQLiteTimestampFormats {
+		if t, err := time.ParseInLocation(format, text, time.UTC); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// sqlLocalTime implements local_time(timestamp): the wall-clock time
+func sqlLocalTime(value interface{}) interface{} {
+	t, ok := parseTimestamp(value)
+	if !ok {
+		return nil
+	}
+	return calendar.Default().Clock(t).Format(localTimeFormat)
+}
+
+// sqlLocalDay implements local_day(timestamp): the YYYY-MM-DD day
+func sqlLocalDay(value interface{}) interface{} {
+	t, ok := parseTimestamp(value)
+	if !ok {
+		return nil
+	}
+	return calendar.Default().DayKey(t)
+}
+
+// recentBound is a lower bound on stored timestamps for the last days days.
+// Stored timestamps carry different offsets, so the bound leaves a day of
+// slack and queries still filter on local_day.
+func recentBound(now time.Time, days int) string {
+	return now.UTC().AddDate(0, 0, -(days + 1)).Format(calendar.DateFormat)
+}
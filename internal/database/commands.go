@@ -3,6 +3,7 @@ package database
 import (
 	"database/sql"
 	"fmt"
+	"time"
 
 	"github.com/oiahoon/termonaut/pkg/models"
 )
@@ -10,7 +11,7 @@ import (
 // GetAllCommands returns all commands from the database
 func (db *DB) GetAllCommands() ([]*models.Command, error) {
 	query := `
-		SELECT id, timestamp, session_id, command, exit_code, cwd, duration_ms
+		SELECT id, timestamp, session_id, command, exit_code, cwd, duration_ms, COALESCE(category, ''), anonymized, finished
 		FROM commands
 		ORDER BY timestamp DESC
 	`
@@ -29,6 +30,7 @@ func (db *DB) GetAllCommands() ([]*models.Command, error) {
 		err := rows.Scan(
 			&cmd.ID, &cmd.Timestamp, &cmd.SessionID,
 			&cmd.Command, &cmd.ExitCode, &cmd.CWD, &durationMs,
+			&cmd.Category, &cmd.Anonymized, &cmd.Finished,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan command: %w", err)
@@ -44,6 +46,64 @@ func (db *DB) GetAllCommands() ([]*models.Command, error) {
 	return commands, nil
 }
 
+// FinishCommand records how a command ended: its exit code and how long it
+// ran until finishedAt. The command is the one the shell with process ID
+// pid numbered seq, and it is only updated once. It reports false when no
+// such command is waiting for its exit status, for instance because it was
+// not logged or is not stored yet.
+func (db *DB) FinishCommand(pid, seq, exitCode int, finishedAt time.Time) (bool, error) {
+	var id int64
+	var started time.Time
+	err := db.conn.QueryRow(`
+		SELECT c.id, c.timestamp
+		FROM commands c
+		JOIN sessions s ON s.id = c.session_id
+		WHERE s.terminal_pid = ? AND s.end_time IS NULL AND c.shell_seq = ? AND c.finished = 0
+		ORDER BY c.id DESC
+		LIMIT 1
+	`, pid, seq).Scan(&id, &started)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to find command %d of shell %d: %w", seq, pid, err)
+	}
+
+	duration := finishedAt.Sub(started).Milliseconds()
+	if duration < 0 {
+		duration = 0
+	}
+	if _, err := db.conn.Exec("UPDATE commands SET exit_code = ?, duration_ms = ?, finished = 1 WHERE id = ?",
+		exitCode, duration, id); err != nil {
+		return false, fmt.Errorf("failed to finish command: %w", err)
+	}
+
+	// Clear cache when data changes
+	db.clearCache()
+
+	return true, nil
+}
+
+// GetCommandCounts returns how often each distinct command was run
+func (db *DB) GetCommandCounts() (map[string]int, error) {
+	rows, err := db.conn.Query("SELECT command, COUNT(*) FROM commands GROUP BY command")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query command counts: %w", err)
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var command string
+		var count int
+		if err := rows.Scan(&command, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan command count: %w", err)
+		}
+		counts[command] = count
+	}
+	return counts, rows.Err()
+}
+
 // GetAllSessions returns all sessions from the database
 func (db *DB) GetAllSessions() ([]*models.Session, error) {
 	query := `
@@ -84,7 +144,7 @@ func (db *DB) GetAllSessions() ([]*models.Session, error) {
 // GetRecentCommands returns the most recent commands (limited by count)
 func (db *DB) GetRecentCommands(limit int) ([]*models.Command, error) {
 	query := `
-		SELECT id, timestamp, session_id, command, exit_code, cwd, duration_ms
+		SELECT id, timestamp, session_id, command, exit_code, cwd, duration_ms, COALESCE(category, ''), anonymized, finished
 		FROM commands
 		ORDER BY timestamp DESC
 		LIMIT ?
@@ -104,6 +164,7 @@ func (db *DB) GetRecentCommands(limit int) ([]*models.Command, error) {
 		err := rows.Scan(
 			&cmd.ID, &cmd.Timestamp, &cmd.SessionID,
 			&cmd.Command, &cmd.ExitCode, &cmd.CWD, &durationMs,
+			&cmd.Category, &cmd.Anonymized, &cmd.Finished,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan command: %w", err)
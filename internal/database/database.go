@@ -5,11 +5,13 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"sync"
 	"time"
 
-	_ "github.com/mattn/go-sqlite3"
 	"github.com/oiahoon/termonaut/internal/cache"
+	"github.com/oiahoon/termonaut/internal/calendar"
+	"github.com/oiahoon/termonaut/internal/categories"
 	"github.com/oiahoon/termonaut/pkg/models"
 	"github.com/sirupsen/logrus"
 )
@@ -55,7 +57,7 @@ func New(dataDir string, logger *logrus.Logger) (*DB, error) {
 	dbPath := filepath.Join(dataDir, DatabaseName)
 
 	// Open SQLite database with optimized settings
-	conn, err := sql.Open("sqlite3", dbPath+"?_journal_mode=WAL&_timeout=10000&_foreign_keys=on&_synchronous=NORMAL&_cache_size=10000&_temp_store=memory")
+	conn, err := sql.Open(driverName, dbPath+"?_journal_mode=WAL&_timeout=10000&_foreign_keys=on&_synchronous=NORMAL&_cache_size=10000&_temp_store=memory")
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
@@ -155,6 +157,10 @@ func (db *DB) initialize() error {
 		exit_code INTEGER DEFAULT 0,
 		cwd TEXT,
 		duration_ms INTEGER,
+		category TEXT,                    -- primary category when stored; see "categories reclassify"
+		anonymized INTEGER NOT NULL DEFAULT 0, -- command and cwd are hashes; see "privacy anonymize"
+		shell_seq INTEGER,                -- the shell's number for the command; see FinishCommand
+		finished INTEGER NOT NULL DEFAULT 0, -- exit_code and duration_ms were reported by the shell
 		FOREIGN KEY (session_id) REFERENCES sessions(id)
 	);
 
@@ -209,6 +215,57 @@ func (db *DB) initialize() error {
 		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
 	);
 
+	-- Recommendations the user dismissed, by recommendation ID
+	CREATE TABLE IF NOT EXISTS dismissed_recommendations (
+		id TEXT PRIMARY KEY,
+		dismissed_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+
+	-- Progress history: user_progress as of the end of each active day
+	CREATE TABLE IF NOT EXISTS progress_snapshots (
+		date TEXT PRIMARY KEY,
+		total_xp INTEGER NOT NULL DEFAULT 0,
+		level INTEGER NOT NULL DEFAULT 1,
+		commands_count INTEGER NOT NULL DEFAULT 0,
+		unique_commands_count INTEGER NOT NULL DEFAULT 0,
+		current_streak INTEGER NOT NULL DEFAULT 0,
+		longest_streak INTEGER NOT NULL DEFAULT 0,
+		achievements_count INTEGER NOT NULL DEFAULT 0,
+		estimated BOOLEAN NOT NULL DEFAULT 0 -- backfilled from command history
+	);
+
+	-- Command segments: each command of a pipeline or chain with its category.
+	-- The primary segment decides the category of the whole command line.
+	CREATE TABLE IF NOT EXISTS command_segments (
+		command_id INTEGER NOT NULL,
+		position INTEGER NOT NULL,
+		operator TEXT,                    -- |, &&, ... joining it to the previous segment
+		command TEXT NOT NULL,            -- without wrappers and environment assignments
+		tool TEXT,
+		wrappers TEXT,                    -- space separated, e.g. "sudo time"
+		category TEXT NOT NULL,
+		is_primary BOOLEAN NOT NULL DEFAULT 0,
+		PRIMARY KEY (command_id, position),
+		FOREIGN KEY (command_id) REFERENCES commands(id)
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_command_segments_category ON command_segments(category);
+
+	-- Learned categories: tools the user assigned to a category
+	CREATE TABLE IF NOT EXISTS learned_categories (
+		tool TEXT PRIMARY KEY,
+		category TEXT NOT NULL,
+		assigned_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+
+	-- Reclassification checkpoint: lets an interrupted "categories reclassify" resume
+	CREATE TABLE IF NOT EXISTS reclassify_state (
+		id INTEGER PRIMARY KEY CHECK (id = 1), -- Singleton table
+		last_id INTEGER NOT NULL DEFAULT 0,    -- last command reclassified
+		progress TEXT NOT NULL,                -- JSON ReclassifyResult so far
+		updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+
 	-- Initialize user progress if not exists
 	INSERT OR IGNORE INTO user_progress (id) VALUES (1);
 	`
@@ -218,20 +275,92 @@ func (db *DB) initialize() error {
 		return fmt.Errorf("failed to create schema: %w", err)
 	}
 
+	if err := db.migrate(); err != nil {
+		return err
+	}
+
 	db.logger.Info("Database schema initialized successfully")
 	return nil
 }
 
-// StoreCommand saves a command to the database
+// columnMigrations are columns added to tables after they were first created.
+// CREATE TABLE IF NOT EXISTS leaves older databases without them.
+var columnMigrations = []struct {
+	table, column, definition string
+}{
+	{"commands", "category", "TEXT"},
+	{"commands", "anonymized", "INTEGER NOT NULL DEFAULT 0"},
+	{"commands", "shell_seq", "INTEGER"},
+	{"commands", "finished", "INTEGER NOT NULL DEFAULT 0"},
+}
+
+// migrate adds missing columns to databases created by older versions
+func (db *DB) migrate() error {
+	for _, m := range columnMigrations {
+		exists, err := db.hasColumn(m.table, m.column)
+		if err != nil {
+			return err
+		}
+		if exists {
+			continue
+		}
+		if _, err := db.conn.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", m.table, m.column, m.definition)); err != nil {
+			return fmt.Errorf("failed to add column %s.%s: %w", m.table, m.column, err)
+		}
+		db.logger.Infof("Added column %s.%s", m.table, m.column)
+	}
+
+	// Indexes on migrated columns can only be created once the column exists
+	if _, err := db.conn.Exec("CREATE INDEX IF NOT EXISTS idx_commands_category ON commands(category)"); err != nil {
+		return fmt.Errorf("failed to create category index: %w", err)
+	}
+	return nil
+}
+
+// hasColumn reports whether a table has a column
+func (db *DB) hasColumn(table, column string) (bool, error) {
+	rows, err := db.conn.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return false, fmt.Errorf("failed to inspect table %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var cid, notNull, pk int
+		var name, columnType string
+		var defaultValue sql.NullString
+		if err := rows.Scan(&cid, &name, &columnType, &notNull, &defaultValue, &pk); err != nil {
+			return false, fmt.Errorf("failed to scan table info: %w", err)
+		}
+		if name == column {
+			return true, nil
+		}
+	}
+	return false, rows.Err()
+}
+
+// StoreCommand saves a command to the database along with its classified segments
 func (db *DB) StoreCommand(cmd *models.Command) error {
+	return db.storeCommand(cmd, categories.NewCommandClassifier().Classify(cmd.Command))
+}
+
+// storeCommand saves a command and the segments it was classified into
+func (db *DB) storeCommand(cmd *models.Command, classification *categories.Classification) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
 	query := `
-		INSERT INTO commands (timestamp, session_id, command, exit_code, cwd, duration_ms)
-		VALUES (?, ?, ?, ?, ?, ?)
+		INSERT INTO commands (timestamp, session_id, command, exit_code, cwd, duration_ms, category, anonymized, shell_seq, finished)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
-	result, err := db.conn.Exec(query,
+	category := string(classification.Primary)
+	result, err := tx.Exec(query,
 		cmd.Timestamp, cmd.SessionID, cmd.Command,
-		cmd.ExitCode, cmd.CWD, cmd.DurationMS)
+		cmd.ExitCode, cmd.CWD, cmd.DurationMS, category, cmd.Anonymized, shellSeq(cmd), cmd.Finished)
 	if err != nil {
 		return fmt.Errorf("failed to store command: %w", err)
 	}
@@ -241,7 +370,15 @@ func (db *DB) StoreCommand(cmd *models.Command) error {
 		return fmt.Errorf("failed to get command ID: %w", err)
 	}
 
+	if err := storeSegments(tx, id, classification); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit command: %w", err)
+	}
+
 	cmd.ID = id
+	cmd.Category = category
 	
 	// Clear cache when new data is added
 	db.clearCache()
@@ -249,6 +386,14 @@ func (db *DB) StoreCommand(cmd *models.Command) error {
 	return nil
 }
 
+// shellSeq returns the shell's number for a command, or NULL if it has none
+func shellSeq(cmd *models.Command) interface{} {
+	if cmd.ShellSeq <= 0 {
+		return nil
+	}
+	return cmd.ShellSeq
+}
+
 // StoreCommandsBatch saves multiple commands to the database in a single transaction
 func (db *DB) StoreCommandsBatch(commands []*models.Command) error {
 	if len(commands) == 0 {
@@ -266,18 +411,20 @@ func (db *DB) StoreCommandsBatch(commands []*models.Command) error {
 	}()
 
 	stmt, err := tx.Prepare(`
-		INSERT INTO commands (timestamp, session_id, command, exit_code, cwd, duration_ms)
-		VALUES (?, ?, ?, ?, ?, ?)
+		INSERT INTO commands (timestamp, session_id, command, exit_code, cwd, duration_ms, category, anonymized, shell_seq, finished)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`)
 	if err != nil {
 		return fmt.Errorf("failed to prepare statement: %w", err)
 	}
 	defer stmt.Close()
 
+	classifier := categories.NewCommandClassifier()
 	for _, cmd := range commands {
+		classification := classifier.Classify(cmd.Command)
 		result, execErr := stmt.Exec(
 			cmd.Timestamp, cmd.SessionID, cmd.Command,
-			cmd.ExitCode, cmd.CWD, cmd.DurationMS)
+			cmd.ExitCode, cmd.CWD, cmd.DurationMS, string(classification.Primary), cmd.Anonymized, shellSeq(cmd), cmd.Finished)
 		if execErr != nil {
 			err = fmt.Errorf("failed to execute batch insert: %w", execErr)
 			return err
@@ -289,6 +436,11 @@ func (db *DB) StoreCommandsBatch(commands []*models.Command) error {
 			return err
 		}
 		cmd.ID = id
+		cmd.Category = string(classification.Primary)
+
+		if err = storeSegments(tx, id, classification); err != nil {
+			return err
+		}
 	}
 
 	err = tx.Commit()
@@ -364,11 +516,12 @@ func (db *DB) GetBasicStats() (map[string]interface{}, error) {
 			(SELECT COUNT(*) FROM commands) as total_commands,
 			(SELECT COUNT(*) FROM sessions) as total_sessions,
 			(SELECT COUNT(DISTINCT command) FROM commands) as unique_commands,
-			(SELECT COUNT(*) FROM commands WHERE date(timestamp) = date('now')) as commands_today
+			(SELECT COUNT(*) FROM commands WHERE timestamp >= ? AND local_day(timestamp) = ?) as commands_today
 	`
 	
+	now := time.Now()
 	var totalCommands, totalSessions, uniqueCommands, commandsToday int
-	err := db.conn.QueryRow(query).Scan(&totalCommands, &totalSessions, &uniqueCommands, &commandsToday)
+	err := db.conn.QueryRow(query, recentBound(now, 1), calendar.Default().Today(now)).Scan(&totalCommands, &totalSessions, &uniqueCommands, &commandsToday)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get basic stats: %w", err)
 	}
@@ -395,8 +548,8 @@ func (db *DB) GetBasicStats() (map[string]interface{}, error) {
 	var commandsToday int
 	err = db.conn.QueryRow(`
 		SELECT COUNT(*) FROM commands
-		WHERE DATE(timestamp) = DATE('now')
-	`).Scan(&commandsToday)
+		WHERE timestamp >= ? AND local_day(timestamp) = ?
+	`, recentBound(time.Now(), 1), calendar.Default().Today(time.Now())).Scan(&commandsToday)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get today's commands: %w", err)
 	}
@@ -455,6 +608,60 @@ func (db *DB) GetTopCommands(limit int) ([]map[string]interface{}, error) {
 	return commands, nil
 }
 
+// GetTopNormalizedCommands returns the most frequently used commands grouped
+// by tool and subcommand, so "git commit -m x" and "git commit -m y" count
+// together as "git commit"
+func (db *DB) GetTopNormalizedCommands(limit int) ([]map[string]interface{}, error) {
+	rows, err := db.conn.Query(`
+		SELECT command, COUNT(*) as count
+		FROM commands
+		GROUP BY command
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query commands: %w", err)
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var command string
+		var count int
+		if err := rows.Scan(&command, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan command: %w", err)
+		}
+		if key := categories.NormalizeCommand(command).Key(); key != "" {
+			counts[key] += count
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read commands: %w", err)
+	}
+
+	keys := make([]string, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if counts[keys[i]] != counts[keys[j]] {
+			return counts[keys[i]] > counts[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+	if len(keys) > limit {
+		keys = keys[:limit]
+	}
+
+	commands := make([]map[string]interface{}, 0, len(keys))
+	for _, key := range keys {
+		commands = append(commands, map[string]interface{}{
+			"command": key,
+			"count":   counts[key],
+		})
+	}
+
+	return commands, nil
+}
+
 // Health checks database connectivity
 func (db *DB) Health() error {
 	return db.conn.Ping()
@@ -510,52 +717,6 @@ func (db *DB) GetCacheStats() cache.CacheStats {
 	return db.lruCache.Stats()
 }
 
-// StoreCommandsBatch stores multiple commands in a single transaction for better performance
-func (db *DB) StoreCommandsBatch(commands []*models.Command) error {
-	if len(commands) == 0 {
-		return nil
-	}
-
-	tx, err := db.conn.Begin()
-	if err != nil {
-		return fmt.Errorf("failed to begin transaction: %w", err)
-	}
-	defer tx.Rollback()
-
-	stmt, err := tx.Prepare(`
-		INSERT INTO commands (timestamp, session_id, command, exit_code, cwd, duration_ms, category)
-		VALUES (?, ?, ?, ?, ?, ?, ?)
-	`)
-	if err != nil {
-		return fmt.Errorf("failed to prepare statement: %w", err)
-	}
-	defer stmt.Close()
-
-	for _, cmd := range commands {
-		_, err = stmt.Exec(
-			cmd.Timestamp,
-			cmd.SessionID,
-			cmd.Command,
-			cmd.ExitCode,
-			cmd.CWD,
-			cmd.DurationMs,
-			cmd.Category,
-		)
-		if err != nil {
-			return fmt.Errorf("failed to execute statement: %w", err)
-		}
-	}
-
-	if err = tx.Commit(); err != nil {
-		return fmt.Errorf("failed to commit transaction: %w", err)
-	}
-
-	// Invalidate cache after batch insert
-	db.invalidateCache("stats")
-	
-	return nil
-}
-
 // WithTransaction executes a function within a database transaction
 func (db *DB) WithTransaction(fn func(*sql.Tx) error) error {
 	tx, err := db.conn.Begin()
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/oiahoon/termonaut/internal/calendar"
 	"github.com/oiahoon/termonaut/internal/categories"
 	"github.com/oiahoon/termonaut/internal/gamification"
 	"github.com/oiahoon/termonaut/pkg/models"
@@ -57,7 +58,15 @@ func (db *DB) addXPWithTransaction(tx *sql.Tx, xpGained int) error {
 	levelCalc := gamification.NewLevelCalculator()
 	newLevel := levelCalc.CalculateLevel(currentXP + xpGained)
 
-	_, err = tx.Exec(query, xpGained, newLevel)
+	if _, err = tx.Exec(query, xpGained, newLevel); err != nil {
+		return err
+	}
+
+	// Record the XP in the daily history used for forecasting
+	_, err = tx.Exec(`
+		INSERT INTO daily_stats (date, xp_earned) VALUES (?, ?)
+		ON CONFLICT(date) DO UPDATE SET xp_earned = xp_earned + excluded.xp_earned
+	`, calendar.Default().Today(time.Now()), xpGained)
 	return err
 }
 
@@ -79,6 +88,69 @@ func (db *DB) storeAchievementWithTransaction(tx *sql.Tx, userAchievement *gamif
 	return err
 }
 
+// GetDailyActivity returns XP earned and commands run for each of the last
+// n days, oldest first, including days without activity
+func (db *DB) GetDailyActivity(days int) ([]*gamification.DailyActivity, error) {
+	now := time.Now()
+	dates := calendar.Default().LastDays(now, days)
+	start := dates[0]
+
+	byDate := make(map[string]*gamification.DailyActivity)
+	getDay := func(date string) *gamification.DailyActivity {
+		if day, exists := byDate[date]; exists {
+			return day
+		}
+		day := &gamification.DailyActivity{Date: date}
+		byDate[date] = day
+		return day
+	}
+
+	rows, err := db.conn.Query(`
+		SELECT local_day(timestamp) as cmd_date, COUNT(*)
+		FROM commands
+		WHERE timestamp >= ? AND local_day(timestamp) >= ?
+		GROUP BY cmd_date
+	`, recentBound(now, days), start)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query daily commands: %w", err)
+	}
+	for rows.Next() {
+		var date string
+		var count int
+		if err := rows.Scan(&date, &count); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan daily commands: %w", err)
+		}
+		getDay(date).Commands = count
+	}
+	rows.Close()
+
+	rows, err = db.conn.Query(`
+		SELECT DATE(date), xp_earned
+		FROM daily_stats
+		WHERE DATE(date) >= ?
+	`, start)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query daily XP: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var date string
+		var xp int
+		if err := rows.Scan(&date, &xp); err != nil {
+			return nil, fmt.Errorf("failed to scan daily XP: %w", err)
+		}
+		getDay(date).XP = xp
+	}
+
+	history := make([]*gamification.DailyActivity, 0, days)
+	for _, date := range dates {
+		history = append(history, getDay(date))
+	}
+
+	return history, nil
+}
+
 // GetUserProgress returns current user progress
 func (db *DB) GetUserProgress() (*models.UserProgress, error) {
 	query := `
@@ -172,12 +244,13 @@ func (db *DB) UpdateStreakAndCommands() error {
 		    unique_commands_count = ?,
 		    current_streak = ?,
 		    longest_streak = CASE WHEN ? > longest_streak THEN ? ELSE longest_streak END,
-		    last_activity_date = DATE('now'),
+		    last_activity_date = ?,
 		    updated_at = CURRENT_TIMESTAMP
 		WHERE id = 1
 	`
 
-	_, err = tx.Exec(updateQuery, totalCommands, uniqueCommands, currentStreak, longestStreak, longestStreak)
+	_, err = tx.Exec(updateQuery, totalCommands, uniqueCommands, currentStreak, longestStreak, longestStreak,
+		calendar.Default().Today(time.Now()))
 	if err != nil {
 		return fmt.Errorf("failed to update user progress: %w", err)
 	}
@@ -185,17 +258,17 @@ func (db *DB) UpdateStreakAndCommands() error {
 	return tx.Commit()
 }
 
+// streakWindow is how many days back streaks are calculated over
+const streakWindow = 400
+
 // calculateStreaks calculates current and longest streaks
 func (db *DB) calculateStreaks(tx *sql.Tx) (int, int) {
-	// Get command dates in descending order
-	query := `
-		SELECT DISTINCT DATE(timestamp) as cmd_date
+	now := time.Now()
+	rows, err := tx.Query(`
+		SELECT DISTINCT local_day(timestamp)
 		FROM commands
-		ORDER BY cmd_date DESC
-		LIMIT 365
-	`
-
-	rows, err := tx.Query(query)
+		WHERE timestamp >= ?
+	`, recentBound(now, streakWindow))
 	if err != nil {
 		db.logger.Errorf("Failed to query command dates: %v", err)
 		return 0, 0
@@ -204,62 +277,14 @@ func (db *DB) calculateStreaks(tx *sql.Tx) (int, int) {
 
 	var dates []string
 	for rows.Next() {
-		var date string
-		if err := rows.Scan(&date); err != nil {
+		var date sql.NullString
+		if err := rows.Scan(&date); err != nil || !date.Valid {
 			continue
 		}
-		dates = append(dates, date)
-	}
-
-	if len(dates) == 0 {
-		return 0, 0
-	}
-
-	// Calculate current streak
-	currentStreak := 1
-	today := time.Now().Format("2006-01-02")
-	yesterday := time.Now().AddDate(0, 0, -1).Format("2006-01-02")
-
-	// Check if user has activity today or yesterday
-	if len(dates) > 0 && dates[0] != today && dates[0] != yesterday {
-		currentStreak = 0
-	} else {
-		for i := 1; i < len(dates); i++ {
-			prevDate, _ := time.Parse("2006-01-02", dates[i-1])
-			currDate, _ := time.Parse("2006-01-02", dates[i])
-
-			// Check if dates are consecutive
-			if prevDate.AddDate(0, 0, -1).Format("2006-01-02") == currDate.Format("2006-01-02") {
-				currentStreak++
-			} else {
-				break
-			}
-		}
-	}
-
-	// Calculate longest streak (simplified version)
-	longestStreak := currentStreak
-	tempStreak := 1
-
-	for i := 1; i < len(dates); i++ {
-		prevDate, _ := time.Parse("2006-01-02", dates[i-1])
-		currDate, _ := time.Parse("2006-01-02", dates[i])
-
-		if prevDate.AddDate(0, 0, -1).Format("2006-01-02") == currDate.Format("2006-01-02") {
-			tempStreak++
-		} else {
-			if tempStreak > longestStreak {
-				longestStreak = tempStreak
-			}
-			tempStreak = 1
-		}
-	}
-
-	if tempStreak > longestStreak {
-		longestStreak = tempStreak
+		dates = append(dates, date.String)
 	}
 
-	return currentStreak, longestStreak
+	return calendar.Streaks(dates, calendar.Default().Today(now))
 }
 
 // GetGamificationStats returns stats needed for gamification calculations
@@ -290,8 +315,23 @@ func (db *DB) GetGamificationStats() (*gamification.UserStats, error) {
 
 // StoreCommandWithXP stores a command and calculates XP
 func (db *DB) StoreCommandWithXP(cmd *models.Command) error {
-	// First store the command normally
-	err := db.StoreCommand(cmd)
+	// Classify command to get category and XP multiplier, then store it
+	return db.StoreClassifiedCommandWithXP(cmd, categories.NewCommandClassifier().Classify(cmd.Command))
+}
+
+// StoreClassifiedCommandWithXP stores a command classified by the caller
+// and calculates XP. Anonymous mode classifies a command before replacing
+// it with hashes, which cannot be classified.
+func (db *DB) StoreClassifiedCommandWithXP(cmd *models.Command, classification *categories.Classification) error {
+	classifier := categories.NewCommandClassifier()
+
+	// Progress is snapshotted once a day, by its first command
+	snapshotDue, err := db.startProgressDay()
+	if err != nil {
+		db.logger.Warnf("Failed to complete the last progress snapshot: %v", err)
+	}
+
+	err = db.storeCommand(cmd, classification)
 	if err != nil {
 		return err
 	}
@@ -309,20 +349,25 @@ func (db *DB) StoreCommandWithXP(cmd *models.Command) error {
 		db.logger.Warnf("Failed to update streaks: %v", err)
 	}
 
-	// Classify command to get category and XP multiplier
-	classifier := categories.NewCommandClassifier()
-	category := classifier.ClassifyCommand(cmd.Command)
-	categoryStr := string(category)
+	categoryStr := string(classification.Primary)
 
-	// Calculate XP for this command
-	xpCalc := gamification.NewXPCalculator(nil)
+	// Calculate XP for this command, with XP bonuses from the categories file
+	xpConfig := gamification.DefaultXPConfig()
+	for custom, bonus := range classifier.XPBonusOverrides() {
+		xpConfig.CategoryMultipliers[string(custom)] = bonus
+	}
+	xpCalc := gamification.NewXPCalculator(xpConfig)
 	stats, err := db.GetGamificationStats()
 	if err != nil {
 		db.logger.Warnf("Failed to get gamification stats: %v", err)
 		return nil // Don't fail the whole operation
 	}
 
-	xpGained := xpCalc.CalculateCommandXP(cmd, isNewCommand, stats.CurrentStreak, categoryStr)
+	labels := make(map[string]float64)
+	for _, label := range classification.Labels {
+		labels[string(label.Category)] = label.Weight
+	}
+	xpGained := xpCalc.CalculateLabeledCommandXP(cmd, isNewCommand, stats.CurrentStreak, categoryStr, labels)
 
 	// Check for new achievements
 	achievementManager := gamification.NewAchievementManager()
@@ -346,6 +391,12 @@ func (db *DB) StoreCommandWithXP(cmd *models.Command) error {
 		db.logger.Warnf("Failed to update user progress: %v", err)
 	}
 
+	if snapshotDue {
+		if err := db.RecordProgressSnapshot(); err != nil {
+			db.logger.Warnf("Failed to record progress snapshot: %v", err)
+		}
+	}
+
 	return nil
 }
 
@@ -364,11 +415,12 @@ func (db *DB) getEarlyBirdCommands() int {
 	var count int
 	query := `
 		SELECT COUNT(*) FROM commands 
-		WHERE strftime('%H', timestamp) >= '06' 
-		AND strftime('%H', timestamp) < '09'
-		AND date(timestamp) = date('now')
+		WHERE timestamp >= ? AND local_day(timestamp) = ?
+		AND strftime('%H', local_time(timestamp)) >= '06'
+		AND strftime('%H', local_time(timestamp)) < '09'
 	`
-	err := db.conn.QueryRow(query).Scan(&count)
+	now := time.Now()
+	err := db.conn.QueryRow(query, recentBound(now, 1), calendar.Default().Today(now)).Scan(&count)
 	if err != nil {
 		db.logger.WithError(err).Error("Failed to get early bird commands count")
 		return 0
@@ -381,10 +433,11 @@ func (db *DB) getNightOwlCommands() int {
 	var count int
 	query := `
 		SELECT COUNT(*) FROM commands 
-		WHERE (strftime('%H', timestamp) >= '22' OR strftime('%H', timestamp) < '02')
-		AND date(timestamp) = date('now')
+		WHERE timestamp >= ? AND local_day(timestamp) = ?
+		AND (strftime('%H', local_time(timestamp)) >= '22' OR strftime('%H', local_time(timestamp)) < '02')
 	`
-	err := db.conn.QueryRow(query).Scan(&count)
+	now := time.Now()
+	err := db.conn.QueryRow(query, recentBound(now, 1), calendar.Default().Today(now)).Scan(&count)
 	if err != nil {
 		db.logger.WithError(err).Error("Failed to get night owl commands count")
 		return 0
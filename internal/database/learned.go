@@ -0,0 +1,188 @@
+package database
+
+import (
+	"fmt"
+
+	"github.com/oiahoon/termonaut/internal/categories"
+	"github.com/oiahoon/termonaut/internal/gamification"
+	"github.com/oiahoon/termonaut/pkg/models"
+)
+
+// UnknownTool is a tool whose commands could not be classified
+type UnknownTool struct {
+	Tool     string   `json:"tool"`
+	Commands int      `json:"commands"`
+	Examples []string `json:"examples"` // a few of its most recent commands
+}
+
+// maxUnknownToolExamples is how many example commands an unknown tool shows
+const maxUnknownToolExamples = 3
+
+// LoadLearnedCategories makes new classifiers include the learned
+// categories. Opening the database does not load them; the command line
+// does whenever it opens the database.
+func (db *DB) LoadLearnedCategories() error {
+	assignments, err := db.GetLearnedCategories()
+	if err != nil {
+		return err
+	}
+	categories.SetLearned(assignments)
+	return nil
+}
+
+// GetLearnedCategories returns the tools the user assigned to a category,
+// by tool
+func (db *DB) GetLearnedCategories() ([]categories.Assignment, error) {
+	rows, err := db.conn.Query("SELECT tool, category FROM learned_categories ORDER BY tool")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query learned categories: %w", err)
+	}
+	defer rows.Close()
+
+	var assignments []categories.Assignment
+	for rows.Next() {
+		var a categories.Assignment
+		if err := rows.Scan(&a.Tool, &a.Category); err != nil {
+			return nil, fmt.Errorf("failed to scan learned category: %w", err)
+		}
+		assignments = append(assignments, a)
+	}
+	return assignments, rows.Err()
+}
+
+// AssignCategory learns that commands running a tool belong in a category
+// and reclassifies the stored commands that run it. It returns how many of
+// them changed category.
+func (db *DB) AssignCategory(tool string, category categories.Category) (int, error) {
+	if err := categories.NewCommandClassifier().ValidateAssignment(tool, category); err != nil {
+		return 0, err
+	}
+
+	_, err := db.conn.Exec(`
+		INSERT INTO learned_categories (tool, category) VALUES (?, ?)
+		ON CONFLICT(tool) DO UPDATE SET category = excluded.category, assigned_at = CURRENT_TIMESTAMP
+	`, tool, string(category))
+	if err != nil {
+		return 0, fmt.Errorf("failed to store learned category: %w", err)
+	}
+	return db.relearnTool(tool)
+}
+
+// UnassignCategory forgets the category learned for a tool and reclassifies
+// the stored commands that run it. It reports whether the tool had one.
+func (db *DB) UnassignCategory(tool string) (bool, int, error) {
+	result, err := db.conn.Exec("DELETE FROM learned_categories WHERE tool = ?", tool)
+	if err != nil {
+		return false, 0, fmt.Errorf("failed to delete learned category: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return false, 0, nil
+	}
+	changed, err := db.relearnTool(tool)
+	return true, changed, err
+}
+
+// relearnTool reloads the learned categories and reclassifies the stored
+// commands with a segment running the tool, except anonymized ones
+func (db *DB) relearnTool(tool string) (int, error) {
+	if err := db.LoadLearnedCategories(); err != nil {
+		return 0, err
+	}
+
+	classifier := categories.NewCommandClassifier()
+	xpCalc := gamification.NewXPCalculator(nil)
+	result := &ReclassifyResult{Before: make(map[string]int), After: make(map[string]int)}
+
+	var lastID int64
+	for {
+		rows, err := db.conn.Query(`
+			SELECT id, timestamp, command, exit_code, COALESCE(category, '')
+			FROM commands
+			WHERE id > ? AND anonymized = 0 AND id IN (SELECT command_id FROM command_segments WHERE tool = ?)
+			ORDER BY id LIMIT ?
+		`, lastID, tool, defaultReclassifyBatchSize)
+		if err != nil {
+			return 0, fmt.Errorf("failed to query commands running %s: %w", tool, err)
+		}
+
+		var commands []*models.Command
+		for rows.Next() {
+			var cmd models.Command
+			if err := rows.Scan(&cmd.ID, &cmd.Timestamp, &cmd.Command, &cmd.ExitCode, &cmd.Category); err != nil {
+				rows.Close()
+				return 0, fmt.Errorf("failed to scan command: %w", err)
+			}
+			commands = append(commands, &cmd)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return 0, err
+		}
+		if len(commands) == 0 {
+			break
+		}
+
+		if err := db.reclassifyCommands(commands, classifier, xpCalc, result, false); err != nil {
+			return 0, err
+		}
+		lastID = commands[len(commands)-1].ID
+	}
+
+	db.clearCache()
+	return result.Changed, nil
+}
+
+// GetUnknownTools returns the tools of the commands that could not be
+// classified, most used first
+func (db *DB) GetUnknownTools(limit int) ([]*UnknownTool, error) {
+	rows, err := db.conn.Query(`
+		SELECT tool, COUNT(*) AS uses
+		FROM command_segments
+		WHERE is_primary AND category = ? AND COALESCE(tool, '') != ''
+		GROUP BY tool
+		ORDER BY uses DESC, tool
+		LIMIT ?
+	`, string(categories.Unknown), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query unknown tools: %w", err)
+	}
+
+	var tools []*UnknownTool
+	for rows.Next() {
+		var tool UnknownTool
+		if err := rows.Scan(&tool.Tool, &tool.Commands); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan unknown tool: %w", err)
+		}
+		tools = append(tools, &tool)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, tool := range tools {
+		examples, err := db.conn.Query(`
+			SELECT command
+			FROM command_segments
+			WHERE is_primary AND category = ? AND tool = ?
+			GROUP BY command
+			ORDER BY MAX(command_id) DESC
+			LIMIT ?
+		`, string(categories.Unknown), tool.Tool, maxUnknownToolExamples)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query examples for %s: %w", tool.Tool, err)
+		}
+		for examples.Next() {
+			var example string
+			if err := examples.Scan(&example); err != nil {
+				examples.Close()
+				return nil, fmt.Errorf("failed to scan example command: %w", err)
+			}
+			tool.Examples = append(tool.Examples, example)
+		}
+		examples.Close()
+	}
+
+	return tools, nil
+}
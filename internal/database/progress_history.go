@@ -0,0 +1,241 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/oiahoon/termonaut/internal/calendar"
+	"github.com/oiahoon/termonaut/internal/gamification"
+	"github.com/oiahoon/termonaut/pkg/models"
+)
+
+// RecordProgressSnapshot saves today's progress
+func (db *DB) RecordProgressSnapshot() error {
+	return db.recordProgressSnapshot(calendar.Default().Today(time.Now()))
+}
+
+// recordProgressSnapshot saves the current progress as the snapshot of a date
+func (db *DB) recordProgressSnapshot(date string) error {
+	snapshot, err := db.CurrentProgressSnapshot()
+	if err != nil {
+		return err
+	}
+
+	_, err = db.conn.Exec(`
+		INSERT OR REPLACE INTO progress_snapshots (date, total_xp, level, commands_count,
+			unique_commands_count, current_streak, longest_streak, achievements_count, estimated)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, 0)
+	`, date, snapshot.TotalXP, snapshot.Level, snapshot.CommandsCount, snapshot.UniqueCommandsCount,
+		snapshot.CurrentStreak, snapshot.LongestStreak, snapshot.AchievementsCount)
+	if err != nil {
+		return fmt.Errorf("failed to record progress snapshot: %w", err)
+	}
+	return nil
+}
+
+// CurrentProgressSnapshot returns today's progress as a snapshot, without
+// recording it
+func (db *DB) CurrentProgressSnapshot() (*models.ProgressSnapshot, error) {
+	progress, err := db.GetUserProgress()
+	if err != nil {
+		return nil, err
+	}
+	var achievements int
+	if err := db.conn.QueryRow("SELECT COUNT(*) FROM achievements").Scan(&achievements); err != nil {
+		return nil, fmt.Errorf("failed to count achievements: %w", err)
+	}
+
+	return &models.ProgressSnapshot{
+		Date:                calendar.Default().Today(time.Now()),
+		TotalXP:             progress.TotalXP,
+		Level:               progress.CurrentLevel,
+		CommandsCount:       progress.CommandsCount,
+		UniqueCommandsCount: progress.UniqueCommandsCount,
+		CurrentStreak:       progress.CurrentStreak,
+		LongestStreak:       progress.LongestStreak,
+		AchievementsCount:   achievements,
+	}, nil
+}
+
+// startProgressDay runs before a command is stored and reports whether
+// today has no snapshot yet. The progress has not changed since the last
+// active day then, so that day's snapshot is completed first.
+func (db *DB) startProgressDay() (bool, error) {
+	var latest string
+	if err := db.conn.QueryRow("SELECT COALESCE(MAX(date), '') FROM progress_snapshots").Scan(&latest); err != nil {
+		return false, fmt.Errorf("failed to get the latest progress snapshot: %w", err)
+	}
+	if latest >= calendar.Default().Today(time.Now()) {
+		return false, nil
+	}
+	if latest != "" {
+		if err := db.recordProgressSnapshot(latest); err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// BackfillProgressSnapshots estimates snapshots for past active days that
+// have none, from the command history, and returns how many were added.
+// Recorded snapshots are never replaced.
+func (db *DB) BackfillProgressSnapshots() (int, error) {
+	days, err := db.historyDays()
+	if err != nil {
+		return 0, err
+	}
+	progress, err := db.GetUserProgress()
+	if err != nil {
+		return 0, err
+	}
+
+	today := calendar.Default().Today(time.Now())
+	snapshots := gamification.EstimateProgressHistory(days, progress.TotalXP)
+
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	added := 0
+	for _, snapshot := range snapshots {
+		if snapshot.Date >= today {
+			continue // today is recorded as it happens
+		}
+		result, err := tx.Exec(`
+			INSERT OR IGNORE INTO progress_snapshots (date, total_xp, level, commands_count,
+				unique_commands_count, current_streak, longest_streak, achievements_count, estimated)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, 1)
+		`, snapshot.Date, snapshot.TotalXP, snapshot.Level, snapshot.CommandsCount,
+			snapshot.UniqueCommandsCount, snapshot.CurrentStreak, snapshot.LongestStreak,
+			snapshot.AchievementsCount)
+		if err != nil {
+			return 0, fmt.Errorf("failed to backfill progress snapshot: %w", err)
+		}
+		if affected, _ := result.RowsAffected(); affected > 0 {
+			added++
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit progress backfill: %w", err)
+	}
+	return added, nil
+}
+
+// historyDays collects per-day totals of the whole history, oldest first
+func (db *DB) historyDays() ([]gamification.HistoryDay, error) {
+	byDate := make(map[string]*gamification.HistoryDay)
+	var dates []string
+	getDay := func(date string) *gamification.HistoryDay {
+		if day, exists := byDate[date]; exists {
+			return day
+		}
+		day := &gamification.HistoryDay{Date: date}
+		byDate[date] = day
+		dates = append(dates, date)
+		return day
+	}
+
+	queries := []struct {
+		query string
+		apply func(day *gamification.HistoryDay, n int)
+	}{
+		{
+			`SELECT local_day(timestamp) AS day, COUNT(*) FROM commands GROUP BY day`,
+			func(day *gamification.HistoryDay, n int) { day.Commands = n },
+		},
+		{
+			`SELECT day, COUNT(*) FROM (
+				SELECT local_day(MIN(timestamp)) AS day FROM commands GROUP BY command
+			) GROUP BY day`,
+			func(day *gamification.HistoryDay, n int) { day.NewCommands = n },
+		},
+		{
+			`SELECT local_day(earned_at) AS day, COUNT(*) FROM achievements GROUP BY day`,
+			func(day *gamification.HistoryDay, n int) { day.Achievements = n },
+		},
+		{
+			`SELECT DATE(date), xp_earned FROM daily_stats`,
+			func(day *gamification.HistoryDay, n int) { day.XP, day.XPRecorded = n, true },
+		},
+	}
+
+	for _, q := range queries {
+		rows, err := db.conn.Query(q.query)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query progress history: %w", err)
+		}
+		for rows.Next() {
+			var date sql.NullString
+			var n int
+			if err := rows.Scan(&date, &n); err != nil {
+				rows.Close()
+				return nil, fmt.Errorf("failed to scan progress history: %w", err)
+			}
+			if date.Valid {
+				q.apply(getDay(date.String), n)
+			}
+		}
+		rows.Close()
+	}
+
+	sort.Strings(dates)
+	days := make([]gamification.HistoryDay, 0, len(dates))
+	for _, date := range dates {
+		// Only days with commands count as active days
+		if byDate[date].Commands > 0 {
+			days = append(days, *byDate[date])
+		}
+	}
+	return days, nil
+}
+
+// GetProgressHistory returns the progress snapshots, oldest first, with
+// today's brought up to date. Nothing is written.
+func (db *DB) GetProgressHistory() ([]*models.ProgressSnapshot, error) {
+	snapshots, err := db.GetProgressSnapshots()
+	if err != nil {
+		return nil, err
+	}
+	current, err := db.CurrentProgressSnapshot()
+	if err != nil {
+		return nil, err
+	}
+
+	if n := len(snapshots); n > 0 && snapshots[n-1].Date == current.Date {
+		snapshots[n-1] = current
+	} else if current.CommandsCount > 0 {
+		snapshots = append(snapshots, current)
+	}
+	return snapshots, nil
+}
+
+// GetProgressSnapshots returns all progress snapshots, oldest first
+func (db *DB) GetProgressSnapshots() ([]*models.ProgressSnapshot, error) {
+	rows, err := db.conn.Query(`
+		SELECT date, total_xp, level, commands_count, unique_commands_count,
+		       current_streak, longest_streak, achievements_count, estimated
+		FROM progress_snapshots
+		ORDER BY date
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query progress snapshots: %w", err)
+	}
+	defer rows.Close()
+
+	var snapshots []*models.ProgressSnapshot
+	for rows.Next() {
+		var s models.ProgressSnapshot
+		if err := rows.Scan(&s.Date, &s.TotalXP, &s.Level, &s.CommandsCount, &s.UniqueCommandsCount,
+			&s.CurrentStreak, &s.LongestStreak, &s.AchievementsCount, &s.Estimated); err != nil {
+			return nil, fmt.Errorf("failed to scan progress snapshot: %w", err)
+		}
+		snapshots = append(snapshots, &s)
+	}
+
+	return snapshots, rows.Err()
+}
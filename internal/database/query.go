@@ -0,0 +1,116 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// DeriveFields computes the classifier fields of a command line, which are
+// not stored in the database
+type DeriveFields func(command string) (tool, subcommand string)
+
+// RunQuery runs a read-only SELECT built by the query package and returns its
+// column names and rows. When derive is set, the query may join the temporary
+// table query_fields(command, tool, subcommand), which is filled
+// from the distinct commands in history before the query runs.
+func (db *DB) RunQuery(query string, args []interface{}, derive DeriveFields) ([]string, [][]interface{}, error) {
+	ctx := context.Background()
+
+	// Temporary tables are per connection, so pin one for the whole query
+	conn, err := db.conn.Conn(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get connection: %w", err)
+	}
+	defer conn.Close()
+
+	if derive != nil {
+		if _, err := conn.ExecContext(ctx, `
+			CREATE TEMP TABLE IF NOT EXISTS query_fields (
+				command TEXT PRIMARY KEY,
+				tool TEXT,
+				subcommand TEXT
+			)
+		`); err != nil {
+			return nil, nil, fmt.Errorf("failed to create query fields table: %w", err)
+		}
+		defer conn.ExecContext(ctx, "DROP TABLE IF EXISTS temp.query_fields")
+
+		if err := fillQueryFields(ctx, conn, derive); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	rows, err := conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to run query: %w", err)
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read query columns: %w", err)
+	}
+
+	var results [][]interface{}
+	for rows.Next() {
+		values := make([]interface{}, len(columns))
+		pointers := make([]interface{}, len(columns))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+		if err := rows.Scan(pointers...); err != nil {
+			return nil, nil, fmt.Errorf("failed to scan query row: %w", err)
+		}
+		for i, value := range values {
+			if b, ok := value.([]byte); ok {
+				values[i] = string(b)
+			}
+		}
+		results = append(results, values)
+	}
+
+	return columns, results, rows.Err()
+}
+
+// fillQueryFields derives the classifier fields of every distinct command
+func fillQueryFields(ctx context.Context, conn *sql.Conn, derive DeriveFields) error {
+	rows, err := conn.QueryContext(ctx, "SELECT DISTINCT command FROM commands")
+	if err != nil {
+		return fmt.Errorf("failed to query commands: %w", err)
+	}
+	var commands []string
+	for rows.Next() {
+		var command string
+		if err := rows.Scan(&command); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan command: %w", err)
+		}
+		commands = append(commands, command)
+	}
+	rows.Close()
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM temp.query_fields"); err != nil {
+		return fmt.Errorf("failed to clear query fields: %w", err)
+	}
+	stmt, err := tx.PrepareContext(ctx, "INSERT INTO temp.query_fields (command, tool, subcommand) VALUES (?, ?, ?)")
+	if err != nil {
+		return fmt.Errorf("failed to prepare query fields insert: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, command := range commands {
+		tool, subcommand := derive(command)
+		if _, err := stmt.ExecContext(ctx, command, tool, subcommand); err != nil {
+			return fmt.Errorf("failed to store query fields: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,306 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/oiahoon/termonaut/internal/categories"
+	"github.com/oiahoon/termonaut/internal/gamification"
+	"github.com/oiahoon/termonaut/pkg/models"
+)
+
+// UnsetCategory counts commands stored before categories were, in the
+// before counts of a reclassification
+const UnsetCategory = "(unset)"
+
+// defaultReclassifyBatchSize is how many commands are rewritten per transaction
+const defaultReclassifyBatchSize = 500
+
+// ReclassifyOptions controls a reclassification of stored commands
+type ReclassifyOptions struct {
+	Since       time.Time // only commands run since then; zero for all of them
+	BatchSize   int       // commands per transaction and checkpoint
+	Limit       int       // stop after about this many commands, leaving the rest for a later run; 0 for no limit
+	RecomputeXP bool      // adjust total XP by the difference the new categories make
+	DryRun      bool      // count what would change without writing anything
+	Restart     bool      // discard an interrupted run instead of resuming it
+
+	// Progress, if set, is called after each batch
+	Progress func(result *ReclassifyResult)
+}
+
+// ReclassifyResult describes a reclassification. For a resumed run it
+// covers the interrupted runs too.
+type ReclassifyResult struct {
+	Since       time.Time      `json:"since"`
+	RecomputeXP bool           `json:"recompute_xp"`
+	DryRun      bool           `json:"dry_run"`
+	Resumed     bool           `json:"resumed"`
+	Complete    bool           `json:"complete"` // false when stopped at the limit
+	MaxID       int64          `json:"max_id"`   // commands stored later were classified at ingest
+	Processed   int            `json:"processed"`
+	Changed     int            `json:"changed"` // commands whose primary category changed
+	Before      map[string]int `json:"before"`  // commands by stored category
+	After       map[string]int `json:"after"`   // commands by new category
+	XPDelta     int            `json:"xp_delta"`
+	StartedAt   time.Time      `json:"started_at"`
+}
+
+// ReclassifyCommands classifies stored commands again with the current
+// rules, rewriting their category and segments. It works in batches, saving
+// a checkpoint with each one, so a run that is interrupted or stopped at
+// its limit resumes where it left off the next time it is called with the
+// same RecomputeXP and with or without Since as before, keeping its
+// original Since.
+//
+// With RecomputeXP, total XP is adjusted by the difference between the XP
+// each changed command earns in its new category and in its old one. The
+// XP of commands stored before categories were is left alone, since the
+// category they earned it in is not known.
+//
+// Anonymized commands are left as they are: only their tools can be read
+// back, which would lose what they were classified from.
+func (db *DB) ReclassifyCommands(opts ReclassifyOptions) (*ReclassifyResult, error) {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = defaultReclassifyBatchSize
+	}
+
+	result, lastID, err := db.startReclassify(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := db.LoadLearnedCategories(); err != nil {
+		return nil, err
+	}
+	classifier := categories.NewCommandClassifier()
+	xpConfig := gamification.DefaultXPConfig()
+	for custom, bonus := range classifier.XPBonusOverrides() {
+		xpConfig.CategoryMultipliers[string(custom)] = bonus
+	}
+	xpCalc := gamification.NewXPCalculator(xpConfig)
+
+	processed := 0
+	for {
+		if opts.Limit > 0 && processed >= opts.Limit {
+			return result, nil
+		}
+
+		commands, err := db.reclassifyBatch(lastID, result.MaxID, result.Since, opts.BatchSize)
+		if err != nil {
+			return nil, err
+		}
+		if len(commands) == 0 {
+			break
+		}
+
+		if err := db.reclassifyCommands(commands, classifier, xpCalc, result, true); err != nil {
+			return nil, err
+		}
+		lastID = commands[len(commands)-1].ID
+		processed += len(commands)
+		if opts.Progress != nil {
+			opts.Progress(result)
+		}
+	}
+
+	result.Complete = true
+	if !opts.DryRun {
+		if _, err := db.conn.Exec("DELETE FROM reclassify_state"); err != nil {
+			return nil, fmt.Errorf("failed to clear reclassify checkpoint: %w", err)
+		}
+		db.clearCache()
+	}
+	return result, nil
+}
+
+// startReclassify resumes an interrupted reclassification or starts a new one,
+// returning the ID of the last command already done
+func (db *DB) startReclassify(opts ReclassifyOptions) (*ReclassifyResult, int64, error) {
+	if !opts.DryRun {
+		if opts.Restart {
+			if _, err := db.conn.Exec("DELETE FROM reclassify_state"); err != nil {
+				return nil, 0, fmt.Errorf("failed to clear reclassify checkpoint: %w", err)
+			}
+		}
+
+		var lastID int64
+		var progress string
+		err := db.conn.QueryRow("SELECT last_id, progress FROM reclassify_state WHERE id = 1").Scan(&lastID, &progress)
+		switch {
+		case err == nil:
+			var result ReclassifyResult
+			if err := json.Unmarshal([]byte(progress), &result); err != nil {
+				return nil, 0, fmt.Errorf("failed to parse reclassify checkpoint: %w", err)
+			}
+			// A relative since such as 30d moves on between runs, so the
+			// original bound is kept as long as both runs have one
+			if result.Since.IsZero() != opts.Since.IsZero() || result.RecomputeXP != opts.RecomputeXP {
+				return nil, 0, fmt.Errorf("an interrupted reclassification with different options is pending; rerun it with the same options or restart it")
+			}
+			result.Resumed = true
+			result.Complete = false
+			return &result, lastID, nil
+		case err != sql.ErrNoRows:
+			return nil, 0, fmt.Errorf("failed to read reclassify checkpoint: %w", err)
+		}
+	}
+
+	result := &ReclassifyResult{
+		Since:       opts.Since,
+		RecomputeXP: opts.RecomputeXP,
+		DryRun:      opts.DryRun,
+		Before:      make(map[string]int),
+		After:       make(map[string]int),
+		StartedAt:   time.Now(),
+	}
+	if err := db.conn.QueryRow("SELECT COALESCE(MAX(id), 0) FROM commands").Scan(&result.MaxID); err != nil {
+		return nil, 0, fmt.Errorf("failed to get last command ID: %w", err)
+	}
+	return result, 0, nil
+}
+
+// reclassifyBatch returns the next commands to reclassify after lastID
+func (db *DB) reclassifyBatch(lastID, maxID int64, since time.Time, size int) ([]*models.Command, error) {
+	query := `
+		SELECT id, timestamp, command, exit_code, COALESCE(category, '')
+		FROM commands
+		WHERE id > ? AND id <= ? AND anonymized = 0`
+	args := []interface{}{lastID, maxID}
+	if !since.IsZero() {
+		query += " AND julianday(timestamp) >= julianday(?)"
+		args = append(args, since.UTC().Format("2006-01-02 15:04:05"))
+	}
+	query += " ORDER BY id LIMIT ?"
+	args = append(args, size)
+
+	rows, err := db.conn.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query commands to reclassify: %w", err)
+	}
+	defer rows.Close()
+
+	var commands []*models.Command
+	for rows.Next() {
+		var cmd models.Command
+		if err := rows.Scan(&cmd.ID, &cmd.Timestamp, &cmd.Command, &cmd.ExitCode, &cmd.Category); err != nil {
+			return nil, fmt.Errorf("failed to scan command: %w", err)
+		}
+		commands = append(commands, &cmd)
+	}
+	return commands, rows.Err()
+}
+
+// reclassifyCommands classifies a batch of commands and, unless it is a dry
+// run, rewrites them and optionally saves the checkpoint in one transaction
+func (db *DB) reclassifyCommands(commands []*models.Command, classifier *categories.CommandClassifier,
+	xpCalc *gamification.XPCalculator, result *ReclassifyResult, checkpoint bool) error {
+	var tx *sql.Tx
+	if !result.DryRun {
+		var err error
+		if tx, err = db.conn.Begin(); err != nil {
+			return fmt.Errorf("failed to begin transaction: %w", err)
+		}
+		defer tx.Rollback()
+	}
+
+	xpDelta := 0
+	for _, cmd := range commands {
+		classification := classifier.Classify(cmd.Command)
+		category := string(classification.Primary)
+
+		before := cmd.Category
+		if before == "" {
+			before = UnsetCategory
+		}
+		result.Before[before]++
+		result.After[category]++
+		result.Processed++
+		if cmd.Category != category {
+			result.Changed++
+			if result.RecomputeXP && cmd.Category != "" {
+				xpDelta += xpCalc.CalculateCommandXP(cmd, false, 0, category) -
+					xpCalc.CalculateCommandXP(cmd, false, 0, cmd.Category)
+			}
+		}
+
+		if tx == nil {
+			continue
+		}
+		if _, err := tx.Exec("UPDATE commands SET category = ? WHERE id = ?", category, cmd.ID); err != nil {
+			return fmt.Errorf("failed to update command category: %w", err)
+		}
+		if _, err := tx.Exec("DELETE FROM command_segments WHERE command_id = ?", cmd.ID); err != nil {
+			return fmt.Errorf("failed to clear command segments: %w", err)
+		}
+		if err := storeSegments(tx, cmd.ID, classification); err != nil {
+			return err
+		}
+	}
+	result.XPDelta += xpDelta
+
+	if tx == nil {
+		return nil
+	}
+	if xpDelta != 0 {
+		if err := adjustXPWithTransaction(tx, xpDelta); err != nil {
+			return err
+		}
+	}
+
+	if checkpoint {
+		if err := saveReclassifyCheckpoint(tx, commands[len(commands)-1].ID, result); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit reclassified commands: %w", err)
+	}
+	return nil
+}
+
+// saveReclassifyCheckpoint records how far a reclassification has got
+func saveReclassifyCheckpoint(tx *sql.Tx, lastID int64, result *ReclassifyResult) error {
+	progress, err := json.Marshal(result)
+	if err != nil {
+		return fmt.Errorf("failed to marshal reclassify checkpoint: %w", err)
+	}
+	_, err = tx.Exec(`
+		INSERT INTO reclassify_state (id, last_id, progress, updated_at)
+		VALUES (1, ?, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT(id) DO UPDATE SET last_id = excluded.last_id, progress = excluded.progress, updated_at = excluded.updated_at
+	`, lastID, string(progress))
+	if err != nil {
+		return fmt.Errorf("failed to save reclassify checkpoint: %w", err)
+	}
+	return nil
+}
+
+// adjustXPWithTransaction corrects total XP, which never drops below zero,
+// and the level that goes with it. Unlike addXPWithTransaction it leaves the
+// daily XP history alone, since the XP was not earned today.
+func adjustXPWithTransaction(tx *sql.Tx, delta int) error {
+	var currentXP int
+	if err := tx.QueryRow("SELECT total_xp FROM user_progress WHERE id = 1").Scan(&currentXP); err != nil {
+		return fmt.Errorf("failed to get current XP: %w", err)
+	}
+
+	newXP := currentXP + delta
+	if newXP < 0 {
+		newXP = 0
+	}
+	level := gamification.NewLevelCalculator().CalculateLevel(newXP)
+
+	_, err := tx.Exec(`
+		UPDATE user_progress
+		SET total_xp = ?, current_level = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE id = 1
+	`, newXP, level)
+	if err != nil {
+		return fmt.Errorf("failed to adjust XP: %w", err)
+	}
+	return nil
+}
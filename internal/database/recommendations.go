@@ -0,0 +1,53 @@
+package database
+
+import (
+	"fmt"
+	"time"
+)
+
+// DismissRecommendation records that a recommendation should no longer be shown
+func (db *DB) DismissRecommendation(id string) error {
+	_, err := db.conn.Exec(`
+		INSERT INTO dismissed_recommendations (id, dismissed_at) VALUES (?, ?)
+		ON CONFLICT(id) DO UPDATE SET dismissed_at = excluded.dismissed_at
+	`, id, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to dismiss recommendation: %w", err)
+	}
+	return nil
+}
+
+// RestoreRecommendation removes a dismissal, reporting whether one existed
+func (db *DB) RestoreRecommendation(id string) (bool, error) {
+	result, err := db.conn.Exec("DELETE FROM dismissed_recommendations WHERE id = ?", id)
+	if err != nil {
+		return false, fmt.Errorf("failed to restore recommendation: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to restore recommendation: %w", err)
+	}
+	return affected > 0, nil
+}
+
+// GetDismissedRecommendations returns dismissed recommendation IDs and when
+// they were dismissed
+func (db *DB) GetDismissedRecommendations() (map[string]time.Time, error) {
+	rows, err := db.conn.Query("SELECT id, dismissed_at FROM dismissed_recommendations")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query dismissed recommendations: %w", err)
+	}
+	defer rows.Close()
+
+	dismissed := make(map[string]time.Time)
+	for rows.Next() {
+		var id string
+		var dismissedAt time.Time
+		if err := rows.Scan(&id, &dismissedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan dismissed recommendation: %w", err)
+		}
+		dismissed[id] = dismissedAt
+	}
+
+	return dismissed, rows.Err()
+}
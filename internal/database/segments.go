@@ -0,0 +1,121 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/oiahoon/termonaut/internal/categories"
+)
+
+// CategoryUsage counts how often a category was used, by whole command and
+// by segment. Top-level categories include their subcategories.
+type CategoryUsage struct {
+	Category      string           `json:"category"`
+	Commands      int              `json:"commands"` // commands with it as their primary category
+	Segments      int              `json:"segments"` // segments in it, including inside pipelines and chains
+	Subcategories []*CategoryUsage `json:"subcategories,omitempty"`
+}
+
+// storeSegments saves the classified segments of a command
+func storeSegments(tx *sql.Tx, commandID int64, classification *categories.Classification) error {
+	for i, segment := range classification.Segments {
+		_, err := tx.Exec(`
+			INSERT INTO command_segments (command_id, position, operator, command, tool, wrappers, category, is_primary)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		`, commandID, i, segment.Operator, segment.Command, segment.Tool,
+			strings.Join(segment.Wrappers, " "), string(segment.Category), i == classification.PrimarySegment)
+		if err != nil {
+			return fmt.Errorf("failed to store command segment: %w", err)
+		}
+	}
+	return nil
+}
+
+// GetCommandSegments returns the classified segments of a command, in order
+func (db *DB) GetCommandSegments(commandID int64) ([]*categories.Segment, error) {
+	rows, err := db.conn.Query(`
+		SELECT operator, command, tool, wrappers, category
+		FROM command_segments
+		WHERE command_id = ?
+		ORDER BY position
+	`, commandID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query command segments: %w", err)
+	}
+	defer rows.Close()
+
+	var segments []*categories.Segment
+	for rows.Next() {
+		var segment categories.Segment
+		var operator, tool, wrappers sql.NullString
+		var category string
+		if err := rows.Scan(&operator, &segment.Command, &tool, &wrappers, &category); err != nil {
+			return nil, fmt.Errorf("failed to scan command segment: %w", err)
+		}
+		segment.Operator = operator.String
+		segment.Tool = tool.String
+		segment.Wrappers = strings.Fields(wrappers.String)
+		segment.Category = categories.Category(category)
+		segments = append(segments, &segment)
+	}
+
+	return segments, rows.Err()
+}
+
+// GetCategoryUsage counts commands by primary category and segments by
+// category, rolled up into top-level categories, most used first
+func (db *DB) GetCategoryUsage() ([]*CategoryUsage, error) {
+	rows, err := db.conn.Query(`
+		SELECT category, SUM(is_primary), COUNT(*)
+		FROM command_segments
+		GROUP BY category
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query category usage: %w", err)
+	}
+	defer rows.Close()
+
+	roots := make(map[categories.Category]*CategoryUsage)
+	for rows.Next() {
+		var u CategoryUsage
+		if err := rows.Scan(&u.Category, &u.Commands, &u.Segments); err != nil {
+			return nil, fmt.Errorf("failed to scan category usage: %w", err)
+		}
+
+		root := categories.Category(u.Category).Root()
+		if roots[root] == nil {
+			roots[root] = &CategoryUsage{Category: string(root)}
+		}
+		roots[root].Commands += u.Commands
+		roots[root].Segments += u.Segments
+		if string(root) != u.Category {
+			roots[root].Subcategories = append(roots[root].Subcategories, &u)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	usage := make([]*CategoryUsage, 0, len(roots))
+	for _, u := range roots {
+		sortCategoryUsage(u.Subcategories)
+		usage = append(usage, u)
+	}
+	sortCategoryUsage(usage)
+	return usage, nil
+}
+
+// sortCategoryUsage orders usage by commands, then segments, then name
+func sortCategoryUsage(usage []*CategoryUsage) {
+	sort.Slice(usage, func(i, j int) bool {
+		if usage[i].Commands != usage[j].Commands {
+			return usage[i].Commands > usage[j].Commands
+		}
+		if usage[i].Segments != usage[j].Segments {
+			return usage[i].Segments > usage[j].Segments
+		}
+		return usage[i].Category < usage[j].Category
+	})
+}
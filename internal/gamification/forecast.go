@@ -0,0 +1,275 @@
+package gamification
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+const (
+	// ForecastWindowDays is how many recent days of history feed the forecast
+	ForecastWindowDays = 28
+
+	// confidenceZ is the z-value for the 80% confidence range around the velocity
+	confidenceZ = 1.28
+)
+
+// DailyActivity is one day of XP and command history
+type DailyActivity struct {
+	Date     string `json:"date"`
+	XP       int    `json:"xp"`
+	Commands int    `json:"commands"`
+}
+
+// Velocity is an average daily rate with a confidence range
+type Velocity struct {
+	PerDay     float64 `json:"per_day"`
+	Low        float64 `json:"low"`
+	High       float64 `json:"high"`
+	WindowDays int     `json:"window_days"`
+	ActiveDays int     `json:"active_days"`
+}
+
+// Prediction estimates when a goal will be reached at the current pace
+type Prediction struct {
+	Target    string     `json:"target"`
+	Remaining int        `json:"remaining"`
+	Unit      string     `json:"unit"`
+	Days      float64    `json:"days"`     // -1 when the goal is out of reach at the current pace
+	MinDays   float64    `json:"min_days"` // optimistic end of the confidence range
+	MaxDays   float64    `json:"max_days"` // pessimistic end, -1 when unbounded
+	ETA       *time.Time `json:"eta,omitempty"`
+	Summary   string     `json:"summary"` // short form for prompts, e.g. "Level 12 in ~4 days"
+	Message   string     `json:"message"`
+}
+
+// Forecast holds predictions for the next level, achievement and streak record
+type Forecast struct {
+	GeneratedAt     time.Time   `json:"generated_at"`
+	XPVelocity      *Velocity   `json:"xp_velocity"`
+	CommandVelocity *Velocity   `json:"command_velocity"`
+	NextLevel       *Prediction `json:"next_level"`
+	NextAchievement *Prediction `json:"next_achievement,omitempty"`
+	StreakRecord    *Prediction `json:"streak_record"`
+}
+
+// Forecaster predicts goal completion from recent activity
+type Forecaster struct {
+	levelCalc          *LevelCalculator
+	achievementManager *AchievementManager
+}
+
+// NewForecaster creates a new forecaster
+func NewForecaster() *Forecaster {
+	return &Forecaster{
+		levelCalc:          NewLevelCalculator(),
+		achievementManager: NewAchievementManager(),
+	}
+}
+
+// Forecast predicts when the user reaches their next goals. History should
+// cover the recent window oldest first, including days without activity.
+func (f *Forecaster) Forecast(stats *UserStats, earned map[string]*UserAchievement, history []*DailyActivity, now time.Time) *Forecast {
+	forecast := &Forecast{
+		GeneratedAt:     now,
+		XPVelocity:      calculateVelocity(history, f.dailyXP(stats)),
+		CommandVelocity: calculateVelocity(history, func(day *DailyActivity) float64 { return float64(day.Commands) }),
+	}
+
+	// Next level
+	nextLevel := f.levelCalc.CalculateLevel(stats.TotalXP) + 1
+	_, xpNeeded, _ := f.levelCalc.CalculateXPToNextLevel(stats.TotalXP)
+	forecast.NextLevel = predict(fmt.Sprintf("Level %d", nextLevel), xpNeeded, "xp", forecast.XPVelocity, now)
+
+	// Next achievement: whichever predictable achievement is expected soonest
+	for id, achievement := range f.achievementManager.GetAllAchievements() {
+		if _, isEarned := earned[id]; isEarned || achievement.Hidden {
+			continue
+		}
+
+		progress, target, _ := f.achievementManager.GetProgressToAchievement(id, stats)
+		var prediction *Prediction
+		switch achievement.Type {
+		case CommandCount:
+			prediction = predict(achievement.Name, target-progress, "commands", forecast.CommandVelocity, now)
+		case XPMilestone:
+			prediction = predict(achievement.Name, target-progress, "xp", forecast.XPVelocity, now)
+		case LevelMilestone:
+			xpRemaining := f.levelCalc.CalculateXPForLevel(target) - stats.TotalXP
+			prediction = predict(achievement.Name, xpRemaining, "xp", forecast.XPVelocity, now)
+		case Streak:
+			prediction = predictStreak(achievement.Name, target, stats, forecast.CommandVelocity, now)
+		default:
+			continue
+		}
+
+		if prediction.Days < 0 {
+			continue
+		}
+		if forecast.NextAchievement == nil || prediction.Days < forecast.NextAchievement.Days ||
+			(prediction.Days == forecast.NextAchievement.Days && prediction.Target < forecast.NextAchievement.Target) {
+			forecast.NextAchievement = prediction
+		}
+	}
+
+	// Longest streak record
+	forecast.StreakRecord = predictStreak(
+		fmt.Sprintf("Streak record (%d days)", stats.LongestStreak+1),
+		stats.LongestStreak+1, stats, forecast.CommandVelocity, now)
+
+	return forecast
+}
+
+// dailyXP returns the XP value for a day. Days recorded before XP history was
+// kept only have a command count, so their XP is estimated from the lifetime
+// XP-per-command average.
+func (f *Forecaster) dailyXP(stats *UserStats) func(*DailyActivity) float64 {
+	xpPerCommand := 0.0
+	if stats.TotalCommands > 0 {
+		xpPerCommand = float64(stats.TotalXP) / float64(stats.TotalCommands)
+	}
+	return func(day *DailyActivity) float64 {
+		if day.XP == 0 && day.Commands > 0 {
+			return float64(day.Commands) * xpPerCommand
+		}
+		return float64(day.XP)
+	}
+}
+
+// calculateVelocity averages a daily value and derives an 80% confidence
+// range from the standard error of the mean
+func calculateVelocity(history []*DailyActivity, value func(*DailyActivity) float64) *Velocity {
+	velocity := &Velocity{WindowDays: len(history)}
+	if len(history) == 0 {
+		return velocity
+	}
+
+	total := 0.0
+	for _, day := range history {
+		total += value(day)
+		if day.Commands > 0 {
+			velocity.ActiveDays++
+		}
+	}
+	velocity.PerDay = total / float64(len(history))
+
+	variance := 0.0
+	for _, day := range history {
+		diff := value(day) - velocity.PerDay
+		variance += diff * diff
+	}
+	if len(history) > 1 {
+		variance /= float64(len(history) - 1)
+	}
+	margin := confidenceZ * math.Sqrt(variance) / math.Sqrt(float64(len(history)))
+
+	velocity.Low = math.Max(0, velocity.PerDay-margin)
+	velocity.High = velocity.PerDay + margin
+
+	return velocity
+}
+
+// predict estimates how many days it takes to cover the remaining amount
+func predict(target string, remaining int, unit string, velocity *Velocity, now time.Time) *Prediction {
+	prediction := &Prediction{
+		Target:    target,
+		Remaining: remaining,
+		Unit:      unit,
+	}
+
+	if remaining <= 0 {
+		prediction.Summary = fmt.Sprintf("%s reached", target)
+		prediction.Message = fmt.Sprintf("%s is within reach on your next command!", target)
+		return prediction
+	}
+
+	if velocity.PerDay <= 0 {
+		prediction.Days, prediction.MinDays, prediction.MaxDays = -1, -1, -1
+		prediction.Summary = fmt.Sprintf("%s: no recent activity", target)
+		prediction.Message = fmt.Sprintf("%s needs %d more %s — not enough recent activity to forecast", target, remaining, unit)
+		return prediction
+	}
+
+	prediction.Days = float64(remaining) / velocity.PerDay
+	prediction.MinDays = float64(remaining) / velocity.High
+	prediction.MaxDays = -1
+	if velocity.Low > 0 {
+		prediction.MaxDays = float64(remaining) / velocity.Low
+	}
+
+	eta := now.Add(time.Duration(prediction.Days * float64(24*time.Hour)))
+	prediction.ETA = &eta
+
+	prediction.Summary = fmt.Sprintf("%s in %s", target, FormatForecastDays(prediction.Days))
+	prediction.Message = fmt.Sprintf("%s in %s (%s) at your current pace of %.0f %s/day",
+		target, FormatForecastDays(prediction.Days), formatRange(prediction.MinDays, prediction.MaxDays),
+		velocity.PerDay, unit)
+
+	return prediction
+}
+
+// predictStreak estimates when a streak target is reached. Streaks grow one
+// day at a time, so the range reflects how often the user is active.
+func predictStreak(target string, streakTarget int, stats *UserStats, commandVelocity *Velocity, now time.Time) *Prediction {
+	remaining := streakTarget - stats.CurrentStreak
+	prediction := &Prediction{
+		Target:    target,
+		Remaining: remaining,
+		Unit:      "days",
+	}
+
+	if remaining <= 0 {
+		prediction.Summary = fmt.Sprintf("%s reached", target)
+		prediction.Message = fmt.Sprintf("%s reached — keep the streak alive!", target)
+		return prediction
+	}
+
+	if commandVelocity.WindowDays == 0 || commandVelocity.ActiveDays == 0 {
+		prediction.Days, prediction.MinDays, prediction.MaxDays = -1, -1, -1
+		prediction.Summary = fmt.Sprintf("%s: no recent activity", target)
+		prediction.Message = fmt.Sprintf("%s needs %d more active days in a row", target, remaining)
+		return prediction
+	}
+
+	activeRatio := float64(commandVelocity.ActiveDays) / float64(commandVelocity.WindowDays)
+	prediction.Days = float64(remaining)
+	prediction.MinDays = float64(remaining)
+	prediction.MaxDays = -1
+	if activeRatio >= 1 {
+		prediction.MaxDays = float64(remaining)
+	}
+
+	eta := now.AddDate(0, 0, remaining)
+	prediction.ETA = &eta
+
+	prediction.Summary = fmt.Sprintf("%s in %s", target, FormatForecastDays(prediction.Days))
+	prediction.Message = fmt.Sprintf("%s in %s if you stay active every day (active %d of the last %d days)",
+		target, FormatForecastDays(prediction.Days), commandVelocity.ActiveDays, commandVelocity.WindowDays)
+
+	return prediction
+}
+
+// FormatForecastDays renders a day estimate such as "~4 days"
+func FormatForecastDays(days float64) string {
+	switch {
+	case days < 0:
+		return "—"
+	case days < 1:
+		return "less than a day"
+	case days < 1.5:
+		return "~1 day"
+	case days < 14:
+		return fmt.Sprintf("~%.0f days", days)
+	case days < 60:
+		return fmt.Sprintf("~%.0f weeks", days/7)
+	default:
+		return fmt.Sprintf("~%.0f months", days/30)
+	}
+}
+
+func formatRange(minDays, maxDays float64) string {
+	if maxDays < 0 {
+		return fmt.Sprintf("%s or longer", FormatForecastDays(minDays))
+	}
+	return fmt.Sprintf("%s to %s", FormatForecastDays(minDays), FormatForecastDays(maxDays))
+}
@@ -0,0 +1,116 @@
+package gamification
+
+import (
+	"math"
+
+	"github.com/oiahoon/termonaut/internal/calendar"
+	"github.com/oiahoon/termonaut/pkg/models"
+)
+
+// HistoryDay is what happened on one active day, used to estimate progress
+// snapshots for days before snapshots were recorded
+type HistoryDay struct {
+	Date         string
+	Commands     int  // commands run that day
+	NewCommands  int  // distinct commands first run that day
+	Achievements int  // achievements earned that day
+	XP           int  // XP recorded in the daily history
+	XPRecorded   bool // false when the day predates the daily XP history
+}
+
+// EstimateProgressHistory reconstructs end-of-day snapshots from daily
+// totals, oldest first. XP is taken from the daily history where it was
+// recorded; the rest of totalXP is spread over the other days in proportion
+// to the commands run.
+func EstimateProgressHistory(days []HistoryDay, totalXP int) []*models.ProgressSnapshot {
+	knownXP, unknownCommands := 0, 0
+	for _, day := range days {
+		if day.XPRecorded {
+			knownXP += day.XP
+		} else {
+			unknownCommands += day.Commands
+		}
+	}
+	xpPerCommand := 0.0
+	if unknownCommands > 0 && totalXP > knownXP {
+		xpPerCommand = float64(totalXP-knownXP) / float64(unknownCommands)
+	}
+
+	levelCalc := NewLevelCalculator()
+	snapshots := make([]*models.ProgressSnapshot, 0, len(days))
+	xp := 0.0
+	var commands, unique, achievements, streak, longest int
+	previous := ""
+	for _, day := range days {
+		if day.XPRecorded {
+			xp += float64(day.XP)
+		} else {
+			xp += float64(day.Commands) * xpPerCommand
+		}
+		commands += day.Commands
+		unique += day.NewCommands
+		achievements += day.Achievements
+
+		if previous != "" && calendar.AddDays(previous, 1) == day.Date {
+			streak++
+		} else {
+			streak = 1
+		}
+		if streak > longest {
+			longest = streak
+		}
+		previous = day.Date
+
+		totalSoFar := int(math.Round(xp))
+		snapshots = append(snapshots, &models.ProgressSnapshot{
+			Date:                day.Date,
+			TotalXP:             totalSoFar,
+			Level:               levelCalc.CalculateLevel(totalSoFar),
+			CommandsCount:       commands,
+			UniqueCommandsCount: unique,
+			CurrentStreak:       streak,
+			LongestStreak:       longest,
+			AchievementsCount:   achievements,
+			Estimated:           true,
+		})
+	}
+
+	return snapshots
+}
+
+// ProgressSeries returns one snapshot per date, carrying the latest earlier
+// snapshot forward over days without activity. snapshots must be sorted by
+// date. Dates before the first snapshot get an empty level 1 snapshot, and
+// the current streak drops to zero once a day is missed.
+func ProgressSeries(snapshots []*models.ProgressSnapshot, dates []string) []*models.ProgressSnapshot {
+	series := make([]*models.ProgressSnapshot, 0, len(dates))
+	next := 0
+	var latest *models.ProgressSnapshot
+	for _, date := range dates {
+		for next < len(snapshots) && snapshots[next].Date <= date {
+			latest = snapshots[next]
+			next++
+		}
+
+		point := &models.ProgressSnapshot{Date: date, Level: 1}
+		if latest != nil {
+			*point = *latest
+			point.Date = date
+			if latest.Date != date && calendar.AddDays(latest.Date, 1) < date {
+				point.CurrentStreak = 0
+			}
+		}
+		series = append(series, point)
+	}
+
+	return series
+}
+
+// SnapshotAt returns the progress as of the end of a day, or nil if there
+// is no snapshot on or before it. snapshots must be sorted by date.
+func SnapshotAt(snapshots []*models.ProgressSnapshot, date string) *models.ProgressSnapshot {
+	if len(snapshots) == 0 || snapshots[0].Date > date {
+		return nil
+	}
+	return ProgressSeries(snapshots, []string{date})[0]
+}
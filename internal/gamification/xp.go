@@ -5,6 +5,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/oiahoon/termonaut/internal/calendar"
 	"github.com/oiahoon/termonaut/pkg/models"
 )
 
@@ -51,8 +52,50 @@ func NewXPCalculator(config *XPConfig) *XPCalculator {
 	}
 }
 
-// CalculateCommandXP calculates XP for a single command
+// CalculateCommandXP calculates XP for a single command. The category may
+// be a subcategory such as "git/remote"; without a multiplier of its own it
+// uses its parent's.
 func (xp *XPCalculator) CalculateCommandXP(cmd *models.Command, isNewCommand bool, streak int, category string) int {
+	return xp.calculateXP(cmd, isNewCommand, streak, category, xp.CategoryMultiplier(category))
+}
+
+// CalculateLabeledCommandXP calculates XP for a command with several
+// weighted category labels. The category multiplier is the weighted mean of
+// the labels' multipliers; the primary category decides everything else.
+func (xp *XPCalculator) CalculateLabeledCommandXP(cmd *models.Command, isNewCommand bool, streak int, primary string, labels map[string]float64) int {
+	multiplier, total := 0.0, 0.0
+	for category, weight := range labels {
+		multiplier += weight * xp.CategoryMultiplier(category)
+		total += weight
+	}
+	if total == 0 {
+		return xp.CalculateCommandXP(cmd, isNewCommand, streak, primary)
+	}
+	return xp.calculateXP(cmd, isNewCommand, streak, primary, multiplier/total)
+}
+
+// CategoryMultiplier returns the XP multiplier of a category, or of its
+// closest parent that has one, or 1
+func (xp *XPCalculator) CategoryMultiplier(category string) float64 {
+	for category != "" {
+		if multiplier, exists := xp.config.CategoryMultipliers[category]; exists {
+			return multiplier
+		}
+		slash := strings.LastIndex(category, "/")
+		if slash < 0 {
+			break
+		}
+		category = category[:slash]
+	}
+	return 1.0
+}
+
+// calculateXP calculates XP for a command given its category multiplier
+func (xp *XPCalculator) calculateXP(cmd *models.Command, isNewCommand bool, streak int, category string, multiplier float64) int {
+	// Penalties and complexity adjustments go by the top-level category
+	if slash := strings.Index(category, "/"); slash >= 0 {
+		category = category[:slash]
+	}
 	baseXP := float64(xp.config.BaseXPPerCommand)
 
 	// Apply failure penalty first
@@ -67,9 +110,7 @@ func (xp *XPCalculator) CalculateCommandXP(cmd *models.Command, isNewCommand boo
 	}
 
 	// Category multiplier
-	if multiplier, exists := xp.config.CategoryMultipliers[category]; exists {
-		baseXP *= multiplier
-	}
+	baseXP *= multiplier
 
 	// Command complexity bonus
 	complexityBonus := xp.calculateComplexityBonus(cmd.Command, category)
@@ -179,7 +220,7 @@ func (xp *XPCalculator) calculateComplexityBonus(command string, category string
 
 // calculateTimeBonus calculates time-based XP bonus
 func (xp *XPCalculator) calculateTimeBonus(timestamp time.Time) float64 {
-	hour := timestamp.Hour()
+	hour := calendar.Default().Clock(timestamp).Hour()
 
 	// Morning productivity bonus (6-10 AM)
 	if hour >= 6 && hour <= 10 {
@@ -7,15 +7,20 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
 	"strings"
 	"time"
 
+	"github.com/oiahoon/termonaut/internal/analytics"
 	"github.com/oiahoon/termonaut/internal/avatar"
 	"github.com/oiahoon/termonaut/internal/config"
 	"github.com/oiahoon/termonaut/internal/stats"
 	"github.com/oiahoon/termonaut/pkg/models"
 )
 
+// badgeSlugPattern matches characters not allowed in badge file names
+var badgeSlugPattern = regexp.MustCompile(`[^a-z0-9]+`)
+
 // SyncManager handles GitHub synchronization
 type SyncManager struct {
 	config           *config.Config
@@ -268,9 +273,37 @@ func (sm *SyncManager) generateBadges(userProgress *models.UserProgress, repoPat
 		badgeCount++
 	}
 
+	// User-defined metrics marked badge = true
+	if len(sm.config.Metrics) > 0 {
+		engine, err := analytics.NewMetricEngine(sm.config.Metrics)
+		if err != nil {
+			return badgeCount, fmt.Errorf("invalid metrics configuration: %w", err)
+		}
+		values, err := sm.statsCalculator.ComputeMetrics(engine, 30)
+		if err != nil {
+			return badgeCount, err
+		}
+		for _, value := range values {
+			if !value.Badge {
+				continue
+			}
+			metricBadgeJSON := sm.generateBadgeJSON(value.Name, value.Display(), "blue")
+			if err := os.WriteFile(filepath.Join(badgesDir, metricBadgeFile(value.Name)), []byte(metricBadgeJSON), 0644); err != nil {
+				return badgeCount, err
+			}
+			badgeCount++
+		}
+	}
+
 	return badgeCount, nil
 }
 
+// metricBadgeFile returns the badge file name for a user-defined metric
+func metricBadgeFile(name string) string {
+	slug := strings.Trim(badgeSlugPattern.ReplaceAllString(strings.ToLower(name), "-"), "-")
+	return "metric-" + slug + ".json"
+}
+
 // generateProfile generates and saves profile markdown
 func (sm *SyncManager) generateProfile(userProgress *models.UserProgress, repoPath string) (int, error) {
 	// Get avatar information
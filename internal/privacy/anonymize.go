@@ -0,0 +1,128 @@
+package privacy
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/oiahoon/termonaut/internal/categories"
+)
+
+const (
+	// AnonymousKeyFileName holds the secret key of anonymous mode in the
+	// data directory
+	AnonymousKeyFileName = "anonymous.key"
+
+	// HashPrefix starts every hashed value
+	HashPrefix = "#"
+
+	// anonymousKeySize is the size of the secret key in bytes
+	anonymousKeySize = 32
+	// hashLength is how many hex digits of an HMAC are kept
+	hashLength = 16
+)
+
+var hashPattern = regexp.MustCompile(`^` + HashPrefix + `[0-9a-f]{16}$`)
+
+// LoadOrCreateKey reads the secret key of anonymous mode, creating it with
+// random bytes on first use. Losing the key only means later commands no
+// longer match the ones stored before.
+func LoadOrCreateKey(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err == nil {
+		key, err := hex.DecodeString(strings.TrimSpace(string(data)))
+		if err != nil || len(key) < anonymousKeySize {
+			return nil, fmt.Errorf("invalid anonymous mode key in %s", path)
+		}
+		return key, nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read anonymous mode key: %w", err)
+	}
+
+	key := make([]byte, anonymousKeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("failed to generate anonymous mode key: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create key directory: %w", err)
+	}
+
+	// O_EXCL so that two shells logging their first command at once end up
+	// with the same key
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if os.IsExist(err) {
+		return LoadOrCreateKey(path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create anonymous mode key: %w", err)
+	}
+	defer file.Close()
+	if _, err := file.WriteString(hex.EncodeToString(key) + "\n"); err != nil {
+		return nil, fmt.Errorf("failed to write anonymous mode key: %w", err)
+	}
+	return key, nil
+}
+
+// Anonymizer replaces commands and directories with keyed hashes. The same
+// text always gets the same hash, so repetition and uniqueness can still be
+// counted, but it cannot be read back or guessed without the key.
+type Anonymizer struct {
+	key []byte
+}
+
+// NewAnonymizer creates an anonymizer with a secret key
+func NewAnonymizer(key []byte) *Anonymizer {
+	return &Anonymizer{key: key}
+}
+
+// Hash returns the HMAC-SHA256 of a value, shortened and prefixed with #
+func (a *Anonymizer) Hash(value string) string {
+	mac := hmac.New(sha256.New, a.key)
+	mac.Write([]byte(value))
+	return HashPrefix + hex.EncodeToString(mac.Sum(nil))[:hashLength]
+}
+
+// IsHash reports whether a value is a hash made by an anonymizer
+func IsHash(value string) bool {
+	return hashPattern.MatchString(value)
+}
+
+// AnonymizeCommand classifies a command and returns what anonymous mode
+// stores for it: its primary tool followed by the hash of the whole command,
+// and the classification, whose segments are rewritten the same way with
+// their tools, wrappers and categories kept. Logging a command and
+// migrating a stored one both go through it, so a command gets the same
+// hash either way.
+func (a *Anonymizer) AnonymizeCommand(command string, classifier *categories.CommandClassifier) (string, *categories.Classification) {
+	command = strings.TrimSpace(command)
+	classification := classifier.Classify(command)
+	for _, segment := range classification.Segments {
+		segment.Command = a.anonymizeSegment(segment.Tool, segment.Command)
+	}
+
+	return a.anonymizeSegment(classification.Tool(), command), classification
+}
+
+// anonymizeSegment returns a tool followed by the hash of a command
+func (a *Anonymizer) anonymizeSegment(tool, command string) string {
+	if tool == "" {
+		return a.Hash(command)
+	}
+	return tool + " " + a.Hash(command)
+}
+
+// AnonymizeDirectory returns the hash of a directory, or an empty string
+// for none. A directory that is already hashed is kept.
+func (a *Anonymizer) AnonymizeDirectory(dir string) string {
+	if dir == "" || IsHash(dir) {
+		return dir
+	}
+	return a.Hash(dir)
+}
@@ -0,0 +1,372 @@
+package privacy
+
+import (
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Finding is a secret found in a command. Start and End are byte offsets
+// into the command; the secret itself is never kept.
+type Finding struct {
+	Detector string `json:"detector"`
+	Start    int    `json:"start"`
+	End      int    `json:"end"`
+}
+
+// Detector finds secrets in a command
+type Detector interface {
+	// Name identifies the detector and labels its redactions
+	Name() string
+	// Detect returns the spans of the secrets in a command
+	Detect(command string) []Finding
+}
+
+// Label is the placeholder a redaction by the named detector leaves, such
+// as [AWS_ACCESS_KEY] for aws-access-key
+func Label(detector string) string {
+	return "[" + strings.ToUpper(strings.ReplaceAll(detector, "-", "_")) + "]"
+}
+
+// DetectorRegistry holds detectors by priority: when findings overlap, the
+// one from the detector registered first wins
+type DetectorRegistry struct {
+	detectors []Detector
+}
+
+// NewDetectorRegistry creates a registry with the given detectors
+func NewDetectorRegistry(detectors ...Detector) *DetectorRegistry {
+	return &DetectorRegistry{detectors: detectors}
+}
+
+// Register adds a detector after the ones already registered
+func (r *DetectorRegistry) Register(detector Detector) {
+	r.detectors = append(r.detectors, detector)
+}
+
+// Detectors returns the registered detectors by priority
+func (r *DetectorRegistry) Detectors() []Detector {
+	return r.detectors
+}
+
+// labelPattern matches the labels of redactions, which are not secrets
+var labelPattern = regexp.MustCompile(`\[[A-Z][A-Z_]*\]`)
+
+// Detect returns the non-overlapping findings in a command, by position.
+// Labels left by earlier redactions are never reported, so redacting a
+// command again leaves it as it is.
+func (r *DetectorRegistry) Detect(command string) []Finding {
+	var labels []Finding
+	for _, span := range labelPattern.FindAllStringIndex(command, -1) {
+		labels = append(labels, Finding{Start: span[0], End: span[1]})
+	}
+
+	var accepted []Finding
+	for _, detector := range r.detectors {
+		for _, finding := range detector.Detect(command) {
+			if finding.End <= finding.Start || overlaps(accepted, finding) || within(labels, finding) {
+				continue
+			}
+			accepted = append(accepted, finding)
+		}
+	}
+	sort.Slice(accepted, func(i, j int) bool { return accepted[i].Start < accepted[j].Start })
+	return accepted
+}
+
+// Redact replaces each secret in a command with the label of the detector
+// that found it
+func (r *DetectorRegistry) Redact(command string) (string, []Finding) {
+	findings := r.Detect(command)
+	if len(findings) == 0 {
+		return command, nil
+	}
+
+	var redacted strings.Builder
+	last := 0
+	for _, finding := range findings {
+		redacted.WriteString(command[last:finding.Start])
+		redacted.WriteString(Label(finding.Detector))
+		last = finding.End
+	}
+	redacted.WriteString(command[last:])
+	return redacted.String(), findings
+}
+
+// overlaps reports whether a finding overlaps one of the accepted ones
+func overlaps(accepted []Finding, finding Finding) bool {
+	for _, a := range accepted {
+		if finding.Start < a.End && a.Start < finding.End {
+			return true
+		}
+	}
+	return false
+}
+
+// within reports whether a finding lies inside one of the spans
+func within(spans []Finding, finding Finding) bool {
+	for _, span := range spans {
+		if span.Start <= finding.Start && finding.End <= span.End {
+			return true
+		}
+	}
+	return false
+}
+
+// DefaultDetectors returns the built-in detectors enabled by a sanitization
+// configuration: known token formats first, then secrets recognized by
+// their context, the custom sensitive patterns, and high-entropy strings
+func DefaultDetectors(config *SanitizationConfig) *DetectorRegistry {
+	registry := NewDetectorRegistry()
+	if config.SanitizeTokens {
+		for _, detector := range tokenFormatDetectors {
+			registry.Register(detector)
+		}
+		registry.Register(authHeaderDetector)
+	}
+	if config.SanitizePasswords {
+		registry.Register(secretFlagDetector)
+		registry.Register(mysqlPasswordDetector)
+		registry.Register(basicAuthDetector)
+		registry.Register(passwordDetector)
+	}
+	if config.SanitizeTokens {
+		registry.Register(tokenDetector)
+	}
+	if patterns := SensitivePatternDetector(config.SensitivePatterns); patterns != nil {
+		registry.Register(patterns)
+	}
+	if config.SanitizeTokens {
+		registry.Register(NewEntropyDetector())
+	}
+	return registry
+}
+
+// RegexDetector finds secrets with regular expressions. When a pattern has
+// a capture group, only the first group is the secret; otherwise the whole
+// match is.
+type RegexDetector struct {
+	name     string
+	patterns []*regexp.Regexp
+}
+
+// NewRegexDetector creates a detector from patterns, which must compile
+func NewRegexDetector(name string, patterns ...string) *RegexDetector {
+	detector := &RegexDetector{name: name}
+	for _, pattern := range patterns {
+		detector.patterns = append(detector.patterns, regexp.MustCompile(pattern))
+	}
+	return detector
+}
+
+// Name identifies the detector
+func (d *RegexDetector) Name() string {
+	return d.name
+}
+
+// Detect returns the spans matched by the patterns
+func (d *RegexDetector) Detect(command string) []Finding {
+	var findings []Finding
+	for _, re := range d.patterns {
+		for _, match := range re.FindAllStringSubmatchIndex(command, -1) {
+			start, end := match[0], match[1]
+			if len(match) >= 4 {
+				if match[2] < 0 {
+					continue
+				}
+				start, end = match[2], match[3]
+			}
+			findings = append(findings, Finding{Detector: d.name, Start: start, End: end})
+		}
+	}
+	return findings
+}
+
+// SensitivePatternDetector redacts whole matches of the sensitive patterns,
+// case-insensitively. Invalid patterns are skipped; it returns nil when no
+// pattern is valid.
+func SensitivePatternDetector(patterns []string) *RegexDetector {
+	detector := &RegexDetector{name: "sensitive-pattern"}
+	for _, pattern := range patterns {
+		if re, err := regexp.Compile(`(?i)(?:` + pattern + `)`); err == nil {
+			detector.patterns = append(detector.patterns, re)
+		}
+	}
+	if len(detector.patterns) == 0 {
+		return nil
+	}
+	return detector
+}
+
+// secretValue matches a quoted or unquoted argument value
+const secretValue = `("[^"]*"|'[^']*'|[^\s"']+)`
+
+var (
+	tokenFormatDetectors = []Detector{
+		NewRegexDetector("private-key",
+			`(?s)-----BEGIN[A-Z ]*PRIVATE KEY(?: BLOCK)?-----.*?(?:-----END[A-Z ]*PRIVATE KEY(?: BLOCK)?-----|$)`),
+		NewRegexDetector("aws-access-key",
+			`\b((?:AKIA|ASIA|ABIA|ACCA|AGPA|AIDA|AROA)[0-9A-Z]{16})\b`),
+		NewRegexDetector("aws-secret-key",
+			`(?i)aws_?secret_?access_?key(?:\s*[=:]\s*|\s+)["']?([A-Za-z0-9/+=]{40})`),
+		NewRegexDetector("github-token",
+			`\b((?:ghp|gho|ghu|ghs|ghr)_[A-Za-z0-9]{36,255})\b`,
+			`\b(github_pat_[A-Za-z0-9_]{22,255})\b`),
+		NewRegexDetector("slack-token",
+			`\b(xox[abposr]-[A-Za-z0-9-]{10,})`,
+			`(https://hooks\.slack\.com/services/[A-Za-z0-9/]+)`),
+		NewRegexDetector("jwt",
+			`\b(eyJ[A-Za-z0-9_-]{5,}\.eyJ[A-Za-z0-9_-]{5,}\.[A-Za-z0-9_-]*)`),
+	}
+
+	// Credentials in HTTP headers, such as curl -H "Authorization: Bearer ..."
+	authHeaderDetector = NewRegexDetector("auth-header",
+		`(?i)\b(?:proxy-)?authorization\s*:\s*(?:(?:bearer|basic|token|digest)\s+)?([^\s"']+)`,
+		`(?i)\bx-[a-z-]*(?:key|token|secret|auth)[a-z-]*\s*:\s*([^\s"']+)`,
+		`(?i)\bbearer\s+([^\s"']+)`)
+
+	// Values of flags that take a secret, like --password hunter2
+	secretFlagDetector = NewRegexDetector("secret-flag",
+		`(?i)(?:^|\s)--?(?:password|passwd|pass|token|api-?key|secret|client-secret|access-key|secret-key|auth-token|private-key)(?:=|\s+)`+secretValue)
+
+	// Passwords attached to -p, as the MySQL clients take them: mysql -psecret
+	mysqlPasswordDetector = NewRegexDetector("mysql-password",
+		`(?:^|[\s;&|(])(?:mysql|mysqldump|mysqladmin|mysqlimport|mysqlshow|mysqlcheck|mysqlpump|mariadb|mariadb-dump)(?:\s+[^\s;&|]+)*?\s+-p`+secretValue)
+
+	// Passwords in user:password credentials, for curl -u and URLs
+	basicAuthDetector = NewRegexDetector("basic-auth",
+		`(?:^|\s)(?:-u|--user)(?:=|\s+)["']?[^\s:"']+:([^\s"'@]+)`,
+		`://[^\s/:@]+:([^\s/@]+)@`)
+
+	passwordDetector = NewRegexDetector("password",
+		`(?i)\b(?:password|passwd|pwd)\s*[=:]\s*`+secretValue)
+
+	tokenDetector = NewRegexDetector("token",
+		`(?i)\b[a-z0-9_]*(?:token|secret|api_?key)\s*[=:]\s*`+secretValue)
+)
+
+// entropyCandidate matches strings that could be encoded secrets
+var entropyCandidate = regexp.MustCompile(`[A-Za-z0-9+/_=-]{16,}`)
+
+// base64Bits is the entropy of a random base64 character, the most a
+// candidate can have per character however long it is
+const base64Bits = 6
+
+// hexString matches hex strings such as commit hashes, which are not secrets
+var hexString = regexp.MustCompile(`^[0-9a-fA-F-]+$`)
+
+// separatedIdentifier matches lowercase names joined by dashes or
+// underscores, such as pod names and snake_case identifiers
+var separatedIdentifier = regexp.MustCompile(`^[a-z0-9]+(?:[-_][a-z0-9]+)+$`)
+
+// quotedProse matches quoted text with spaces in it, such as commit
+// messages, whose words are not secrets
+var quotedProse = regexp.MustCompile(`"[^"]*\s[^"]*"|'[^']*\s[^']*'`)
+
+// EntropyDetector finds long random-looking strings. A string is reported
+// when its Shannon entropy reaches Ratio times the highest entropy a string
+// of its length can have, which stops growing once every character of the
+// base64 alphabet could appear in it, and when at least Mix of its adjacent
+// characters differ in kind (upper case, lower case, digit or symbol), as
+// in encoded data and unlike in words.
+type EntropyDetector struct {
+	MinLength int
+	Ratio     float64
+	Mix       float64
+}
+
+// NewEntropyDetector creates an entropy detector with thresholds that let
+// identifiers and commit hashes through
+func NewEntropyDetector() *EntropyDetector {
+	return &EntropyDetector{MinLength: 24, Ratio: 0.85, Mix: 0.4}
+}
+
+// Name identifies the detector
+func (d *EntropyDetector) Name() string {
+	return "high-entropy"
+}
+
+// Detect returns the high-entropy strings of a command
+func (d *EntropyDetector) Detect(command string) []Finding {
+	var prose []Finding
+	for _, span := range quotedProse.FindAllStringIndex(command, -1) {
+		prose = append(prose, Finding{Start: span[0], End: span[1]})
+	}
+
+	var findings []Finding
+	for _, span := range entropyCandidate.FindAllStringIndex(command, -1) {
+		start, end := span[0], span[1]
+		// Paths and the words of messages are not secrets
+		if strings.HasPrefix(command[start:end], "/") || (start > 0 && strings.ContainsRune("/.~", rune(command[start-1]))) {
+			continue
+		}
+		if within(prose, Finding{Start: start, End: end}) {
+			continue
+		}
+		if d.isSecret(strings.Trim(command[start:end], "=-_")) {
+			findings = append(findings, Finding{Detector: d.Name(), Start: start, End: end})
+		}
+	}
+	return findings
+}
+
+// isSecret reports whether a candidate string looks random enough
+func (d *EntropyDetector) isSecret(candidate string) bool {
+	if len(candidate) < d.MinLength || hexString.MatchString(candidate) || separatedIdentifier.MatchString(candidate) {
+		return false
+	}
+	hasLetter := strings.IndexFunc(candidate, unicode.IsLetter) >= 0
+	hasDigit := strings.IndexFunc(candidate, unicode.IsDigit) >= 0
+	if !hasLetter || !hasDigit || characterMix(candidate) < d.Mix {
+		return false
+	}
+	return ShannonEntropy(candidate) >= d.Ratio*math.Min(math.Log2(float64(len(candidate))), base64Bits)
+}
+
+// characterMix returns the share of adjacent characters of a string that
+// differ in kind
+func characterMix(s string) float64 {
+	kind := func(r rune) int {
+		switch {
+		case unicode.IsUpper(r):
+			return 0
+		case unicode.IsLower(r):
+			return 1
+		case unicode.IsDigit(r):
+			return 2
+		}
+		return 3
+	}
+	runes := []rune(s)
+	if len(runes) < 2 {
+		return 0
+	}
+	changes := 0
+	for i := 1; i < len(runes); i++ {
+		if kind(runes[i]) != kind(runes[i-1]) {
+			changes++
+		}
+	}
+	return float64(changes) / float64(len(runes)-1)
+}
+
+// ShannonEntropy returns the entropy of a string in bits per character
+func ShannonEntropy(s string) float64 {
+	if s == "" {
+		return 0
+	}
+	counts := make(map[rune]int)
+	total := 0
+	for _, r := range s {
+		counts[r]++
+		total++
+	}
+	entropy := 0.0
+	for _, count := range counts {
+		p := float64(count) / float64(total)
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
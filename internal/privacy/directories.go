@@ -0,0 +1,335 @@
+package privacy
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// TrackingMode is how much of the commands run in a directory is recorded
+type TrackingMode string
+
+const (
+	// TrackFull records commands as the sanitizer leaves them
+	TrackFull TrackingMode = "full"
+	// TrackBaseCommand records only the programs a command runs
+	TrackBaseCommand TrackingMode = "base-command-only"
+	// TrackIgnore records nothing
+	TrackIgnore TrackingMode = "ignore"
+)
+
+const (
+	// IgnoreFileName marks a directory tree whose commands are not recorded
+	IgnoreFileName = ".termonautignore"
+	// RulesFileName sets the tracking mode of a directory tree
+	RulesFileName = ".termonaut.toml"
+
+	// maxCachedDirectories bounds the decision cache
+	maxCachedDirectories = 256
+)
+
+// ParseTrackingMode checks a tracking mode name
+func ParseTrackingMode(mode string) (TrackingMode, error) {
+	switch m := TrackingMode(mode); m {
+	case TrackFull, TrackBaseCommand, TrackIgnore:
+		return m, nil
+	}
+	return "", fmt.Errorf("invalid tracking mode %q, must be one of %s, %s, %s", mode, TrackFull, TrackBaseCommand, TrackIgnore)
+}
+
+// DirectoryRule sets the tracking mode of the directories matching a path.
+// The path may start with ~ and hold glob patterns; a rule applies to the
+// matching directories and everything below them.
+type DirectoryRule struct {
+	Path string       `mapstructure:"path" json:"path"`
+	Mode TrackingMode `mapstructure:"mode" json:"mode"`
+}
+
+// ValidateDirectoryRules checks the directory rules of the configuration
+func ValidateDirectoryRules(rules []DirectoryRule) error {
+	for i, rule := range rules {
+		if strings.TrimSpace(rule.Path) == "" {
+			return fmt.Errorf("directory_rules[%d]: path is required", i)
+		}
+		if _, err := filepath.Match(expandHome(rule.Path), ""); err != nil {
+			return fmt.Errorf("directory_rules[%d]: invalid path pattern %q: %w", i, rule.Path, err)
+		}
+		if _, err := ParseTrackingMode(string(rule.Mode)); err != nil {
+			return fmt.Errorf("directory_rules[%d]: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// Decision is the tracking mode of a directory and the rule it comes from
+type Decision struct {
+	Mode      TrackingMode `json:"mode"`
+	Source    string       `json:"source"`              // the rule file, "config" or "default"
+	Directory string       `json:"directory,omitempty"` // the directory the rule applies from
+	Rule      string       `json:"rule,omitempty"`      // the path of a config rule
+	Error     string       `json:"error,omitempty"`     // why the rule file could not be read
+	Cached    bool         `json:"cached"`
+}
+
+// restrictiveness orders the tracking modes from recording the most to the
+// least
+func (m TrackingMode) restrictiveness() int {
+	switch m {
+	case TrackIgnore:
+		return 2
+	case TrackBaseCommand:
+		return 1
+	}
+	return 0
+}
+
+// DirectoryRules decides how commands run in a directory are tracked, from
+// rule files found upward from it and the rules of the configuration. The
+// most restrictive rule on the path wins, so a rule file in a deeper
+// directory can tighten the tracking but never loosen it; between rules of
+// the same mode, the deepest one is reported.
+type DirectoryRules struct {
+	rules       []DirectoryRule
+	fingerprint string
+	cacheDir    string
+}
+
+// NewDirectoryRules creates the directory rules. Decisions are cached in
+// cacheDir, one file per directory, if it is not empty.
+func NewDirectoryRules(rules []DirectoryRule, cacheDir string) *DirectoryRules {
+	expanded := make([]DirectoryRule, len(rules))
+	for i, rule := range rules {
+		expanded[i] = DirectoryRule{Path: filepath.Clean(expandHome(rule.Path)), Mode: rule.Mode}
+	}
+	fingerprint, _ := json.Marshal(expanded)
+	return &DirectoryRules{rules: expanded, fingerprint: string(fingerprint), cacheDir: cacheDir}
+}
+
+// Decide returns the tracking mode of a directory. A cached decision is
+// used as long as the directories it looked in and the rule file it comes
+// from are unchanged, since adding or removing a rule file changes the
+// modification time of its directory.
+func (r *DirectoryRules) Decide(dir string) *Decision {
+	dir = filepath.Clean(dir)
+
+	if entry := r.loadEntry(dir); entry != nil && entry.fresh() {
+		decision := *entry.Decision
+		decision.Cached = true
+		return &decision
+	}
+
+	decisions, stamps := r.walk(dir, false)
+	decision := decisions[0]
+	if r.cacheDir != "" {
+		r.saveEntry(dir, &directoryEntry{Decision: decision, Stamps: stamps})
+	}
+	return decision
+}
+
+// Explain returns every rule that applies to a directory, the one that
+// decides first, without using the cache
+func (r *DirectoryRules) Explain(dir string) []*Decision {
+	decisions, _ := r.walk(filepath.Clean(dir), true)
+	return decisions
+}
+
+// walk looks for rules from dir up to the root and returns them with the
+// deciding one first. Unless all is set, it stops at the first ignore rule
+// since nothing above can be more restrictive. It also returns the
+// modification times of the directories it looked in and of the rule files
+// it read.
+func (r *DirectoryRules) walk(dir string, all bool) ([]*Decision, map[string]int64) {
+	var decisions []*Decision
+	stamps := make(map[string]int64)
+	deciding := -1
+
+	add := func(decision *Decision) {
+		decisions = append(decisions, decision)
+		if deciding < 0 || decision.Mode.restrictiveness() > decisions[deciding].Mode.restrictiveness() {
+			deciding = len(decisions) - 1
+		}
+	}
+
+	for current := dir; ; current = filepath.Dir(current) {
+		stamps[current] = modTime(current)
+		ignorePath := filepath.Join(current, IgnoreFileName)
+		if stamp := modTime(ignorePath); stamp != 0 {
+			stamps[ignorePath] = stamp
+			add(&Decision{Mode: TrackIgnore, Source: ignorePath, Directory: current})
+		}
+		// Editing a rule file changes only its own modification time
+		rulesPath := filepath.Join(current, RulesFileName)
+		if stamp := modTime(rulesPath); stamp != 0 {
+			stamps[rulesPath] = stamp
+			add(readRulesFile(rulesPath, current))
+		}
+		for _, rule := range r.rules {
+			if matched, _ := filepath.Match(rule.Path, current); matched {
+				add(&Decision{Mode: rule.Mode, Source: "config", Directory: current, Rule: rule.Path})
+			}
+		}
+
+		parent := filepath.Dir(current)
+		if parent == current || (!all && deciding >= 0 && decisions[deciding].Mode == TrackIgnore) {
+			break
+		}
+	}
+
+	if deciding > 0 {
+		decision := decisions[deciding]
+		copy(decisions[1:deciding+1], decisions[:deciding])
+		decisions[0] = decision
+	}
+	decisions = append(decisions, &Decision{Mode: TrackFull, Source: "default"})
+	return decisions, stamps
+}
+
+// readRulesFile reads the mode of a .termonaut.toml. A file that cannot be
+// read ignores the commands, so a mistake never records more than meant.
+func readRulesFile(path, dir string) *Decision {
+	decision := &Decision{Mode: TrackIgnore, Source: path, Directory: dir}
+
+	v := viper.New()
+	v.SetConfigFile(path)
+	v.SetConfigType("toml")
+	if err := v.ReadInConfig(); err != nil {
+		decision.Error = fmt.Sprintf("failed to read %s: %v", path, err)
+		return decision
+	}
+	mode, err := ParseTrackingMode(v.GetString("mode"))
+	if err != nil {
+		decision.Error = err.Error()
+		return decision
+	}
+	decision.Mode = mode
+	return decision
+}
+
+// directoryEntry is a cached decision and the modification times of the
+// paths it was made from; 0 means missing
+type directoryEntry struct {
+	Decision *Decision        `json:"decision"`
+	Stamps   map[string]int64 `json:"stamps"`
+}
+
+// fresh reports whether nothing the decision was made from has changed
+func (e *directoryEntry) fresh() bool {
+	if e.Decision == nil {
+		return false
+	}
+	for path, stamp := range e.Stamps {
+		if modTime(path) != stamp {
+			return false
+		}
+	}
+	return true
+}
+
+// entryPath returns the cache file of a directory. Its name also depends on
+// the config rules, so decisions made with other rules are not used.
+func (r *DirectoryRules) entryPath(dir string) string {
+	sum := sha256.Sum256([]byte(r.fingerprint + "\x00" + dir))
+	return filepath.Join(r.cacheDir, hex.EncodeToString(sum[:16])+".json")
+}
+
+// loadEntry reads the cached decision of a directory, or nil if there is
+// none or it cannot be read
+func (r *DirectoryRules) loadEntry(dir string) *directoryEntry {
+	if r.cacheDir == "" {
+		return nil
+	}
+	data, err := os.ReadFile(r.entryPath(dir))
+	if err != nil {
+		return nil
+	}
+	var entry directoryEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil
+	}
+	return &entry
+}
+
+// saveEntry caches the decision of a directory and evicts the oldest
+// entries beyond the limit. Failing to is not an error since the rules are
+// read again next time. Each shell writes its own temporary file, so shells
+// deciding at once do not overwrite each other's.
+func (r *DirectoryRules) saveEntry(dir string, entry *directoryEntry) {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(r.cacheDir, 0700); err != nil {
+		return
+	}
+	tmp, err := os.CreateTemp(r.cacheDir, "entry-*.tmp")
+	if err != nil {
+		return
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), r.entryPath(dir))
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return
+	}
+	r.evictEntries()
+}
+
+// evictEntries removes the oldest cached decisions beyond the limit
+func (r *DirectoryRules) evictEntries() {
+	files, err := os.ReadDir(r.cacheDir)
+	if err != nil {
+		return
+	}
+	type cached struct {
+		path    string
+		written int64
+	}
+	var entries []cached
+	for _, file := range files {
+		if filepath.Ext(file.Name()) != ".json" {
+			continue
+		}
+		path := filepath.Join(r.cacheDir, file.Name())
+		entries = append(entries, cached{path: path, written: modTime(path)})
+	}
+	if len(entries) <= maxCachedDirectories {
+		return
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].written < entries[j].written })
+	for _, entry := range entries[:len(entries)-maxCachedDirectories] {
+		os.Remove(entry.path)
+	}
+}
+
+// modTime returns the modification time of a path, or 0 if it is missing
+func modTime(path string) int64 {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0
+	}
+	return info.ModTime().UnixNano()
+}
+
+// expandHome replaces a leading ~ with the home directory
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, strings.TrimPrefix(path, "~"))
+}
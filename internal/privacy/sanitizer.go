@@ -7,7 +7,8 @@ import (
 
 // CommandSanitizer handles command sanitization for privacy protection
 type CommandSanitizer struct {
-	config *SanitizationConfig
+	config    *SanitizationConfig
+	detectors *DetectorRegistry
 }
 
 // SanitizationConfig holds configuration for command sanitization
@@ -19,6 +20,7 @@ type SanitizationConfig struct {
 	SanitizeTokens     bool     `json:"sanitize_tokens"`
 	SanitizeEmails     bool     `json:"sanitize_emails"`
 	IgnoreCommands     []string `json:"ignore_commands"`     // Commands to completely ignore
+	OptOutWords        []string `json:"opt_out_words"`       // Words that make any command ignored
 	SensitivePatterns  []string `json:"sensitive_patterns"`  // Custom patterns to sanitize
 	PreservePrefixes   []string `json:"preserve_prefixes"`   // Prefixes to keep (like git, npm)
 	MaxArgLength       int      `json:"max_arg_length"`      // Max length for arguments
@@ -29,7 +31,13 @@ func NewCommandSanitizer(config *SanitizationConfig) *CommandSanitizer {
 	if config == nil {
 		config = DefaultSanitizationConfig()
 	}
-	return &CommandSanitizer{config: config}
+	return &CommandSanitizer{config: config, detectors: DefaultDetectors(config)}
+}
+
+// Detectors returns the detectors that find secrets to redact; more can be
+// registered on it
+func (cs *CommandSanitizer) Detectors() *DetectorRegistry {
+	return cs.detectors
 }
 
 // DefaultSanitizationConfig returns default sanitization configuration
@@ -60,53 +68,103 @@ func DefaultSanitizationConfig() *SanitizationConfig {
 	}
 }
 
-// SanitizeCommand sanitizes a command for privacy protection
+// Result describes what sanitization does with a command
+type Result struct {
+	Command   string `json:"command"`
+	Stored    string `json:"stored"`               // what gets recorded, empty when ignored
+	Ignored   bool   `json:"ignored"`              // the command is not recorded at all
+	IgnoredBy string `json:"ignored_by,omitempty"` // the opt-out or ignore entry that matched
+	OptedOut  bool   `json:"opted_out,omitempty"`  // IgnoredBy is an opt-out word
+	Preserved bool   `json:"preserved"`            // the tool keeps its flags as they are, only other arguments are sanitized
+
+	// Secrets redacted from the command, with offsets into it after
+	// trimming spaces
+	Redactions []Finding `json:"redactions,omitempty"`
+}
+
+// SanitizeCommand sanitizes a command for privacy protection. The second
+// return value reports whether the command should not be recorded at all.
 func (cs *CommandSanitizer) SanitizeCommand(command string) (string, bool) {
-	if !cs.config.Enabled {
-		return command, false
-	}
+	result := cs.Inspect(command)
+	return result.Stored, result.Ignored
+}
 
-	originalCommand := command
+// Inspect sanitizes a command and reports why it is stored the way it is
+func (cs *CommandSanitizer) Inspect(command string) *Result {
+	result := &Result{Command: command}
 	command = strings.TrimSpace(command)
-	
+
 	if command == "" {
-		return "", true
+		result.Ignored = true
+		return result
 	}
 
-	// Check if command should be completely ignored
-	if cs.shouldIgnoreCommand(command) {
-		return "", true // Return empty string to ignore this command
+	// Opt-out words apply even with the sanitizer disabled
+	if word := cs.optOutWord(command); word != "" {
+		result.Ignored = true
+		result.IgnoredBy = word
+		result.OptedOut = true
+		return result
 	}
 
-	// Parse command into parts
-	parts := strings.Fields(command)
-	if len(parts) == 0 {
-		return "", true
+	if !cs.config.Enabled {
+		result.Stored = command
+		return result
 	}
 
+	// Check if command should be completely ignored
+	if ignored := cs.ignoredBy(command); ignored != "" {
+		result.Ignored = true
+		result.IgnoredBy = ignored
+		return result
+	}
+
+	// Redact the secrets found by the detectors
+	redacted, findings := cs.detectors.Redact(command)
+	result.Redactions = findings
+
+	// Parse command into parts
+	parts := strings.Fields(redacted)
 	baseCommand := parts[0]
 	args := parts[1:]
 
-	// Preserve known safe prefixes
-	if cs.isPreservedPrefix(baseCommand) {
-		sanitizedArgs := cs.sanitizeArguments(args, baseCommand)
-		return baseCommand + " " + strings.Join(sanitizedArgs, " "), false
-	}
-
-	// Apply general sanitization
-	sanitizedCommand := cs.applySanitization(command)
-	
-	return sanitizedCommand, sanitizedCommand != originalCommand
+	// Every command has its URLs, paths and emails sanitized; known safe
+	// tools keep their flags as they are
+	result.Preserved = cs.isPreservedPrefix(baseCommand)
+	result.Stored = strings.Join(append([]string{baseCommand}, cs.sanitizeArguments(args, result.Preserved)...), " ")
+	return result
 }
 
-// shouldIgnoreCommand checks if a command should be completely ignored
-func (cs *CommandSanitizer) shouldIgnoreCommand(command string) bool {
+// ignoredBy returns the ignored command a command runs, or "" if it runs
+// none of them
+func (cs *CommandSanitizer) ignoredBy(command string) string {
+	lower := strings.ToLower(command)
 	for _, ignored := range cs.config.IgnoreCommands {
-		if strings.HasPrefix(strings.ToLower(command), strings.ToLower(ignored)) {
-			return true
+		prefix := strings.ToLower(strings.TrimSpace(ignored))
+		if prefix == "" {
+			continue
+		}
+		if lower == prefix || strings.HasPrefix(lower, prefix+" ") || strings.HasPrefix(lower, prefix+"\t") {
+			return ignored
 		}
 	}
-	return false
+	return ""
+}
+
+// optOutWord returns the opt-out word a command contains, or "" if it
+// contains none of them. A word matches when no letter, digit or underscore
+// touches it, so words such as --password or .env match too.
+func (cs *CommandSanitizer) optOutWord(command string) string {
+	for _, word := range cs.config.OptOutWords {
+		if word == "" {
+			continue
+		}
+		re, err := regexp.Compile(`(?i)(^|\W)` + regexp.QuoteMeta(word) + `(\W|$)`)
+		if err == nil && re.MatchString(command) {
+			return word
+		}
+	}
+	return ""
 }
 
 // isPreservedPrefix checks if a command starts with a preserved prefix
@@ -119,31 +177,26 @@ func (cs *CommandSanitizer) isPreservedPrefix(baseCommand string) bool {
 	return false
 }
 
-// sanitizeArguments sanitizes command arguments based on command type
-func (cs *CommandSanitizer) sanitizeArguments(args []string, baseCommand string) []string {
+// sanitizeArguments sanitizes command arguments. Flags are kept as they
+// are when keepFlags is set; otherwise the values of --flag=value flags are
+// sanitized too.
+func (cs *CommandSanitizer) sanitizeArguments(args []string, keepFlags bool) []string {
 	var sanitizedArgs []string
 	
 	for _, arg := range args {
 		sanitizedArg := arg
 		
-		// Skip flags and options (starting with -)
 		if strings.HasPrefix(arg, "-") {
-			sanitizedArgs = append(sanitizedArgs, arg)
-			continue
-		}
-		
-		// Apply sanitization based on context
-		if cs.config.SanitizeURLs && cs.isURL(arg) {
-			sanitizedArg = cs.sanitizeURL(arg)
-		} else if cs.config.SanitizeFilePaths && cs.isFilePath(arg) {
-			sanitizedArg = cs.sanitizeFilePath(arg)
-		} else if cs.config.SanitizeEmails && cs.isEmail(arg) {
-			sanitizedArg = "[EMAIL]"
-		} else if cs.config.SanitizeTokens && cs.isToken(arg) {
-			sanitizedArg = "[TOKEN]"
+			// Skip flags and options (starting with -)
+			if keepFlags {
+				sanitizedArgs = append(sanitizedArgs, arg)
+				continue
+			}
+			if flag, value, found := strings.Cut(arg, "="); found {
+				sanitizedArg = flag + "=" + cs.sanitizeArgument(value)
+			}
 		} else {
-			// Apply general pattern sanitization
-			sanitizedArg = cs.sanitizePatterns(arg)
+			sanitizedArg = cs.sanitizeArgument(arg)
 		}
 		
 		// Truncate long arguments
@@ -157,26 +210,19 @@ func (cs *CommandSanitizer) sanitizeArguments(args []string, baseCommand string)
 	return sanitizedArgs
 }
 
-// applySanitization applies general sanitization patterns
-func (cs *CommandSanitizer) applySanitization(command string) string {
-	sanitized := command
-	
-	if cs.config.SanitizePasswords {
-		sanitized = cs.sanitizePasswords(sanitized)
-	}
-	
-	if cs.config.SanitizeTokens {
-		sanitized = cs.sanitizeTokens(sanitized)
-	}
-	
-	// Apply custom sensitive patterns
-	for _, pattern := range cs.config.SensitivePatterns {
-		if re, err := regexp.Compile(`(?i)`+pattern); err == nil {
-			sanitized = re.ReplaceAllString(sanitized, "[REDACTED]")
+// sanitizeArgument hides the parts of a URL, path or email argument that
+// the configuration sanitizes
+func (cs *CommandSanitizer) sanitizeArgument(arg string) string {
+	if cs.isURL(arg) {
+		if cs.config.SanitizeURLs {
+			return cs.sanitizeURL(arg)
 		}
+	} else if cs.config.SanitizeFilePaths && cs.isFilePath(arg) {
+		return cs.sanitizeFilePath(arg)
+	} else if cs.config.SanitizeEmails && cs.isEmail(arg) {
+		return "[EMAIL]"
 	}
-	
-	return sanitized
+	return arg
 }
 
 // Helper methods for detection and sanitization
@@ -189,8 +235,11 @@ func (cs *CommandSanitizer) isURL(arg string) bool {
 
 func (cs *CommandSanitizer) sanitizeURL(url string) string {
 	// Keep protocol and domain, hide path and parameters
-	re := regexp.MustCompile(`^(https?://[^/]+).*`)
+	re := regexp.MustCompile(`^([A-Za-z][A-Za-z0-9+.-]*://[^/?#]+)(.*)`)
 	if matches := re.FindStringSubmatch(url); len(matches) > 1 {
+		if matches[2] == "" || matches[2] == "/" {
+			return url
+		}
 		return matches[1] + "/[PATH]"
 	}
 	return "[URL]"
@@ -204,9 +253,9 @@ func (cs *CommandSanitizer) isFilePath(arg string) bool {
 }
 
 func (cs *CommandSanitizer) sanitizeFilePath(path string) string {
-	// Keep just the filename or directory name
+	// Keep just the filename or directory name; ./name reveals nothing more
 	parts := strings.Split(path, "/")
-	if len(parts) > 1 {
+	if len(parts) > 1 && !(len(parts) == 2 && parts[0] == ".") {
 		return "[PATH]/" + parts[len(parts)-1]
 	}
 	return path
@@ -217,65 +266,15 @@ func (cs *CommandSanitizer) isEmail(arg string) bool {
 	return re.MatchString(arg)
 }
 
-func (cs *CommandSanitizer) isToken(arg string) bool {
-	// Detect common token patterns
-	if len(arg) > 20 && (strings.Contains(arg, "_") || 
-		regexp.MustCompile(`^[a-zA-Z0-9+/=]{20,}$`).MatchString(arg)) {
-		return true
-	}
-	// Common prefixes for tokens
-	prefixes := []string{"ghp_", "gho_", "ghu_", "ghs_", "sk-", "pk_", "rk_"}
-	for _, prefix := range prefixes {
-		if strings.HasPrefix(arg, prefix) {
-			return true
-		}
-	}
-	return false
-}
+// homeDirPattern matches the home directory prefix of a path
+var homeDirPattern = regexp.MustCompile(`^(/home/[^/]+|/Users/[^/]+|/root)(/|$)`)
 
-func (cs *CommandSanitizer) sanitizePasswords(command string) string {
-	patterns := []string{
-		`password\s*[=:]\s*\S+`,
-		`passwd\s+\S+`,
-		`-p\s+\S+`,
-		`--password\s+\S+`,
+// SanitizeDirectory anonymizes a working directory according to the
+// file-path settings. The home directory becomes "~" so the username is not
+// exposed; the rest of the path is kept so activity can still be grouped.
+func (cs *CommandSanitizer) SanitizeDirectory(path string) string {
+	if !cs.config.Enabled || !cs.config.SanitizeFilePaths {
+		return path
 	}
-	
-	result := command
-	for _, pattern := range patterns {
-		if re, err := regexp.Compile(`(?i)`+pattern); err == nil {
-			result = re.ReplaceAllString(result, "[PASSWORD]")
-		}
-	}
-	return result
-}
-
-func (cs *CommandSanitizer) sanitizeTokens(command string) string {
-	patterns := []string{
-		`token\s*[=:]\s*\S+`,
-		`--token\s+\S+`,
-		`-t\s+\S+`,
-		`bearer\s+\S+`,
-	}
-	
-	result := command
-	for _, pattern := range patterns {
-		if re, err := regexp.Compile(`(?i)`+pattern); err == nil {
-			result = re.ReplaceAllString(result, "[TOKEN]")
-		}
-	}
-	return result
+	return homeDirPattern.ReplaceAllString(path, "~$2")
 }
-
-func (cs *CommandSanitizer) sanitizePatterns(arg string) string {
-	// Apply custom sensitive patterns
-	result := arg
-	for _, pattern := range cs.config.SensitivePatterns {
-		if re, err := regexp.Compile(`(?i)`+pattern); err == nil {
-			if re.MatchString(arg) {
-				return "[REDACTED]"
-			}
-		}
-	}
-	return result
-} 
\ No newline at end of file
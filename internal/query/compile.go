@@ -0,0 +1,341 @@
+package query
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// field value kinds
+const (
+	kindText = iota
+	kindNumber
+	kindTime
+)
+
+// fieldDef maps a query field to a SQL expression
+type fieldDef struct {
+	expr        string
+	kind        int
+	derived     bool // computed by the classifier, not stored in the database
+	bucket      bool // a time bucket, ordered chronologically when grouped
+	description string
+}
+
+// fields are the only names a query can reference. Commands are aliased c,
+// sessions s and classifier-derived fields d. Categories are the ones stored
+// with the commands, kept up to date by "categories reclassify".
+var fields = map[string]fieldDef{
+	"id":          {expr: "c.id", kind: kindNumber, description: "command id"},
+	"timestamp":   {expr: "c.timestamp", kind: kindTime, description: "when the command ran"},
+	"session_id":  {expr: "c.session_id", kind: kindNumber, description: "terminal session id"},
+	"command":     {expr: "c.command", kind: kindText, description: "full command line"},
+	"exit_code":   {expr: "c.exit_code", kind: kindNumber, description: "exit status"},
+	"cwd":         {expr: "c.cwd", kind: kindText, description: "working directory"},
+	"duration_ms": {expr: "c.duration_ms", kind: kindNumber, description: "run time in milliseconds"},
+	"failed":      {expr: "(CASE WHEN c.exit_code != 0 THEN 1 ELSE 0 END)", kind: kindNumber, description: "1 if the command failed, else 0"},
+	"shell":       {expr: "s.shell_type", kind: kindText, description: "shell of the session"},
+	"tool":        {expr: "d.tool", kind: kindText, derived: true, description: "program run, e.g. git"},
+	"subcommand":  {expr: "d.subcommand", kind: kindText, derived: true, description: "subcommand, e.g. commit"},
+	"category":    {expr: "(CASE WHEN instr(c.category, '/') > 0 THEN substr(c.category, 1, instr(c.category, '/') - 1) ELSE c.category END)", kind: kindText, description: "top-level command category, e.g. git"},
+	"subcategory": {expr: "c.category", kind: kindText, description: "most specific category, e.g. git/remote"},
+	"hour":        {expr: "strftime('%Y-%m-%d %H:00', local_time(c.timestamp))", kind: kindText, bucket: true, description: "hour bucket"},
+	"day":         {expr: "local_day(c.timestamp)", kind: kindText, bucket: true, description: "day bucket"},
+	"week":        {expr: "DATE(local_day(c.timestamp), 'weekday 0', '-6 days')", kind: kindText, bucket: true, description: "week bucket (Monday)"},
+	"month":       {expr: "strftime('%Y-%m', local_day(c.timestamp))", kind: kindText, bucket: true, description: "month bucket"},
+	"year":        {expr: "strftime('%Y', local_day(c.timestamp))", kind: kindText, bucket: true, description: "year bucket"},
+	"weekday":     {expr: "substr('SunMonTueWedThuFriSat', 1 + 3 * strftime('%w', local_day(c.timestamp)), 3)", kind: kindText, description: "day of the week, Mon-Sun"},
+	"hour_of_day": {expr: "CAST(strftime('%H', local_time(c.timestamp)) AS INTEGER)", kind: kindNumber, description: "hour of the day, 0-23"},
+}
+
+// relativeTimePattern matches relative times such as 30d or 6mo
+var relativeTimePattern = regexp.MustCompile(`^(\d+)(h|d|w|mo|y)$`)
+
+// Compiled is a query translated to SQL
+type Compiled struct {
+	SQL     string
+	Args    []interface{}
+	Columns []string
+	Derived bool // the SQL joins the classifier-derived fields table
+}
+
+// FieldInfo describes a queryable field
+type FieldInfo struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// Fields lists the fields a query can reference, sorted by name
+func Fields() []FieldInfo {
+	infos := make([]FieldInfo, 0, len(fields))
+	for name, def := range fields {
+		infos = append(infos, FieldInfo{Name: name, Description: def.description})
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].Name < infos[j].Name
+	})
+	return infos
+}
+
+// Compile translates a parsed query into parameterized SQL. Relative times
+// in filters are resolved against now.
+func Compile(q *Query, now time.Time) (*Compiled, error) {
+	compiled := &Compiled{}
+	used := make(map[string]bool)
+
+	lookup := func(name string) (fieldDef, error) {
+		def, exists := fields[name]
+		if !exists {
+			return fieldDef{}, fmt.Errorf("unknown field %q", name)
+		}
+		used[name] = true
+		return def, nil
+	}
+
+	aggregated := len(q.GroupBy) > 0
+	for _, item := range q.Select {
+		if item.Aggregate != nil {
+			aggregated = true
+		}
+	}
+
+	var selects, groups []string
+	grouped := make(map[string]bool)
+	bucketed := false
+	for _, name := range q.GroupBy {
+		def, err := lookup(name)
+		if err != nil {
+			return nil, err
+		}
+		if grouped[name] {
+			continue
+		}
+		grouped[name] = true
+		bucketed = bucketed || def.bucket
+		groups = append(groups, def.expr)
+		selects = append(selects, fmt.Sprintf("%s AS %q", def.expr, name))
+		compiled.Columns = append(compiled.Columns, name)
+	}
+
+	firstAggregate := ""
+	for _, item := range q.Select {
+		if item.Aggregate == nil {
+			def, err := lookup(item.Field)
+			if err != nil {
+				return nil, err
+			}
+			if grouped[item.Field] {
+				continue
+			}
+			if aggregated {
+				return nil, fmt.Errorf("field %q must be grouped with by or wrapped in an aggregate", item.Field)
+			}
+			selects = append(selects, fmt.Sprintf("%s AS %q", def.expr, item.Field))
+			compiled.Columns = append(compiled.Columns, item.Field)
+			continue
+		}
+
+		expr, err := aggregateExpr(item.Aggregate, lookup)
+		if err != nil {
+			return nil, err
+		}
+		alias := item.Aggregate.Alias()
+		selects = append(selects, fmt.Sprintf("%s AS %q", expr, alias))
+		compiled.Columns = append(compiled.Columns, alias)
+		if firstAggregate == "" {
+			firstAggregate = alias
+		}
+	}
+
+	var conditions []string
+	for _, filter := range q.Filters {
+		condition, args, err := filterExpr(filter, now, lookup)
+		if err != nil {
+			return nil, err
+		}
+		conditions = append(conditions, condition)
+		compiled.Args = append(compiled.Args, args...)
+	}
+
+	// Ordering: an output column if given, otherwise time buckets
+	// chronologically, the first aggregate descending, or newest rows first
+	var order string
+	switch {
+	case q.OrderBy != "":
+		found := false
+		for _, column := range compiled.Columns {
+			if column == q.OrderBy {
+				found = true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("cannot order by %q: not an output column (have %s)",
+				q.OrderBy, strings.Join(compiled.Columns, ", "))
+		}
+		order = fmt.Sprintf("%q", q.OrderBy)
+		if q.OrderDesc {
+			order += " DESC"
+		}
+	case bucketed:
+		order = "1"
+	case firstAggregate != "":
+		order = fmt.Sprintf("%q DESC", firstAggregate)
+	default:
+		order = "c.timestamp DESC"
+	}
+
+	var sql strings.Builder
+	sql.WriteString("SELECT " + strings.Join(selects, ", "))
+	sql.WriteString(" FROM commands c LEFT JOIN sessions s ON s.id = c.session_id")
+	for name := range used {
+		if fields[name].derived {
+			compiled.Derived = true
+		}
+	}
+	if compiled.Derived {
+		sql.WriteString(" LEFT JOIN query_fields d ON d.command = c.command")
+	}
+	if len(conditions) > 0 {
+		sql.WriteString(" WHERE " + strings.Join(conditions, " AND "))
+	}
+	if len(groups) > 0 {
+		sql.WriteString(" GROUP BY " + strings.Join(groups, ", "))
+	}
+	sql.WriteString(" ORDER BY " + order)
+	sql.WriteString(" LIMIT ?")
+	compiled.Args = append(compiled.Args, q.Limit)
+
+	compiled.SQL = sql.String()
+	return compiled, nil
+}
+
+func aggregateExpr(aggregate *Aggregate, lookup func(string) (fieldDef, error)) (string, error) {
+	if aggregate.Field == "" {
+		if aggregate.Func != "count" {
+			return "", fmt.Errorf("%s() needs a field", aggregate.Func)
+		}
+		return "COUNT(*)", nil
+	}
+
+	def, err := lookup(aggregate.Field)
+	if err != nil {
+		return "", err
+	}
+
+	switch aggregate.Func {
+	case "count":
+		return fmt.Sprintf("COUNT(%s)", def.expr), nil
+	case "distinct":
+		return fmt.Sprintf("COUNT(DISTINCT %s)", def.expr), nil
+	case "sum", "avg":
+		if def.kind != kindNumber {
+			return "", fmt.Errorf("%s() needs a numeric field, %q is not", aggregate.Func, aggregate.Field)
+		}
+		return fmt.Sprintf("%s(%s)", strings.ToUpper(aggregate.Func), def.expr), nil
+	case "min", "max":
+		return fmt.Sprintf("%s(%s)", strings.ToUpper(aggregate.Func), def.expr), nil
+	default:
+		return "", fmt.Errorf("unknown function %s() (use count, distinct, sum, avg, min or max)", aggregate.Func)
+	}
+}
+
+func filterExpr(filter Filter, now time.Time, lookup func(string) (fieldDef, error)) (string, []interface{}, error) {
+	// since/until are shorthands for timestamp comparisons
+	switch filter.Field {
+	case "since", "until":
+		if filter.Operator != "=" {
+			return "", nil, fmt.Errorf("%s only supports =", filter.Field)
+		}
+		t, err := ParseTime(filter.Value, now)
+		if err != nil {
+			return "", nil, err
+		}
+		op := ">="
+		if filter.Field == "until" {
+			op = "<"
+		}
+		return fmt.Sprintf("julianday(c.timestamp) %s julianday(?)", op), []interface{}{formatTime(t)}, nil
+	}
+
+	def, err := lookup(filter.Field)
+	if err != nil {
+		return "", nil, err
+	}
+
+	op := filter.Operator
+	switch def.kind {
+	case kindTime:
+		if op == "~" || op == "!~" {
+			return "", nil, fmt.Errorf("%s does not support %s", filter.Field, op)
+		}
+		t, err := ParseTime(filter.Value, now)
+		if err != nil {
+			return "", nil, err
+		}
+		return fmt.Sprintf("julianday(%s) %s julianday(?)", def.expr, op), []interface{}{formatTime(t)}, nil
+
+	case kindNumber:
+		if op == "~" || op == "!~" {
+			return "", nil, fmt.Errorf("%s is numeric and does not support %s", filter.Field, op)
+		}
+		value, err := strconv.ParseFloat(filter.Value, 64)
+		if err != nil {
+			return "", nil, fmt.Errorf("%s needs a number, got %q", filter.Field, filter.Value)
+		}
+		return fmt.Sprintf("%s %s ?", def.expr, op), []interface{}{value}, nil
+
+	default:
+		switch op {
+		case "~", "!~":
+			not := ""
+			if op == "!~" {
+				not = "NOT "
+			}
+			return fmt.Sprintf("COALESCE(%s, '') %sLIKE ? ESCAPE '\\'", def.expr, not),
+				[]interface{}{"%" + escapeLike(filter.Value) + "%"}, nil
+		case "!=":
+			return fmt.Sprintf("COALESCE(%s, '') != ?", def.expr), []interface{}{filter.Value}, nil
+		default:
+			return fmt.Sprintf("%s %s ?", def.expr, op), []interface{}{filter.Value}, nil
+		}
+	}
+}
+
+// ParseTime parses a date, a date and time, or a relative time before now
+func ParseTime(value string, now time.Time) (time.Time, error) {
+	if match := relativeTimePattern.FindStringSubmatch(value); match != nil {
+		n, _ := strconv.Atoi(match[1])
+		switch match[2] {
+		case "h":
+			return now.Add(-time.Duration(n) * time.Hour), nil
+		case "d":
+			return now.AddDate(0, 0, -n), nil
+		case "w":
+			return now.AddDate(0, 0, -7*n), nil
+		case "mo":
+			return now.AddDate(0, -n, 0), nil
+		default:
+			return now.AddDate(-n, 0, 0), nil
+		}
+	}
+
+	for _, layout := range []string{"2006-01-02", "2006-01-02 15:04", "2006-01-02T15:04:05", time.RFC3339} {
+		if t, err := time.ParseInLocation(layout, value, now.Location()); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("invalid time %q (use 2024-01-31, \"2024-01-31 09:00\" or a relative time like 12h, 30d, 4w, 6mo, 1y)", value)
+}
+
+// formatTime formats a time for comparison with julianday, which works in UTC
+func formatTime(t time.Time) string {
+	return t.UTC().Format("2006-01-02 15:04:05")
+}
+
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(value)
+}
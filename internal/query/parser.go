@@ -0,0 +1,377 @@
+// Package query implements a small, safe query language over command history.
+//
+//	count() by tool where category=git and since=30d order by count desc limit 10
+//
+// A query is an optional select list followed by optional clauses:
+//
+//	[select] [by group, ...] [where filter and ...] [order by column [asc|desc]] [limit n]
+//
+// The select list holds fields or aggregates: count(), distinct(field),
+// sum(field), avg(field), min(field) and max(field). It defaults to count().
+// Group-by accepts any field or a time bucket: hour, day, week, month, year,
+// weekday or hour_of_day. Filters compare a field with a value using =, !=,
+// >, >=, <, <=, ~ (contains) or !~ (does not contain); since= and until=
+// take a date (2024-01-31) or a relative time (12h, 30d, 4w, 6mo, 1y).
+//
+// Queries are compiled to parameterized SQL: field names come from a fixed
+// list and every value is bound as a parameter.
+package query
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// MaxLimit caps the number of rows a query can return
+const MaxLimit = 10000
+
+// DefaultLimit applies when a query has no limit clause
+const DefaultLimit = 100
+
+// Aggregate is an aggregate function applied to a field
+type Aggregate struct {
+	Func  string // count, distinct, sum, avg, min, max
+	Field string // empty for count()
+}
+
+// Alias is the output column name of the aggregate, e.g. "avg_duration_ms"
+func (a *Aggregate) Alias() string {
+	if a.Field == "" {
+		return a.Func
+	}
+	return a.Func + "_" + a.Field
+}
+
+// SelectItem is a plain field or an aggregate in the select list
+type SelectItem struct {
+	Field     string
+	Aggregate *Aggregate
+}
+
+// Filter compares a field with a value
+type Filter struct {
+	Field    string
+	Operator string
+	Value    string
+}
+
+// Query is a parsed query
+type Query struct {
+	Select    []SelectItem
+	GroupBy   []string
+	Filters   []Filter
+	OrderBy   string
+	OrderDesc bool
+	Limit     int
+}
+
+// token kinds
+const (
+	tokenWord = iota
+	tokenString
+	tokenSymbol
+	tokenEOF
+)
+
+type token struct {
+	kind int
+	text string
+	pos  int
+}
+
+// keywords end the select list and start clauses
+var keywords = map[string]bool{
+	"by": true, "where": true, "and": true, "order": true, "asc": true, "desc": true, "limit": true,
+}
+
+// Parse parses a query string
+func Parse(input string) (*Query, error) {
+	tokens, err := tokenize(input)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+	return p.parse()
+}
+
+func tokenize(input string) ([]token, error) {
+	var tokens []token
+	runes := []rune(input)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+
+		case r == '\'' || r == '"':
+			end := i + 1
+			var value strings.Builder
+			for end < len(runes) && runes[end] != r {
+				value.WriteRune(runes[end])
+				end++
+			}
+			if end >= len(runes) {
+				return nil, fmt.Errorf("unterminated string at position %d", i+1)
+			}
+			tokens = append(tokens, token{kind: tokenString, text: value.String(), pos: i})
+			i = end + 1
+
+		case r == '(' || r == ')' || r == ',':
+			tokens = append(tokens, token{kind: tokenSymbol, text: string(r), pos: i})
+			i++
+
+		case r == '=' || r == '~':
+			tokens = append(tokens, token{kind: tokenSymbol, text: string(r), pos: i})
+			i++
+
+		case r == '!' || r == '<' || r == '>':
+			if i+1 < len(runes) && (runes[i+1] == '=' || (r == '!' && runes[i+1] == '~')) {
+				tokens = append(tokens, token{kind: tokenSymbol, text: string(runes[i : i+2]), pos: i})
+				i += 2
+			} else if r == '!' {
+				return nil, fmt.Errorf("unexpected '!' at position %d", i+1)
+			} else {
+				tokens = append(tokens, token{kind: tokenSymbol, text: string(r), pos: i})
+				i++
+			}
+
+		default:
+			start := i
+			for i < len(runes) && !unicode.IsSpace(runes[i]) && !strings.ContainsRune("()=,~!<>'\"", runes[i]) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenWord, text: string(runes[start:i]), pos: start})
+		}
+	}
+
+	return append(tokens, token{kind: tokenEOF, pos: len(runes)}), nil
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+// isKeyword reports whether the next token is the given keyword
+func (p *parser) isKeyword(keyword string) bool {
+	t := p.peek()
+	return t.kind == tokenWord && strings.EqualFold(t.text, keyword)
+}
+
+func (p *parser) expectSymbol(symbol string) error {
+	t := p.next()
+	if t.kind != tokenSymbol || t.text != symbol {
+		return p.errorAt(t, fmt.Sprintf("expected %q", symbol))
+	}
+	return nil
+}
+
+func (p *parser) expectKeyword(keyword string) error {
+	t := p.next()
+	if t.kind != tokenWord || !strings.EqualFold(t.text, keyword) {
+		return p.errorAt(t, fmt.Sprintf("expected %q", keyword))
+	}
+	return nil
+}
+
+func (p *parser) errorAt(t token, message string) error {
+	if t.kind == tokenEOF {
+		return fmt.Errorf("%s at end of query", message)
+	}
+	return fmt.Errorf("%s at position %d (near %q)", message, t.pos+1, t.text)
+}
+
+// name reads a field or column name
+func (p *parser) name() (string, error) {
+	t := p.next()
+	if t.kind != tokenWord || keywords[strings.ToLower(t.text)] {
+		return "", p.errorAt(t, "expected a field name")
+	}
+	return strings.ToLower(t.text), nil
+}
+
+func (p *parser) parse() (*Query, error) {
+	q := &Query{Limit: DefaultLimit}
+
+	// Select list, unless the query starts with a clause
+	if p.peek().kind != tokenEOF && !p.isKeyword("by") && !p.isKeyword("where") &&
+		!p.isKeyword("order") && !p.isKeyword("limit") {
+		for {
+			item, err := p.selectItem()
+			if err != nil {
+				return nil, err
+			}
+			q.Select = append(q.Select, item)
+			if p.peek().kind != tokenSymbol || p.peek().text != "," {
+				break
+			}
+			p.next()
+		}
+	}
+
+	if p.isKeyword("by") {
+		p.next()
+		for {
+			field, err := p.name()
+			if err != nil {
+				return nil, err
+			}
+			q.GroupBy = append(q.GroupBy, field)
+			if p.peek().kind != tokenSymbol || p.peek().text != "," {
+				break
+			}
+			p.next()
+		}
+	}
+
+	if p.isKeyword("where") {
+		p.next()
+		for {
+			filter, err := p.filter()
+			if err != nil {
+				return nil, err
+			}
+			q.Filters = append(q.Filters, filter)
+			if !p.isKeyword("and") {
+				break
+			}
+			p.next()
+		}
+	}
+
+	if p.isKeyword("order") {
+		p.next()
+		if err := p.expectKeyword("by"); err != nil {
+			return nil, err
+		}
+		column, err := p.orderColumn()
+		if err != nil {
+			return nil, err
+		}
+		q.OrderBy = column
+		if p.isKeyword("desc") {
+			p.next()
+			q.OrderDesc = true
+		} else if p.isKeyword("asc") {
+			p.next()
+		}
+	}
+
+	if p.isKeyword("limit") {
+		p.next()
+		t := p.next()
+		limit, err := strconv.Atoi(t.text)
+		if t.kind != tokenWord || err != nil || limit <= 0 {
+			return nil, p.errorAt(t, "expected a positive number")
+		}
+		if limit > MaxLimit {
+			limit = MaxLimit
+		}
+		q.Limit = limit
+	}
+
+	if t := p.peek(); t.kind != tokenEOF {
+		return nil, p.errorAt(t, "unexpected input")
+	}
+
+	if len(q.Select) == 0 {
+		q.Select = []SelectItem{{Aggregate: &Aggregate{Func: "count"}}}
+	}
+
+	return q, nil
+}
+
+func (p *parser) selectItem() (SelectItem, error) {
+	name, err := p.name()
+	if err != nil {
+		return SelectItem{}, err
+	}
+
+	if p.peek().kind != tokenSymbol || p.peek().text != "(" {
+		return SelectItem{Field: name}, nil
+	}
+
+	aggregate, err := p.aggregateArgs(name)
+	if err != nil {
+		return SelectItem{}, err
+	}
+	return SelectItem{Aggregate: aggregate}, nil
+}
+
+// aggregateArgs parses the parenthesized argument of an aggregate function
+func (p *parser) aggregateArgs(function string) (*Aggregate, error) {
+	if err := p.expectSymbol("("); err != nil {
+		return nil, err
+	}
+
+	aggregate := &Aggregate{Func: function}
+	if p.peek().kind != tokenSymbol || p.peek().text != ")" {
+		field, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		aggregate.Field = field
+	}
+
+	if err := p.expectSymbol(")"); err != nil {
+		return nil, err
+	}
+	return aggregate, nil
+}
+
+// orderColumn reads an output column: a field, an alias such as
+// avg_duration_ms, or an aggregate such as avg(duration_ms)
+func (p *parser) orderColumn() (string, error) {
+	name, err := p.name()
+	if err != nil {
+		return "", err
+	}
+	if p.peek().kind == tokenSymbol && p.peek().text == "(" {
+		aggregate, err := p.aggregateArgs(name)
+		if err != nil {
+			return "", err
+		}
+		return aggregate.Alias(), nil
+	}
+	return name, nil
+}
+
+func (p *parser) filter() (Filter, error) {
+	field, err := p.name()
+	if err != nil {
+		return Filter{}, err
+	}
+
+	op := p.next()
+	switch op.text {
+	case "=", "!=", ">", ">=", "<", "<=", "~", "!~":
+		if op.kind != tokenSymbol {
+			return Filter{}, p.errorAt(op, "expected a comparison operator")
+		}
+	default:
+		return Filter{}, p.errorAt(op, "expected a comparison operator")
+	}
+
+	value := p.next()
+	if value.kind != tokenWord && value.kind != tokenString {
+		return Filter{}, p.errorAt(value, "expected a value")
+	}
+
+	return Filter{Field: field, Operator: op.text, Value: value.text}, nil
+}
@@ -0,0 +1,177 @@
+package query
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/oiahoon/termonaut/internal/categories"
+	"github.com/oiahoon/termonaut/internal/database"
+)
+
+// Result holds the rows returned by a query
+type Result struct {
+	Query   string          `json:"query"`
+	Columns []string        `json:"columns"`
+	Rows    [][]interface{} `json:"rows"`
+}
+
+// InvalidQueryError is a query that cannot be parsed or compiled, as
+// opposed to one that failed to run
+type InvalidQueryError struct {
+	Err error
+}
+
+func (e *InvalidQueryError) Error() string {
+	return "invalid query: " + e.Err.Error()
+}
+
+func (e *InvalidQueryError) Unwrap() error {
+	return e.Err
+}
+
+// Run parses, compiles and executes a query. A query that cannot be parsed
+// or compiled returns an *InvalidQueryError.
+func Run(db *database.DB, input string, now time.Time) (*Result, error) {
+	q, err := Parse(input)
+	if err != nil {
+		return nil, &InvalidQueryError{Err: err}
+	}
+
+	compiled, err := Compile(q, now)
+	if err != nil {
+		return nil, &InvalidQueryError{Err: err}
+	}
+
+	var derive database.DeriveFields
+	if compiled.Derived {
+		derive = func(command string) (string, string) {
+			normalized := categories.NormalizeCommand(command)
+			return normalized.Tool, normalized.Subcommand
+		}
+	}
+
+	_, rows, err := db.RunQuery(compiled.SQL, compiled.Args, derive)
+	if err != nil {
+		return nil, err
+	}
+	if rows == nil {
+		rows = [][]interface{}{}
+	}
+
+	return &Result{Query: input, Columns: compiled.Columns, Rows: rows}, nil
+}
+
+// Records returns the rows as column-to-value maps
+func (r *Result) Records() []map[string]interface{} {
+	records := make([]map[string]interface{}, 0, len(r.Rows))
+	for _, row := range r.Rows {
+		record := make(map[string]interface{}, len(r.Columns))
+		for i, column := range r.Columns {
+			record[column] = row[i]
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+// FormatTable renders the result as an aligned text table
+func FormatTable(r *Result) string {
+	if len(r.Rows) == 0 {
+		return "No matching commands.\n"
+	}
+
+	cells := make([][]string, len(r.Rows))
+	widths := make([]int, len(r.Columns))
+	for i, column := range r.Columns {
+		widths[i] = len(column)
+	}
+	for i, row := range r.Rows {
+		cells[i] = make([]string, len(row))
+		for j, value := range row {
+			cells[i][j] = formatValue(value)
+			if w := len([]rune(cells[i][j])); w > widths[j] {
+				widths[j] = w
+			}
+		}
+	}
+
+	var result strings.Builder
+	writeRow := func(values []string) {
+		for i, value := range values {
+			if i > 0 {
+				result.WriteString("  ")
+			}
+			padding := widths[i] - len([]rune(value))
+			if i == len(values)-1 {
+				padding = 0
+			}
+			result.WriteString(value + strings.Repeat(" ", padding))
+		}
+		result.WriteString("\n")
+	}
+
+	writeRow(r.Columns)
+	separators := make([]string, len(widths))
+	for i, w := range widths {
+		separators[i] = strings.Repeat("─", w)
+	}
+	writeRow(separators)
+	for _, row := range cells {
+		writeRow(row)
+	}
+	result.WriteString(fmt.Sprintf("\n%d row(s)\n", len(r.Rows)))
+
+	return result.String()
+}
+
+// FormatCSV renders the result as CSV with a header row
+func FormatCSV(r *Result) (string, error) {
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+
+	if err := writer.Write(r.Columns); err != nil {
+		return "", fmt.Errorf("failed to write CSV: %w", err)
+	}
+	for _, row := range r.Rows {
+		record := make([]string, len(row))
+		for i, value := range row {
+			record[i] = formatValue(value)
+		}
+		if err := writer.Write(record); err != nil {
+			return "", fmt.Errorf("failed to write CSV: %w", err)
+		}
+	}
+	writer.Flush()
+
+	return buf.String(), writer.Error()
+}
+
+// FormatJSON renders the result as a JSON array of records
+func FormatJSON(r *Result) (string, error) {
+	data, err := json.MarshalIndent(r.Records(), "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal JSON: %w", err)
+	}
+	return string(data) + "\n", nil
+}
+
+func formatValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case float64:
+		if v == float64(int64(v)) {
+			return strconv.FormatInt(int64(v), 10)
+		}
+		return strconv.FormatFloat(v, 'f', 2, 64)
+	case time.Time:
+		return v.Local().Format("2006-01-02 15:04:05")
+	default:
+		return fmt.Sprint(v)
+	}
+}
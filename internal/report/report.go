@@ -0,0 +1,374 @@
+// Package report builds periodic usage reports and renders them through
+// text/template or html/template.
+//
+// Templates receive a *Data value. Custom templates can use every exported
+// field documented below, plus the helper functions listed in TemplateFuncs.
+package report
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/oiahoon/termonaut/internal/analytics"
+	"github.com/oiahoon/termonaut/internal/calendar"
+	"github.com/oiahoon/termonaut/internal/categories"
+	"github.com/oiahoon/termonaut/internal/gamification"
+	"github.com/oiahoon/termonaut/pkg/models"
+)
+
+// Period is the time span a report covers
+type Period string
+
+const (
+	PeriodWeek  Period = "week"
+	PeriodMonth Period = "month"
+	PeriodYear  Period = "year"
+)
+
+// workflowGap is the longest pause between commands that still belong to one workflow
+const workflowGap = 10 * time.Minute
+
+// ParsePeriod validates a period name
+func ParsePeriod(name string) (Period, error) {
+	switch Period(strings.ToLower(name)) {
+	case PeriodWeek:
+		return PeriodWeek, nil
+	case PeriodMonth:
+		return PeriodMonth, nil
+	case PeriodYear:
+		return PeriodYear, nil
+	default:
+		return "", fmt.Errorf("invalid period %q. Must be: week, month, year", name)
+	}
+}
+
+// Range returns the window covered by the period, ending at now
+func (p Period) Range(now time.Time) (time.Time, time.Time) {
+	switch p {
+	case PeriodMonth:
+		return now.AddDate(0, -1, 0), now
+	case PeriodYear:
+		return now.AddDate(-1, 0, 0), now
+	default:
+		return now.AddDate(0, 0, -7), now
+	}
+}
+
+// Data is the model passed to report templates
+type Data struct {
+	Title        string             // e.g. "Weekly Terminal Report"
+	Period       Period             // week, month or year
+	Start        time.Time          // start of the reporting window
+	End          time.Time          // end of the reporting window
+	GeneratedAt  time.Time          // when the report was rendered
+	Stats        Summary            // headline numbers
+	Categories   []CategoryShare    // command categories, most used first
+	TopCommands  []CommandCount     // most used base commands
+	Heatmap      Heatmap            // activity by weekday and hour
+	Achievements []AchievementEntry // achievements earned during the period
+	Workflows    []Workflow         // recurring command sequences
+	Focus        FocusSummary       // deep-work blocks during the period
+}
+
+// Summary holds headline numbers for the period
+type Summary struct {
+	TotalCommands  int     // commands run in the period
+	UniqueCommands int     // distinct command lines
+	ActiveDays     int     // days with at least one command
+	AveragePerDay  float64 // commands per active day
+	SuccessRate    float64 // percentage of commands that exited 0
+	CurrentStreak  int     // current daily streak (all time)
+	LongestStreak  int     // longest daily streak (all time)
+	TotalXP        int     // total XP (all time)
+	Level          int     // current level (all time)
+}
+
+// CategoryShare is one category's share of the period's commands
+type CategoryShare struct {
+	Name       string
+	Icon       string
+	Count      int
+	Percentage float64
+}
+
+// CommandCount is how often a base command was used
+type CommandCount struct {
+	Command string
+	Count   int
+}
+
+// Heatmap holds command counts by weekday (Monday first) and hour
+type Heatmap struct {
+	Days     []string   // weekday labels, Monday first
+	Grid     [7][24]int // Grid[day][hour]
+	Max      int        // largest cell value, for scaling
+	PeakDay  string     // busiest weekday
+	PeakHour int        // busiest hour of the day
+}
+
+// AchievementEntry is an achievement earned during the period
+type AchievementEntry struct {
+	Name        string
+	Icon        string
+	Description string
+	XPReward    int
+	EarnedAt    time.Time
+}
+
+// Workflow is a recurring sequence of base commands
+type Workflow struct {
+	Steps []string
+	Count int
+}
+
+// FocusSummary summarizes deep-work blocks in the period
+type FocusSummary struct {
+	Blocks         int
+	Hours          float64
+	AverageMinutes float64
+	BestHours      []int
+}
+
+// Builder assembles report data from command history
+type Builder struct {
+	classifier *categories.CommandClassifier
+}
+
+// NewBuilder creates a new report builder
+func NewBuilder() *Builder {
+	return &Builder{
+		classifier: categories.NewCommandClassifier(),
+	}
+}
+
+// Build creates the report data for the period ending at now
+func (b *Builder) Build(period Period, now time.Time, commands []*models.Command,
+	achievements map[string]*gamification.UserAchievement, progress *models.UserProgress) *Data {
+	start, end := period.Range(now)
+
+	var inPeriod []*models.Command
+	for _, cmd := range commands {
+		if !cmd.Timestamp.Before(start) && !cmd.Timestamp.After(end) {
+			inPeriod = append(inPeriod, cmd)
+		}
+	}
+	sort.Slice(inPeriod, func(i, j int) bool {
+		return inPeriod[i].Timestamp.Before(inPeriod[j].Timestamp)
+	})
+
+	titles := map[Period]string{
+		PeriodWeek:  "Weekly Terminal Report",
+		PeriodMonth: "Monthly Terminal Report",
+		PeriodYear:  "Yearly Terminal Report",
+	}
+
+	data := &Data{
+		Title:        titles[period],
+		Period:       period,
+		Start:        start,
+		End:          end,
+		GeneratedAt:  now,
+		Stats:        b.buildSummary(inPeriod, progress),
+		Categories:   b.buildCategories(inPeriod),
+		TopCommands:  buildTopCommands(inPeriod, 10),
+		Heatmap:      buildHeatmap(inPeriod),
+		Achievements: buildAchievements(achievements, start, end),
+		Workflows:    buildWorkflows(inPeriod, 3, 5),
+	}
+
+	focus := analytics.NewFocusAnalyzer().AnalyzeFocus(inPeriod)
+	data.Focus = FocusSummary{
+		Blocks:         focus.TotalBlocks,
+		Hours:          focus.TotalFocusMinutes / 60,
+		AverageMinutes: focus.AverageMinutes,
+		BestHours:      focus.BestHours,
+	}
+
+	return data
+}
+
+func (b *Builder) buildSummary(commands []*models.Command, progress *models.UserProgress) Summary {
+	summary := Summary{TotalCommands: len(commands)}
+
+	unique := make(map[string]bool)
+	days := make(map[string]bool)
+	successful := 0
+	for _, cmd := range commands {
+		unique[cmd.Command] = true
+		days[calendar.Default().DayKey(cmd.Timestamp)] = true
+		if cmd.ExitCode == 0 {
+			successful++
+		}
+	}
+
+	summary.UniqueCommands = len(unique)
+	summary.ActiveDays = len(days)
+	if summary.ActiveDays > 0 {
+		summary.AveragePerDay = float64(summary.TotalCommands) / float64(summary.ActiveDays)
+	}
+	if summary.TotalCommands > 0 {
+		summary.SuccessRate = float64(successful) / float64(summary.TotalCommands) * 100
+	}
+
+	if progress != nil {
+		summary.CurrentStreak = progress.CurrentStreak
+		summary.LongestStreak = progress.LongestStreak
+		summary.TotalXP = progress.TotalXP
+		summary.Level = progress.CurrentLevel
+	}
+
+	return summary
+}
+
+func (b *Builder) buildCategories(commands []*models.Command) []CategoryShare {
+	shares := []CategoryShare{}
+	for category, stats := range b.classifier.AnalyzeCommandCategories(commands) {
+		info := b.classifier.GetCategoryInfo(category)
+		shares = append(shares, CategoryShare{
+			Name:       info.Name,
+			Icon:       info.Icon,
+			Count:      stats.Count,
+			Percentage: stats.Percentage,
+		})
+	}
+	sort.Slice(shares, func(i, j int) bool {
+		if shares[i].Count != shares[j].Count {
+			return shares[i].Count > shares[j].Count
+		}
+		return shares[i].Name < shares[j].Name
+	})
+
+	return shares
+}
+
+func buildTopCommands(commands []*models.Command, limit int) []CommandCount {
+	counts := make(map[string]int)
+	for _, cmd := range commands {
+		if fields := strings.Fields(cmd.Command); len(fields) > 0 {
+			counts[fields[0]]++
+		}
+	}
+
+	top := []CommandCount{}
+	for command, count := range counts {
+		top = append(top, CommandCount{Command: command, Count: count})
+	}
+	sort.Slice(top, func(i, j int) bool {
+		if top[i].Count != top[j].Count {
+			return top[i].Count > top[j].Count
+		}
+		return top[i].Command < top[j].Command
+	})
+	if len(top) > limit {
+		top = top[:limit]
+	}
+
+	return top
+}
+
+func buildHeatmap(commands []*models.Command) Heatmap {
+	heatmap := Heatmap{
+		Days: []string{"Mon", "Tue", "Wed", "Thu", "Fri", "Sat", "Sun"},
+	}
+
+	var dayTotals [7]int
+	var hourTotals [24]int
+	for _, cmd := range commands {
+		day := (int(calendar.Default().Weekday(cmd.Timestamp)) + 6) % 7 // Monday first
+		hour := calendar.Default().Clock(cmd.Timestamp).Hour()
+		heatmap.Grid[day][hour]++
+		dayTotals[day]++
+		hourTotals[hour]++
+		if heatmap.Grid[day][hour] > heatmap.Max {
+			heatmap.Max = heatmap.Grid[day][hour]
+		}
+	}
+
+	peakDay := 0
+	for day, total := range dayTotals {
+		if total > dayTotals[peakDay] {
+			peakDay = day
+		}
+	}
+	for hour, total := range hourTotals {
+		if total > hourTotals[heatmap.PeakHour] {
+			heatmap.PeakHour = hour
+		}
+	}
+	heatmap.PeakDay = heatmap.Days[peakDay]
+
+	return heatmap
+}
+
+func buildAchievements(achievements map[string]*gamification.UserAchievement, start, end time.Time) []AchievementEntry {
+	entries := []AchievementEntry{}
+	for _, earned := range achievements {
+		if earned.EarnedAt.Before(start) || earned.EarnedAt.After(end) || earned.Achievement == nil {
+			continue
+		}
+		entries = append(entries, AchievementEntry{
+			Name:        earned.Achievement.Name,
+			Icon:        earned.Achievement.Icon,
+			Description: earned.Achievement.Description,
+			XPReward:    earned.Achievement.XPReward,
+			EarnedAt:    earned.EarnedAt,
+		})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].EarnedAt.Before(entries[j].EarnedAt)
+	})
+
+	return entries
+}
+
+// buildWorkflows finds the most common runs of consecutive base commands
+func buildWorkflows(commands []*models.Command, length, limit int) []Workflow {
+	counts := make(map[string]int)
+	for i := 0; i+length <= len(commands); i++ {
+		steps := make([]string, 0, length)
+		for j := i; j < i+length; j++ {
+			if j > i && commands[j].Timestamp.Sub(commands[j-1].Timestamp) > workflowGap {
+				break
+			}
+			fields := strings.Fields(commands[j].Command)
+			if len(fields) == 0 {
+				break
+			}
+			steps = append(steps, fields[0])
+		}
+		if len(steps) < length || allSame(steps) {
+			continue
+		}
+		counts[strings.Join(steps, " → ")]++
+	}
+
+	workflows := []Workflow{}
+	for key, count := range counts {
+		if count < 2 {
+			continue
+		}
+		workflows = append(workflows, Workflow{Steps: strings.Split(key, " → "), Count: count})
+	}
+	sort.Slice(workflows, func(i, j int) bool {
+		if workflows[i].Count != workflows[j].Count {
+			return workflows[i].Count > workflows[j].Count
+		}
+		return strings.Join(workflows[i].Steps, " ") < strings.Join(workflows[j].Steps, " ")
+	})
+	if len(workflows) > limit {
+		workflows = workflows[:limit]
+	}
+
+	return workflows
+}
+
+func allSame(steps []string) bool {
+	for _, step := range steps[1:] {
+		if step != steps[0] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,298 @@
+package report
+
+import (
+	"bytes"
+	"fmt"
+	htmltemplate "html/template"
+	"os"
+	"path/filepath"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	"github.com/oiahoon/termonaut/internal/analytics"
+)
+
+// Format is the output format of a rendered report
+type Format string
+
+const (
+	FormatText Format = "text"
+	FormatHTML Format = "html"
+)
+
+// ParseFormat validates a format name
+func ParseFormat(name string) (Format, error) {
+	switch strings.ToLower(name) {
+	case "text", "txt", "md", "markdown":
+		return FormatText, nil
+	case "html", "htm":
+		return FormatHTML, nil
+	default:
+		return "", fmt.Errorf("invalid format %q. Must be: text, html", name)
+	}
+}
+
+// FormatFromPath guesses the format from a file extension, defaulting to text
+func FormatFromPath(path string) Format {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".html", ".htm":
+		return FormatHTML
+	default:
+		return FormatText
+	}
+}
+
+// TemplateFuncs returns the helper functions available to report templates:
+//
+//	bar     {{bar 42.5 20}}            → "████████░░░░░░░░░░░░"
+//	date    {{date .Start}}            → "Jan 02, 2006"
+//	hour    {{hour 14}}                → "14:00"
+//	pct     {{pct 42.456}}             → "42.5%"
+//	join    {{join .Steps " → "}}      → "git → go → git"
+//	heat    {{heat 12 .Heatmap.Max}}   → background colour for a heatmap cell
+//	add     {{add 1 2}}                → 3
+//	dur     {{dur 90500}}              → "1m30s" (milliseconds)
+//	change  {{change 12.5}}            → "+12.5%"
+//	scale   {{scale 30 120 400}}       → 100 (value scaled from 0..max to 0..width)
+//	mul     {{mul 3 36}}               → 108
+func TemplateFuncs() map[string]interface{} {
+	return map[string]interface{}{
+		"bar":    bar,
+		"date":   func(t time.Time) string { return t.Format("Jan 02, 2006") },
+		"hour":   func(hour int) string { return fmt.Sprintf("%02d:00", hour) },
+		"pct":    func(value float64) string { return fmt.Sprintf("%.1f%%", value) },
+		"join":   strings.Join,
+		"heat":   heatColor,
+		"add":    func(a, b int) int { return a + b },
+		"dur":    analytics.FormatDuration,
+		"change": func(value float64) string { return fmt.Sprintf("%+.1f%%", value) },
+		"scale":  scale,
+		"mul":    func(a, b int) int { return a * b },
+	}
+}
+
+// Render executes the template source against data. HTML output uses
+// html/template so command text is escaped.
+func Render(data *Data, source string, format Format) (string, error) {
+	return execute(source, data, format == FormatHTML)
+}
+
+// execute runs a template with the report helper functions; escaped selects
+// html/template, which also suits SVG output
+func execute(source string, data interface{}, escaped bool) (string, error) {
+	var buf bytes.Buffer
+
+	if escaped {
+		tmpl, err := htmltemplate.New("report").Funcs(TemplateFuncs()).Parse(source)
+		if err != nil {
+			return "", fmt.Errorf("failed to parse template: %w", err)
+		}
+		if err := tmpl.Execute(&buf, data); err != nil {
+			return "", fmt.Errorf("failed to render template: %w", err)
+		}
+		return buf.String(), nil
+	}
+
+	tmpl, err := texttemplate.New("report").Funcs(TemplateFuncs()).Parse(source)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse template: %w", err)
+	}
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to render template: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// LoadTemplate returns the template source at path, or the built-in
+// template for the format when path is empty
+func LoadTemplate(path string, format Format) (string, error) {
+	if path == "" {
+		if format == FormatHTML {
+			return DefaultHTMLTemplate, nil
+		}
+		return DefaultTextTemplate, nil
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read template: %w", err)
+	}
+	return string(content), nil
+}
+
+func bar(percentage float64, width int) string {
+	if percentage > 100 {
+		percentage = 100
+	}
+	if percentage < 0 {
+		percentage = 0
+	}
+	filled := int(percentage * float64(width) / 100)
+	return strings.Repeat("█", filled) + strings.Repeat("░", width-filled)
+}
+
+// scale maps value from 0..max onto 0..width
+func scale(value, max, width int) int {
+	if max <= 0 {
+		return 0
+	}
+	return value * width / max
+}
+
+// heatColor maps a heatmap cell to a green shade, GitHub-style
+func heatColor(value, max int) htmltemplate.CSS {
+	colors := []string{"#ebedf0", "#9be9a8", "#40c463", "#30a14e", "#216e39"}
+	if value <= 0 || max <= 0 {
+		return htmltemplate.CSS(colors[0])
+	}
+	level := 1 + value*(len(colors)-2)/max
+	if level >= len(colors) {
+		level = len(colors) - 1
+	}
+	return htmltemplate.CSS(colors[level])
+}
+
+// DefaultTextTemplate is the built-in Markdown report
+const DefaultTextTemplate = `# 🚀 {{.Title}}
+
+{{date .Start}} – {{date .End}}
+
+## 📊 Summary
+
+- **Commands:** {{.Stats.TotalCommands}} ({{.Stats.UniqueCommands}} unique)
+- **Active days:** {{.Stats.ActiveDays}} ({{printf "%.1f" .Stats.AveragePerDay}} commands/day)
+- **Success rate:** {{pct .Stats.SuccessRate}}
+- **Level:** {{.Stats.Level}} ({{.Stats.TotalXP}} XP)
+- **Streak:** {{.Stats.CurrentStreak}} days (best {{.Stats.LongestStreak}})
+{{- if .Categories}}
+
+## 🗂️ Categories
+{{range .Categories}}
+- {{.Icon}} {{printf "%-16s" .Name}} {{bar .Percentage 20}} {{pct .Percentage}} ({{.Count}})
+{{- end}}
+{{- end}}
+{{- if .TopCommands}}
+
+## 🏆 Top Commands
+{{range $i, $cmd := .TopCommands}}
+{{add $i 1}}. ` + "`{{$cmd.Command}}`" + ` — {{$cmd.Count}}
+{{- end}}
+{{- end}}
+{{- if .Stats.TotalCommands}}
+
+## 🔥 Activity
+
+Busiest day: **{{.Heatmap.PeakDay}}** · Busiest hour: **{{hour .Heatmap.PeakHour}}**
+{{- end}}
+{{- if .Focus.Blocks}}
+
+## 🎯 Deep Work
+
+{{.Focus.Blocks}} focus blocks, {{printf "%.1f" .Focus.Hours}}h total, {{printf "%.0f" .Focus.AverageMinutes}} min average
+{{- end}}
+{{- if .Workflows}}
+
+## 🔁 Top Workflows
+{{range .Workflows}}
+- {{join .Steps " → "}} ({{.Count}}×)
+{{- end}}
+{{- end}}
+{{- if .Achievements}}
+
+## 🎖️ Achievements Earned
+{{range .Achievements}}
+- {{.Icon}} **{{.Name}}** — {{.Description}} (+{{.XPReward}} XP)
+{{- end}}
+{{- end}}
+
+---
+Generated by Termonaut on {{date .GeneratedAt}}
+`
+
+// DefaultHTMLTemplate is the built-in HTML report. Styles are inlined so the
+// output renders correctly when pasted into an email.
+const DefaultHTMLTemplate = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+</head>
+<body style="margin:0;padding:24px;background:#f6f8fa;font-family:-apple-system,Segoe UI,Helvetica,Arial,sans-serif;color:#24292f;">
+<table width="100%" cellpadding="0" cellspacing="0" style="max-width:640px;margin:0 auto;background:#ffffff;border:1px solid #d0d7de;border-radius:6px;">
+<tr><td style="padding:24px;">
+<h1 style="margin:0 0 4px 0;font-size:22px;">🚀 {{.Title}}</h1>
+<p style="margin:0 0 20px 0;color:#57606a;">{{date .Start}} – {{date .End}}</p>
+
+<h2 style="font-size:16px;border-bottom:1px solid #d0d7de;padding-bottom:4px;">📊 Summary</h2>
+<table cellpadding="4" cellspacing="0" style="font-size:14px;">
+<tr><td>Commands</td><td><strong>{{.Stats.TotalCommands}}</strong> ({{.Stats.UniqueCommands}} unique)</td></tr>
+<tr><td>Active days</td><td><strong>{{.Stats.ActiveDays}}</strong> ({{printf "%.1f" .Stats.AveragePerDay}} commands/day)</td></tr>
+<tr><td>Success rate</td><td><strong>{{pct .Stats.SuccessRate}}</strong></td></tr>
+<tr><td>Level</td><td><strong>{{.Stats.Level}}</strong> ({{.Stats.TotalXP}} XP)</td></tr>
+<tr><td>Streak</td><td><strong>{{.Stats.CurrentStreak}} days</strong> (best {{.Stats.LongestStreak}})</td></tr>
+</table>
+{{- if .Categories}}
+
+<h2 style="font-size:16px;border-bottom:1px solid #d0d7de;padding-bottom:4px;">🗂️ Categories</h2>
+<table cellpadding="3" cellspacing="0" width="100%" style="font-size:14px;">
+{{- range .Categories}}
+<tr>
+<td width="40%">{{.Icon}} {{.Name}}</td>
+<td><div style="background:#40c463;height:10px;width:{{printf "%.0f" .Percentage}}%;"></div></td>
+<td width="20%" align="right">{{pct .Percentage}} ({{.Count}})</td>
+</tr>
+{{- end}}
+</table>
+{{- end}}
+{{- if .TopCommands}}
+
+<h2 style="font-size:16px;border-bottom:1px solid #d0d7de;padding-bottom:4px;">🏆 Top Commands</h2>
+<ol style="font-size:14px;padding-left:20px;">
+{{- range .TopCommands}}
+<li><code>{{.Command}}</code> — {{.Count}}</li>
+{{- end}}
+</ol>
+{{- end}}
+{{- if .Stats.TotalCommands}}
+
+<h2 style="font-size:16px;border-bottom:1px solid #d0d7de;padding-bottom:4px;">🔥 Activity</h2>
+<table cellpadding="0" cellspacing="2" style="font-size:10px;">
+{{- $max := .Heatmap.Max}}
+{{- range $day, $hours := .Heatmap.Grid}}
+<tr><td style="padding-right:4px;">{{index $.Heatmap.Days $day}}</td>
+{{- range $hours}}<td style="width:14px;height:14px;background:{{heat . $max}};"></td>{{end}}</tr>
+{{- end}}
+</table>
+<p style="font-size:14px;">Busiest day: <strong>{{.Heatmap.PeakDay}}</strong> · Busiest hour: <strong>{{hour .Heatmap.PeakHour}}</strong></p>
+{{- end}}
+{{- if .Focus.Blocks}}
+
+<h2 style="font-size:16px;border-bottom:1px solid #d0d7de;padding-bottom:4px;">🎯 Deep Work</h2>
+<p style="font-size:14px;">{{.Focus.Blocks}} focus blocks, {{printf "%.1f" .Focus.Hours}}h total, {{printf "%.0f" .Focus.AverageMinutes}} min average</p>
+{{- end}}
+{{- if .Workflows}}
+
+<h2 style="font-size:16px;border-bottom:1px solid #d0d7de;padding-bottom:4px;">🔁 Top Workflows</h2>
+<ul style="font-size:14px;padding-left:20px;">
+{{- range .Workflows}}
+<li><code>{{join .Steps " → "}}</code> ({{.Count}}×)</li>
+{{- end}}
+</ul>
+{{- end}}
+{{- if .Achievements}}
+
+<h2 style="font-size:16px;border-bottom:1px solid #d0d7de;padding-bottom:4px;">🎖️ Achievements Earned</h2>
+<ul style="font-size:14px;padding-left:20px;">
+{{- range .Achievements}}
+<li>{{.Icon}} <strong>{{.Name}}</strong> — {{.Description}} (+{{.XPReward}} XP)</li>
+{{- end}}
+</ul>
+{{- end}}
+
+<p style="margin-top:24px;font-size:12px;color:#57606a;">Generated by Termonaut on {{date .GeneratedAt}}</p>
+</td></tr>
+</table>
+</body>
+</html>
+`
@@ -0,0 +1,326 @@
+package report
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/oiahoon/termonaut/internal/analytics"
+	"github.com/oiahoon/termonaut/internal/calendar"
+	"github.com/oiahoon/termonaut/internal/gamification"
+	"github.com/oiahoon/termonaut/pkg/models"
+)
+
+// FormatSVG renders a wrapped recap as a shareable SVG card
+const FormatSVG Format = "svg"
+
+// Wrapped is a calendar-year recap, the model passed to wrapped templates
+type Wrapped struct {
+	Year           int                // calendar year covered
+	Start          time.Time          // January 1st, local time
+	End            time.Time          // end of the year, or now for the current year
+	GeneratedAt    time.Time          // when the recap was built
+	TotalCommands  int                // commands run in the year
+	UniqueCommands int                // distinct command lines
+	ActiveDays     int                // days with at least one command
+	DaysInYear     int                // days covered, for ratios
+	SuccessRate    float64            // percentage of commands that exited 0
+	TotalTimeMS    int64              // run time of timed commands
+	TopTools       []ToolCount        // most used tools
+	BusiestDay     DayCount           // the day with the most commands
+	LongestStreak  int                // longest run of consecutive active days in the year
+	PeakHour       int                // busiest hour of the day
+	TimeSinks      []TimeSink         // commands with the most total run time
+	NewTools       []NewTool          // tools first used this year
+	Achievements   []AchievementEntry // achievements earned in the year
+	Personality    Personality        // terminal personality from working patterns
+	Comparison     *Comparison        // set by CompareTo
+}
+
+// ToolCount is how often a tool was used
+type ToolCount struct {
+	Tool       string
+	Count      int
+	Percentage float64 // share of the year's commands
+}
+
+// DayCount is the number of commands on a day
+type DayCount struct {
+	Date  time.Time
+	Count int
+}
+
+// TimeSink is a command line with a large total run time
+type TimeSink struct {
+	Command string
+	TotalMS int64
+	Count   int
+}
+
+// NewTool is a tool used for the first time during the year
+type NewTool struct {
+	Tool      string
+	FirstSeen time.Time
+	Uses      int
+}
+
+// Personality describes when someone works, derived from WorkingPattern
+type Personality struct {
+	Name        string
+	Icon        string
+	Description string
+	Intensity   float64 // 0-100
+}
+
+// Comparison contrasts a recap with another year
+type Comparison struct {
+	Year             int
+	TotalCommands    int
+	ActiveDays       int
+	LongestStreak    int
+	NewTools         int
+	CommandsChange   float64     // percentage change in commands
+	ActiveDaysChange float64     // percentage change in active days
+	RisingTools      []string    // top tools this year that were not top tools then
+	FadingTools      []string    // top tools then that are not top tools this year
+	Personality      Personality // personality in the compared year
+}
+
+// personalityIcons maps WorkingPattern names to icons
+var personalityIcons = map[string]string{
+	"Early Bird":      "🐦",
+	"Standard Hours":  "💼",
+	"Night Owl":       "🦉",
+	"Weekend Warrior": "⚔️",
+}
+
+// BuildWrapped creates the recap of a calendar year. commands is the whole
+// history, so tools first used during the year can be told apart from
+// tools used before it.
+func (b *Builder) BuildWrapped(year int, now time.Time, commands []*models.Command,
+	achievements map[string]*gamification.UserAchievement) *Wrapped {
+	start := time.Date(year, time.January, 1, 0, 0, 0, 0, now.Location())
+	end := start.AddDate(1, 0, 0)
+	if now.Before(end) {
+		end = now
+	}
+
+	w := &Wrapped{
+		Year:         year,
+		Start:        start,
+		End:          end,
+		GeneratedAt:  now,
+		DaysInYear:   int(end.Sub(start).Hours()/24 + 0.5),
+		TopTools:     []ToolCount{},
+		TimeSinks:    []TimeSink{},
+		NewTools:     []NewTool{},
+		Achievements: buildAchievements(achievements, start, end),
+	}
+	if w.DaysInYear < 1 {
+		w.DaysInYear = 1
+	}
+
+	// Commands belong to the year of their calendar day, so the last hours
+	// before the day start on New Year's Eve still count
+	cal := calendar.Default()
+	startKey, endKey := start.Format(calendar.DateFormat), start.AddDate(1, 0, 0).Format(calendar.DateFormat)
+	var inYear []*models.Command
+	firstSeen := make(map[string]time.Time)
+	for _, cmd := range commands {
+		tool := b.classifier.BaseCommand(cmd.Command)
+		if seen, exists := firstSeen[tool]; tool != "" && (!exists || cmd.Timestamp.Before(seen)) {
+			firstSeen[tool] = cmd.Timestamp
+		}
+		if key := cal.DayKey(cmd.Timestamp); key >= startKey && key < endKey && !cmd.Timestamp.After(now) {
+			inYear = append(inYear, cmd)
+		}
+	}
+	w.TotalCommands = len(inYear)
+	if w.TotalCommands == 0 {
+		w.Personality = Personality{Name: "Newcomer", Icon: "🌱", Description: "No commands recorded this year yet."}
+		return w
+	}
+
+	unique := make(map[string]bool)
+	dayCounts := make(map[string]int)
+	toolCounts := make(map[string]int)
+	successful := 0
+	for _, cmd := range inYear {
+		unique[cmd.Command] = true
+		dayCounts[cal.DayKey(cmd.Timestamp)]++
+		if tool := b.classifier.BaseCommand(cmd.Command); tool != "" {
+			toolCounts[tool]++
+		}
+		if cmd.ExitCode == 0 {
+			successful++
+		}
+		w.TotalTimeMS += cmd.DurationMS
+	}
+	w.UniqueCommands = len(unique)
+	w.ActiveDays = len(dayCounts)
+	w.SuccessRate = float64(successful) / float64(w.TotalCommands) * 100
+
+	for tool, count := range toolCounts {
+		w.TopTools = append(w.TopTools, ToolCount{
+			Tool:       tool,
+			Count:      count,
+			Percentage: float64(count) / float64(w.TotalCommands) * 100,
+		})
+	}
+	sort.Slice(w.TopTools, func(i, j int) bool {
+		if w.TopTools[i].Count != w.TopTools[j].Count {
+			return w.TopTools[i].Count > w.TopTools[j].Count
+		}
+		return w.TopTools[i].Tool < w.TopTools[j].Tool
+	})
+	if len(w.TopTools) > 5 {
+		w.TopTools = w.TopTools[:5]
+	}
+
+	w.BusiestDay, w.LongestStreak = busiestDayAndStreak(dayCounts, now.Location())
+	w.PeakHour = buildHeatmap(inYear).PeakHour
+
+	for _, sink := range analytics.NewDurationAnalyzer().AnalyzeDurations(inYear).TimeSinks {
+		if len(w.TimeSinks) == 3 || sink.TotalMS == 0 {
+			break
+		}
+		w.TimeSinks = append(w.TimeSinks, TimeSink{Command: sink.Command, TotalMS: sink.TotalMS, Count: sink.Count})
+	}
+
+	for tool, seen := range firstSeen {
+		if !seen.Before(start) && seen.Before(end) {
+			w.NewTools = append(w.NewTools, NewTool{Tool: tool, FirstSeen: seen, Uses: toolCounts[tool]})
+		}
+	}
+	sort.Slice(w.NewTools, func(i, j int) bool {
+		if w.NewTools[i].Uses != w.NewTools[j].Uses {
+			return w.NewTools[i].Uses > w.NewTools[j].Uses
+		}
+		return w.NewTools[i].Tool < w.NewTools[j].Tool
+	})
+	if len(w.NewTools) > 5 {
+		w.NewTools = w.NewTools[:5]
+	}
+
+	w.Personality = personality(inYear)
+	return w
+}
+
+// busiestDayAndStreak finds the day with the most commands and the longest
+// run of consecutive active days
+func busiestDayAndStreak(dayCounts map[string]int, loc *time.Location) (DayCount, int) {
+	days := make([]string, 0, len(dayCounts))
+	for day := range dayCounts {
+		days = append(days, day)
+	}
+	sort.Strings(days)
+
+	var busiest DayCount
+	longest, current := 0, 0
+	var previous time.Time
+	for _, day := range days {
+		date, _ := time.ParseInLocation("2006-01-02", day, loc)
+		if dayCounts[day] > busiest.Count {
+			busiest = DayCount{Date: date, Count: dayCounts[day]}
+		}
+		if !previous.IsZero() && date.Equal(previous.AddDate(0, 0, 1)) {
+			current++
+		} else {
+			current = 1
+		}
+		if current > longest {
+			longest = current
+		}
+		previous = date
+	}
+
+	return busiest, longest
+}
+
+// personality picks the most intense working pattern of the year
+func personality(commands []*models.Command) Personality {
+	heatmap := analytics.NewHeatmapAnalyzer().GenerateHeatmap(commands)
+
+	var strongest *analytics.WorkingPattern
+	for i := range heatmap.WorkingPatterns {
+		if strongest == nil || heatmap.WorkingPatterns[i].Intensity > strongest.Intensity {
+			strongest = &heatmap.WorkingPatterns[i]
+		}
+	}
+	if strongest == nil {
+		return Personality{
+			Name:        "Free Spirit",
+			Icon:        "🧭",
+			Description: fmt.Sprintf("No fixed schedule: your activity is %s across the clock.", heatmap.DistributionType),
+		}
+	}
+
+	icon := personalityIcons[strongest.Name]
+	if icon == "" {
+		icon = "✨"
+	}
+	return Personality{
+		Name:        strongest.Name,
+		Icon:        icon,
+		Description: strongest.Description,
+		Intensity:   strongest.Intensity,
+	}
+}
+
+// CompareTo records how this recap differs from another year's
+func (w *Wrapped) CompareTo(other *Wrapped) {
+	c := &Comparison{
+		Year:             other.Year,
+		TotalCommands:    other.TotalCommands,
+		ActiveDays:       other.ActiveDays,
+		LongestStreak:    other.LongestStreak,
+		NewTools:         len(other.NewTools),
+		CommandsChange:   percentChange(other.TotalCommands, w.TotalCommands),
+		ActiveDaysChange: percentChange(other.ActiveDays, w.ActiveDays),
+		RisingTools:      []string{},
+		FadingTools:      []string{},
+		Personality:      other.Personality,
+	}
+
+	was := make(map[string]bool)
+	for _, tool := range other.TopTools {
+		was[tool.Tool] = true
+	}
+	now := make(map[string]bool)
+	for _, tool := range w.TopTools {
+		now[tool.Tool] = true
+		if !was[tool.Tool] {
+			c.RisingTools = append(c.RisingTools, tool.Tool)
+		}
+	}
+	for _, tool := range other.TopTools {
+		if !now[tool.Tool] {
+			c.FadingTools = append(c.FadingTools, tool.Tool)
+		}
+	}
+
+	w.Comparison = c
+}
+
+func percentChange(from, to int) float64 {
+	if from == 0 {
+		if to == 0 {
+			return 0
+		}
+		return 100
+	}
+	return float64(to-from) / float64(from) * 100
+}
+
+// RenderWrapped renders a recap with the built-in template for the format:
+// Markdown (FormatText), HTML or an SVG card
+func RenderWrapped(w *Wrapped, format Format) (string, error) {
+	switch format {
+	case FormatHTML:
+		return execute(WrappedHTMLTemplate, w, true)
+	case FormatSVG:
+		return execute(WrappedSVGTemplate, w, true)
+	default:
+		return execute(WrappedTextTemplate, w, false)
+	}
+}
@@ -0,0 +1,215 @@
+package report
+
+// WrappedTextTemplate is the built-in Markdown year-in-review
+const WrappedTextTemplate = `# 🎁 Termonaut Wrapped {{.Year}}
+
+{{date .Start}} – {{date .End}}
+{{- if not .TotalCommands}}
+
+No commands recorded in {{.Year}}.
+{{- else}}
+
+## 📊 Your Year in Numbers
+
+- **{{.TotalCommands}}** commands ({{.UniqueCommands}} unique)
+- **{{.ActiveDays}}** active days out of {{.DaysInYear}}
+- **{{.LongestStreak}}**-day longest streak
+- **{{pct .SuccessRate}}** success rate
+{{- if .TotalTimeMS}}
+- **{{dur .TotalTimeMS}}** of measured run time
+{{- end}}
+
+## {{.Personality.Icon}} Terminal Personality: {{.Personality.Name}}
+
+{{.Personality.Description}}
+
+## 🏆 Top Tools
+{{range $i, $tool := .TopTools}}
+{{add $i 1}}. ` + "`{{$tool.Tool}}`" + ` — {{$tool.Count}} ({{pct $tool.Percentage}})
+{{- end}}
+
+## 🔥 Busiest Day
+
+**{{date .BusiestDay.Date}}** with {{.BusiestDay.Count}} commands · Peak hour: **{{hour .PeakHour}}**
+{{- if .TimeSinks}}
+
+## ⌛ Biggest Time Sinks
+{{range .TimeSinks}}
+- ` + "`{{.Command}}`" + ` — {{dur .TotalMS}} over {{.Count}} runs
+{{- end}}
+{{- end}}
+{{- if .NewTools}}
+
+## 🌱 New Tools Learned
+{{range .NewTools}}
+- ` + "`{{.Tool}}`" + ` — first used {{date .FirstSeen}}, {{.Uses}} uses
+{{- end}}
+{{- end}}
+{{- if .Achievements}}
+
+## 🎖️ Achievements Earned
+{{range .Achievements}}
+- {{.Icon}} **{{.Name}}** — {{.Description}}
+{{- end}}
+{{- end}}
+{{- end}}
+{{- with .Comparison}}
+
+## 📈 Compared with {{.Year}}
+
+- Commands: {{$.TotalCommands}} vs {{.TotalCommands}} ({{change .CommandsChange}})
+- Active days: {{$.ActiveDays}} vs {{.ActiveDays}} ({{change .ActiveDaysChange}})
+- Longest streak: {{$.LongestStreak}} vs {{.LongestStreak}} days
+- Personality: {{$.Personality.Name}} (was {{.Personality.Name}})
+{{- if .RisingTools}}
+- Rising: {{join .RisingTools ", "}}
+{{- end}}
+{{- if .FadingTools}}
+- Fading: {{join .FadingTools ", "}}
+{{- end}}
+{{- end}}
+
+---
+Generated by Termonaut on {{date .GeneratedAt}}
+`
+
+// WrappedHTMLTemplate is the built-in HTML year-in-review, with inline
+// styles so it can be shared or emailed as a single file
+const WrappedHTMLTemplate = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Termonaut Wrapped {{.Year}}</title>
+</head>
+<body style="margin:0;padding:24px;background:#0d1117;font-family:-apple-system,Segoe UI,Helvetica,Arial,sans-serif;color:#e6edf3;">
+<table width="100%" cellpadding="0" cellspacing="0" style="max-width:640px;margin:0 auto;background:#161b22;border:1px solid #30363d;border-radius:12px;">
+<tr><td style="padding:32px;">
+<h1 style="margin:0 0 4px 0;font-size:28px;">🎁 Termonaut Wrapped {{.Year}}</h1>
+<p style="margin:0 0 24px 0;color:#8b949e;">{{date .Start}} – {{date .End}}</p>
+{{- if not .TotalCommands}}
+<p>No commands recorded in {{.Year}}.</p>
+{{- else}}
+
+<table width="100%" cellpadding="8" cellspacing="0" style="text-align:center;">
+<tr>
+<td><div style="font-size:32px;font-weight:bold;color:#58a6ff;">{{.TotalCommands}}</div><div style="color:#8b949e;">commands</div></td>
+<td><div style="font-size:32px;font-weight:bold;color:#3fb950;">{{.ActiveDays}}</div><div style="color:#8b949e;">active days</div></td>
+<td><div style="font-size:32px;font-weight:bold;color:#f78166;">{{.LongestStreak}}</div><div style="color:#8b949e;">day streak</div></td>
+</tr>
+</table>
+
+<h2 style="font-size:18px;margin-top:24px;">{{.Personality.Icon}} {{.Personality.Name}}</h2>
+<p style="color:#8b949e;">{{.Personality.Description}}</p>
+
+<h2 style="font-size:18px;margin-top:24px;">🏆 Top Tools</h2>
+<table width="100%" cellpadding="4" cellspacing="0" style="font-size:14px;">
+{{- range .TopTools}}
+<tr>
+<td width="25%"><code>{{.Tool}}</code></td>
+<td><div style="background:#58a6ff;height:12px;border-radius:6px;width:{{printf "%.0f" .Percentage}}%;"></div></td>
+<td width="25%" align="right">{{.Count}} ({{pct .Percentage}})</td>
+</tr>
+{{- end}}
+</table>
+
+<h2 style="font-size:18px;margin-top:24px;">🔥 Busiest Day</h2>
+<p><strong>{{date .BusiestDay.Date}}</strong> with {{.BusiestDay.Count}} commands · peak hour <strong>{{hour .PeakHour}}</strong> · {{pct .SuccessRate}} success rate</p>
+{{- if .TimeSinks}}
+
+<h2 style="font-size:18px;margin-top:24px;">⌛ Biggest Time Sinks</h2>
+<ul style="font-size:14px;padding-left:20px;">
+{{- range .TimeSinks}}
+<li><code>{{.Command}}</code> — {{dur .TotalMS}} over {{.Count}} runs</li>
+{{- end}}
+</ul>
+{{- end}}
+{{- if .NewTools}}
+
+<h2 style="font-size:18px;margin-top:24px;">🌱 New Tools Learned</h2>
+<ul style="font-size:14px;padding-left:20px;">
+{{- range .NewTools}}
+<li><code>{{.Tool}}</code> — first used {{date .FirstSeen}}, {{.Uses}} uses</li>
+{{- end}}
+</ul>
+{{- end}}
+{{- if .Achievements}}
+
+<h2 style="font-size:18px;margin-top:24px;">🎖️ Achievements Earned</h2>
+<ul style="font-size:14px;padding-left:20px;">
+{{- range .Achievements}}
+<li>{{.Icon}} <strong>{{.Name}}</strong> — {{.Description}}</li>
+{{- end}}
+</ul>
+{{- end}}
+{{- end}}
+{{- with .Comparison}}
+
+<h2 style="font-size:18px;margin-top:24px;">📈 Compared with {{.Year}}</h2>
+<table cellpadding="4" cellspacing="0" style="font-size:14px;">
+<tr><td>Commands</td><td>{{$.TotalCommands}} vs {{.TotalCommands}}</td><td>{{change .CommandsChange}}</td></tr>
+<tr><td>Active days</td><td>{{$.ActiveDays}} vs {{.ActiveDays}}</td><td>{{change .ActiveDaysChange}}</td></tr>
+<tr><td>Longest streak</td><td>{{$.LongestStreak}} vs {{.LongestStreak}} days</td><td></td></tr>
+<tr><td>Personality</td><td>{{$.Personality.Name}} (was {{.Personality.Name}})</td><td></td></tr>
+</table>
+{{- if .RisingTools}}
+<p style="font-size:14px;">Rising: {{join .RisingTools ", "}}</p>
+{{- end}}
+{{- if .FadingTools}}
+<p style="font-size:14px;">Fading: {{join .FadingTools ", "}}</p>
+{{- end}}
+{{- end}}
+
+<p style="margin-top:24px;font-size:12px;color:#8b949e;">Generated by Termonaut on {{date .GeneratedAt}}</p>
+</td></tr>
+</table>
+</body>
+</html>
+`
+
+// WrappedSVGTemplate is the built-in shareable SVG card
+const WrappedSVGTemplate = `<svg xmlns="http://www.w3.org/2000/svg" width="600" height="900" viewBox="0 0 600 900" font-family="-apple-system,Segoe UI,Helvetica,Arial,sans-serif">
+<defs>
+<linearGradient id="bg" x1="0" y1="0" x2="1" y2="1">
+<stop offset="0%" stop-color="#1f1147"/>
+<stop offset="100%" stop-color="#0d1117"/>
+</linearGradient>
+</defs>
+<rect width="600" height="900" rx="24" fill="url(#bg)"/>
+<text x="40" y="70" font-size="30" font-weight="bold" fill="#ffffff">Termonaut Wrapped {{.Year}}</text>
+<text x="40" y="98" font-size="14" fill="#a5a5c8">{{date .Start}} – {{date .End}}</text>
+
+<text x="40" y="180" font-size="64" font-weight="bold" fill="#58a6ff">{{.TotalCommands}}</text>
+<text x="40" y="208" font-size="16" fill="#a5a5c8">commands · {{.UniqueCommands}} unique</text>
+
+<text x="40" y="270" font-size="32" font-weight="bold" fill="#3fb950">{{.ActiveDays}}</text>
+<text x="40" y="292" font-size="13" fill="#a5a5c8">active days</text>
+<text x="220" y="270" font-size="32" font-weight="bold" fill="#f78166">{{.LongestStreak}}</text>
+<text x="220" y="292" font-size="13" fill="#a5a5c8">day streak</text>
+<text x="400" y="270" font-size="32" font-weight="bold" fill="#d2a8ff">{{printf "%.0f" .SuccessRate}}%</text>
+<text x="400" y="292" font-size="13" fill="#a5a5c8">success rate</text>
+
+<text x="40" y="350" font-size="18" font-weight="bold" fill="#ffffff">Top tools</text>
+{{- if .TopTools}}
+{{- $max := (index .TopTools 0).Count}}
+{{- range $i, $tool := .TopTools}}
+<text x="40" y="{{add 384 (mul $i 34)}}" font-size="15" fill="#e6edf3">{{$tool.Tool}}</text>
+<rect x="180" y="{{add 371 (mul $i 34)}}" width="{{scale $tool.Count $max 300}}" height="16" rx="8" fill="#58a6ff"/>
+<text x="{{add 190 (scale $tool.Count $max 300)}}" y="{{add 384 (mul $i 34)}}" font-size="13" fill="#a5a5c8">{{$tool.Count}}</text>
+{{- end}}
+{{- end}}
+
+<text x="40" y="590" font-size="18" font-weight="bold" fill="#ffffff">{{.Personality.Icon}} {{.Personality.Name}}</text>
+<text x="40" y="614" font-size="12" fill="#a5a5c8">{{.Personality.Description}}</text>
+
+<text x="40" y="670" font-size="14" fill="#e6edf3">Busiest day: {{date .BusiestDay.Date}} ({{.BusiestDay.Count}} commands)</text>
+<text x="40" y="694" font-size="14" fill="#e6edf3">Peak hour: {{hour .PeakHour}}</text>
+{{- if .NewTools}}
+<text x="40" y="718" font-size="14" fill="#e6edf3">New tools: {{range $i, $tool := .NewTools}}{{if $i}}, {{end}}{{$tool.Tool}}{{end}}</text>
+{{- end}}
+{{- with .Comparison}}
+<text x="40" y="770" font-size="14" fill="#3fb950">vs {{.Year}}: {{change .CommandsChange}} commands, {{change .ActiveDaysChange}} active days</text>
+{{- end}}
+
+<text x="40" y="860" font-size="12" fill="#6e7681">Generated by Termonaut on {{date .GeneratedAt}}</text>
+</svg>
+`
@@ -123,10 +123,31 @@ func (h *HookInstaller) InstallWithForce(force bool) error {
 	return nil
 }
 
+// GenerateHook returns the hook content installed for a shell, running the
+// binary at binaryPath
+func GenerateHook(shellType ShellType, binaryPath string) (string, error) {
+	h := &HookInstaller{shellType: shellType, binaryPath: binaryPath}
+	switch shellType {
+	case Zsh:
+		return h.generateZshHook(), nil
+	case Bash:
+		return h.generateBashHook(), nil
+	case Fish:
+		return h.generateFishHook(), nil
+	case PowerShell:
+		return h.generatePowerShellHook(), nil
+	}
+	return "", fmt.Errorf("unsupported shell: %s", shellType)
+}
+
 // generateZshHook generates the Zsh hook content
 func (h *HookInstaller) generateZshHook() string {
 	return fmt.Sprintf(`# Termonaut shell integration (v0.9.3 Safe)
 termonaut_preexec() {
+    _termonaut_last_command="$1"
+    # Number the command so its exit status can be matched to it
+    _termonaut_seq=$(( ${_termonaut_seq:-0} + 1 ))
+
     # Complete job control suppression - eliminate ALL job messages
     {
         # Disable job control notifications globally
@@ -138,7 +159,7 @@ termonaut_preexec() {
         # Method 1: Use subshell with complete isolation
         (
             # Run in completely isolated subshell
-            %s log-command "$1" >/dev/null 2>&1 &
+            %s log-command --pid $$ --seq $_termonaut_seq -- "$1" >/dev/null 2>&1 &
             disown %%%% 2>/dev/null || true
         ) >/dev/null 2>&1 &
 
@@ -164,7 +185,28 @@ fi
 # Add our function to preexec_functions if not already present
 if [[ ! " ${preexec_functions[@]} " =~ " termonaut_preexec " ]]; then
     preexec_functions+=(termonaut_preexec)
-fi`, h.binaryPath)
+fi
+
+# Record how the last command ended, and suggest a correction when it was
+# not found
+termonaut_precmd() {
+    local exit_code=$?
+    if [[ -n "$_termonaut_last_command" ]]; then
+        ( %s log-exit --pid $$ --seq $_termonaut_seq $exit_code >/dev/null 2>&1 & ) >/dev/null 2>&1
+        if [[ $exit_code -eq 127 ]]; then
+            %s did-you-mean "$_termonaut_last_command"
+        fi
+    fi
+    _termonaut_last_command=""
+}
+
+if [[ -z "${precmd_functions+x}" ]]; then
+    precmd_functions=()
+fi
+
+if [[ ! " ${precmd_functions[@]} " =~ " termonaut_precmd " ]]; then
+    precmd_functions+=(termonaut_precmd)
+fi`, h.binaryPath, h.binaryPath, h.binaryPath)
 }
 
 // generateBashHook generates the Bash hook content
@@ -172,15 +214,24 @@ func (h *HookInstaller) generateBashHook() string {
 	return fmt.Sprintf(`# Termonaut shell integration (v0.9.3 Safe)
 termonaut_log_command() {
     if [ -n "$BASH_COMMAND" ]; then
+        # The DEBUG trap also fires for PROMPT_COMMAND, which is not a command
+        case "$BASH_COMMAND" in
+            termonaut_postexec*) return ;;
+        esac
+        _termonaut_last_command="$BASH_COMMAND"
+        # Number the command so its exit status can be matched to it
+        _termonaut_seq=$(( ${_termonaut_seq:-0} + 1 ))
+
         # Complete job control suppression - eliminate ALL job messages
         {
             # Disable job control globally
             set +m 2>/dev/null || true
             set +b 2>/dev/null || true
 
-            # Method 1: Use exec with complete redirection
+            # Method 1: Use exec with complete redirection; in the subshell
+            # BASH_COMMAND is already the exec itself
             (
-                exec %s log-command "$BASH_COMMAND" >/dev/null 2>&1 &
+                exec %s log-command --pid $$ --seq $_termonaut_seq -- "$_termonaut_last_command" >/dev/null 2>&1 &
             ) 2>/dev/null &
 
             # Method 2: Disown all background jobs
@@ -193,17 +244,50 @@ termonaut_log_command() {
     fi
 }
 
-# Set up DEBUG trap
-trap 'termonaut_log_command' DEBUG`, h.binaryPath)
+# Record how the last command ended, and suggest a correction when it was
+# not found
+termonaut_postexec() {
+    local exit_code=$?
+    if [ -n "$_termonaut_last_command" ]; then
+        ( exec %s log-exit --pid $$ --seq $_termonaut_seq $exit_code >/dev/null 2>&1 & ) 2>/dev/null
+        if [ "$exit_code" -eq 127 ]; then
+            %s did-you-mean "$_termonaut_last_command"
+        fi
+    fi
+    _termonaut_last_command=""
+    return $exit_code
+}
+
+case ";${PROMPT_COMMAND};" in
+    *";termonaut_postexec;"*) ;;
+    *) PROMPT_COMMAND="termonaut_postexec${PROMPT_COMMAND:+;$PROMPT_COMMAND}" ;;
+esac
+
+# Set up DEBUG trap last, so the lines above are not logged
+trap 'termonaut_log_command' DEBUG`, h.binaryPath, h.binaryPath, h.binaryPath)
 }
 
 // generateFishHook generates the Fish hook content
 func (h *HookInstaller) generateFishHook() string {
 	return fmt.Sprintf(`# Termonaut shell integration (v0.9.3 Safe)
 function termonaut_preexec --on-event fish_preexec
-    %s log-command "$argv" >/dev/null 2>&1 &
+    # Number the command so its exit status can be matched to it
+    set -q _termonaut_seq; or set -g _termonaut_seq 0
+    set -g _termonaut_seq (math $_termonaut_seq + 1)
+    %s log-command --pid $fish_pid --seq $_termonaut_seq -- "$argv" >/dev/null 2>&1 &
+    disown
+end
+
+# Record how the last command ended, and suggest a correction when it was
+# not found
+function termonaut_postexec --on-event fish_postexec
+    set -l exit_code $status
+    %s log-exit --pid $fish_pid --seq $_termonaut_seq $exit_code >/dev/null 2>&1 &
     disown
-end`, h.binaryPath)
+    if test $exit_code -eq 127
+        %s did-you-mean "$argv"
+    end
+end`, h.binaryPath, h.binaryPath, h.binaryPath)
 }
 
 // generatePowerShellHook generates the PowerShell hook content
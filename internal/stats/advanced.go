@@ -6,6 +6,8 @@ import (
 	"strings"
 	"time"
 
+	"github.com/oiahoon/termonaut/internal/analytics"
+	"github.com/oiahoon/termonaut/internal/calendar"
 	"github.com/oiahoon/termonaut/internal/categories"
 	"github.com/oiahoon/termonaut/internal/database"
 	"github.com/oiahoon/termonaut/pkg/models"
@@ -190,7 +192,7 @@ func (asm *AdvancedStatsManager) CalculateCommandScores(commands []*models.Comma
 		score := &CommandScore{
 			Command:      cmd,
 			Score:        1.0, // Base score
-			Category:     asm.classifier.ClassifyCommand(cmd.Command),
+			Category:     asm.classifier.CommandCategory(cmd),
 			AppliedRules: []string{},
 		}
 
@@ -245,6 +247,14 @@ func (asm *AdvancedStatsManager) PerformBulkOperation(operation *BulkOperation)
 		Errors:    []string{},
 	}
 
+	// Categories are rewritten in the database, in resumable batches
+	if operation.Type == "update_categories" {
+		result, err := asm.performUpdateCategories(operation, result)
+		result.EndTime = time.Now()
+		result.Duration = result.EndTime.Sub(result.StartTime)
+		return result, err
+	}
+
 	// Get commands matching the filter
 	commands, err := asm.FilterCommands(operation.Filters)
 	if err != nil {
@@ -254,8 +264,6 @@ func (asm *AdvancedStatsManager) PerformBulkOperation(operation *BulkOperation)
 	switch operation.Type {
 	case "recalculate_xp":
 		result, err = asm.performRecalculateXP(commands, operation, result)
-	case "update_categories":
-		result, err = asm.performUpdateCategories(commands, operation, result)
 	case "export_data":
 		result, err = asm.performExportData(commands, operation, result)
 	case "delete_commands":
@@ -289,17 +297,18 @@ func (asm *AdvancedStatsManager) GetAdvancedAnalytics(filter *AdvancedFilter) (*
 		return nil, fmt.Errorf("failed to filter commands: %w", err)
 	}
 
-	analytics := &AdvancedAnalytics{
-		Filter:          filter,
-		TotalCommands:   len(commands),
-		TimeRange:       calculateTimeRange(commands),
-		CategoryBreakdown: asm.calculateCategoryBreakdown(commands),
+	result := &AdvancedAnalytics{
+		Filter:             filter,
+		TotalCommands:      len(commands),
+		TimeRange:          calculateTimeRange(commands),
+		CategoryBreakdown:  asm.calculateCategoryBreakdown(commands),
 		PerformanceMetrics: asm.calculatePerformanceMetrics(commands),
-		TrendAnalysis:   asm.calculateTrendAnalysis(commands),
-		Recommendations: asm.generateRecommendations(commands),
+		TrendAnalysis:      asm.calculateTrendAnalysis(commands),
+		Anomalies:          analytics.NewAnomalyDetector().DetectAnomalies(commands),
 	}
+	result.Recommendations = asm.generateRecommendations(commands)
 
-	return analytics, nil
+	return result, nil
 }
 
 // AdvancedAnalytics represents comprehensive analytics results
@@ -310,7 +319,8 @@ type AdvancedAnalytics struct {
 	CategoryBreakdown  map[categories.Category]*CategoryData `json:"category_breakdown"`
 	PerformanceMetrics *PerformanceMetrics                    `json:"performance_metrics"`
 	TrendAnalysis      *TrendAnalysis                         `json:"trend_analysis"`
-	Recommendations    []string                               `json:"recommendations"`
+	Anomalies          []*analytics.Anomaly                   `json:"anomalies"`
+	Recommendations    []*analytics.Recommendation            `json:"recommendations"`
 }
 
 // TimeRange represents a time range
@@ -332,11 +342,13 @@ type CategoryData struct {
 
 // PerformanceMetrics represents performance analysis
 type PerformanceMetrics struct {
-	AverageExecutionTime float64 `json:"average_execution_time"`
-	SuccessRate          float64 `json:"success_rate"`
-	CommandsPerHour      float64 `json:"commands_per_hour"`
-	PeakHours            []int   `json:"peak_hours"`
-	ProductivityScore    float64 `json:"productivity_score"`
+	AverageExecutionTime float64                           `json:"average_execution_time"` // seconds, timed commands only
+	SuccessRate          float64                           `json:"success_rate"` // of the commands with a recorded exit status
+	CommandsPerHour      float64                           `json:"commands_per_hour"`
+	PeakHours            []int                             `json:"peak_hours"`
+	ProductivityScore    float64                           `json:"productivity_score"`
+	DurationPercentiles  *analytics.DurationPercentiles    `json:"duration_percentiles"`
+	TimeSinks            []*analytics.CommandDurationStats `json:"time_sinks"`
 }
 
 // TrendAnalysis represents trend analysis data
@@ -399,11 +411,24 @@ func (asm *AdvancedStatsManager) performRecalculateXP(commands []*models.Command
 	return result, nil
 }
 
-func (asm *AdvancedStatsManager) performUpdateCategories(commands []*models.Command, operation *BulkOperation, result *BulkOperationResult) (*BulkOperationResult, error) {
-	if !operation.DryRun {
-		// Actually update categories
+// performUpdateCategories reclassifies stored commands with the current
+// rules. Filters.DateFrom limits it to recent commands, and the
+// "recompute_xp" parameter adjusts XP for the changed categories.
+func (asm *AdvancedStatsManager) performUpdateCategories(operation *BulkOperation, result *BulkOperationResult) (*BulkOperationResult, error) {
+	opts := database.ReclassifyOptions{DryRun: operation.DryRun}
+	if operation.Filters != nil && operation.Filters.DateFrom != nil {
+		opts.Since = *operation.Filters.DateFrom
 	}
-	result.Affected = len(commands)
+	if recompute, ok := operation.Parameters["recompute_xp"].(bool); ok {
+		opts.RecomputeXP = recompute
+	}
+
+	reclassified, err := asm.db.ReclassifyCommands(opts)
+	if err != nil {
+		return result, fmt.Errorf("failed to reclassify commands: %w", err)
+	}
+	result.Affected = reclassified.Changed
+	result.Details = reclassified
 	return result, nil
 }
 
@@ -460,14 +485,64 @@ func (asm *AdvancedStatsManager) calculateCategoryBreakdown(commands []*models.C
 }
 
 func (asm *AdvancedStatsManager) calculatePerformanceMetrics(commands []*models.Command) *PerformanceMetrics {
-	// Implementation would calculate performance metrics
-	return &PerformanceMetrics{
-		AverageExecutionTime: 0.5,
-		SuccessRate:          0.85,
-		CommandsPerHour:      120,
-		PeakHours:            []int{9, 10, 14, 15},
-		ProductivityScore:    87.5,
+	metrics := &PerformanceMetrics{
+		PeakHours:           []int{},
+		DurationPercentiles: &analytics.DurationPercentiles{},
+		TimeSinks:           []*analytics.CommandDurationStats{},
+	}
+	if len(commands) == 0 {
+		return metrics
+	}
+
+	finished, successful := 0, 0
+	hourCounts := make(map[int]int)
+	activeHours := make(map[string]bool)
+	for _, cmd := range commands {
+		if cmd.Finished {
+			finished++
+			if cmd.ExitCode == 0 {
+				successful++
+			}
+		}
+		hourCounts[calendar.Default().Clock(cmd.Timestamp).Hour()]++
+		activeHours[cmd.Timestamp.Format("2006-01-02 15")] = true
 	}
+
+	if finished > 0 {
+		metrics.SuccessRate = float64(successful) / float64(finished)
+	}
+	metrics.CommandsPerHour = float64(len(commands)) / float64(len(activeHours))
+
+	// Peak hours are the four busiest hours of the day, in clock order
+	hours := make([]int, 0, len(hourCounts))
+	for hour := range hourCounts {
+		hours = append(hours, hour)
+	}
+	sort.Slice(hours, func(i, j int) bool {
+		if hourCounts[hours[i]] != hourCounts[hours[j]] {
+			return hourCounts[hours[i]] > hourCounts[hours[j]]
+		}
+		return hours[i] < hours[j]
+	})
+	if len(hours) > 4 {
+		hours = hours[:4]
+	}
+	sort.Ints(hours)
+	metrics.PeakHours = hours
+
+	durationReport := analytics.NewDurationAnalyzer().AnalyzeDurations(commands)
+	if durationReport.TimedCommands > 0 {
+		metrics.AverageExecutionTime = float64(durationReport.TotalTimeMS) / float64(durationReport.TimedCommands) / 1000
+		metrics.DurationPercentiles = durationReport.Overall
+		metrics.TimeSinks = durationReport.TimeSinks
+		if len(metrics.TimeSinks) > 5 {
+			metrics.TimeSinks = metrics.TimeSinks[:5]
+		}
+	}
+
+	metrics.ProductivityScore = analytics.NewProductivityAnalyzer().AnalyzeProductivity(commands, nil).OverallScore
+
+	return metrics
 }
 
 func (asm *AdvancedStatsManager) calculateTrendAnalysis(commands []*models.Command) *TrendAnalysis {
@@ -480,13 +555,12 @@ func (asm *AdvancedStatsManager) calculateTrendAnalysis(commands []*models.Comma
 	}
 }
 
-func (asm *AdvancedStatsManager) generateRecommendations(commands []*models.Command) []string {
-	recommendations := []string{
-		"Consider automating frequently repeated command sequences",
-		"Your git workflow shows room for optimization",
-		"Peak productivity hours are 9-10 AM and 2-3 PM",
-		"Docker usage has increased 25% this week",
+func (asm *AdvancedStatsManager) generateRecommendations(commands []*models.Command) []*analytics.Recommendation {
+	dismissed, err := asm.db.GetDismissedRecommendations()
+	if err != nil {
+		dismissed = nil // show everything rather than nothing
 	}
-	
-	return recommendations
-} 
\ No newline at end of file
+
+	ctx := analytics.NewRecommendationContext(commands, time.Now())
+	return analytics.NewRecommendationEngine().Evaluate(ctx, dismissed)
+}
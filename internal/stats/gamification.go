@@ -3,6 +3,7 @@ package stats
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/oiahoon/termonaut/internal/gamification"
 	"github.com/oiahoon/termonaut/pkg/models"
@@ -15,6 +16,7 @@ type GamificationStats struct {
 	Achievements       map[string]*gamification.UserAchievement `json:"achievements"`
 	RecentAchievements []*gamification.UserAchievement          `json:"recent_achievements"`
 	NextAchievements   []AchievementProgress                    `json:"next_achievements"`
+	Forecast           *gamification.Forecast                   `json:"forecast,omitempty"`
 }
 
 // AchievementProgress represents progress towards an achievement
@@ -59,15 +61,35 @@ func (s *StatsCalculator) GetGamificationStats() (*GamificationStats, error) {
 	// Get next achievements to unlock
 	nextAchievements := s.getNextAchievements(achievements, userProgress)
 
+	// Forecast upcoming goals from recent XP velocity; forecasting is best effort
+	var forecast *gamification.Forecast
+	if history, err := s.db.GetDailyActivity(gamification.ForecastWindowDays); err == nil {
+		forecast = gamification.NewForecaster().Forecast(
+			userStatsFromProgress(userProgress), achievements, history, time.Now())
+	}
+
 	return &GamificationStats{
 		BasicStats:         basicStats,
 		ProgressInfo:       progressInfo,
 		Achievements:       achievements,
 		RecentAchievements: recentAchievements,
 		NextAchievements:   nextAchievements,
+		Forecast:           forecast,
 	}, nil
 }
 
+// userStatsFromProgress converts stored progress into achievement stats
+func userStatsFromProgress(userProgress *models.UserProgress) *gamification.UserStats {
+	return &gamification.UserStats{
+		TotalCommands:  userProgress.CommandsCount,
+		UniqueCommands: userProgress.UniqueCommandsCount,
+		CurrentStreak:  userProgress.CurrentStreak,
+		LongestStreak:  userProgress.LongestStreak,
+		TotalXP:        userProgress.TotalXP,
+		CurrentLevel:   userProgress.CurrentLevel,
+	}
+}
+
 // getRecentAchievements returns the most recently earned achievements
 func (s *StatsCalculator) getRecentAchievements(achievements map[string]*gamification.UserAchievement, limit int) []*gamification.UserAchievement {
 	var recent []*gamification.UserAchievement
@@ -98,14 +120,7 @@ func (s *StatsCalculator) getNextAchievements(earned map[string]*gamification.Us
 	achievementManager := gamification.NewAchievementManager()
 	allAchievements := achievementManager.GetAllAchievements()
 
-	stats := &gamification.UserStats{
-		TotalCommands:  userProgress.CommandsCount,
-		UniqueCommands: userProgress.UniqueCommandsCount,
-		CurrentStreak:  userProgress.CurrentStreak,
-		LongestStreak:  userProgress.LongestStreak,
-		TotalXP:        userProgress.TotalXP,
-		CurrentLevel:   userProgress.CurrentLevel,
-	}
+	stats := userStatsFromProgress(userProgress)
 
 	var nextAchievements []AchievementProgress
 
@@ -227,6 +242,22 @@ func (s *StatsCalculator) FormatGamificationStats(stats *GamificationStats) stri
 
 	builder.WriteString(fmt.Sprintf("🎖️ Achievements Unlocked: %d/%d\n", totalAchievements, maxAchievements))
 
+	// Forecast
+	if stats.Forecast != nil {
+		builder.WriteString("\n🔮 Forecast:\n")
+		builder.WriteString(fmt.Sprintf("  ⚡ XP velocity: %.0f/day (range %.0f–%.0f, %d of last %d days active)\n",
+			stats.Forecast.XPVelocity.PerDay,
+			stats.Forecast.XPVelocity.Low,
+			stats.Forecast.XPVelocity.High,
+			stats.Forecast.XPVelocity.ActiveDays,
+			stats.Forecast.XPVelocity.WindowDays))
+		builder.WriteString(fmt.Sprintf("  🌟 %s\n", stats.Forecast.NextLevel.Message))
+		if stats.Forecast.NextAchievement != nil {
+			builder.WriteString(fmt.Sprintf("  🏆 %s\n", stats.Forecast.NextAchievement.Message))
+		}
+		builder.WriteString(fmt.Sprintf("  🔥 %s\n", stats.Forecast.StreakRecord.Message))
+	}
+
 	return builder.String()
 }
 
@@ -5,6 +5,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/oiahoon/termonaut/internal/analytics"
 	"github.com/oiahoon/termonaut/internal/database"
 )
 
@@ -40,7 +41,8 @@ func (s *StatsCalculator) GetBasicStats() (*BasicStats, error) {
 		return nil, fmt.Errorf("failed to get basic stats from database: %w", err)
 	}
 
-	topCommands, err := s.db.GetTopCommands(10)
+	// Group by tool and subcommand so arguments don't split counts
+	topCommands, err := s.db.GetTopNormalizedCommands(10)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get top commands: %w", err)
 	}
@@ -54,9 +56,9 @@ func (s *StatsCalculator) GetBasicStats() (*BasicStats, error) {
 	}
 
 	// Set most used command if available
-	if cmd, ok := basicStats["most_used_command"].(string); ok {
-		stats.MostUsedCommand = cmd
-		stats.MostUsedCount = basicStats["most_used_count"].(int)
+	if len(topCommands) > 0 {
+		stats.MostUsedCommand = topCommands[0]["command"].(string)
+		stats.MostUsedCount = topCommands[0]["count"].(int)
 	}
 
 	// Get first and last command times
@@ -71,6 +73,17 @@ func (s *StatsCalculator) GetBasicStats() (*BasicStats, error) {
 	return stats, nil
 }
 
+// ComputeMetrics evaluates user-defined metrics over the full history, with
+// a daily series covering the last days days
+func (s *StatsCalculator) ComputeMetrics(engine *analytics.MetricEngine, days int) ([]*analytics.MetricValue, error) {
+	commands, err := s.db.GetAllCommands()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get commands: %w", err)
+	}
+
+	return engine.Compute(commands, time.Now(), days), nil
+}
+
 // FormatBasicStats returns a formatted string representation of basic stats
 func (s *StatsCalculator) FormatBasicStats(stats *BasicStats) string {
 	var builder strings.Builder
@@ -131,6 +131,11 @@ func (apb *AnimatedProgressBar) IsAnimating() bool {
 	return apb.isAnimating
 }
 
+// SetSpeed sets how fast the bar fills, in percent per second
+func (apb *AnimatedProgressBar) SetSpeed(percentPerSecond float64) {
+	apb.animSpeed = percentPerSecond
+}
+
 // XPProgressRenderer handles XP progress rendering with animations
 type XPProgressRenderer struct {
 	progressBar *AnimatedProgressBar
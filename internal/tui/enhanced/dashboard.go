@@ -2,6 +2,7 @@ package enhanced
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 	"time"
 
@@ -10,7 +11,9 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 
+	"github.com/oiahoon/termonaut/internal/analytics"
 	"github.com/oiahoon/termonaut/internal/avatar"
+	"github.com/oiahoon/termonaut/internal/categories"
 	"github.com/oiahoon/termonaut/internal/database"
 	"github.com/oiahoon/termonaut/internal/stats"
 	"github.com/oiahoon/termonaut/pkg/models"
@@ -60,6 +63,19 @@ type EnhancedDashboard struct {
 	userProgress *models.UserProgress
 	basicStats   *stats.BasicStats
 	avatar       *avatar.Avatar
+	anomalies    []*analytics.Anomaly
+	commands     []*models.Command
+	focusReport  *analytics.FocusReport
+	dirTree      *analytics.DirectoryNode
+	metricValues []*analytics.MetricValue
+	categoryStats []*categories.CategoryStats // top-level categories, most used first
+	
+	// User-defined metrics from config.toml
+	metricEngine *analytics.MetricEngine
+	
+	// Directory tree navigation (Activity tab)
+	dirCursor    int
+	dirExpanded  map[string]bool
 	
 	// Theme and styling
 	theme        *Theme
@@ -79,6 +95,9 @@ type KeyMap struct {
 	NextTab     key.Binding
 	PrevTab     key.Binding
 	Settings    key.Binding
+	Up          key.Binding
+	Down        key.Binding
+	Toggle      key.Binding
 }
 
 // DefaultKeyMap returns the default key bindings
@@ -108,6 +127,18 @@ func DefaultKeyMap() KeyMap {
 			key.WithKeys("s"),
 			key.WithHelp("s", "settings"),
 		),
+		Up: key.NewBinding(
+			key.WithKeys("up", "k"),
+			key.WithHelp("↑/k", "move up"),
+		),
+		Down: key.NewBinding(
+			key.WithKeys("down", "j"),
+			key.WithHelp("↓/j", "move down"),
+		),
+		Toggle: key.NewBinding(
+			key.WithKeys("enter", " "),
+			key.WithHelp("enter", "expand/collapse"),
+		),
 	}
 }
 
@@ -136,6 +167,7 @@ func NewEnhancedDashboard(db *database.DB) *EnhancedDashboard {
 		xpRenderer:     NewXPProgressRenderer(), // Initialize XP renderer
 		theme:          DefaultSpaceTheme(),
 		keyMap:         DefaultKeyMap(),
+		dirExpanded:    make(map[string]bool),
 		modePreference: "smart", // Default to smart mode
 	}
 }
@@ -145,6 +177,11 @@ func (d *EnhancedDashboard) SetModePreference(mode string) {
 	d.modePreference = mode
 }
 
+// SetMetrics sets the user-defined metrics shown on the Analytics tab
+func (d *EnhancedDashboard) SetMetrics(engine *analytics.MetricEngine) {
+	d.metricEngine = engine
+}
+
 // Init initializes the dashboard
 func (d *EnhancedDashboard) Init() tea.Cmd {
 	return tea.Batch(
@@ -192,6 +229,23 @@ func (d *EnhancedDashboard) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			
 		case key.Matches(msg, d.keyMap.Settings):
 			d.activeTab = SettingsTab
+			
+		case d.activeTab == ActivityTab && key.Matches(msg, d.keyMap.Up):
+			if d.dirCursor > 0 {
+				d.dirCursor--
+			}
+			
+		case d.activeTab == ActivityTab && key.Matches(msg, d.keyMap.Down):
+			if d.dirCursor < len(d.directoryRows())-1 {
+				d.dirCursor++
+			}
+			
+		case d.activeTab == ActivityTab && key.Matches(msg, d.keyMap.Toggle):
+			rows := d.directoryRows()
+			if d.dirCursor < len(rows) && rows[d.dirCursor].Expandable {
+				path := rows[d.dirCursor].Node.Path
+				d.dirExpanded[path] = !rows[d.dirCursor].Expanded
+			}
 		}
 		
 	case dataLoadedMsg:
@@ -199,6 +253,15 @@ func (d *EnhancedDashboard) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		d.userProgress = msg.userProgress
 		d.basicStats = msg.basicStats
 		d.avatar = msg.avatar
+		d.anomalies = msg.anomalies
+		d.commands = msg.commands
+		d.focusReport = msg.focusReport
+		d.dirTree = msg.dirTree
+		d.metricValues = msg.metricValues
+		d.categoryStats = msg.categoryStats
+		if rows := d.directoryRows(); d.dirCursor >= len(rows) {
+			d.dirCursor = 0
+		}
 		
 	case spinner.TickMsg:
 		d.spinner, cmd = d.spinner.Update(msg)
@@ -423,12 +486,13 @@ func (d *EnhancedDashboard) renderHomeTabWide() string {
 	// Bottom row: Recent commands
 	recentCommands := d.renderRecentCommands()
 	
-	return lipgloss.JoinVertical(
-		lipgloss.Left,
-		topRow,
-		"",
-		recentCommands,
-	)
+	sections := []string{topRow, ""}
+	if len(d.anomalies) > 0 {
+		sections = append(sections, d.renderAnomalies(), "")
+	}
+	sections = append(sections, recentCommands)
+	
+	return lipgloss.JoinVertical(lipgloss.Left, sections...)
 }
 
 // renderHomeTabNarrow renders home tab for narrow terminals
@@ -436,8 +500,11 @@ func (d *EnhancedDashboard) renderHomeTabNarrow() string {
 	sections := []string{
 		d.renderQuickStats(),
 		"",
-		d.renderRecentCommands(),
 	}
+	if len(d.anomalies) > 0 {
+		sections = append(sections, d.renderAnomalies(), "")
+	}
+	sections = append(sections, d.renderRecentCommands())
 	
 	return lipgloss.JoinVertical(lipgloss.Left, sections...)
 }
@@ -929,6 +996,27 @@ func (d *EnhancedDashboard) renderRecentCommands() string {
 	return cardStyle.Render(content)
 }
 
+// renderAnomalies renders unusual activity from the past week
+func (d *EnhancedDashboard) renderAnomalies() string {
+	cardStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(d.theme.Colors.Warning).
+		Padding(0, 1).
+		Width(d.windowWidth - 4)
+	
+	content := "🔎 Unusual Activity:"
+	for i, anomaly := range d.anomalies {
+		if i >= 3 {
+			break
+		}
+		content += "\n" + lipgloss.NewStyle().
+			Foreground(d.theme.Colors.Text).
+			Render(fmt.Sprintf("  %s %s  %s", analytics.AnomalyIcon(anomaly.Type), anomaly.Date, anomaly.Description))
+	}
+	
+	return cardStyle.Render(content)
+}
+
 // renderFooter renders the help footer
 func (d *EnhancedDashboard) renderFooter() string {
 	footerStyle := lipgloss.NewStyle().
@@ -965,7 +1053,13 @@ func (d *EnhancedDashboard) renderAnalyticsTab() string {
 	sections := []string{
 		d.renderAnalyticsOverview(),
 		d.renderCommandBreakdown(),
-		d.renderProductivityTrends(),
+	}
+	if len(d.categoryStats) > 0 {
+		sections = append(sections, d.renderCategoryBreakdown())
+	}
+	sections = append(sections, d.renderProductivityTrends())
+	if len(d.metricValues) > 0 {
+		sections = append(sections, d.renderCustomMetrics())
 	}
 	
 	return lipgloss.JoinVertical(lipgloss.Left, sections...)
@@ -1029,6 +1123,31 @@ func (d *EnhancedDashboard) renderCommandBreakdown() string {
 	return style.Render(content)
 }
 
+// renderCategoryBreakdown shows the top categories with their subcategories
+// indented below them
+func (d *EnhancedDashboard) renderCategoryBreakdown() string {
+	style := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("99")).
+		Padding(1).
+		Margin(1)
+	
+	classifier := categories.NewCommandClassifier()
+	content := "🗂️  Categories:\n\n"
+	for i, stat := range d.categoryStats {
+		if i >= 6 { break } // Show top 6
+		info := classifier.GetCategoryInfo(stat.Category)
+		bar := strings.Repeat("█", int(stat.Percentage/5))
+		content += fmt.Sprintf("  %s %-22s %5.1f%% %s\n", info.Icon, info.Name, stat.Percentage, bar)
+		for j, sub := range sortCategoryStats(stat.Subcategories) {
+			if j >= 3 { break } // Top 3 subcategories each
+			content += fmt.Sprintf("     ↳ %-20s %5.1f%%\n", classifier.GetCategoryInfo(sub.Category).Name, sub.Percentage)
+		}
+	}
+	
+	return style.Render(content)
+}
+
 func (d *EnhancedDashboard) renderProductivityTrends() string {
 	style := lipgloss.NewStyle().
 		Border(lipgloss.RoundedBorder()).
@@ -1051,6 +1170,23 @@ func (d *EnhancedDashboard) renderProductivityTrends() string {
 	return style.Render(content)
 }
 
+// renderCustomMetrics renders the user-defined metrics with 30-day sparklines
+func (d *EnhancedDashboard) renderCustomMetrics() string {
+	style := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("141")).
+		Padding(1).
+		Margin(1)
+	
+	content := "📏 Custom Metrics\n"
+	for _, value := range d.metricValues {
+		content += fmt.Sprintf("\n  %-18s %8s  today %-4d 7d %-5d %s",
+			value.Name, value.Display(), value.Today, value.Last7Days, value.Sparkline())
+	}
+	
+	return style.Render(content)
+}
+
 func (d *EnhancedDashboard) renderGamificationTab() string {
 	if d.userProgress == nil {
 		return "🎮 Loading gamification data..."
@@ -1153,12 +1289,84 @@ func (d *EnhancedDashboard) renderActivityTab() string {
 	sections := []string{
 		d.renderRecentActivity(),
 		d.renderSessionHistory(),
+		d.renderFocusTimeline(),
+		d.renderDirectoryTree(),
 		d.renderActivityHeatmap(),
 	}
 	
 	return lipgloss.JoinVertical(lipgloss.Left, sections...)
 }
 
+// renderFocusTimeline renders deep-work blocks for the past week
+func (d *EnhancedDashboard) renderFocusTimeline() string {
+	style := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("86")).
+		Padding(1).
+		Margin(1)
+	
+	if d.focusReport == nil || d.focusReport.TotalBlocks == 0 {
+		return style.Render("🎯 Deep Work\n\nNo focus blocks detected yet.")
+	}
+	
+	analyzer := analytics.NewFocusAnalyzer()
+	content := fmt.Sprintf("🎯 Deep Work\n\n🧠 %d blocks | Avg %.0f min | Longest %.0f min\n\n",
+		d.focusReport.TotalBlocks, d.focusReport.AverageMinutes, d.focusReport.LongestMinutes)
+	content += analyzer.FormatFocusTimeline(d.focusReport, d.commands, 7, time.Now())
+	
+	return style.Render(content)
+}
+
+// directoryRows returns the visible rows of the directory tree. Top-level
+// directories start expanded; everything else follows the user's toggles.
+func (d *EnhancedDashboard) directoryRows() []analytics.DirectoryRow {
+	if d.dirTree == nil {
+		return nil
+	}
+	
+	analyzer := analytics.NewDirectoryAnalyzer(nil)
+	return analyzer.FlattenTree(d.dirTree, func(node *analytics.DirectoryNode, depth int) bool {
+		if expanded, toggled := d.dirExpanded[node.Path]; toggled {
+			return expanded
+		}
+		return depth == 0
+	}, 8)
+}
+
+// renderDirectoryTree renders the interactive directory hotspot tree
+func (d *EnhancedDashboard) renderDirectoryTree() string {
+	style := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("214")).
+		Padding(1).
+		Margin(1)
+	
+	rows := d.directoryRows()
+	if len(rows) == 0 {
+		return style.Render("📁 Directory Hotspots\n\nNo directory information recorded yet.")
+	}
+	
+	nameWidth := 40
+	if d.windowWidth < 90 {
+		nameWidth = 28
+	}
+	
+	content := "📁 Directory Hotspots  (↑/↓ move • enter expand/collapse)\n\n"
+	content += fmt.Sprintf("  %-*s %7s %9s %7s\n", nameWidth, "Directory", "Commands", "Time", "Fail%")
+	
+	cursorStyle := lipgloss.NewStyle().Foreground(d.theme.Colors.Accent).Bold(true)
+	for i, row := range rows {
+		line := analytics.FormatDirectoryRow(row, nameWidth)
+		if i == d.dirCursor {
+			content += cursorStyle.Render("▶ "+line) + "\n"
+		} else {
+			content += "  " + line + "\n"
+		}
+	}
+	
+	return style.Render(strings.TrimSuffix(content, "\n"))
+}
+
 func (d *EnhancedDashboard) renderRecentActivity() string {
 	style := lipgloss.NewStyle().
 		Border(lipgloss.RoundedBorder()).
@@ -1414,6 +1622,12 @@ type dataLoadedMsg struct {
 	userProgress *models.UserProgress
 	basicStats   *stats.BasicStats
 	avatar       *avatar.Avatar
+	anomalies    []*analytics.Anomaly
+	commands     []*models.Command
+	focusReport  *analytics.FocusReport
+	dirTree      *analytics.DirectoryNode
+	metricValues []*analytics.MetricValue
+	categoryStats []*categories.CategoryStats
 }
 
 func (d *EnhancedDashboard) loadInitialData() tea.Cmd {
@@ -1472,10 +1686,35 @@ func (d *EnhancedDashboard) loadInitialData() tea.Cmd {
 		// If avatar generation fails, we'll use the default avatar in renderAvatarContent
 		// No need to create a mock here since renderDefaultAvatar handles it
 		
+		// Analyze command history for unusual activity, deep-work blocks and
+		// directory hotspots (paths anonymized with the default sanitizer settings)
+		var anomalies []*analytics.Anomaly
+		var focusReport *analytics.FocusReport
+		var dirTree *analytics.DirectoryNode
+		var metricValues []*analytics.MetricValue
+		var categoryStats []*categories.CategoryStats
+		commands, err := d.db.GetAllCommands()
+		if err == nil {
+			detected := analytics.NewAnomalyDetector().DetectAnomalies(commands)
+			anomalies = analytics.RecentAnomalies(detected, time.Now(), 7)
+			focusReport = analytics.NewFocusAnalyzer().AnalyzeFocus(commands)
+			dirTree = analytics.NewDirectoryAnalyzer(nil).BuildTree(commands)
+			if d.metricEngine != nil {
+				metricValues = d.metricEngine.Compute(commands, time.Now(), 30)
+			}
+			categoryStats = analyzeCategoryBreakdown(commands)
+		}
+		
 		return dataLoadedMsg{
 			userProgress: progress,
 			basicStats:   basicStats,
 			avatar:       avatarResult,
+			anomalies:    anomalies,
+			commands:     commands,
+			focusReport:  focusReport,
+			dirTree:      dirTree,
+			metricValues: metricValues,
+			categoryStats: categoryStats,
 		}
 	}
 }
@@ -1496,3 +1735,24 @@ func generateMockAvatar() string {
  ||   ||
  /\   /\`
 }
+
+// analyzeCategoryBreakdown rolls commands up into top-level categories,
+// most used first
+func analyzeCategoryBreakdown(commands []*models.Command) []*categories.CategoryStats {
+	return sortCategoryStats(categories.NewCommandClassifier().AnalyzeCommandCategories(commands))
+}
+
+// sortCategoryStats orders category stats by share, most used first
+func sortCategoryStats(stats map[categories.Category]*categories.CategoryStats) []*categories.CategoryStats {
+	sorted := make([]*categories.CategoryStats, 0, len(stats))
+	for _, stat := range stats {
+		sorted = append(sorted, stat)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Share != sorted[j].Share {
+			return sorted[i].Share > sorted[j].Share
+		}
+		return sorted[i].Category < sorted[j].Category
+	})
+	return sorted
+}
@@ -0,0 +1,267 @@
+package enhanced
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/oiahoon/termonaut/internal/categories"
+	"github.com/oiahoon/termonaut/internal/database"
+)
+
+// triageVisibleCategories is how many categories the picker shows at once
+const triageVisibleCategories = 10
+
+// AssignFunc assigns a tool to a category, returning how many stored
+// commands changed category
+type AssignFunc func(tool string, category categories.Category) (int, error)
+
+// TriageModel walks the most used unknown tools and lets the user pick a
+// category for each one
+type TriageModel struct {
+	tools    []*database.UnknownTool
+	current  int
+	options  []categories.Category
+	infos    map[categories.Category]*categories.CategoryInfo
+	cursor   int
+	assign   AssignFunc
+	input    textinput.Model
+	typing   bool // entering a new category
+	message  string
+	assigned int
+	skipped  int
+	updated  int // stored commands that changed category
+	width    int
+	theme    *Theme
+}
+
+// NewTriageModel creates the triage screen for unknown tools
+func NewTriageModel(tools []*database.UnknownTool, classifier *categories.CommandClassifier, assign AssignFunc) *TriageModel {
+	input := textinput.New()
+	input.Placeholder = "infra or cloud/terraform"
+	input.CharLimit = 64
+
+	return &TriageModel{
+		tools:   tools,
+		options: triageOptions(classifier),
+		infos:   classifier.GetAllCategories(),
+		assign:  assign,
+		input:   input,
+		theme:   DefaultSpaceTheme(),
+	}
+}
+
+// triageOptions lists the known categories, each followed by its subcategories
+func triageOptions(classifier *categories.CommandClassifier) []categories.Category {
+	var options []categories.Category
+	for category := range classifier.GetAllCategories() {
+		if category != categories.Unknown {
+			options = append(options, category)
+		}
+	}
+	sortTriageOptions(options)
+	return options
+}
+
+// sortTriageOptions orders categories by name, each followed by its subcategories
+func sortTriageOptions(options []categories.Category) {
+	sort.Slice(options, func(i, j int) bool {
+		a, b := options[i], options[j]
+		if a.Root() != b.Root() {
+			return a.Root() < b.Root()
+		}
+		return a < b
+	})
+}
+
+// Summary describes what the triage did, for printing after it closes
+func (m *TriageModel) Summary() string {
+	return fmt.Sprintf("Assigned %d tools, skipped %d, %d stored commands updated",
+		m.assigned, m.skipped, m.updated)
+}
+
+// Init does nothing; the screen waits for keys
+func (m *TriageModel) Init() tea.Cmd {
+	return nil
+}
+
+// Update handles picking, skipping and typing a new category
+func (m *TriageModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+
+	case tea.KeyMsg:
+		if msg.String() == "ctrl+c" {
+			return m, tea.Quit
+		}
+		if m.current >= len(m.tools) {
+			return m, tea.Quit
+		}
+
+		if m.typing {
+			switch msg.String() {
+			case "enter":
+				m.typing = false
+				m.input.Blur()
+				category := categories.Category(strings.TrimSpace(m.input.Value()))
+				m.input.SetValue("")
+				if category != "" {
+					return m, m.assignCurrent(category)
+				}
+			case "esc":
+				m.typing = false
+				m.input.Blur()
+				m.input.SetValue("")
+			default:
+				var cmd tea.Cmd
+				m.input, cmd = m.input.Update(msg)
+				return m, cmd
+			}
+			return m, nil
+		}
+
+		switch msg.String() {
+		case "q", "esc":
+			return m, tea.Quit
+		case "up", "k":
+			if m.cursor > 0 {
+				m.cursor--
+			}
+		case "down", "j":
+			if m.cursor < len(m.options)-1 {
+				m.cursor++
+			}
+		case "enter":
+			if len(m.options) > 0 {
+				return m, m.assignCurrent(m.options[m.cursor])
+			}
+		case "n":
+			m.typing = true
+			return m, m.input.Focus()
+		case "s", "right", "l":
+			m.message = fmt.Sprintf("⏭️  Skipped %s", m.tools[m.current].Tool)
+			m.skipped++
+			return m, m.next()
+		}
+	}
+
+	return m, nil
+}
+
+// assignCurrent assigns the current tool and moves on, or stays on it
+// with the error
+func (m *TriageModel) assignCurrent(category categories.Category) tea.Cmd {
+	tool := m.tools[m.current].Tool
+	changed, err := m.assign(tool, category)
+	if err != nil {
+		m.message = fmt.Sprintf("❌ %v", err)
+		return nil
+	}
+	m.message = fmt.Sprintf("✅ %s → %s (%d commands updated)", tool, category, changed)
+	m.addOption(category)
+	m.assigned++
+	m.updated += changed
+	return m.next()
+}
+
+// addOption offers a newly created category for the following tools
+func (m *TriageModel) addOption(category categories.Category) {
+	for _, option := range m.options {
+		if option == category {
+			return
+		}
+	}
+	m.options = append(m.options, category)
+	sortTriageOptions(m.options)
+}
+
+// next moves to the following tool, quitting after the last one
+func (m *TriageModel) next() tea.Cmd {
+	m.current++
+	m.cursor = 0
+	if m.current >= len(m.tools) {
+		return tea.Quit
+	}
+	return nil
+}
+
+// View renders the current tool and the category picker
+func (m *TriageModel) View() string {
+	titleStyle := lipgloss.NewStyle().Foreground(m.theme.Colors.Primary).Bold(true)
+	toolStyle := lipgloss.NewStyle().Foreground(m.theme.Colors.Accent).Bold(true)
+	mutedStyle := lipgloss.NewStyle().Foreground(m.theme.Colors.TextMuted)
+	selectedStyle := lipgloss.NewStyle().Foreground(m.theme.Colors.Success).Bold(true)
+	hintStyle := lipgloss.NewStyle().Foreground(m.theme.Colors.TextMuted).Italic(true)
+
+	var content strings.Builder
+	content.WriteString(titleStyle.Render("🗂️  Unknown Command Triage"))
+	content.WriteString("\n\n")
+
+	if m.current >= len(m.tools) {
+		content.WriteString("🎉 Nothing left to triage.\n\n")
+		content.WriteString(mutedStyle.Render(m.Summary()))
+		return content.String() + "\n"
+	}
+
+	tool := m.tools[m.current]
+	content.WriteString(fmt.Sprintf("%s  %s\n",
+		toolStyle.Render(tool.Tool),
+		mutedStyle.Render(fmt.Sprintf("%d commands • %d/%d", tool.Commands, m.current+1, len(m.tools)))))
+	for _, example := range tool.Examples {
+		content.WriteString(mutedStyle.Render("  $ "+example) + "\n")
+	}
+	content.WriteString("\n")
+
+	if m.typing {
+		content.WriteString("New category: " + m.input.View() + "\n")
+	} else {
+		start := m.cursor - triageVisibleCategories/2
+		if start > len(m.options)-triageVisibleCategories {
+			start = len(m.options) - triageVisibleCategories
+		}
+		if start < 0 {
+			start = 0
+		}
+		for i := start; i < len(m.options) && i < start+triageVisibleCategories; i++ {
+			category := m.options[i]
+			label := string(category)
+			if info := m.infos[category]; info != nil {
+				label = fmt.Sprintf("%s %s", info.Icon, category)
+			}
+			if category.Parent() != "" {
+				label = "  " + label
+			}
+			if i == m.cursor {
+				content.WriteString(selectedStyle.Render("▸ "+label) + "\n")
+			} else {
+				content.WriteString("  " + label + "\n")
+			}
+		}
+	}
+
+	if m.message != "" {
+		content.WriteString("\n" + m.message + "\n")
+	}
+	content.WriteString("\n")
+	hint := "↑/↓: choose • enter: assign • n: new category • s: skip • q: quit"
+	if m.typing {
+		hint = "enter: assign • esc: back to the list"
+	}
+	content.WriteString(hintStyle.Render(hint))
+
+	box := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(m.theme.Colors.Primary).
+		Padding(1, 3).
+		Render(content.String())
+
+	if m.width > 0 {
+		return lipgloss.PlaceHorizontal(m.width, lipgloss.Center, box)
+	}
+	return box
+}
@@ -0,0 +1,288 @@
+package enhanced
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/oiahoon/termonaut/internal/analytics"
+	"github.com/oiahoon/termonaut/internal/report"
+)
+
+const (
+	wrappedFrame     = 50 * time.Millisecond // animation tick
+	wrappedHold      = 3 * time.Second       // how long a finished slide stays up
+	wrappedBarWidth  = 40
+	wrappedFillSpeed = 60.0 // percent per second
+)
+
+// wrappedSlide is one screen of the wrapped animation
+type wrappedSlide struct {
+	title   string
+	value   string
+	lines   []string
+	percent float64 // how far the progress bar fills
+}
+
+type wrappedTickMsg time.Time
+
+// WrappedModel plays a year-in-review recap as an animated slideshow
+type WrappedModel struct {
+	wrapped    *report.Wrapped
+	slides     []wrappedSlide
+	current    int
+	bar        *AnimatedProgressBar
+	finishedAt time.Time
+	width      int
+	theme      *Theme
+}
+
+// NewWrappedModel creates the slideshow for a recap
+func NewWrappedModel(w *report.Wrapped) *WrappedModel {
+	m := &WrappedModel{
+		wrapped: w,
+		slides:  buildWrappedSlides(w),
+		theme:   DefaultSpaceTheme(),
+	}
+	m.resetBar()
+	return m
+}
+
+func (m *WrappedModel) resetBar() {
+	m.bar = NewAnimatedProgressBar(wrappedBarWidth)
+	m.bar.SetSpeed(wrappedFillSpeed)
+	m.finishedAt = time.Time{}
+}
+
+func wrappedTick() tea.Cmd {
+	return tea.Tick(wrappedFrame, func(t time.Time) tea.Msg {
+		return wrappedTickMsg(t)
+	})
+}
+
+// Init starts the animation
+func (m *WrappedModel) Init() tea.Cmd {
+	return wrappedTick()
+}
+
+// Update advances the animation and handles navigation keys
+func (m *WrappedModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "q", "esc", "ctrl+c":
+			return m, tea.Quit
+		case " ", "enter", "right", "l":
+			if m.current == len(m.slides)-1 {
+				return m, tea.Quit
+			}
+			m.current++
+			m.resetBar()
+		case "left", "h":
+			if m.current > 0 {
+				m.current--
+				m.resetBar()
+			}
+		}
+
+	case wrappedTickMsg:
+		m.bar.Update(m.slides[m.current].percent)
+		if !m.bar.IsAnimating() {
+			if m.finishedAt.IsZero() {
+				m.finishedAt = time.Time(msg)
+			} else if m.current < len(m.slides)-1 && time.Time(msg).Sub(m.finishedAt) >= wrappedHold {
+				m.current++
+				m.resetBar()
+			}
+		}
+		return m, wrappedTick()
+	}
+
+	return m, nil
+}
+
+// View renders the current slide
+func (m *WrappedModel) View() string {
+	slide := m.slides[m.current]
+
+	titleStyle := lipgloss.NewStyle().Foreground(m.theme.Colors.TextMuted)
+	valueStyle := lipgloss.NewStyle().Foreground(m.theme.Colors.Accent).Bold(true)
+	textStyle := lipgloss.NewStyle().Foreground(m.theme.Colors.Text)
+	hintStyle := lipgloss.NewStyle().Foreground(m.theme.Colors.TextMuted).Italic(true)
+
+	var content strings.Builder
+	content.WriteString(lipgloss.NewStyle().Foreground(m.theme.Colors.Primary).Bold(true).
+		Render(fmt.Sprintf("🎁 Termonaut Wrapped %d", m.wrapped.Year)))
+	content.WriteString("\n\n")
+	content.WriteString(titleStyle.Render(slide.title))
+	content.WriteString("\n")
+	content.WriteString(valueStyle.Render(slide.value))
+	content.WriteString("\n\n")
+	content.WriteString(m.bar.Render())
+	content.WriteString("\n")
+	for _, line := range slide.lines {
+		content.WriteString("\n")
+		content.WriteString(textStyle.Render(line))
+	}
+	content.WriteString("\n\n")
+
+	hint := "space: next • ←: back • q: quit"
+	if m.current == len(m.slides)-1 {
+		hint = "space/q: close"
+	}
+	content.WriteString(hintStyle.Render(fmt.Sprintf("%d/%d  %s", m.current+1, len(m.slides), hint)))
+
+	box := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(m.theme.Colors.Primary).
+		Padding(1, 3).
+		Width(wrappedBarWidth + 8).
+		Render(content.String())
+
+	if m.width > 0 {
+		return lipgloss.PlaceHorizontal(m.width, lipgloss.Center, box)
+	}
+	return box
+}
+
+// buildWrappedSlides turns a recap into slides, skipping empty sections
+func buildWrappedSlides(w *report.Wrapped) []wrappedSlide {
+	if w.TotalCommands == 0 {
+		return []wrappedSlide{{
+			title: fmt.Sprintf("%s %s", w.Personality.Icon, w.Personality.Name),
+			value: fmt.Sprintf("No commands recorded in %d", w.Year),
+			lines: []string{w.Personality.Description},
+		}}
+	}
+
+	slides := []wrappedSlide{
+		{
+			title:   "Commands run this year",
+			value:   fmt.Sprintf("%d", w.TotalCommands),
+			lines:   []string{fmt.Sprintf("%d unique command lines", w.UniqueCommands), fmt.Sprintf("%.1f%% succeeded", w.SuccessRate)},
+			percent: w.SuccessRate,
+		},
+		{
+			title:   "Active days",
+			value:   fmt.Sprintf("%d of %d", w.ActiveDays, w.DaysInYear),
+			lines:   []string{fmt.Sprintf("Longest streak: %d days", w.LongestStreak)},
+			percent: ratio(w.ActiveDays, w.DaysInYear),
+		},
+	}
+
+	if len(w.TopTools) > 0 {
+		top := w.TopTools[0]
+		var lines []string
+		for i, tool := range w.TopTools {
+			lines = append(lines, fmt.Sprintf("%d. %-12s %6d  %5.1f%%", i+1, tool.Tool, tool.Count, tool.Percentage))
+		}
+		slides = append(slides, wrappedSlide{
+			title:   "Your top tool",
+			value:   top.Tool,
+			lines:   lines,
+			percent: top.Percentage,
+		})
+	}
+
+	slides = append(slides, wrappedSlide{
+		title: "Busiest day",
+		value: w.BusiestDay.Date.Format("Monday, January 2"),
+		lines: []string{
+			fmt.Sprintf("%d commands in one day", w.BusiestDay.Count),
+			fmt.Sprintf("Peak hour: %02d:00", w.PeakHour),
+		},
+		percent: ratio(w.BusiestDay.Count, w.TotalCommands),
+	})
+
+	if len(w.TimeSinks) > 0 {
+		var lines []string
+		for _, sink := range w.TimeSinks {
+			lines = append(lines, fmt.Sprintf("%s — %s over %d runs", sink.Command, analytics.FormatDuration(sink.TotalMS), sink.Count))
+		}
+		slides = append(slides, wrappedSlide{
+			title:   "Biggest time sink",
+			value:   analytics.FormatDuration(w.TimeSinks[0].TotalMS),
+			lines:   lines,
+			percent: ratio64(w.TimeSinks[0].TotalMS, w.TotalTimeMS),
+		})
+	}
+
+	if len(w.NewTools) > 0 {
+		var lines []string
+		for _, tool := range w.NewTools {
+			lines = append(lines, fmt.Sprintf("%s — since %s, %d uses", tool.Tool, tool.FirstSeen.Format("Jan 2"), tool.Uses))
+		}
+		slides = append(slides, wrappedSlide{
+			title:   "New tools learned",
+			value:   fmt.Sprintf("%d", len(w.NewTools)),
+			lines:   lines,
+			percent: 100,
+		})
+	}
+
+	if len(w.Achievements) > 0 {
+		var lines []string
+		for _, achievement := range w.Achievements {
+			lines = append(lines, fmt.Sprintf("%s %s", achievement.Icon, achievement.Name))
+		}
+		slides = append(slides, wrappedSlide{
+			title:   "Achievements earned",
+			value:   fmt.Sprintf("%d", len(w.Achievements)),
+			lines:   lines,
+			percent: 100,
+		})
+	}
+
+	slides = append(slides, wrappedSlide{
+		title:   "Your terminal personality",
+		value:   fmt.Sprintf("%s %s", w.Personality.Icon, w.Personality.Name),
+		lines:   []string{w.Personality.Description},
+		percent: w.Personality.Intensity,
+	})
+
+	if c := w.Comparison; c != nil {
+		lines := []string{
+			fmt.Sprintf("Commands:    %d vs %d (%+.1f%%)", w.TotalCommands, c.TotalCommands, c.CommandsChange),
+			fmt.Sprintf("Active days: %d vs %d (%+.1f%%)", w.ActiveDays, c.ActiveDays, c.ActiveDaysChange),
+			fmt.Sprintf("Streak:      %d vs %d days", w.LongestStreak, c.LongestStreak),
+		}
+		if len(c.RisingTools) > 0 {
+			lines = append(lines, "Rising: "+strings.Join(c.RisingTools, ", "))
+		}
+		if len(c.FadingTools) > 0 {
+			lines = append(lines, "Fading: "+strings.Join(c.FadingTools, ", "))
+		}
+		slides = append(slides, wrappedSlide{
+			title:   fmt.Sprintf("Compared with %d", c.Year),
+			value:   fmt.Sprintf("%+.1f%% commands", c.CommandsChange),
+			lines:   lines,
+			percent: ratio(w.TotalCommands, w.TotalCommands+c.TotalCommands),
+		})
+	}
+
+	slides = append(slides, wrappedSlide{
+		title:   "That's a wrap",
+		value:   fmt.Sprintf("See you in %d 🚀", w.Year+1),
+		lines:   []string{"Share it: termonaut wrapped --out <dir>"},
+		percent: 100,
+	})
+
+	return slides
+}
+
+func ratio(part, total int) float64 {
+	return ratio64(int64(part), int64(total))
+}
+
+func ratio64(part, total int64) float64 {
+	if total <= 0 {
+		return 0
+	}
+	return float64(part) / float64(total) * 100
+}
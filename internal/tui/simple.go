@@ -8,6 +8,7 @@ import (
 	"github.com/charmbracelet/lipgloss"
 
 	"github.com/oiahoon/termonaut/internal/analytics"
+	"github.com/oiahoon/termonaut/internal/calendar"
 	"github.com/oiahoon/termonaut/internal/database"
 	"github.com/oiahoon/termonaut/internal/stats"
 	"github.com/oiahoon/termonaut/pkg/models"
@@ -284,7 +285,7 @@ func (m SimpleDashboard) renderHeatmap() string {
 	// Create hour-based activity map
 	hourActivity := make(map[int]int)
 	for _, cmd := range m.commands {
-		hour := cmd.Timestamp.Hour()
+		hour := calendar.Default().Clock(cmd.Timestamp).Hour()
 		hourActivity[hour]++
 	}
 
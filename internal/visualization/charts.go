@@ -292,9 +292,9 @@ func (cr *ChartRenderer) RenderCategoryDistribution(commands []*models.Command)
 	classifier := categories.NewCommandClassifier()
 	categoryCount := make(map[string]float64)
 
-	// Count commands by category
+	// Count commands by top-level category
 	for _, cmd := range commands {
-		category := classifier.ClassifyCommand(cmd.Command)
+		category := classifier.CommandCategory(cmd).Root()
 		info := classifier.GetCategoryInfo(category)
 		categoryCount[fmt.Sprintf("%s %s", info.Icon, info.Name)]++
 	}
@@ -13,6 +13,10 @@ type Command struct {
 	ExitCode   int       `json:"exit_code" db:"exit_code"`
 	CWD        string    `json:"cwd" db:"cwd"`
 	DurationMS int64     `json:"duration_ms" db:"duration_ms"`
+	Category   string    `json:"category,omitempty" db:"category"`     // primary category, set when stored
+	Anonymized bool      `json:"anonymized,omitempty" db:"anonymized"` // command and cwd are hashes, see privacy.Anonymizer
+	ShellSeq   int       `json:"-" db:"shell_seq"`                     // the shell's number for the command, to match its exit status
+	Finished   bool      `json:"finished,omitempty" db:"finished"`     // the shell reported the exit code and duration
 }
 
 // Session represents a terminal session
@@ -58,3 +62,16 @@ type DailyStats struct {
 	XPEarned            int       `json:"xp_earned" db:"xp_earned"`
 	CreatedAt           time.Time `json:"created_at" db:"created_at"`
 }
+
+// ProgressSnapshot records user progress as of the end of a day
+type ProgressSnapshot struct {
+	Date                string `json:"date" db:"date"`
+	TotalXP             int    `json:"total_xp" db:"total_xp"`
+	Level               int    `json:"level" db:"level"`
+	CommandsCount       int    `json:"commands_count" db:"commands_count"`
+	UniqueCommandsCount int    `json:"unique_commands_count" db:"unique_commands_count"`
+	CurrentStreak       int    `json:"current_streak" db:"current_streak"`
+	LongestStreak       int    `json:"longest_streak" db:"longest_streak"`
+	AchievementsCount   int    `json:"achievements_count" db:"achievements_count"`
+	Estimated           bool   `json:"estimated" db:"estimated"` // backfilled from command history
+}
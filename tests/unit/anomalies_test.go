@@ -0,0 +1,109 @@
+package unit
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/oiahoon/termonaut/internal/analytics"
+	"github.com/oiahoon/termonaut/pkg/models"
+)
+
+// baselineHistory creates two weeks of steady daytime activity
+func baselineHistory(start time.Time, days int) []*models.Command {
+	var commands []*models.Command
+	for day := 0; day < days; day++ {
+		for i := 0; i < 20+day%3; i++ {
+			commands = append(commands, &models.Command{
+				Command:   fmt.Sprintf("git status %d", i%2),
+				Timestamp: start.AddDate(0, 0, day).Add(time.Duration(10*60+i) * time.Minute),
+				ExitCode:  i % 10 / 9, // one failure per ten commands
+			})
+		}
+	}
+	return commands
+}
+
+func TestAnomalyDetection(t *testing.T) {
+	start := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	spikeDay := start.AddDate(0, 0, 14)
+
+	tests := []struct {
+		name     string
+		extra    func() []*models.Command
+		expected analytics.AnomalyType
+		subject  string
+	}{
+		{
+			name: "failure spike",
+			extra: func() []*models.Command {
+				var commands []*models.Command
+				for i := 0; i < 25; i++ {
+					commands = append(commands, &models.Command{Command: "git push", ExitCode: 1, Timestamp: spikeDay.Add(11 * time.Hour)})
+				}
+				return commands
+			},
+			expected: analytics.AnomalyFailureSpike,
+		},
+		{
+			name: "all nighter",
+			extra: func() []*models.Command {
+				var commands []*models.Command
+				for i := 0; i < 15; i++ {
+					commands = append(commands, &models.Command{Command: "git log", Timestamp: spikeDay.Add(3*time.Hour + time.Duration(i)*time.Minute)})
+				}
+				return commands
+			},
+			expected: analytics.AnomalyAllNighter,
+		},
+		{
+			name: "tool surge",
+			extra: func() []*models.Command {
+				var commands []*models.Command
+				for i := 0; i < 12; i++ {
+					commands = append(commands, &models.Command{Command: "kubectl get pods", Timestamp: spikeDay.Add(14 * time.Hour)})
+				}
+				return commands
+			},
+			expected: analytics.AnomalyToolSurge,
+			subject:  "kubectl",
+		},
+	}
+
+	detector := analytics.NewAnomalyDetector()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			commands := append(baselineHistory(start, 15), tt.extra()...)
+			anomalies := detector.DetectAnomalies(commands)
+
+			found := false
+			for _, anomaly := range anomalies {
+				if anomaly.Type == tt.expected && anomaly.Subject == tt.subject {
+					found = true
+					if anomaly.Date != spikeDay.Format("2006-01-02") {
+						t.Errorf("Expected anomaly on %s, got %s", spikeDay.Format("2006-01-02"), anomaly.Date)
+					}
+				}
+			}
+			if !found {
+				t.Errorf("Expected %s anomaly, got %+v", tt.expected, anomalies)
+			}
+		})
+	}
+}
+
+func TestAnomalyDetectionSteadyHistory(t *testing.T) {
+	start := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	anomalies := analytics.NewAnomalyDetector().DetectAnomalies(baselineHistory(start, 30))
+	if len(anomalies) != 0 {
+		t.Errorf("Expected no anomalies for steady usage, got %+v", anomalies)
+	}
+}
+
+func TestAnomalyDetectionNeedsBaseline(t *testing.T) {
+	start := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	anomalies := analytics.NewAnomalyDetector().DetectAnomalies(baselineHistory(start, 3))
+	if len(anomalies) != 0 {
+		t.Errorf("Expected no anomalies without enough history, got %d", len(anomalies))
+	}
+}
@@ -0,0 +1,241 @@
+package unit
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/oiahoon/termonaut/internal/categories"
+	"github.com/oiahoon/termonaut/internal/database"
+	"github.com/oiahoon/termonaut/internal/privacy"
+	"github.com/oiahoon/termonaut/pkg/models"
+	"github.com/sirupsen/logrus"
+)
+
+func TestAnonymizer(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data", privacy.AnonymousKeyFileName)
+	key, err := privacy.LoadOrCreateKey(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("key file %s: %v, want mode 0600", path, err)
+	}
+	again, err := privacy.LoadOrCreateKey(path)
+	if err != nil || string(again) != string(key) {
+		t.Fatalf("reloading the key gave another one (%v)", err)
+	}
+
+	anonymizer := privacy.NewAnonymizer(key)
+	other := privacy.NewAnonymizer([]byte("another key of thirty-two bytes!"))
+
+	if anonymizer.Hash("ls -la") != anonymizer.Hash("ls -la") {
+		t.Error("the same value hashed differently")
+	}
+	if anonymizer.Hash("ls -la") == anonymizer.Hash("ls -l") {
+		t.Error("different values hashed the same")
+	}
+	if anonymizer.Hash("ls -la") == other.Hash("ls -la") {
+		t.Error("different keys hashed the same")
+	}
+	if dir := anonymizer.AnonymizeDirectory("/home/alice/clients/acme"); !privacy.IsHash(dir) ||
+		anonymizer.AnonymizeDirectory(dir) != dir {
+		t.Errorf("AnonymizeDirectory = %q, want a hash that is kept when anonymized again", dir)
+	}
+
+	classifier := categories.NewCommandClassifier()
+	tests := []struct {
+		command string
+		tool    string
+	}{
+		{`git commit -m "fix login for alice@example.com"`, "git"},
+		{"sudo kubectl get pods -n prod | grep api", "kubectl"},
+		{"FOO=1", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.command, func(t *testing.T) {
+			category := classifier.ClassifyCommand(tt.command)
+			stored, classification := anonymizer.AnonymizeCommand(tt.command, classifier)
+
+			if base := categories.BaseCommand(stored); tt.tool != "" && base != tt.tool {
+				t.Errorf("AnonymizeCommand(%q) = %q, base command %q, want %q", tt.command, stored, base, tt.tool)
+			}
+			if hash := stored[strings.LastIndex(stored, " ")+1:]; !privacy.IsHash(hash) {
+				t.Errorf("AnonymizeCommand(%q) = %q, want it to end with a hash", tt.command, stored)
+			}
+			if classification.Primary != category {
+				t.Errorf("AnonymizeCommand changed the category to %s", classification.Primary)
+			}
+			for _, segment := range classification.Segments {
+				if !strings.HasPrefix(segment.Command, segment.Tool) || strings.Contains(segment.Command, "alice") ||
+					strings.Contains(segment.Command, "prod") {
+					t.Errorf("segment %q keeps more than its tool", segment.Command)
+				}
+			}
+		})
+	}
+}
+
+func TestAnonymizeCommands(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+	db, err := database.New(t.TempDir(), logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	session, err := db.GetOrCreateSession(12345, "zsh")
+	if err != nil {
+		t.Fatal(err)
+	}
+	commands := []string{
+		`git commit -m "secret plan"`,
+		`git commit -m "secret plan"`,
+		"git clone https://github.com/acme/repo.git",
+		"cat notes.txt | grep acme",
+		"ls",
+	}
+	for i, command := range commands {
+		cmd := &models.Command{
+			Timestamp: time.Now().Add(time.Duration(i) * time.Minute),
+			SessionID: session.ID,
+			Command:   command,
+			CWD:       "/home/alice/clients/acme",
+		}
+		if err := db.StoreCommand(cmd); err != nil {
+			t.Fatal(err)
+		}
+	}
+	before, err := db.GetAllCommands()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	anonymizer := privacy.NewAnonymizer([]byte("a test key of thirty-two bytes!!"))
+	report, err := db.AnonymizeCommands(anonymizer, database.AnonymizeOptions{DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Pending != 5 || report.Directories != 1 || report.Anonymized != 0 {
+		t.Errorf("dry run report = %+v, want 5 pending from 1 directory", report)
+	}
+
+	report, err = db.AnonymizeCommands(anonymizer, database.AnonymizeOptions{BatchSize: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Anonymized != 5 || report.Segments != 6 {
+		t.Errorf("report = %+v, want 5 commands and 6 segments anonymized", report)
+	}
+
+	after, err := db.GetAllCommands()
+	if err != nil {
+		t.Fatal(err)
+	}
+	byID := make(map[int64]*models.Command)
+	for _, cmd := range before {
+		byID[cmd.ID] = cmd
+	}
+	stored := make(map[string]int)
+	for _, cmd := range after {
+		original := byID[cmd.ID]
+		stored[cmd.Command]++
+		if strings.Contains(cmd.Command, "secret") || strings.Contains(cmd.Command, "acme") || strings.Contains(cmd.CWD, "alice") {
+			t.Errorf("command %d keeps plaintext: %q in %q", cmd.ID, cmd.Command, cmd.CWD)
+		}
+		if categories.BaseCommand(cmd.Command) != categories.BaseCommand(original.Command) {
+			t.Errorf("command %d stored as %q, want the tool of %q kept", cmd.ID, cmd.Command, original.Command)
+		}
+		if cmd.Category != original.Category {
+			t.Errorf("command %d category %q, want %q kept", cmd.ID, cmd.Category, original.Category)
+		}
+		segments, err := db.GetCommandSegments(cmd.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, segment := range segments {
+			if strings.Contains(segment.Command, "acme") || strings.Contains(segment.Command, "notes") {
+				t.Errorf("segment keeps plaintext: %q", segment.Command)
+			}
+		}
+	}
+	if len(stored) != 4 {
+		t.Errorf("%d distinct stored commands, want 4: repeated commands share a hash", len(stored))
+	}
+
+	// New commands logged in anonymous mode match the migrated ones, and
+	// arguments still tell commands apart
+	classifier := categories.NewCommandClassifier()
+	clone, _ := anonymizer.AnonymizeCommand("git clone https://github.com/acme/repo.git", classifier)
+	other, _ := anonymizer.AnonymizeCommand("git clone https://github.com/acme/other.git", classifier)
+	if _, ok := stored[clone]; !ok || clone == other {
+		t.Errorf("logging git clone gives %q, other repo %q; want the migrated command and a different hash", clone, other)
+	}
+
+	report, err = db.AnonymizeCommands(anonymizer, database.AnonymizeOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Pending != 0 || report.Anonymized != 0 {
+		t.Errorf("second run report = %+v, want nothing left to anonymize", report)
+	}
+
+	result, err := db.ReclassifyCommands(database.ReclassifyOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Processed != 0 {
+		t.Errorf("reclassify processed %d anonymized commands, want 0", result.Processed)
+	}
+}
+
+func TestAnonymizedCategoryStats(t *testing.T) {
+	t.Cleanup(func() { categories.SetLearned(nil) })
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+	db, err := database.New(t.TempDir(), logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.AssignCategory("frob", categories.Build); err != nil {
+		t.Fatal(err)
+	}
+
+	session, err := db.GetOrCreateSession(12345, "zsh")
+	if err != nil {
+		t.Fatal(err)
+	}
+	anonymizer := privacy.NewAnonymizer([]byte("a test key of thirty-two bytes!!"))
+	classifier := categories.NewCommandClassifier()
+	for _, command := range []string{"git push origin main", "git log --oneline", "git log -p", "frob --all"} {
+		stored, classification := anonymizer.AnonymizeCommand(command, classifier)
+		cmd := &models.Command{Timestamp: time.Now(), SessionID: session.ID, Command: stored, Anonymized: true}
+		if err := db.StoreClassifiedCommandWithXP(cmd, classification); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Learned categories change; anonymized commands keep theirs
+	categories.SetLearned(nil)
+	commands, err := db.GetAllCommands()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stats := categories.NewCommandClassifier().AnalyzeCommandCategories(commands)
+
+	git := stats[categories.Git]
+	if git == nil || git.Count != 3 || git.Subcategories[categories.GitRemote].Count != 1 ||
+		git.Subcategories[categories.GitHistory].Count != 2 {
+		t.Errorf("git stats = %+v, want 3 commands split into git/remote and git/history", git)
+	}
+	if build := stats[categories.Build]; build == nil || build.Count != 1 {
+		t.Errorf("build stats = %+v, want the learned category of frob", build)
+	}
+	if stats[categories.Unknown] != nil {
+		t.Errorf("anonymized commands were classified from their hashes: %+v", stats[categories.Unknown])
+	}
+}
@@ -0,0 +1,240 @@
+package unit
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/oiahoon/termonaut/internal/calendar"
+	"github.com/oiahoon/termonaut/internal/categories"
+	"github.com/oiahoon/termonaut/internal/config"
+	"github.com/oiahoon/termonaut/internal/database"
+	"github.com/oiahoon/termonaut/internal/query"
+	"github.com/oiahoon/termonaut/pkg/models"
+	"github.com/sirupsen/logrus"
+)
+
+func useCalendar(t *testing.T, timezone string, dayStart int) {
+	t.Helper()
+	cal, err := calendar.New(timezone, dayStart)
+	if err != nil {
+		t.Fatalf("calendar.New(%q, %d): %v", timezone, dayStart, err)
+	}
+	previous := calendar.Default()
+	calendar.SetDefault(cal)
+	t.Cleanup(func() { calendar.SetDefault(previous) })
+}
+
+func TestCalendarDayKey(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("timezone data unavailable: %v", err)
+	}
+	// 23:30 in New York, recorded while traveling; 04:30 the next day in UTC
+	travel := time.Date(2024, 3, 10, 23, 30, 0, 0, time.FixedZone("", -5*3600))
+	tokyo := time.Date(2024, 3, 11, 2, 0, 0, 0, time.FixedZone("", 9*3600))
+
+	tests := []struct {
+		name     string
+		timezone string
+		dayStart int
+		at       time.Time
+		day      string
+		hour     int
+	}{
+		{"recorded keeps the traveler's day", "recorded", 0, travel, "2024-03-10", 23},
+		{"configured zone converts", "UTC", 0, travel, "2024-03-11", 4},
+		{"day start moves early hours back", "recorded", 4, tokyo, "2024-03-10", 2},
+		{"day start in a configured zone", "Asia/Tokyo", 4, travel, "2024-03-11", 13},
+		{"spring forward", "America/New_York", 0, time.Date(2024, 3, 10, 7, 30, 0, 0, time.UTC), "2024-03-10", 3},
+		{"fall back", "America/New_York", 0, time.Date(2024, 11, 3, 5, 30, 0, 0, time.UTC), "2024-11-03", 1},
+		{"fall back before day start", "America/New_York", 2, time.Date(2024, 11, 3, 6, 30, 0, 0, time.UTC), "2024-11-02", 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cal, err := calendar.New(tt.timezone, tt.dayStart)
+			if err != nil {
+				t.Fatalf("New: %v", err)
+			}
+			if got := cal.DayKey(tt.at); got != tt.day {
+				t.Errorf("DayKey = %s, want %s", got, tt.day)
+			}
+			if got := cal.Clock(tt.at).Hour(); got != tt.hour {
+				t.Errorf("hour = %d, want %d", got, tt.hour)
+			}
+		})
+	}
+
+	cal, _ := calendar.New("America/New_York", 4)
+	start := cal.DayStart(time.Date(2024, 3, 10, 12, 0, 0, 0, newYork))
+	if want := time.Date(2024, 3, 10, 4, 0, 0, 0, newYork); !start.Equal(want) {
+		t.Errorf("DayStart = %v, want %v", start, want)
+	}
+}
+
+func TestCalendarValidation(t *testing.T) {
+	if _, err := calendar.New("Mars/Olympus_Mons", 0); err == nil {
+		t.Error("expected an error for an unknown timezone")
+	}
+	if _, err := calendar.New("", 24); err == nil {
+		t.Error("expected an error for day start 24")
+	}
+	if _, err := calendar.New("local", 23); err != nil {
+		t.Errorf("local timezone: %v", err)
+	}
+}
+
+func TestCalendarStreaks(t *testing.T) {
+	tests := []struct {
+		name    string
+		days    []string
+		today   string
+		current int
+		longest int
+	}{
+		{"empty", nil, "2024-03-12", 0, 0},
+		{"active today", []string{"2024-03-12", "2024-03-11", "2024-03-10"}, "2024-03-12", 3, 3},
+		{"not yet active today", []string{"2024-03-11", "2024-03-10"}, "2024-03-12", 2, 2},
+		{"broken streak", []string{"2024-03-09", "2024-03-08"}, "2024-03-12", 0, 2},
+		{"across a DST change", []string{"2024-03-09", "2024-03-10", "2024-03-11"}, "2024-03-11", 3, 3},
+		{"longest in the past", []string{"2024-02-27", "2024-02-28", "2024-02-29", "2024-03-01", "2024-03-12"}, "2024-03-12", 1, 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			current, longest := calendar.Streaks(tt.days, tt.today)
+			if current != tt.current || longest != tt.longest {
+				t.Errorf("Streaks = %d, %d, want %d, %d", current, longest, tt.current, tt.longest)
+			}
+		})
+	}
+
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("timezone data unavailable: %v", err)
+	}
+	before := time.Date(2024, 3, 9, 0, 0, 0, 0, newYork)
+	after := time.Date(2024, 3, 11, 0, 0, 0, 0, newYork) // only 47 hours later
+	if got := calendar.DaysBetween(before, after); got != 2 {
+		t.Errorf("DaysBetween across DST = %d, want 2", got)
+	}
+}
+
+func TestCalendarDatabaseBuckets(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+
+	db, err := database.New(t.TempDir(), logger)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	session, err := db.GetOrCreateSession(12345, "zsh")
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+
+	// Recorded at 23:30 in New York, which SQLite's DATE() puts on the 11th
+	at := time.Date(2024, 3, 10, 23, 30, 0, 0, time.FixedZone("", -5*3600))
+	if err := db.StoreCommand(&models.Command{Timestamp: at, SessionID: session.ID, Command: "ls"}); err != nil {
+		t.Fatalf("Failed to store command: %v", err)
+	}
+
+	tests := []struct {
+		timezone string
+		dayStart int
+		day      string
+		hour     int64
+	}{
+		{"recorded", 0, "2024-03-10", 23},
+		{"UTC", 0, "2024-03-11", 4},
+		{"UTC", 5, "2024-03-10", 4},
+	}
+	for _, tt := range tests {
+		useCalendar(t, tt.timezone, tt.dayStart)
+		result, err := query.Run(db, "day, hour_of_day", time.Now())
+		if err != nil {
+			t.Fatalf("Run: %v", err)
+		}
+		records := result.Records()
+		if len(records) != 1 || records[0]["day"] != tt.day || records[0]["hour_of_day"] != tt.hour {
+			t.Errorf("%s/%d: records = %v, want day %s hour %d", tt.timezone, tt.dayStart, records, tt.day, tt.hour)
+		}
+	}
+}
+
+func TestCalendarStreakInDatabase(t *testing.T) {
+	useCalendar(t, "recorded", 0)
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+
+	db, err := database.New(t.TempDir(), logger)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	session, err := db.GetOrCreateSession(12345, "zsh")
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+
+	now := time.Now()
+	noon := time.Date(now.Year(), now.Month(), now.Day(), 12, 0, 0, 0, now.Location())
+	for _, daysAgo := range []int{0, 1, 2, 5} {
+		if err := db.StoreCommand(&models.Command{
+			Timestamp: noon.AddDate(0, 0, -daysAgo),
+			SessionID: session.ID,
+			Command:   "ls",
+		}); err != nil {
+			t.Fatalf("Failed to store command: %v", err)
+		}
+	}
+
+	if err := db.UpdateStreakAndCommands(); err != nil {
+		t.Fatalf("UpdateStreakAndCommands: %v", err)
+	}
+	progress, err := db.GetUserProgress()
+	if err != nil {
+		t.Fatalf("GetUserProgress: %v", err)
+	}
+	if progress.CurrentStreak != 3 || progress.LongestStreak != 3 {
+		t.Errorf("streaks = %d, %d, want 3, 3", progress.CurrentStreak, progress.LongestStreak)
+	}
+}
+
+func TestConfigApply(t *testing.T) {
+	useCalendar(t, "UTC", 4)
+	t.Cleanup(func() { categories.SetDefinitions(nil) })
+
+	broken := filepath.Join(t.TempDir(), "categories.toml")
+	if err := os.WriteFile(broken, []byte("[[categories]\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := config.DefaultConfig()
+	cfg.Timezone = "Mars/Olympus_Mons"
+	cfg.CategoriesFile = broken
+
+	// Bad settings are reported and leave the ones in use
+	err := config.Apply(cfg)
+	if err == nil || !strings.Contains(err.Error(), "Mars/Olympus_Mons") || !strings.Contains(err.Error(), broken) {
+		t.Errorf("Apply = %v, want both the timezone and the categories file reported", err)
+	}
+	if cal := calendar.Default(); cal.DayStartHour() != 4 {
+		t.Errorf("day start = %d after a bad timezone, want 4 kept", cal.DayStartHour())
+	}
+
+	cfg = config.DefaultConfig()
+	cfg.CategoriesFile = filepath.Join(t.TempDir(), "missing.toml")
+	cfg.DayStartHour = 6
+	if err := config.Apply(cfg); err == nil {
+		t.Error("Apply accepted a missing categories file")
+	}
+	if cal := calendar.Default(); cal.DayStartHour() != 6 {
+		t.Errorf("day start = %d, want 6 applied alongside the bad categories file", cal.DayStartHour())
+	}
+}
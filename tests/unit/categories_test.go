@@ -0,0 +1,282 @@
+package unit
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/oiahoon/termonaut/internal/categories"
+	"github.com/oiahoon/termonaut/internal/gamification"
+	"github.com/oiahoon/termonaut/pkg/models"
+)
+
+func TestBuiltinClassification(t *testing.T) {
+	classifier := categories.NewBuiltinClassifier()
+
+	tests := []struct {
+		command  string
+		category categories.Category
+	}{
+		{"git push origin main", categories.GitRemote},
+		{"gh pr create", categories.Git},
+		{"ls -la", categories.System},
+		{"ls", categories.System},
+		{"lsof -i :8080", categories.Network},
+		{"wget https://example.com", categories.Network},
+		{"psql -U postgres", categories.Database},
+		{"cdk deploy", categories.Cloud},
+		{"cd ..", categories.Navigation},
+		{"k9s", categories.Kubernetes},
+		{"sudo docker ps", categories.DockerInspect}, // wrappers are skipped
+		{"sudo -i", categories.System},
+		{"frobnicate --now", categories.Unknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.command, func(t *testing.T) {
+			// Matching must not depend on map iteration order
+			for i := 0; i < 20; i++ {
+				if got := classifier.ClassifyCommand(tt.command); got != tt.category {
+					t.Fatalf("ClassifyCommand(%q) = %s, want %s", tt.command, got, tt.category)
+				}
+			}
+		})
+	}
+}
+
+func TestCategoryDefinitions(t *testing.T) {
+	bonus := 2.0
+	defs := &categories.Definitions{
+		Source: "categories.toml",
+		Categories: []categories.Definition{
+			{ID: "infra", Name: "Infrastructure", Icon: "🏗️", XPBonus: &bonus, Patterns: []string{`^(terraform|tofu)\s+`}},
+			{ID: "docker", Replace: true, Patterns: []string{`^nerdctl\s+`}},
+			{ID: "deploy", Priority: 5, Patterns: []string{`^kubectl\s+apply\b`}},
+			{ID: "git", Name: "Version Control"},
+		},
+	}
+	classifier := categories.NewCommandClassifierWith(defs)
+
+	tests := []struct {
+		command  string
+		category categories.Category
+	}{
+		{"terraform plan", "infra"},           // beats the built-in cloud rule at equal priority
+		{"nerdctl run alpine", "docker"},      // replacement pattern
+		{"docker ps", categories.Unknown},     // built-in docker patterns replaced
+		{"kubectl apply -f x.yaml", "deploy"}, // higher priority
+		{"kubectl get pods", categories.KubernetesInspect},
+		{"git status", categories.GitCommit},
+	}
+	for _, tt := range tests {
+		if got := classifier.ClassifyCommand(tt.command); got != tt.category {
+			t.Errorf("ClassifyCommand(%q) = %s, want %s", tt.command, got, tt.category)
+		}
+	}
+
+	if info := classifier.GetCategoryInfo("infra"); info.Name != "Infrastructure" || info.XPBonus != 2.0 {
+		t.Errorf("infra info = %+v", info)
+	}
+	if info := classifier.GetCategoryInfo(categories.Git); info.Name != "Version Control" || info.Icon != "🌿" || info.XPBonus != 1.5 {
+		t.Errorf("git info = %+v, want renamed with built-in icon and bonus", info)
+	}
+	if overrides := classifier.XPBonusOverrides(); len(overrides) != 1 || overrides["infra"] != 2.0 {
+		t.Errorf("XPBonusOverrides = %v", overrides)
+	}
+	if info := categories.NewBuiltinClassifier().GetCategoryInfo(categories.Git); info.Name == "Version Control" {
+		t.Error("definitions leaked into the built-in metadata")
+	}
+
+	matches := classifier.Explain("terraform apply")
+	if len(matches) != 2 || matches[0].Category != "infra" || matches[0].Source != "categories.toml" ||
+		matches[1].Category != categories.Cloud || matches[1].Source != categories.BuiltinSource {
+		t.Errorf("Explain = %+v, want infra then shadowed cloud", matches)
+	}
+}
+
+func TestLoadCategoryDefinitions(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"categories.toml": `
+[[categories]]
+id = "infra"
+icon = "🏗️"
+xp_bonus = 1.6
+priority = 10
+patterns = ['^terraform\s+']
+`,
+		"categories.yaml": `
+categories:
+  - id: infra
+    icon: "🏗️"
+    xp_bonus: 1.6
+    priority: 10
+    patterns: ['^terraform\s+']
+`,
+	}
+
+	for name, content := range files {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(dir, name)
+			if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			defs, err := categories.LoadDefinitions(path)
+			if err != nil {
+				t.Fatalf("LoadDefinitions: %v", err)
+			}
+			if len(defs.Categories) != 1 || defs.Source != path {
+				t.Fatalf("definitions = %+v", defs)
+			}
+			def := defs.Categories[0]
+			if def.ID != "infra" || def.Priority != 10 || def.XPBonus == nil || *def.XPBonus != 1.6 || len(def.Patterns) != 1 {
+				t.Errorf("definition = %+v", def)
+			}
+		})
+	}
+}
+
+func TestValidateCategoryDefinitions(t *testing.T) {
+	negative := -1.0
+	tests := []struct {
+		name string
+		def  categories.Definition
+		err  string
+	}{
+		{"bad id", categories.Definition{ID: "Infra Tools", Patterns: []string{"^x"}}, "invalid id"},
+		{"new without patterns", categories.Definition{ID: "infra"}, "at least one pattern"},
+		{"bad pattern", categories.Definition{ID: "infra", Patterns: []string{"^(terraform"}}, "invalid pattern"},
+		{"negative bonus", categories.Definition{ID: "git", XPBonus: &negative}, "xp_bonus"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := categories.ValidateDefinitions([]categories.Definition{tt.def})
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("error = %v, want %q", err, tt.err)
+			}
+		})
+	}
+
+	dup := []categories.Definition{{ID: "git"}, {ID: "git"}}
+	if err := categories.ValidateDefinitions(dup); err == nil || !strings.Contains(err.Error(), "duplicate") {
+		t.Errorf("duplicate error = %v", err)
+	}
+	if err := categories.ValidateDefinitions([]categories.Definition{{ID: "git", Name: "VCS"}}); err != nil {
+		t.Errorf("overriding a built-in without patterns: %v", err)
+	}
+}
+
+func TestCategoryHierarchy(t *testing.T) {
+	if c := categories.GitRemote; c.Parent() != categories.Git || c.Root() != categories.Git || !c.Within(categories.Git) {
+		t.Errorf("git/remote: parent %q, root %q", c.Parent(), c.Root())
+	}
+	if categories.Git.Parent() != "" || categories.Git.Within(categories.GitRemote) || categories.Category("gitx").Within(categories.Git) {
+		t.Error("git must be top-level and not within git/remote; gitx is not within git")
+	}
+
+	classifier := categories.NewBuiltinClassifier()
+	if info := classifier.GetCategoryInfo(categories.GitRemote); info.Icon != "🌿" || info.XPBonus != 1.5 || info.Name != "Git Remotes" {
+		t.Errorf("git/remote info = %+v, want git's icon and bonus", info)
+	}
+	if info := classifier.GetCategoryInfo("git/unheard-of"); info.Name != "Git & Version Control" {
+		t.Errorf("unknown subcategory info = %+v, want git's", info)
+	}
+
+	// docker build is a docker subcategory and a build command
+	labels := classifier.Classify("docker build -t app .").Labels
+	if len(labels) != 2 || labels[0].Category != categories.DockerBuild || labels[1].Category != categories.Build ||
+		math.Abs(labels[0].Weight-2.0/3) > 1e-9 || math.Abs(labels[1].Weight-1.0/3) > 1e-9 {
+		t.Errorf("labels = %+v, want docker/build 2/3 and build 1/3", labels)
+	}
+	// The parent rule matching too does not make git a second label
+	if labels := classifier.Classify("git push").Labels; len(labels) != 1 || labels[0].Weight != 1 {
+		t.Errorf("git push labels = %+v, want git/remote alone", labels)
+	}
+
+	stats := classifier.AnalyzeCategories([]string{"git push", "git log", "git status", "docker build .", "ls"})
+	git := stats[categories.Git]
+	if git == nil || git.Count != 3 || git.Share != 3 || git.Percentage != 60 || len(git.Subcategories) != 3 {
+		t.Fatalf("git stats = %+v, want 3 commands in 3 subcategories", git)
+	}
+	if sub := git.Subcategories[categories.GitRemote]; sub == nil || sub.Count != 1 || sub.Percentage != 20 {
+		t.Errorf("git/remote stats = %+v", sub)
+	}
+	if docker, build := stats[categories.Docker], stats[categories.Build]; docker.Count != 1 || build.Count != 1 ||
+		math.Abs(docker.Share+build.Share-1) > 1e-9 || docker.Share <= build.Share {
+		t.Errorf("docker %+v and build %+v should split one command", docker, build)
+	}
+	if _, exists := stats[categories.GitRemote]; exists {
+		t.Error("subcategories must roll up, not appear at the top level")
+	}
+	total := 0.0
+	for _, s := range stats {
+		total += s.Percentage
+	}
+	if math.Abs(total-100) > 1e-9 {
+		t.Errorf("percentages add up to %.2f, want 100", total)
+	}
+}
+
+func TestSubcategoryDefinitions(t *testing.T) {
+	bonus := 3.0
+	defs := &categories.Definitions{
+		Source: "categories.toml",
+		Categories: []categories.Definition{
+			{ID: "k8s", Name: "K8s", Icon: "☸️", Patterns: []string{`^k\s+`}},
+			{ID: "k8s/deploy", XPBonus: &bonus, Patterns: []string{`^k\s+apply(\s|$)`}},
+		},
+	}
+	if err := categories.ValidateDefinitions(defs.Categories); err != nil {
+		t.Fatalf("ValidateDefinitions: %v", err)
+	}
+	classifier := categories.NewCommandClassifierWith(defs)
+
+	if got := classifier.ClassifyCommand("k apply -f x.yaml"); got != "k8s/deploy" {
+		t.Errorf("ClassifyCommand = %s, want k8s/deploy", got)
+	}
+	if info := classifier.GetCategoryInfo("k8s/deploy"); info.Icon != "☸️" || info.XPBonus != 3.0 {
+		t.Errorf("k8s/deploy info = %+v, want k8s icon and own bonus", info)
+	}
+
+	orphan := []categories.Definition{{ID: "infra/plan", Patterns: []string{"^x"}}}
+	if err := categories.ValidateDefinitions(orphan); err == nil || !strings.Contains(err.Error(), "parent category") {
+		t.Errorf("orphan subcategory error = %v", err)
+	}
+
+	// Replacing a category drops the built-in rules of its subcategories too
+	replaced := categories.NewCommandClassifierWith(&categories.Definitions{
+		Categories: []categories.Definition{{ID: "git", Replace: true, Patterns: []string{`^jj\s+`}}},
+	})
+	if got := replaced.ClassifyCommand("git push"); got != categories.Unknown {
+		t.Errorf("git push after replacing git = %s, want unknown", got)
+	}
+}
+
+func TestHierarchicalXPMultiplier(t *testing.T) {
+	config := gamification.DefaultXPConfig()
+	config.CategoryMultipliers["git/remote"] = 2.0
+	xp := gamification.NewXPCalculator(config)
+
+	tests := []struct {
+		category string
+		want     float64
+	}{
+		{"git/remote", 2.0},
+		{"git/history", 1.5}, // git's
+		{"docker/build", 1.0},
+		{"git", 1.5},
+	}
+	for _, tt := range tests {
+		if got := xp.CategoryMultiplier(tt.category); got != tt.want {
+			t.Errorf("CategoryMultiplier(%q) = %.2f, want %.2f", tt.category, got, tt.want)
+		}
+	}
+
+	cmd := &models.Command{Command: "x"}
+	labeled := xp.CalculateLabeledCommandXP(cmd, false, 0, "git/remote", map[string]float64{"git/remote": 0.5, "navigation": 0.5})
+	if single := xp.CalculateCommandXP(cmd, false, 0, "git/remote"); labeled >= single {
+		t.Errorf("labeled XP %d should be below git/remote alone %d, navigation has a lower multiplier", labeled, single)
+	}
+}
@@ -0,0 +1,121 @@
+package unit
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/oiahoon/termonaut/internal/analytics"
+	"github.com/oiahoon/termonaut/internal/privacy"
+	"github.com/oiahoon/termonaut/pkg/models"
+)
+
+func directoryCommands() []*models.Command {
+	now := time.Date(2024, 7, 15, 12, 0, 0, 0, time.UTC)
+	var commands []*models.Command
+	add := func(cwd string, count, failures int, durationMS int64) {
+		for i := 0; i < count; i++ {
+			exitCode := 0
+			if i < failures {
+				exitCode = 1
+			}
+			commands = append(commands, &models.Command{
+				Command:    "make",
+				CWD:        cwd,
+				ExitCode:   exitCode,
+				DurationMS: durationMS,
+				Timestamp:  now.Add(time.Duration(i) * time.Minute),
+			})
+		}
+	}
+
+	add("/home/alice/code/termonaut", 6, 3, 1000)
+	add("/home/alice/code/termonaut/internal", 2, 0, 500)
+	add("/home/alice/code/website", 2, 0, 100)
+	add("/tmp", 1, 1, 0)
+	add("", 5, 0, 0) // no directory recorded
+
+	return commands
+}
+
+func TestDirectoryTreeAggregation(t *testing.T) {
+	root := analytics.NewDirectoryAnalyzer(nil).BuildTree(directoryCommands())
+
+	if root.Commands != 11 {
+		t.Fatalf("root.Commands = %d, want 11", root.Commands)
+	}
+
+	tests := []struct {
+		path        string
+		commands    int
+		own         int
+		failureRate float64
+		durationMS  int64
+	}{
+		{"~", 10, 0, 30, 7200},
+		{"~/code/termonaut", 8, 6, 37.5, 7000},
+		{"~/code/termonaut/internal", 2, 2, 0, 1000},
+		{"/tmp", 1, 1, 100, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			node := root.Find(tt.path)
+			if node == nil {
+				t.Fatalf("Find(%q) = nil", tt.path)
+			}
+			if node.Commands != tt.commands || node.OwnCommands != tt.own {
+				t.Errorf("commands = %d/%d own, want %d/%d", node.Commands, node.OwnCommands, tt.commands, tt.own)
+			}
+			if node.FailureRate != tt.failureRate {
+				t.Errorf("FailureRate = %.1f, want %.1f", node.FailureRate, tt.failureRate)
+			}
+			if node.TotalDurationMS != tt.durationMS {
+				t.Errorf("TotalDurationMS = %d, want %d", node.TotalDurationMS, tt.durationMS)
+			}
+		})
+	}
+
+	if root.Find("/home/alice") != nil {
+		t.Error("home directory should be anonymized as ~")
+	}
+}
+
+func TestDirectoryTreeRespectsSanitizer(t *testing.T) {
+	config := privacy.DefaultSanitizationConfig()
+	config.SanitizeFilePaths = false
+	root := analytics.NewDirectoryAnalyzer(privacy.NewCommandSanitizer(config)).BuildTree(directoryCommands())
+
+	if root.Find("/home/alice/code/termonaut") == nil {
+		t.Error("expected full paths when file-path sanitization is disabled")
+	}
+}
+
+func TestDirectoryTreeRendering(t *testing.T) {
+	analyzer := analytics.NewDirectoryAnalyzer(nil)
+	root := analyzer.BuildTree(directoryCommands())
+
+	// Single-child chains are collapsed into one row
+	rows := analyzer.FlattenTree(root, func(*analytics.DirectoryNode, int) bool { return true }, 0)
+	labels := make([]string, len(rows))
+	for i, row := range rows {
+		labels[i] = row.Label
+	}
+	want := []string{"~/code", "termonaut", "internal", "website", "/tmp"}
+	if strings.Join(labels, ",") != strings.Join(want, ",") {
+		t.Errorf("labels = %v, want %v", labels, want)
+	}
+
+	// Depth limit
+	output := analyzer.FormatDirectoryTree(root, 1, 0)
+	if !strings.Contains(output, "~/code ▸") || strings.Contains(output, "termonaut") {
+		t.Errorf("depth 1 should show collapsed top-level directories only:\n%s", output)
+	}
+
+	// Per-directory limit
+	rows = analyzer.FlattenTree(root, func(*analytics.DirectoryNode, int) bool { return true }, 1)
+	last := rows[len(rows)-1]
+	if last.Node != nil || last.Label != "… 1 more" {
+		t.Errorf("expected trailing summary row, got %+v", last)
+	}
+}
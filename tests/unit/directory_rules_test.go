@@ -0,0 +1,157 @@
+package unit
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/oiahoon/termonaut/internal/categories"
+	"github.com/oiahoon/termonaut/internal/config"
+	"github.com/oiahoon/termonaut/internal/privacy"
+)
+
+func TestDirectoryRules(t *testing.T) {
+	root := t.TempDir()
+	mkdir := func(parts ...string) string {
+		dir := filepath.Join(append([]string{root}, parts...)...)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		return dir
+	}
+	write := func(path, content string) {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	secret := mkdir("clients", "acme", "src")
+	write(filepath.Join(root, "clients", "acme", privacy.IgnoreFileName), "")
+	docs := mkdir("clients", "docs")
+	write(filepath.Join(docs, privacy.RulesFileName), `mode = "full"`)
+	oss := mkdir("oss", "tool", "cmd")
+	write(filepath.Join(root, "oss", privacy.RulesFileName), `mode = "base-command-only"`)
+	broken := mkdir("broken")
+	write(filepath.Join(broken, privacy.RulesFileName), `mode = "sometimes"`)
+	other := mkdir("other")
+	client := mkdir("private", "client", "repo")
+	write(filepath.Join(client, privacy.RulesFileName), `mode = "full"`)
+
+	rules := privacy.NewDirectoryRules([]privacy.DirectoryRule{
+		{Path: filepath.Join(root, "clients", "*"), Mode: privacy.TrackBaseCommand},
+		{Path: filepath.Join(root, "oss", "tool"), Mode: privacy.TrackFull},
+		{Path: filepath.Join(root, "private", "*"), Mode: privacy.TrackIgnore},
+	}, filepath.Join(root, "cache"))
+
+	tests := []struct {
+		name   string
+		dir    string
+		mode   privacy.TrackingMode
+		source string
+	}{
+		{"ignore file above", secret, privacy.TrackIgnore, filepath.Join(root, "clients", "acme", privacy.IgnoreFileName)},
+		{"config beats a looser rules file in the same directory", docs, privacy.TrackBaseCommand, "config"},
+		{"rules file above beats a looser deeper config rule", oss, privacy.TrackBaseCommand, filepath.Join(root, "oss", privacy.RulesFileName)},
+		{"deeper rules file cannot loosen a config ignore", client, privacy.TrackIgnore, "config"},
+		{"rules file", filepath.Join(root, "oss"), privacy.TrackBaseCommand, filepath.Join(root, "oss", privacy.RulesFileName)},
+		{"invalid rules file ignores", broken, privacy.TrackIgnore, filepath.Join(broken, privacy.RulesFileName)},
+		{"no rule", other, privacy.TrackFull, "default"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decision := rules.Decide(tt.dir)
+			if decision.Mode != tt.mode || decision.Source != tt.source || decision.Cached {
+				t.Errorf("Decide(%s) = %+v, want %s from %s", tt.dir, decision, tt.mode, tt.source)
+			}
+			if cached := rules.Decide(tt.dir); !cached.Cached || cached.Mode != tt.mode {
+				t.Errorf("second Decide(%s) = %+v, want the cached decision", tt.dir, cached)
+			}
+		})
+	}
+
+	if explained := rules.Explain(oss); len(explained) != 3 || explained[0].Mode != privacy.TrackBaseCommand || explained[1].Source != "config" {
+		t.Errorf("Explain(%s) = %d rules, want the rules file, the config rule and the default", oss, len(explained))
+	}
+
+	// Adding a rule file invalidates the cached decision
+	write(filepath.Join(other, privacy.IgnoreFileName), "")
+	if decision := rules.Decide(other); decision.Mode != privacy.TrackIgnore || decision.Cached {
+		t.Errorf("after adding %s: %+v", privacy.IgnoreFileName, decision)
+	}
+
+	// So does editing one
+	path := filepath.Join(root, "oss", privacy.RulesFileName)
+	write(path, `mode = "ignore"`)
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatal(err)
+	}
+	if decision := rules.Decide(filepath.Join(root, "oss")); decision.Mode != privacy.TrackIgnore {
+		t.Errorf("after editing %s: %+v", path, decision)
+	}
+
+	// And removing one
+	if err := os.Remove(filepath.Join(other, privacy.IgnoreFileName)); err != nil {
+		t.Fatal(err)
+	}
+	if decision := rules.Decide(other); decision.Mode != privacy.TrackFull || decision.Cached {
+		t.Errorf("after removing %s: %+v", privacy.IgnoreFileName, decision)
+	}
+
+	// Shells deciding at once each write their own temporary file
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			rules.Decide(mkdir("clients", "acme", "src", fmt.Sprint(i%3)))
+		}(i)
+	}
+	wg.Wait()
+	if leftovers, _ := filepath.Glob(filepath.Join(root, "cache", "*.tmp")); len(leftovers) > 0 {
+		t.Errorf("temporary cache files left behind: %v", leftovers)
+	}
+	if decision := rules.Decide(filepath.Join(secret, "1")); decision.Mode != privacy.TrackIgnore || !decision.Cached {
+		t.Errorf("decision cached by concurrent shells = %+v", decision)
+	}
+
+	// Other config rules do not use the cached decisions
+	fresh := privacy.NewDirectoryRules([]privacy.DirectoryRule{{Path: filepath.Join(root, "oss"), Mode: privacy.TrackFull}},
+		filepath.Join(root, "cache"))
+	if decision := fresh.Decide(filepath.Join(root, "oss")); decision.Cached {
+		t.Errorf("a decision cached with other config rules was used: %+v", decision)
+	}
+
+	invalid := [][]privacy.DirectoryRule{
+		{{Path: "", Mode: privacy.TrackIgnore}},
+		{{Path: "~/work", Mode: "sometimes"}},
+		{{Path: "~/work/[", Mode: privacy.TrackIgnore}},
+	}
+	for _, rules := range invalid {
+		cfg := config.DefaultConfig()
+		cfg.DirectoryRules = rules
+		if err := config.Validate(cfg); err == nil {
+			t.Errorf("Validate accepted directory rules %+v", rules)
+		}
+	}
+}
+
+func TestStripArguments(t *testing.T) {
+	tests := []struct {
+		command string
+		want    string
+	}{
+		{"git commit -m 'secret plan'", "git"},
+		{"sudo FOO=1 /usr/local/bin/kubectl get pods -n prod", "kubectl"},
+		{"make test && ./deploy.sh --env prod | tee log", "make && deploy.sh | tee"},
+		{"FOO=1", ""},
+	}
+	for _, tt := range tests {
+		if got := categories.StripArguments(tt.command); got != tt.want {
+			t.Errorf("StripArguments(%q) = %q, want %q", tt.command, got, tt.want)
+		}
+	}
+}
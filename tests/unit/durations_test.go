@@ -0,0 +1,132 @@
+package unit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/oiahoon/termonaut/internal/analytics"
+	"github.com/oiahoon/termonaut/internal/database"
+	"github.com/oiahoon/termonaut/pkg/models"
+	"github.com/sirupsen/logrus"
+)
+
+func timedCommands(command string, start time.Time, durations ...int64) []*models.Command {
+	commands := make([]*models.Command, len(durations))
+	for i, ms := range durations {
+		commands[i] = &models.Command{
+			Command:    command,
+			Timestamp:  start.Add(time.Duration(i) * time.Hour),
+			DurationMS: ms,
+		}
+	}
+	return commands
+}
+
+func TestDurationPercentiles(t *testing.T) {
+	start := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	var durations []int64
+	for i := int64(1); i <= 100; i++ {
+		durations = append(durations, i*10)
+	}
+	commands := timedCommands("make build", start, durations...)
+	// Untimed commands are ignored
+	commands = append(commands, &models.Command{Command: "ls", Timestamp: start})
+
+	report := analytics.NewDurationAnalyzer().AnalyzeDurations(commands)
+
+	tests := []struct {
+		name     string
+		got      int64
+		expected int64
+	}{
+		{"p50", report.Overall.P50, 500},
+		{"p90", report.Overall.P90, 900},
+		{"p99", report.Overall.P99, 990},
+		{"max", report.Overall.Max, 1000},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.expected {
+			t.Errorf("%s: expected %d, got %d", tt.name, tt.expected, tt.got)
+		}
+	}
+
+	if report.TimedCommands != 100 || report.TotalCommands != 101 {
+		t.Errorf("Expected 100 of 101 timed commands, got %d of %d", report.TimedCommands, report.TotalCommands)
+	}
+}
+
+func TestDurationTimeSinksAndRegressions(t *testing.T) {
+	start := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	var commands []*models.Command
+	commands = append(commands, timedCommands("go test ./...", start, 1000, 1100, 1000, 2000, 2100, 2200)...)
+	commands = append(commands, timedCommands("ls", start, 5, 5, 5, 5, 5, 5, 5, 5)...)
+	commands = append(commands, timedCommands("npm install", start, 30000)...)
+
+	report := analytics.NewDurationAnalyzer().AnalyzeDurations(commands)
+
+	if len(report.TimeSinks) != 3 || report.TimeSinks[0].Command != "npm install" {
+		t.Fatalf("Expected npm install to be the top time sink, got %+v", report.TimeSinks)
+	}
+
+	if len(report.Regressions) != 1 || report.Regressions[0].Command != "go test ./..." {
+		t.Fatalf("Expected go test to be flagged as a regression, got %+v", report.Regressions)
+	}
+	if report.Regressions[0].ChangePercent < 90 {
+		t.Errorf("Expected roughly +100%% change, got %.1f%%", report.Regressions[0].ChangePercent)
+	}
+}
+
+func TestFinishCommand(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+	db, err := database.New(t.TempDir(), logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	now := time.Now()
+	for _, shell := range []struct {
+		pid     int
+		command string
+	}{{4242, "make test"}, {999, "ls"}} {
+		session, err := db.GetOrCreateSession(shell.pid, "zsh")
+		if err != nil {
+			t.Fatal(err)
+		}
+		command := &models.Command{Timestamp: now.Add(-1500 * time.Millisecond), SessionID: session.ID, Command: shell.command, ShellSeq: 1}
+		if err := db.StoreCommand(command); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if finished, err := db.FinishCommand(4242, 2, 0, now); err != nil || finished {
+		t.Errorf("FinishCommand of a command not stored = %v, %v", finished, err)
+	}
+	if finished, err := db.FinishCommand(4242, 1, 3, now); err != nil || !finished {
+		t.Fatalf("FinishCommand = %v, %v", finished, err)
+	}
+	if finished, _ := db.FinishCommand(4242, 1, 0, now); finished {
+		t.Error("a command was finished twice")
+	}
+
+	commands, err := db.GetAllCommands()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, cmd := range commands {
+		switch cmd.Command {
+		case "make test":
+			if !cmd.Finished || cmd.ExitCode != 3 || cmd.DurationMS != 1500 {
+				t.Errorf("finished command = %+v, want exit code 3 after 1500ms", cmd)
+			}
+		case "ls":
+			if cmd.Finished || cmd.DurationMS != 0 {
+				t.Errorf("command of another shell was finished: %+v", cmd)
+			}
+		}
+	}
+	if report := analytics.NewDurationAnalyzer().AnalyzeDurations(commands); report.TimedCommands != 1 {
+		t.Errorf("TimedCommands = %d, want the finished command", report.TimedCommands)
+	}
+}
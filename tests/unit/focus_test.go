@@ -0,0 +1,98 @@
+package unit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/oiahoon/termonaut/internal/analytics"
+	"github.com/oiahoon/termonaut/pkg/models"
+)
+
+// focusRun creates count commands spaced interval apart in cwd
+func focusRun(start time.Time, count int, interval time.Duration, command, cwd string) []*models.Command {
+	commands := make([]*models.Command, count)
+	for i := range commands {
+		commands[i] = &models.Command{
+			Command:   command,
+			CWD:       cwd,
+			Timestamp: start.Add(time.Duration(i) * interval),
+		}
+	}
+	return commands
+}
+
+func TestFocusBlockDetection(t *testing.T) {
+	start := time.Date(2024, 7, 1, 9, 0, 0, 0, time.UTC)
+	project := "/home/dev/code/termonaut/internal"
+	other := "/home/dev/code/website"
+
+	tests := []struct {
+		name     string
+		commands []*models.Command
+		blocks   int
+	}{
+		{
+			name:     "sustained single project",
+			commands: focusRun(start, 20, 3*time.Minute, "go test ./...", project),
+			blocks:   1,
+		},
+		{
+			name:     "too short",
+			commands: focusRun(start, 20, time.Minute, "go test ./...", project),
+			blocks:   0,
+		},
+		{
+			name: "long gap splits the block",
+			commands: append(
+				focusRun(start, 12, 3*time.Minute, "go build", project),
+				focusRun(start.Add(2*time.Hour), 12, 3*time.Minute, "go build", project)...),
+			blocks: 2,
+		},
+		{
+			name: "brief excursion is tolerated",
+			commands: append(append(
+				focusRun(start, 10, 2*time.Minute, "vim main.go", project),
+				focusRun(start.Add(20*time.Minute), 1, time.Minute, "ls", other)...),
+				focusRun(start.Add(22*time.Minute), 10, 2*time.Minute, "go test", project)...),
+			blocks: 1,
+		},
+		{
+			name: "context switch splits the block",
+			commands: append(
+				focusRun(start, 15, 2*time.Minute, "go test", project),
+				focusRun(start.Add(30*time.Minute), 15, 2*time.Minute, "npm run dev", other)...),
+			blocks: 2,
+		},
+	}
+
+	analyzer := analytics.NewFocusAnalyzer()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := analyzer.AnalyzeFocus(tt.commands)
+			if report.TotalBlocks != tt.blocks {
+				t.Errorf("Expected %d blocks, got %d: %+v", tt.blocks, report.TotalBlocks, report.Blocks)
+			}
+		})
+	}
+}
+
+func TestFocusReportSummary(t *testing.T) {
+	start := time.Date(2024, 7, 1, 9, 0, 0, 0, time.UTC)
+	commands := focusRun(start, 21, 3*time.Minute, "go test ./...", "/home/dev/code/termonaut")
+
+	report := analytics.NewFocusAnalyzer().AnalyzeFocus(commands)
+	if report.TotalBlocks != 1 {
+		t.Fatalf("Expected 1 block, got %d", report.TotalBlocks)
+	}
+
+	block := report.Blocks[0]
+	if block.DurationMinutes != 60 || block.Project != "code/termonaut" {
+		t.Errorf("Unexpected block: %+v", block)
+	}
+	if report.FocusScore != 100 {
+		t.Errorf("Expected focus score 100, got %.1f", report.FocusScore)
+	}
+	if len(report.BestHours) != 1 || report.BestHours[0] != 9 {
+		t.Errorf("Expected best hour 9, got %v", report.BestHours)
+	}
+}
@@ -0,0 +1,83 @@
+package unit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/oiahoon/termonaut/internal/gamification"
+)
+
+func steadyHistory(days, xpPerDay, commandsPerDay int) []*gamification.DailyActivity {
+	start := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	history := make([]*gamification.DailyActivity, days)
+	for i := range history {
+		history[i] = &gamification.DailyActivity{
+			Date:     start.AddDate(0, 0, i).Format("2006-01-02"),
+			XP:       xpPerDay,
+			Commands: commandsPerDay,
+		}
+	}
+	return history
+}
+
+func TestForecastNextLevel(t *testing.T) {
+	now := time.Date(2024, 6, 29, 12, 0, 0, 0, time.UTC)
+	stats := &gamification.UserStats{TotalXP: 400, TotalCommands: 200, CurrentLevel: 3}
+	// Level 4 needs 900 XP, so 500 XP remain
+
+	tests := []struct {
+		name     string
+		history  []*gamification.DailyActivity
+		expected float64
+	}{
+		{"steady pace", steadyHistory(28, 100, 50), 5},
+		{"estimated from commands", steadyHistory(28, 0, 50), 5}, // 2 XP per command lifetime average
+		{"no activity", steadyHistory(28, 0, 0), -1},
+	}
+
+	forecaster := gamification.NewForecaster()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			forecast := forecaster.Forecast(stats, nil, tt.history, now)
+			if forecast.NextLevel.Target != "Level 4" {
+				t.Errorf("Expected target Level 4, got %s", forecast.NextLevel.Target)
+			}
+			if forecast.NextLevel.Days != tt.expected {
+				t.Errorf("Expected %.1f days, got %.1f", tt.expected, forecast.NextLevel.Days)
+			}
+		})
+	}
+}
+
+func TestForecastConfidenceRange(t *testing.T) {
+	now := time.Date(2024, 6, 29, 12, 0, 0, 0, time.UTC)
+	history := steadyHistory(28, 100, 50)
+	for i := 0; i < len(history); i += 2 {
+		history[i].XP = 50
+		history[i+1].XP = 150
+	}
+
+	stats := &gamification.UserStats{TotalXP: 400, TotalCommands: 200}
+	forecast := gamification.NewForecaster().Forecast(stats, nil, history, now)
+
+	prediction := forecast.NextLevel
+	if !(prediction.MinDays < prediction.Days && prediction.Days < prediction.MaxDays) {
+		t.Errorf("Expected min < days < max, got %.2f / %.2f / %.2f", prediction.MinDays, prediction.Days, prediction.MaxDays)
+	}
+	if prediction.ETA == nil || !prediction.ETA.After(now) {
+		t.Errorf("Expected an ETA in the future, got %v", prediction.ETA)
+	}
+}
+
+func TestForecastStreakRecord(t *testing.T) {
+	now := time.Date(2024, 6, 29, 12, 0, 0, 0, time.UTC)
+	stats := &gamification.UserStats{CurrentStreak: 7, LongestStreak: 10, TotalCommands: 100, TotalXP: 100}
+
+	forecast := gamification.NewForecaster().Forecast(stats, nil, steadyHistory(28, 10, 5), now)
+	if forecast.StreakRecord.Remaining != 4 || forecast.StreakRecord.Days != 4 {
+		t.Errorf("Expected streak record in 4 days, got %+v", forecast.StreakRecord)
+	}
+	if forecast.NextAchievement == nil {
+		t.Error("Expected a next achievement prediction")
+	}
+}
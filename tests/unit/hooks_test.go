@@ -0,0 +1,87 @@
+package unit
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/oiahoon/termonaut/internal/shell"
+)
+
+func TestBashHookSkipsPromptCommand(t *testing.T) {
+	hook, err := shell.GenerateHook(shell.Bash, "/usr/local/bin/termonaut")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	skip := strings.Index(hook, "termonaut_postexec*) return ;;")
+	logCommand := strings.Index(hook, "log-command --pid $$ --seq $_termonaut_seq")
+	if skip < 0 || logCommand < 0 || skip > logCommand {
+		t.Errorf("the DEBUG trap logs termonaut_postexec before returning:\n%s", hook)
+	}
+	if !strings.Contains(hook, `PROMPT_COMMAND="termonaut_postexec`) {
+		t.Error("termonaut_postexec is not added to PROMPT_COMMAND")
+	}
+
+	if bash, err := exec.LookPath("bash"); err == nil {
+		if out, err := exec.Command(bash, "-n", "-c", hook).CombinedOutput(); err != nil {
+			t.Errorf("bash hook has a syntax error: %v\n%s", err, out)
+		}
+	}
+}
+
+func TestBashHookReportsExitStatus(t *testing.T) {
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("bash is not installed")
+	}
+
+	// A stand-in binary that records how the hook calls it
+	dir := t.TempDir()
+	calls := filepath.Join(dir, "calls.txt")
+	binary := filepath.Join(dir, "termonaut")
+	if err := os.WriteFile(binary, []byte("#!/bin/sh\necho \"$@\" >> "+calls+"\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	hook, err := shell.GenerateHook(shell.Bash, binary)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// PROMPT_COMMAND only runs in interactive shells, so call it directly
+	script := hook + "\nsh -c 'exit 3'\ntermonaut_postexec\necho ok >/dev/null\ntermonaut_postexec\n"
+	if out, err := exec.Command(bash, "-c", script).CombinedOutput(); err != nil {
+		t.Fatalf("bash hook failed: %v\n%s", err, out)
+	}
+
+	want := []string{
+		"-- sh -c 'exit 3'",
+		"-- echo ok",
+		"--seq 1 3",
+		"--seq 2 0",
+	}
+	var got string
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(50 * time.Millisecond) {
+		data, _ := os.ReadFile(calls)
+		if got = string(data); strings.Count(got, "\n") >= len(want) {
+			break
+		}
+	}
+	for _, call := range want {
+		if !strings.Contains(got, call) {
+			t.Errorf("hook calls missing %q:\n%s", call, got)
+		}
+	}
+	if strings.Contains(got, "exec ") || strings.Count(got, "\n") != len(want) {
+		t.Errorf("hook logged something other than the commands run:\n%s", got)
+	}
+}
+
+func TestGenerateHookUnsupportedShell(t *testing.T) {
+	if _, err := shell.GenerateHook("tcsh", "termonaut"); err == nil {
+		t.Error("GenerateHook accepted an unsupported shell")
+	}
+}
@@ -0,0 +1,202 @@
+package unit
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/oiahoon/termonaut/internal/categories"
+	"github.com/oiahoon/termonaut/internal/database"
+	"github.com/oiahoon/termonaut/internal/tui/enhanced"
+	"github.com/oiahoon/termonaut/pkg/models"
+	"github.com/sirupsen/logrus"
+)
+
+func TestLearnedClassification(t *testing.T) {
+	t.Cleanup(func() { categories.SetLearned(nil) })
+	categories.SetLearned([]categories.Assignment{
+		{Tool: "terraform", Category: "infra"},
+		{Tool: "frob", Category: categories.Build},
+	})
+	classifier := categories.NewCommandClassifier()
+
+	tests := []struct {
+		command  string
+		category categories.Category
+	}{
+		{"terraform plan", "infra"}, // beats the built-in cloud rule
+		{"/usr/local/bin/terraform apply", "infra"},
+		{"sudo frob --all | less", categories.Build},
+		{"terraformer import", categories.Unknown},
+		{"git status", categories.GitCommit},
+	}
+	for _, tt := range tests {
+		if got := classifier.ClassifyCommand(tt.command); got != tt.category {
+			t.Errorf("ClassifyCommand(%q) = %s, want %s", tt.command, got, tt.category)
+		}
+	}
+
+	if matches := classifier.Explain("terraform plan"); matches[0].Source != categories.LearnedSource {
+		t.Errorf("Explain first match = %+v, want the learned rule", matches[0])
+	}
+	if info := classifier.GetCategoryInfo("infra"); info.Name != "infra" {
+		t.Errorf("infra info = %+v, want metadata for the new category", info)
+	}
+	if categories.NewBuiltinClassifier().ClassifyCommand("terraform plan") != categories.Cloud {
+		t.Error("learned categories leaked into the built-in classifier")
+	}
+
+	invalid := []struct {
+		tool     string
+		category categories.Category
+	}{
+		{"", "infra"},
+		{"two words", "infra"},
+		{"terraform", "Infra"},
+		{"terraform", "nope/terraform"},
+	}
+	for _, tt := range invalid {
+		if err := classifier.ValidateAssignment(tt.tool, tt.category); err == nil {
+			t.Errorf("ValidateAssignment(%q, %q) should fail", tt.tool, tt.category)
+		}
+	}
+	if err := classifier.ValidateAssignment("terraform", "cloud/terraform"); err != nil {
+		t.Errorf("ValidateAssignment with a built-in parent: %v", err)
+	}
+}
+
+func TestAssignCategory(t *testing.T) {
+	t.Cleanup(func() { categories.SetLearned(nil) })
+
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+	dir := t.TempDir()
+	db, err := database.New(dir, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	session, err := db.GetOrCreateSession(12345, "zsh")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, command := range []string{"frob --all", "zork", "frob -v", "git status", "frob --all"} {
+		if err := db.StoreCommand(&models.Command{Timestamp: time.Now(), SessionID: session.ID, Command: command}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	unknown, err := db.GetUnknownTools(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(unknown) != 2 || unknown[0].Tool != "frob" || unknown[0].Commands != 3 ||
+		len(unknown[0].Examples) != 2 || unknown[1].Tool != "zork" {
+		t.Fatalf("GetUnknownTools = %+v, want frob (3 commands, 2 examples) then zork", unknown)
+	}
+
+	if _, err := db.AssignCategory("frob", "Not Valid"); err == nil {
+		t.Error("AssignCategory with an invalid category should fail")
+	}
+	changed, err := db.AssignCategory("frob", "tooling")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changed != 3 {
+		t.Errorf("AssignCategory changed %d commands, want 3", changed)
+	}
+	if got := categories.NewCommandClassifier().ClassifyCommand("frob x"); got != "tooling" {
+		t.Errorf("new classifiers classify frob as %s, want tooling", got)
+	}
+	if unknown, _ := db.GetUnknownTools(10); len(unknown) != 1 || unknown[0].Tool != "zork" {
+		t.Errorf("GetUnknownTools after assigning = %+v, want only zork", unknown)
+	}
+
+	// Opening the database does not load the assignments; commands that
+	// classify load them
+	categories.SetLearned(nil)
+	reopened, err := database.New(dir, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reopened.Close()
+	if got := categories.NewCommandClassifier().ClassifyCommand("frob x"); got == "tooling" {
+		t.Error("opening the database loaded the learned categories")
+	}
+	if err := reopened.LoadLearnedCategories(); err != nil {
+		t.Fatal(err)
+	}
+	assignments, err := db.GetLearnedCategories()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(assignments) != 1 || assignments[0].Tool != "frob" || assignments[0].Category != "tooling" {
+		t.Errorf("GetLearnedCategories = %+v", assignments)
+	}
+	if got := categories.NewCommandClassifier().ClassifyCommand("frob x"); got != "tooling" {
+		t.Errorf("after loading, frob is %s, want tooling", got)
+	}
+
+	// Shared as a categories file, it classifies the same way
+	path := filepath.Join(t.TempDir(), "team.toml")
+	if err := categories.WriteDefinitions(path, categories.LearnedDefinitions(assignments)); err != nil {
+		t.Fatal(err)
+	}
+	defs, err := categories.LoadDefinitions(path)
+	if err != nil {
+		t.Fatalf("LoadDefinitions of the export: %v", err)
+	}
+	if got := categories.NewCommandClassifierWith(defs).ClassifyCommand("/opt/bin/frob --all"); got != "tooling" {
+		t.Errorf("exported file classifies frob as %s, want tooling", got)
+	}
+
+	found, changed, err := db.UnassignCategory("frob")
+	if err != nil || !found || changed != 3 {
+		t.Errorf("UnassignCategory = %v, %d, %v; want found with 3 changed", found, changed, err)
+	}
+	if found, _, _ := db.UnassignCategory("frob"); found {
+		t.Error("unassigning twice should find nothing")
+	}
+}
+
+func TestTriageModel(t *testing.T) {
+	tools := []*database.UnknownTool{
+		{Tool: "frob", Commands: 3, Examples: []string{"frob --all"}},
+		{Tool: "zork", Commands: 1},
+		{Tool: "quux", Commands: 1},
+	}
+	assigned := make(map[string]categories.Category)
+	assign := func(tool string, category categories.Category) (int, error) {
+		assigned[tool] = category
+		return 1, nil
+	}
+	model := enhanced.NewTriageModel(tools, categories.NewBuiltinClassifier(), assign)
+
+	keys := []tea.KeyMsg{
+		{Type: tea.KeyDown},
+		{Type: tea.KeyEnter}, // frob gets the second category
+		{Type: tea.KeyRunes, Runes: []rune("s")},
+		{Type: tea.KeyRunes, Runes: []rune("n")},
+		{Type: tea.KeyRunes, Runes: []rune("tooling")},
+		{Type: tea.KeyEnter}, // quux gets a new category
+	}
+	var cmd tea.Cmd
+	for _, key := range keys {
+		_, cmd = model.Update(key)
+	}
+
+	if len(assigned) != 2 || assigned["frob"] == "" || assigned["quux"] != "tooling" {
+		t.Errorf("assigned = %v, want frob and quux (tooling)", assigned)
+	}
+	if _, skipped := assigned["zork"]; skipped {
+		t.Error("zork was skipped but got assigned")
+	}
+	if cmd == nil {
+		t.Error("the triage should quit after the last tool")
+	}
+	if got, want := model.Summary(), "Assigned 2 tools, skipped 1, 2 stored commands updated"; got != want {
+		t.Errorf("Summary = %q, want %q", got, want)
+	}
+}
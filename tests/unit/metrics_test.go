@@ -0,0 +1,139 @@
+package unit
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/oiahoon/termonaut/internal/analytics"
+	"github.com/oiahoon/termonaut/internal/config"
+	"github.com/oiahoon/termonaut/pkg/models"
+	"github.com/spf13/viper"
+)
+
+func TestMetricsConfigDecoding(t *testing.T) {
+	v := viper.New()
+	v.SetConfigType("toml")
+	err := v.ReadConfig(strings.NewReader(`
+log_level = "info"
+
+[[metrics]]
+name = "deploys"
+pattern = "^kubectl .*apply"
+status = "success"
+badge = true
+
+[[metrics]]
+name = "test-time"
+type = "timer"
+category = "test"
+exit_code = 0
+`))
+	if err != nil {
+		t.Fatalf("ReadConfig: %v", err)
+	}
+
+	var cfg config.Config
+	if err := v.Unmarshal(&cfg); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+
+	if len(cfg.Metrics) != 2 {
+		t.Fatalf("got %d metrics, want 2", len(cfg.Metrics))
+	}
+	if m := cfg.Metrics[0]; m.Name != "deploys" || m.Pattern != "^kubectl .*apply" || !m.Badge || m.Status != "success" {
+		t.Errorf("metrics[0] = %+v", m)
+	}
+	if m := cfg.Metrics[1]; m.Type != "timer" || m.ExitCode == nil || *m.ExitCode != 0 {
+		t.Errorf("metrics[1] = %+v", m)
+	}
+}
+
+func TestValidateMetrics(t *testing.T) {
+	tests := []struct {
+		name    string
+		metrics []config.MetricConfig
+		wantErr string
+	}{
+		{"valid", []config.MetricConfig{{Name: "a", Pattern: "^git"}, {Name: "b", Category: "test", Type: "timer"}}, ""},
+		{"missing name", []config.MetricConfig{{Pattern: "x"}}, "name is required"},
+		{"duplicate", []config.MetricConfig{{Name: "a", Pattern: "x"}, {Name: "a", Pattern: "y"}}, "duplicate"},
+		{"bad type", []config.MetricConfig{{Name: "a", Pattern: "x", Type: "gauge"}}, "invalid type"},
+		{"bad status", []config.MetricConfig{{Name: "a", Pattern: "x", Status: "ok"}}, "invalid status"},
+		{"bad regex", []config.MetricConfig{{Name: "a", Pattern: "("}}, "invalid pattern"},
+		{"no conditions", []config.MetricConfig{{Name: "a", Status: "failure"}}, "at least one"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := config.ValidateMetrics(tt.metrics)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMetricEngineCompute(t *testing.T) {
+	now := time.Date(2024, 6, 15, 18, 0, 0, 0, time.Local)
+	zero := 0
+	engine, err := analytics.NewMetricEngine([]config.MetricConfig{
+		{Name: "deploys", Pattern: `^kubectl .*apply`, Directory: "/deploy", Status: "success"},
+		{Name: "test-time", Type: "timer", Pattern: `^go test`, Exclude: "-short", ExitCode: &zero},
+	})
+	if err != nil {
+		t.Fatalf("NewMetricEngine: %v", err)
+	}
+
+	cmd := func(command, cwd string, exitCode int, daysAgo int, durationMS int64) *models.Command {
+		return &models.Command{
+			Command:    command,
+			CWD:        cwd,
+			ExitCode:   exitCode,
+			Timestamp:  now.AddDate(0, 0, -daysAgo),
+			DurationMS: durationMS,
+			Finished:   true,
+		}
+	}
+	commands := []*models.Command{
+		cmd("kubectl apply -f app.yaml", "/srv/deploy", 0, 0, 0),
+		cmd("kubectl apply -f app.yaml", "/srv/deploy", 1, 0, 0), // failed
+		cmd("kubectl apply -f app.yaml", "/home/me", 0, 0, 0),    // wrong directory
+		cmd("kubectl -n prod apply -f db.yaml", "/srv/deploy", 0, 3, 0),
+		cmd("kubectl apply -f old.yaml", "/srv/deploy", 0, 40, 0),
+		cmd("go test ./...", "/src", 0, 1, 2000),
+		cmd("go test -short ./...", "/src", 0, 1, 500), // excluded
+		cmd("go test ./...", "/src", 1, 1, 800),        // wrong exit code
+	}
+	unfinished := cmd("kubectl apply -f app.yaml", "/srv/deploy", 0, 0, 0) // exit status not reported
+	unfinished.Finished = false
+	commands = append(commands, unfinished)
+
+	values := engine.Compute(commands, now, 7)
+	if len(values) != 2 {
+		t.Fatalf("got %d values, want 2", len(values))
+	}
+
+	deploys := analytics.FindMetric(values, "deploys")
+	if deploys.Total != 3 || deploys.Today != 1 || deploys.Last7Days != 2 || deploys.Last30Days != 2 {
+		t.Errorf("deploys = total %d today %d 7d %d 30d %d, want 3/1/2/2",
+			deploys.Total, deploys.Today, deploys.Last7Days, deploys.Last30Days)
+	}
+	if len(deploys.Series) != 7 || deploys.Series[6].Count != 1 || deploys.Series[3].Count != 1 {
+		t.Errorf("deploys series = %+v", deploys.Series)
+	}
+	if deploys.Display() != "3" {
+		t.Errorf("deploys display = %q, want 3", deploys.Display())
+	}
+
+	testTime := analytics.FindMetric(values, "test-time")
+	if testTime.Total != 1 || testTime.TotalDurationMS != 2000 || testTime.Display() != "2.0s" {
+		t.Errorf("test-time = %+v (display %q)", testTime, testTime.Display())
+	}
+}
@@ -0,0 +1,121 @@
+package unit
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/oiahoon/termonaut/internal/analytics"
+	"github.com/oiahoon/termonaut/internal/categories"
+	"github.com/oiahoon/termonaut/internal/database"
+	"github.com/oiahoon/termonaut/pkg/models"
+	"github.com/sirupsen/logrus"
+)
+
+func TestNormalizeCommand(t *testing.T) {
+	tests := []struct {
+		command    string
+		tool       string
+		subcommand string
+		flags      string
+	}{
+		{`git commit -m "fix bug"`, "git", "commit", "-m"},
+		{`git commit -m "other"`, "git", "commit", "-m"},
+		{"git -C ~/src/app status --short", "git", "status", "--short,-C"},
+		{"kubectl -n prod get pods -o=wide", "kubectl", "get", "-n,-o"},
+		{"ls -la /tmp", "ls", "", "-a,-l"},
+		{"tail -n10 app.log", "tail", "", "-n"},
+		{"go test -race -run TestFoo ./...", "go", "test", "-race,-run"},
+		{"sudo docker run --rm -it ubuntu bash", "docker", "run", "--rm,-i,-t"},
+		{"npm install ./local-pkg", "npm", "install", ""},
+		{"docker ./weird", "docker", "", ""},
+		{"git status | grep modified", "git", "status", ""},
+		{"", "", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.command, func(t *testing.T) {
+			got := categories.NormalizeCommand(tt.command)
+			if got.Tool != tt.tool || got.Subcommand != tt.subcommand {
+				t.Errorf("got %q/%q, want %q/%q", got.Tool, got.Subcommand, tt.tool, tt.subcommand)
+			}
+			if flags := strings.Join(got.Flags, ","); flags != tt.flags {
+				t.Errorf("flags = %q, want %q", flags, tt.flags)
+			}
+		})
+	}
+}
+
+func TestToolUsageBreakdown(t *testing.T) {
+	var commands []*models.Command
+	add := func(count int, command string, exitCode int) {
+		for i := 0; i < count; i++ {
+			commands = append(commands, &models.Command{Command: command, ExitCode: exitCode})
+		}
+	}
+	add(4, `git commit -m "work"`, 0)
+	add(1, "git commit --amend", 0)
+	add(3, "git status", 0)
+	add(2, "git push", 1)
+	add(5, "ls -la", 0)
+
+	usage := analytics.NewToolAnalyzer().AnalyzeToolUsage("git", commands)
+
+	if usage.TotalUses != 10 {
+		t.Fatalf("TotalUses = %d, want 10", usage.TotalUses)
+	}
+	if usage.Subcommands[0].Name != "commit" || usage.Subcommands[0].Count != 5 {
+		t.Errorf("top subcommand = %+v, want commit x5", usage.Subcommands[0])
+	}
+	for _, sub := range usage.Subcommands {
+		if sub.Name == "push" && sub.FailureRate != 100 {
+			t.Errorf("push FailureRate = %.0f, want 100", sub.FailureRate)
+		}
+	}
+	if flag := usage.Flags[0]; flag.Subcommand != "commit" || flag.Flag != "-m" || flag.Percentage != 80 {
+		t.Errorf("top flag = %+v, want commit -m at 80%%", flag)
+	}
+
+	// --amend is used; status --short is not; push has too few uses for hints
+	var unused []string
+	for _, hint := range usage.UnusedFlags {
+		unused = append(unused, hint.Subcommand+" "+hint.Flags[0])
+	}
+	if got := strings.Join(unused, ","); got != "commit -v,status -s" {
+		t.Errorf("UnusedFlags = %q, want %q", got, "commit -v,status -s")
+	}
+}
+
+func TestTopNormalizedCommands(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+
+	db, err := database.New(t.TempDir(), logger)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	session, err := db.GetOrCreateSession(12345, "zsh")
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+
+	for _, command := range []string{`git commit -m "a"`, `git commit -m "b"`, `git commit -m "c"`, "ls", "ls -la"} {
+		if err := db.StoreCommand(&models.Command{
+			Timestamp: time.Now(),
+			SessionID: session.ID,
+			Command:   command,
+		}); err != nil {
+			t.Fatalf("Failed to store command: %v", err)
+		}
+	}
+
+	top, err := db.GetTopNormalizedCommands(10)
+	if err != nil {
+		t.Fatalf("GetTopNormalizedCommands: %v", err)
+	}
+	if len(top) != 2 || top[0]["command"] != "git commit" || top[0]["count"] != 3 || top[1]["count"] != 2 {
+		t.Errorf("top = %v, want git commit x3 then ls x2", top)
+	}
+}
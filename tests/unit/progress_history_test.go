@@ -0,0 +1,211 @@
+package unit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/oiahoon/termonaut/internal/calendar"
+	"github.com/oiahoon/termonaut/internal/database"
+	"github.com/oiahoon/termonaut/internal/gamification"
+	"github.com/oiahoon/termonaut/pkg/models"
+	"github.com/sirupsen/logrus"
+)
+
+func TestEstimateProgressHistory(t *testing.T) {
+	days := []gamification.HistoryDay{
+		{Date: "2024-02-28", Commands: 10, NewCommands: 5},
+		{Date: "2024-02-29", Commands: 30, NewCommands: 3, Achievements: 1},
+		{Date: "2024-03-02", Commands: 20, NewCommands: 2, XP: 500, XPRecorded: true},
+	}
+
+	snapshots := gamification.EstimateProgressHistory(days, 900)
+	if len(snapshots) != 3 {
+		t.Fatalf("got %d snapshots, want 3", len(snapshots))
+	}
+
+	tests := []struct {
+		date     string
+		xp       int
+		level    int
+		commands int
+		unique   int
+		streak   int
+		longest  int
+	}{
+		// 400 XP unaccounted for by the daily history, spread over 40 commands
+		{"2024-02-28", 100, 2, 10, 5, 1, 1},
+		{"2024-02-29", 400, 3, 40, 8, 2, 2},
+		{"2024-03-02", 900, 4, 60, 10, 1, 2},
+	}
+	for i, tt := range tests {
+		s := snapshots[i]
+		if s.Date != tt.date || s.TotalXP != tt.xp || s.Level != tt.level || s.CommandsCount != tt.commands ||
+			s.UniqueCommandsCount != tt.unique || s.CurrentStreak != tt.streak || s.LongestStreak != tt.longest || !s.Estimated {
+			t.Errorf("snapshot %d = %+v, want %+v", i, s, tt)
+		}
+	}
+	if snapshots[2].AchievementsCount != 1 {
+		t.Errorf("achievements = %d, want 1", snapshots[2].AchievementsCount)
+	}
+}
+
+func TestProgressSeries(t *testing.T) {
+	snapshots := []*models.ProgressSnapshot{
+		{Date: "2024-03-01", TotalXP: 100, Level: 2, CurrentStreak: 3},
+		{Date: "2024-03-03", TotalXP: 250, Level: 2, CurrentStreak: 1},
+	}
+	dates := []string{"2024-02-29", "2024-03-01", "2024-03-02", "2024-03-03", "2024-03-04", "2024-03-05"}
+
+	series := gamification.ProgressSeries(snapshots, dates)
+	wantXP := []int{0, 100, 100, 250, 250, 250}
+	wantStreak := []int{0, 3, 3, 1, 1, 0}
+	for i, point := range series {
+		if point.Date != dates[i] || point.TotalXP != wantXP[i] || point.CurrentStreak != wantStreak[i] {
+			t.Errorf("point %d = %+v, want xp %d streak %d", i, point, wantXP[i], wantStreak[i])
+		}
+	}
+	if series[0].Level != 1 {
+		t.Errorf("level before history = %d, want 1", series[0].Level)
+	}
+
+	if s := gamification.SnapshotAt(snapshots, "2024-03-02"); s == nil || s.TotalXP != 100 {
+		t.Errorf("SnapshotAt(2024-03-02) = %+v, want 100 XP", s)
+	}
+	if s := gamification.SnapshotAt(snapshots, "2024-02-01"); s != nil {
+		t.Errorf("SnapshotAt before history = %+v, want nil", s)
+	}
+}
+
+func TestProgressSnapshotBackfill(t *testing.T) {
+	useCalendar(t, "recorded", 0)
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+
+	db, err := database.New(t.TempDir(), logger)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	session, err := db.GetOrCreateSession(12345, "zsh")
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+
+	// An existing user with history from before snapshots were recorded
+	now := time.Now()
+	noon := time.Date(now.Year(), now.Month(), now.Day(), 12, 0, 0, 0, now.Location())
+	for _, c := range []struct {
+		command string
+		daysAgo int
+	}{
+		{"ls", 3}, {"git status", 3}, {"ls", 2}, {"make", 1},
+	} {
+		if err := db.StoreCommand(&models.Command{
+			Timestamp: noon.AddDate(0, 0, -c.daysAgo),
+			SessionID: session.ID,
+			Command:   c.command,
+		}); err != nil {
+			t.Fatalf("Failed to store command: %v", err)
+		}
+	}
+	if err := db.UpdateStreakAndCommands(); err != nil {
+		t.Fatalf("UpdateStreakAndCommands: %v", err)
+	}
+
+	if added, err := db.BackfillProgressSnapshots(); err != nil || added != 3 {
+		t.Fatalf("BackfillProgressSnapshots added %d, %v; want 3", added, err)
+	}
+	if err := db.RecordProgressSnapshot(); err != nil {
+		t.Fatalf("RecordProgressSnapshot: %v", err)
+	}
+	snapshots, err := db.GetProgressSnapshots()
+	if err != nil {
+		t.Fatalf("GetProgressSnapshots: %v", err)
+	}
+
+	today := calendar.Default().Today(now)
+	if len(snapshots) != 4 {
+		t.Fatalf("got %d snapshots, want 3 backfilled and today's: %+v", len(snapshots), snapshots)
+	}
+	for i, s := range snapshots[:3] {
+		if !s.Estimated || s.Date != calendar.AddDays(today, i-3) {
+			t.Errorf("snapshot %d = %+v, want estimated for %s", i, s, calendar.AddDays(today, i-3))
+		}
+	}
+	if s := snapshots[1]; s.CommandsCount != 3 || s.UniqueCommandsCount != 2 || s.CurrentStreak != 2 {
+		t.Errorf("two days ago = %+v, want 3 commands, 2 unique, streak 2", s)
+	}
+	if s := snapshots[3]; s.Estimated || s.Date != today || s.CommandsCount != 4 || s.UniqueCommandsCount != 3 {
+		t.Errorf("today = %+v, want recorded with 4 commands, 3 unique", s)
+	}
+
+	// Backfilling again leaves existing snapshots alone
+	if added, err := db.BackfillProgressSnapshots(); err != nil || added != 0 {
+		t.Errorf("second backfill added %d, %v; want 0", added, err)
+	}
+}
+
+func TestProgressSnapshotOncePerDay(t *testing.T) {
+	useCalendar(t, "recorded", 0)
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+
+	db, err := database.New(t.TempDir(), logger)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	session, err := db.GetOrCreateSession(12345, "zsh")
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+	store := func(command string, at time.Time) {
+		if err := db.StoreCommandWithXP(&models.Command{Timestamp: at, SessionID: session.ID, Command: command}); err != nil {
+			t.Fatalf("Failed to store command: %v", err)
+		}
+	}
+
+	// Yesterday's commands, estimated as if logged before snapshots existed
+	now := time.Now()
+	yesterday := time.Date(now.Year(), now.Month(), now.Day(), 12, 0, 0, 0, now.Location()).AddDate(0, 0, -1)
+	if err := db.StoreCommand(&models.Command{Timestamp: yesterday, SessionID: session.ID, Command: "ls"}); err != nil {
+		t.Fatalf("Failed to store command: %v", err)
+	}
+	if err := db.UpdateStreakAndCommands(); err != nil {
+		t.Fatalf("UpdateStreakAndCommands: %v", err)
+	}
+	if _, err := db.BackfillProgressSnapshots(); err != nil {
+		t.Fatalf("BackfillProgressSnapshots: %v", err)
+	}
+
+	store("git status", now)
+	store("make", now)
+	snapshots, err := db.GetProgressSnapshots()
+	if err != nil {
+		t.Fatalf("GetProgressSnapshots: %v", err)
+	}
+	today := calendar.Default().Today(now)
+	if len(snapshots) != 2 {
+		t.Fatalf("got %d snapshots, want yesterday's and today's: %+v", len(snapshots), snapshots)
+	}
+	if s := snapshots[0]; s.Estimated || s.CommandsCount != 1 {
+		t.Errorf("yesterday = %+v, want completed by today's first command with 1 command", s)
+	}
+	if s := snapshots[1]; s.Date != today || s.CommandsCount != 2 {
+		t.Errorf("today = %+v, want recorded by the first command only", s)
+	}
+
+	// Reading the history brings today up to date without writing it
+	history, err := db.GetProgressHistory()
+	if err != nil {
+		t.Fatalf("GetProgressHistory: %v", err)
+	}
+	if s := history[len(history)-1]; s.Date != today || s.CommandsCount != 3 {
+		t.Errorf("history today = %+v, want 3 commands", s)
+	}
+	if snapshots, _ := db.GetProgressSnapshots(); snapshots[1].CommandsCount != 2 {
+		t.Errorf("GetProgressHistory rewrote today's snapshot: %+v", snapshots[1])
+	}
+}
@@ -0,0 +1,190 @@
+package unit
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/oiahoon/termonaut/internal/database"
+	"github.com/oiahoon/termonaut/internal/query"
+	"github.com/oiahoon/termonaut/pkg/models"
+	"github.com/sirupsen/logrus"
+)
+
+func TestQueryCompile(t *testing.T) {
+	now := time.Date(2024, 6, 15, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		input   string
+		columns string
+		sql     []string // fragments the SQL must contain
+		args    int
+		wantErr string
+	}{
+		{
+			name:    "count by tool",
+			input:   "count() by tool where category=git and since=30d order by count desc limit 10",
+			columns: "tool,count",
+			sql:     []string{"COUNT(*)", "GROUP BY d.tool", "ELSE c.category END) = ?", "julianday(c.timestamp) >= julianday(?)", `ORDER BY "count" DESC`, "LEFT JOIN query_fields d"},
+			args:    3,
+		},
+		{
+			name:    "default select",
+			input:   "by day",
+			columns: "day,count",
+			sql:     []string{"local_day(c.timestamp)", "ORDER BY 1"},
+			args:    1,
+		},
+		{
+			name:    "aggregates",
+			input:   "avg(duration_ms), distinct(command) by week",
+			columns: "week,avg_duration_ms,distinct_command",
+			sql:     []string{"AVG(c.duration_ms)", "COUNT(DISTINCT c.command)"},
+			args:    1,
+		},
+		{
+			name:    "raw rows",
+			input:   "timestamp, command where cwd ~ '50%_done' and exit_code != 0",
+			columns: "timestamp,command",
+			sql:     []string{"LIKE ? ESCAPE", "c.exit_code != ?", "ORDER BY c.timestamp DESC"},
+			args:    3,
+		},
+		{
+			name:    "order by aggregate call",
+			input:   "sum(failed) by shell order by sum(failed)",
+			columns: "shell,sum_failed",
+			sql:     []string{`ORDER BY "sum_failed"`},
+			args:    1,
+		},
+		{name: "unknown field", input: "count() by secret", wantErr: "unknown field"},
+		{name: "ungrouped field", input: "command, count()", wantErr: "must be grouped"},
+		{name: "sum of text", input: "sum(command)", wantErr: "numeric"},
+		{name: "bad number", input: "where exit_code=abc", wantErr: "needs a number"},
+		{name: "bad time", input: "where since=yesterday", wantErr: "invalid time"},
+		{name: "bad order", input: "count() by tool order by cwd", wantErr: "cannot order by"},
+		{name: "injection", input: "count() by tool; DROP TABLE commands", wantErr: "unexpected input"},
+		{name: "unterminated", input: "where command='git", wantErr: "unterminated"},
+		{name: "bad limit", input: "limit -1", wantErr: "positive number"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := query.Parse(tt.input)
+			var compiled *query.Compiled
+			if err == nil {
+				compiled, err = query.Compile(q, now)
+			}
+
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got := strings.Join(compiled.Columns, ","); got != tt.columns {
+				t.Errorf("columns = %s, want %s", got, tt.columns)
+			}
+			for _, fragment := range tt.sql {
+				if !strings.Contains(compiled.SQL, fragment) {
+					t.Errorf("SQL %q does not contain %q", compiled.SQL, fragment)
+				}
+			}
+			if len(compiled.Args) != tt.args {
+				t.Errorf("args = %v, want %d", compiled.Args, tt.args)
+			}
+		})
+	}
+}
+
+func TestQueryRun(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+
+	db, err := database.New(t.TempDir(), logger)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	session, err := db.GetOrCreateSession(12345, "zsh")
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+
+	now := time.Now()
+	commands := []struct {
+		command  string
+		exitCode int
+		age      time.Duration
+	}{
+		{"git status", 0, time.Hour},
+		{"git commit -m 'x'", 0, time.Hour},
+		{"git push", 1, 2 * time.Hour},
+		{"ls -la", 0, time.Hour},
+		{"git log", 0, 60 * 24 * time.Hour}, // outside since=30d
+	}
+	for _, c := range commands {
+		if err := db.StoreCommand(&models.Command{
+			Timestamp: now.Add(-c.age),
+			SessionID: session.ID,
+			Command:   c.command,
+			ExitCode:  c.exitCode,
+		}); err != nil {
+			t.Fatalf("Failed to store command: %v", err)
+		}
+	}
+
+	result, err := query.Run(db, "count(), sum(failed) by tool where since=30d order by count desc", now)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	records := result.Records()
+	if len(records) != 2 || records[0]["tool"] != "git" || records[0]["count"] != int64(3) || records[0]["sum_failed"] != int64(1) {
+		t.Errorf("records = %v, want git x3 (1 failed) then ls", records)
+	}
+
+	result, err = query.Run(db, "command where shell=zsh and category=git and subcommand!=status and since=30d order by command", now)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if len(result.Rows) != 2 || result.Rows[0][0] != "git commit -m 'x'" || result.Rows[1][0] != "git push" {
+		t.Errorf("rows = %v, want git commit and git push", result.Rows)
+	}
+
+	csv, err := query.FormatCSV(result)
+	if err != nil {
+		t.Fatalf("FormatCSV: %v", err)
+	}
+	if !strings.HasPrefix(csv, "command\ngit commit -m 'x'\n") {
+		t.Errorf("CSV = %q", csv)
+	}
+	if table := query.FormatTable(result); !strings.Contains(table, "2 row(s)") {
+		t.Errorf("table = %q", table)
+	}
+
+	result, err = query.Run(db, "count() by subcategory where category=git and since=30d order by subcategory", now)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if records := result.Records(); len(records) != 2 || records[0]["subcategory"] != "git/commit" || records[0]["count"] != int64(2) || records[1]["subcategory"] != "git/remote" {
+		t.Errorf("subcategories = %v, want git/commit x2 and git/remote", records)
+	}
+
+	// Mistakes in the query are told apart from failures to run it
+	var invalid *query.InvalidQueryError
+	for _, input := range []string{"count( by tool", "count() by nosuchfield"} {
+		if _, err := query.Run(db, input, now); !errors.As(err, &invalid) {
+			t.Errorf("Run(%q) error = %v, want an InvalidQueryError", input, err)
+		}
+	}
+	db.Close()
+	if _, err := query.Run(db, "count() by tool", now); err == nil || errors.As(err, &invalid) {
+		t.Errorf("Run on a closed database error = %v, want an execution error", err)
+	}
+}
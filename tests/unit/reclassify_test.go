@@ -0,0 +1,249 @@
+package unit
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/oiahoon/termonaut/internal/categories"
+	"github.com/oiahoon/termonaut/internal/database"
+	"github.com/oiahoon/termonaut/internal/query"
+	"github.com/oiahoon/termonaut/pkg/models"
+	"github.com/sirupsen/logrus"
+)
+
+func TestStoredCategory(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+	db, err := database.New(t.TempDir(), logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	session, err := db.GetOrCreateSession(12345, "zsh")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := &models.Command{Timestamp: time.Now(), SessionID: session.ID, Command: "git push origin main"}
+	if err := db.StoreCommand(cmd); err != nil {
+		t.Fatal(err)
+	}
+	if cmd.Category != string(categories.GitRemote) {
+		t.Errorf("Category = %q, want %q", cmd.Category, categories.GitRemote)
+	}
+
+	batch := []*models.Command{
+		{Timestamp: time.Now(), SessionID: session.ID, Command: "docker ps"},
+		{Timestamp: time.Now(), SessionID: session.ID, Command: "cd /tmp"},
+	}
+	if err := db.StoreCommandsBatch(batch); err != nil {
+		t.Fatal(err)
+	}
+	if batch[0].Category != string(categories.DockerInspect) || batch[1].Category != string(categories.Navigation) {
+		t.Errorf("batch categories = %q, %q", batch[0].Category, batch[1].Category)
+	}
+	if segments, err := db.GetCommandSegments(batch[0].ID); err != nil || len(segments) != 1 {
+		t.Errorf("GetCommandSegments = %v, %v; want the batch command's segment", segments, err)
+	}
+}
+
+func TestStoredCategoryIsRead(t *testing.T) {
+	dir := t.TempDir()
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+	db, err := database.New(dir, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	session, err := db.GetOrCreateSession(12345, "zsh")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, command := range []string{"frob --all", "git log"} {
+		if err := db.StoreCommand(&models.Command{Timestamp: time.Now(), SessionID: session.ID, Command: command}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// As "categories reclassify" leaves it after the rules changed
+	conn, err := sql.Open("sqlite3", filepath.Join(dir, database.DatabaseName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = conn.Exec("UPDATE commands SET category = 'build' WHERE command = 'frob --all'")
+	conn.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	commands, err := db.GetAllCommands()
+	if err != nil {
+		t.Fatal(err)
+	}
+	classifier := categories.NewCommandClassifier()
+	for _, cmd := range commands {
+		if cmd.Command == "frob --all" && (cmd.Category != "build" || classifier.CommandCategory(cmd) != categories.Build) {
+			t.Errorf("GetAllCommands category = %q, want the stored build", cmd.Category)
+		}
+	}
+	stats := classifier.AnalyzeCommandCategories(commands)
+	if stats[categories.Build] == nil || stats[categories.Build].Count != 1 || stats[categories.Unknown] != nil {
+		t.Errorf("AnalyzeCommandCategories = %v, want frob counted as build", stats)
+	}
+	if git := stats[categories.Git]; git == nil || git.Subcategories[categories.GitHistory] == nil {
+		t.Errorf("AnalyzeCommandCategories lost the git/history subcategory: %v", stats)
+	}
+
+	result, err := query.Run(db, "count() by category order by category", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if records := result.Records(); len(records) != 2 || records[0]["category"] != "build" || records[1]["category"] != "git" {
+		t.Errorf("count() by category = %v, want build and git", records)
+	}
+}
+
+func TestReclassifyCommands(t *testing.T) {
+	t.Cleanup(func() { categories.SetDefinitions(nil) })
+
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+	db, err := database.New(t.TempDir(), logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	session, err := db.GetOrCreateSession(12345, "zsh")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, command := range []string{"terraform plan", "git status", "terraform apply", "ls", "terraform init"} {
+		cmd := &models.Command{Timestamp: time.Now(), SessionID: session.ID, Command: command}
+		if err := db.StoreCommand(cmd); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The rules change: terraform is now infrastructure, worth more XP
+	bonus := 2.0
+	categories.SetDefinitions(&categories.Definitions{
+		Source: "categories.toml",
+		Categories: []categories.Definition{
+			{ID: "infra", XPBonus: &bonus, Patterns: []string{`^terraform\s+`}},
+		},
+	})
+
+	preview, err := db.ReclassifyCommands(database.ReclassifyOptions{DryRun: true, RecomputeXP: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !preview.Complete || preview.Processed != 5 || preview.Changed != 3 ||
+		preview.Before["cloud"] != 3 || preview.After["infra"] != 3 || preview.After["cloud"] != 0 || preview.XPDelta <= 0 {
+		t.Errorf("dry run = %+v", preview)
+	}
+	if usage, _ := db.GetCategoryUsage(); usage[0].Category != "cloud" {
+		t.Errorf("dry run changed the stored categories: %+v", usage[0])
+	}
+
+	before, err := db.GetUserProgress()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Stopped at the limit, then resumed
+	partial, err := db.ReclassifyCommands(database.ReclassifyOptions{BatchSize: 2, Limit: 2, RecomputeXP: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if partial.Complete || partial.Processed != 2 || partial.Changed != 1 {
+		t.Errorf("partial run = %+v, want 2 processed and 1 changed", partial)
+	}
+	if _, err := db.ReclassifyCommands(database.ReclassifyOptions{}); err == nil {
+		t.Error("resuming with different options should fail")
+	}
+
+	result, err := db.ReclassifyCommands(database.ReclassifyOptions{BatchSize: 2, RecomputeXP: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Resumed || !result.Complete || result.Processed != 5 || result.Changed != 3 ||
+		result.Before["cloud"] != 3 || result.After["infra"] != 3 || result.XPDelta != preview.XPDelta {
+		t.Errorf("resumed run = %+v, want the same counts as the dry run", result)
+	}
+
+	after, err := db.GetUserProgress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if after.TotalXP != before.TotalXP+result.XPDelta {
+		t.Errorf("total XP = %d, want %d + %d", after.TotalXP, before.TotalXP, result.XPDelta)
+	}
+
+	usage, err := db.GetCategoryUsage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if usage[0].Category != "infra" || usage[0].Commands != 3 {
+		t.Errorf("top category = %+v, want infra with 3 commands", usage[0])
+	}
+
+	// A finished run leaves nothing to resume
+	again, err := db.ReclassifyCommands(database.ReclassifyOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.Resumed || again.Changed != 0 || again.Processed != 5 {
+		t.Errorf("second run = %+v, want a fresh run without changes", again)
+	}
+}
+
+func TestReclassifyMigratedDatabase(t *testing.T) {
+	dir := t.TempDir()
+
+	// A database from before categories were stored
+	conn, err := sql.Open("sqlite3", filepath.Join(dir, database.DatabaseName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = conn.Exec(`
+		CREATE TABLE commands (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			timestamp DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			session_id INTEGER NOT NULL,
+			command TEXT NOT NULL,
+			exit_code INTEGER DEFAULT 0,
+			cwd TEXT,
+			duration_ms INTEGER
+		);
+		INSERT INTO commands (session_id, command) VALUES (1, 'git log'), (1, 'make');
+	`)
+	conn.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+	db, err := database.New(dir, logger)
+	if err != nil {
+		t.Fatalf("opening an old database: %v", err)
+	}
+	defer db.Close()
+
+	since := time.Now().Add(-time.Hour)
+	result, err := db.ReclassifyCommands(database.ReclassifyOptions{Since: since, RecomputeXP: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Before[database.UnsetCategory] != 2 || result.Changed != 2 ||
+		result.After[string(categories.GitHistory)] != 1 || result.XPDelta != 0 {
+		t.Errorf("result = %+v, want both commands categorized without XP changes", result)
+	}
+}
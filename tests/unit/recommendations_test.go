@@ -0,0 +1,183 @@
+package unit
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/oiahoon/termonaut/internal/analytics"
+	"github.com/oiahoon/termonaut/internal/database"
+	"github.com/oiahoon/termonaut/pkg/models"
+	"github.com/sirupsen/logrus"
+)
+
+func TestRecommendationRules(t *testing.T) {
+	now := time.Date(2024, 6, 15, 18, 0, 0, 0, time.UTC)
+	at := func(daysAgo, hour int) time.Time {
+		day := now.AddDate(0, 0, -daysAgo)
+		return time.Date(day.Year(), day.Month(), day.Day(), hour, 0, 0, 0, time.UTC)
+	}
+	repeat := func(n int, command string, exitCode int, hour int) []*models.Command {
+		var commands []*models.Command
+		for i := 0; i < n; i++ {
+			commands = append(commands, &models.Command{Command: command, ExitCode: exitCode, Timestamp: at(i%7+1, hour), Finished: true})
+		}
+		return commands
+	}
+	concat := func(groups ...[]*models.Command) []*models.Command {
+		var commands []*models.Command
+		for _, group := range groups {
+			commands = append(commands, group...)
+		}
+		return commands
+	}
+
+	tests := []struct {
+		name     string
+		commands []*models.Command
+		rule     string
+		severity analytics.Severity
+		evidence string
+	}{
+		{
+			name:     "climbing directories",
+			commands: repeat(12, "cd ../..", 0, 10),
+			rule:     "jump-tool",
+			severity: analytics.SeverityInfo,
+			evidence: "12 `cd ../..`-style commands",
+		},
+		{
+			name:     "rejected pushes",
+			commands: concat(repeat(4, "git push origin main", 1, 10), repeat(6, "git push", 0, 10)),
+			rule:     "git-push-failures",
+			severity: analytics.SeverityWarning,
+			evidence: "4 of 10 `git push` runs failed",
+		},
+		{
+			name:     "failing commands",
+			commands: concat(repeat(20, "make tset", 2, 10), repeat(40, "make test", 0, 10)),
+			rule:     "low-success-rate",
+			severity: analytics.SeverityWarning,
+			evidence: "Only 67% of 60 commands",
+		},
+		{
+			name:     "late nights",
+			commands: repeat(5, "vim notes.md", 0, 2),
+			rule:     "late-night",
+			severity: analytics.SeverityWarning,
+			evidence: "on 5 of the last 30 days",
+		},
+		{
+			name:     "retyped command",
+			commands: repeat(6, "docker compose up -d --build api", 0, 10),
+			rule:     "repeated-command",
+			severity: analytics.SeverityInfo,
+			evidence: "typed 6 times",
+		},
+	}
+
+	engine := analytics.NewRecommendationEngine()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recs := engine.Evaluate(analytics.NewRecommendationContext(tt.commands, now), nil)
+
+			var found *analytics.Recommendation
+			for _, rec := range recs {
+				if rec.Rule == tt.rule {
+					found = rec
+				}
+			}
+			if found == nil {
+				t.Fatalf("rule %s did not fire; got %+v", tt.rule, recs)
+			}
+			if found.Severity != tt.severity {
+				t.Errorf("severity = %s, want %s", found.Severity, tt.severity)
+			}
+			if found.Action == "" || found.ID == "" {
+				t.Errorf("recommendation missing action or id: %+v", found)
+			}
+			if !strings.Contains(strings.Join(found.Evidence, "\n"), tt.evidence) {
+				t.Errorf("evidence = %q, want %q", found.Evidence, tt.evidence)
+			}
+		})
+	}
+}
+
+func TestRecommendationRulesStayQuiet(t *testing.T) {
+	now := time.Date(2024, 6, 15, 18, 0, 0, 0, time.UTC)
+	var commands []*models.Command
+	for i := 0; i < 12; i++ {
+		commands = append(commands,
+			&models.Command{Command: "cd ../..", Timestamp: now.Add(-time.Duration(i+1) * time.Hour)},
+			&models.Command{Command: "git push", ExitCode: 0, Timestamp: now.Add(-time.Duration(i+1) * time.Hour)},
+		)
+	}
+	// Someone already using a jump tool does not need one suggested
+	commands = append(commands, &models.Command{Command: "z termonaut", Timestamp: now.Add(-time.Minute)})
+	// Activity outside the window is ignored
+	commands = append(commands, &models.Command{Command: "ls", Timestamp: now.AddDate(0, 0, -40).Add(-20 * time.Hour)})
+
+	recs := analytics.NewRecommendationEngine().Evaluate(analytics.NewRecommendationContext(commands, now), nil)
+	for _, rec := range recs {
+		if rec.Rule == "jump-tool" || rec.Rule == "git-push-failures" || rec.Rule == "late-night" {
+			t.Errorf("unexpected recommendation %+v", rec)
+		}
+	}
+}
+
+func TestRecommendationEngineCustomRulesAndDismissals(t *testing.T) {
+	engine := analytics.NewRecommendationEngine()
+	engine.Register(analytics.NewRecommendationRule("custom", func(ctx *analytics.RecommendationContext) []*analytics.Recommendation {
+		return []*analytics.Recommendation{
+			{ID: "custom:a", Title: "A", Action: "do a"},
+			{ID: "custom:b", Title: "B", Action: "do b", Severity: analytics.SeverityCritical},
+		}
+	}))
+
+	ctx := analytics.NewRecommendationContext(nil, time.Now())
+	recs := engine.Evaluate(ctx, map[string]time.Time{"custom:a": time.Now()})
+	if len(recs) != 1 || recs[0].ID != "custom:b" || recs[0].Rule != "custom" {
+		t.Fatalf("recommendations = %+v, want only custom:b", recs)
+	}
+
+	only := engine.Only("custom").Evaluate(ctx, nil)
+	if len(only) != 2 || only[0].ID != "custom:b" || only[1].Severity != analytics.SeverityInfo {
+		t.Errorf("Only(custom) = %+v, want critical first and info default", only)
+	}
+}
+
+func TestRecommendationDismissalPersistence(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+
+	dir := t.TempDir()
+	db, err := database.New(dir, logger)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	if err := db.DismissRecommendation("late-night"); err != nil {
+		t.Fatalf("DismissRecommendation: %v", err)
+	}
+	db.Close()
+
+	db, err = database.New(dir, logger)
+	if err != nil {
+		t.Fatalf("Failed to reopen database: %v", err)
+	}
+	defer db.Close()
+
+	dismissed, err := db.GetDismissedRecommendations()
+	if err != nil {
+		t.Fatalf("GetDismissedRecommendations: %v", err)
+	}
+	if _, ok := dismissed["late-night"]; !ok || len(dismissed) != 1 {
+		t.Errorf("dismissed = %v, want late-night", dismissed)
+	}
+
+	if restored, err := db.RestoreRecommendation("late-night"); err != nil || !restored {
+		t.Errorf("RestoreRecommendation = %v, %v", restored, err)
+	}
+	if restored, _ := db.RestoreRecommendation("late-night"); restored {
+		t.Error("restoring twice should report nothing restored")
+	}
+}
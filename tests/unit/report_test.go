@@ -0,0 +1,170 @@
+package unit
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/oiahoon/termonaut/internal/gamification"
+	"github.com/oiahoon/termonaut/internal/report"
+	"github.com/oiahoon/termonaut/pkg/models"
+)
+
+func reportCommands(now time.Time) []*models.Command {
+	var commands []*models.Command
+	add := func(ago time.Duration, command string, exitCode int) {
+		commands = append(commands, &models.Command{
+			Command:   command,
+			ExitCode:  exitCode,
+			Timestamp: now.Add(-ago),
+		})
+	}
+
+	// Recent workflow repeated twice within the last week
+	for _, base := range []time.Duration{48 * time.Hour, 24 * time.Hour} {
+		add(base, "git status", 0)
+		add(base-time.Minute, "go test ./...", 1)
+		add(base-2*time.Minute, "git commit -m wip", 0)
+	}
+	// Older activity only covered by the month and year reports
+	add(20*24*time.Hour, "docker ps", 0)
+	add(200*24*time.Hour, "kubectl get pods", 0)
+
+	return commands
+}
+
+func TestReportPeriodFiltering(t *testing.T) {
+	now := time.Date(2024, 7, 15, 12, 0, 0, 0, time.UTC)
+	commands := reportCommands(now)
+
+	tests := []struct {
+		period   report.Period
+		commands int
+		title    string
+	}{
+		{report.PeriodWeek, 6, "Weekly Terminal Report"},
+		{report.PeriodMonth, 7, "Monthly Terminal Report"},
+		{report.PeriodYear, 8, "Yearly Terminal Report"},
+	}
+
+	builder := report.NewBuilder()
+	for _, tt := range tests {
+		t.Run(string(tt.period), func(t *testing.T) {
+			data := builder.Build(tt.period, now, commands, nil, nil)
+			if data.Stats.TotalCommands != tt.commands {
+				t.Errorf("TotalCommands = %d, want %d", data.Stats.TotalCommands, tt.commands)
+			}
+			if data.Title != tt.title {
+				t.Errorf("Title = %q, want %q", data.Title, tt.title)
+			}
+		})
+	}
+}
+
+func TestReportData(t *testing.T) {
+	now := time.Date(2024, 7, 15, 12, 0, 0, 0, time.UTC)
+	achievements := map[string]*gamification.UserAchievement{
+		"recent": {
+			Achievement: &gamification.Achievement{Name: "Recent", Icon: "🆕"},
+			EarnedAt:    now.Add(-24 * time.Hour),
+		},
+		"old": {
+			Achievement: &gamification.Achievement{Name: "Old", Icon: "📜"},
+			EarnedAt:    now.AddDate(0, -2, 0),
+		},
+	}
+	progress := &models.UserProgress{TotalXP: 1200, CurrentLevel: 5, CurrentStreak: 3, LongestStreak: 9}
+
+	data := report.NewBuilder().Build(report.PeriodWeek, now, reportCommands(now), achievements, progress)
+
+	if data.Stats.ActiveDays != 2 {
+		t.Errorf("ActiveDays = %d, want 2", data.Stats.ActiveDays)
+	}
+	if got := int(data.Stats.SuccessRate + 0.5); got != 67 {
+		t.Errorf("SuccessRate = %.1f, want ~66.7", data.Stats.SuccessRate)
+	}
+	if data.Stats.Level != 5 || data.Stats.LongestStreak != 9 {
+		t.Errorf("progress not copied into stats: %+v", data.Stats)
+	}
+	if len(data.TopCommands) == 0 || data.TopCommands[0].Command != "git" || data.TopCommands[0].Count != 4 {
+		t.Errorf("TopCommands = %+v, want git first with 4", data.TopCommands)
+	}
+	if len(data.Achievements) != 1 || data.Achievements[0].Name != "Recent" {
+		t.Errorf("Achievements = %+v, want only Recent", data.Achievements)
+	}
+	if len(data.Workflows) != 1 || strings.Join(data.Workflows[0].Steps, " ") != "git go git" || data.Workflows[0].Count != 2 {
+		t.Errorf("Workflows = %+v, want git → go → git twice", data.Workflows)
+	}
+	if data.Heatmap.PeakHour != 12 {
+		t.Errorf("PeakHour = %d, want 12", data.Heatmap.PeakHour)
+	}
+}
+
+func TestReportRendering(t *testing.T) {
+	now := time.Date(2024, 7, 15, 12, 0, 0, 0, time.UTC)
+	commands := append(reportCommands(now), &models.Command{
+		Command:   "<script>alert(1)</script>",
+		Timestamp: now.Add(-time.Hour),
+	})
+	data := report.NewBuilder().Build(report.PeriodWeek, now, commands, nil, nil)
+
+	tests := []struct {
+		name     string
+		format   report.Format
+		contains []string
+		excludes []string
+	}{
+		{
+			name:     "markdown",
+			format:   report.FormatText,
+			contains: []string{"# 🚀 Weekly Terminal Report", "Commands:** 7", "`git` — 4", "git → go → git (2×)"},
+		},
+		{
+			name:     "html",
+			format:   report.FormatHTML,
+			contains: []string{"<!DOCTYPE html>", "<title>Weekly Terminal Report</title>", "<code>git</code> — 4", "&lt;script&gt;"},
+			excludes: []string{"<script>"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source, err := report.LoadTemplate("", tt.format)
+			if err != nil {
+				t.Fatalf("LoadTemplate: %v", err)
+			}
+			output, err := report.Render(data, source, tt.format)
+			if err != nil {
+				t.Fatalf("Render: %v", err)
+			}
+			for _, want := range tt.contains {
+				if !strings.Contains(output, want) {
+					t.Errorf("output missing %q", want)
+				}
+			}
+			for _, unwanted := range tt.excludes {
+				if strings.Contains(output, unwanted) {
+					t.Errorf("output contains %q", unwanted)
+				}
+			}
+		})
+	}
+}
+
+func TestReportFormatSelection(t *testing.T) {
+	tests := []struct {
+		path string
+		want report.Format
+	}{
+		{"report.html", report.FormatHTML},
+		{"REPORT.HTM", report.FormatHTML},
+		{"report.md", report.FormatText},
+		{"", report.FormatText},
+	}
+
+	for _, tt := range tests {
+		if got := report.FormatFromPath(tt.path); got != tt.want {
+			t.Errorf("FormatFromPath(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
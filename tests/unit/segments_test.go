@@ -0,0 +1,139 @@
+package unit
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/oiahoon/termonaut/internal/categories"
+	"github.com/oiahoon/termonaut/internal/database"
+	"github.com/oiahoon/termonaut/pkg/models"
+	"github.com/sirupsen/logrus"
+)
+
+func TestSplitCommand(t *testing.T) {
+	tests := []struct {
+		command string
+		want    []string // operator, command and tool of each segment
+	}{
+		{"sudo docker ps | grep foo && kubectl get pods",
+			[]string{" docker ps docker", "| grep foo grep", "&& kubectl get pods kubectl"}},
+		{"FOO=1 BAR='a b' make test", []string{" make test make"}},
+		{"time nohup ./run.sh; echo done", []string{" ./run.sh run.sh", "; echo done echo"}},
+		{"sudo -u deploy env -i PATH=/bin timeout 10 /usr/bin/make", []string{" /usr/bin/make make"}},
+		{`git commit -m "fix: a | b && c; d"`, []string{` git commit -m "fix: a | b && c; d" git`}},
+		{`echo a\|b || true`, []string{` echo a\|b echo`, "|| true true"}},
+		{"make build 2>&1 | tee log &> out &", []string{" make build 2>&1 make", "| tee log &> out tee"}},
+		{"echo $(date; whoami) `hostname; id`", []string{" echo $(date; whoami) `hostname; id` echo"}},
+		{"(cd src && make) |& less", []string{" cd src cd", "&& make make", "|& less less"}},
+		{"for f in *; do rm $f; done", []string{" for f in * for", "; rm $f rm"}},
+		{"sudo -i", []string{" sudo -i sudo"}},
+		{"   ;  ", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.command, func(t *testing.T) {
+			var got []string
+			for _, s := range categories.SplitCommand(tt.command) {
+				got = append(got, s.Operator+" "+s.Command+" "+s.Tool)
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("SplitCommand(%q) =\n%s\nwant\n%s", tt.command, strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+
+	segments := categories.SplitCommand("sudo time make")
+	if len(segments) != 1 || strings.Join(segments[0].Wrappers, ",") != "sudo,time" {
+		t.Errorf("wrappers = %+v, want sudo and time", segments)
+	}
+}
+
+func TestClassifyCompoundCommand(t *testing.T) {
+	classifier := categories.NewBuiltinClassifier()
+
+	tests := []struct {
+		command  string
+		primary  categories.Category
+		segments []categories.Category
+	}{
+		{"sudo docker ps | grep foo && kubectl get pods", categories.DockerInspect,
+			[]categories.Category{categories.DockerInspect, categories.Text, categories.KubernetesInspect}},
+		{"cd repo && git pull", categories.GitRemote, []categories.Category{categories.Navigation, categories.GitRemote}},
+		{"frobnicate | grep x", categories.Text, []categories.Category{categories.Unknown, categories.Text}},
+		{"FOO=1 frobnicate", categories.Unknown, []categories.Category{categories.Unknown}},
+	}
+
+	for _, tt := range tests {
+		c := classifier.Classify(tt.command)
+		if c.Primary != tt.primary || classifier.ClassifyCommand(tt.command) != tt.primary {
+			t.Errorf("Classify(%q).Primary = %s, want %s", tt.command, c.Primary, tt.primary)
+		}
+		if len(c.Segments) != len(tt.segments) {
+			t.Fatalf("Classify(%q) has %d segments, want %d", tt.command, len(c.Segments), len(tt.segments))
+		}
+		for i, s := range c.Segments {
+			if s.Category != tt.segments[i] {
+				t.Errorf("Classify(%q) segment %d = %s, want %s", tt.command, i, s.Category, tt.segments[i])
+			}
+		}
+		if c.Segments[c.PrimarySegment].Category != c.Primary {
+			t.Errorf("Classify(%q) primary segment %d is %s", tt.command, c.PrimarySegment, c.Segments[c.PrimarySegment].Category)
+		}
+	}
+
+	if c := classifier.Classify("  "); c.Primary != categories.Unknown || c.PrimarySegment != -1 {
+		t.Errorf("empty command = %+v", c)
+	}
+}
+
+func TestStoreCommandSegments(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+
+	db, err := database.New(t.TempDir(), logger)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	session, err := db.GetOrCreateSession(12345, "zsh")
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+
+	var stored []*models.Command
+	for _, command := range []string{"sudo docker ps | grep foo && kubectl get pods", "docker images", "ls"} {
+		cmd := &models.Command{Timestamp: time.Now(), SessionID: session.ID, Command: command}
+		if err := db.StoreCommand(cmd); err != nil {
+			t.Fatalf("Failed to store command: %v", err)
+		}
+		stored = append(stored, cmd)
+	}
+
+	segments, err := db.GetCommandSegments(stored[0].ID)
+	if err != nil {
+		t.Fatalf("GetCommandSegments: %v", err)
+	}
+	if len(segments) != 3 || segments[0].Command != "docker ps" || segments[0].Wrappers[0] != "sudo" ||
+		segments[1].Operator != "|" || segments[2].Category != categories.KubernetesInspect {
+		t.Errorf("segments = %+v", segments)
+	}
+
+	usage, err := db.GetCategoryUsage()
+	if err != nil {
+		t.Fatalf("GetCategoryUsage: %v", err)
+	}
+	want := map[string][2]int{"docker": {2, 2}, "system": {1, 1}, "text": {0, 1}, "kubernetes": {0, 1}}
+	if len(usage) != len(want) || usage[0].Category != "docker" {
+		t.Fatalf("usage = %+v, want docker first", usage)
+	}
+	for _, u := range usage {
+		if w := want[u.Category]; u.Commands != w[0] || u.Segments != w[1] {
+			t.Errorf("%s usage = %d commands, %d segments; want %v", u.Category, u.Commands, u.Segments, w)
+		}
+	}
+	if subs := usage[0].Subcategories; len(subs) != 1 || subs[0].Category != "docker/inspect" || subs[0].Commands != 2 {
+		t.Errorf("docker subcategories = %+v, want docker/inspect with 2 commands", subs)
+	}
+}
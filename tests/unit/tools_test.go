@@ -0,0 +1,110 @@
+package unit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/oiahoon/termonaut/internal/analytics"
+	"github.com/oiahoon/termonaut/internal/categories"
+	"github.com/oiahoon/termonaut/pkg/models"
+)
+
+func TestBaseCommand(t *testing.T) {
+	tests := []struct {
+		command string
+		want    string
+	}{
+		{"kubectl get pods", "kubectl"},
+		{"sudo kubectl apply -f x.yaml", "kubectl"},
+		{"KUBECONFIG=~/.kube/dev kubectl get ns", "kubectl"},
+		{"/usr/local/bin/terraform plan", "terraform"},
+		{"time make build", "make"},
+		{"env -i FOO=1 make test", "make"},
+		{"./scripts/deploy.sh", "deploy.sh"},
+		{"sudo FOO=1 make install", "make"},
+		{"cd src && make test | tee log", "make"},
+		{"   ", ""},
+	}
+
+	for _, tt := range tests {
+		if got := categories.BaseCommand(tt.command); got != tt.want {
+			t.Errorf("BaseCommand(%q) = %q, want %q", tt.command, got, tt.want)
+		}
+	}
+}
+
+func TestToolAdoption(t *testing.T) {
+	now := time.Date(2024, 7, 15, 12, 0, 0, 0, time.UTC)
+	var commands []*models.Command
+	add := func(daysAgo int, command string, exitCode int) {
+		commands = append(commands, &models.Command{
+			Command:   command,
+			ExitCode:  exitCode,
+			Timestamp: now.AddDate(0, 0, -daysAgo),
+		})
+	}
+
+	// kubectl adopted 60 days ago: early failures, later clean
+	for i := 60; i > 0; i -= 2 {
+		exitCode := 0
+		if i > 45 {
+			exitCode = 1
+		}
+		add(i, "kubectl get pods -n default", exitCode)
+	}
+	// rg adopted a few days ago
+	for i := 0; i < 5; i++ {
+		add(3, "rg --hidden TODO", 0)
+	}
+	// terraform not used for months
+	for i := 0; i < 6; i++ {
+		add(120+i, "terraform plan", 0)
+	}
+	// One-off commands are ignored
+	add(1, "htop", 0)
+
+	report := analytics.NewToolAnalyzer().AnalyzeTools(commands, now)
+
+	if report.TotalTools != 3 {
+		t.Fatalf("TotalTools = %d, want 3", report.TotalTools)
+	}
+	if report.Tools[0].Tool != "rg" {
+		t.Errorf("most recently adopted tool = %q, want rg", report.Tools[0].Tool)
+	}
+	if len(report.NewTools) != 1 || report.NewTools[0] != "rg" {
+		t.Errorf("NewTools = %v, want [rg]", report.NewTools)
+	}
+
+	tests := []struct {
+		tool  string
+		stage string
+		flags int
+	}{
+		{"kubectl", analytics.ToolStageProficient, 1},
+		{"rg", analytics.ToolStageNew, 1},
+		{"terraform", analytics.ToolStageDormant, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.tool, func(t *testing.T) {
+			adoption := report.FindTool(tt.tool)
+			if adoption == nil {
+				t.Fatalf("tool %s missing from report", tt.tool)
+			}
+			if adoption.Stage != tt.stage {
+				t.Errorf("Stage = %q, want %q", adoption.Stage, tt.stage)
+			}
+			if adoption.UniqueFlags != tt.flags {
+				t.Errorf("UniqueFlags = %d, want %d", adoption.UniqueFlags, tt.flags)
+			}
+		})
+	}
+
+	kubectl := report.FindTool("kubectl")
+	if kubectl.EarlyFailureRate <= kubectl.RecentFailureRate {
+		t.Errorf("expected falling failure rate, got %.0f%% → %.0f%%", kubectl.EarlyFailureRate, kubectl.RecentFailureRate)
+	}
+	if !kubectl.FirstSeen.Equal(now.AddDate(0, 0, -60)) {
+		t.Errorf("FirstSeen = %v, want 60 days ago", kubectl.FirstSeen)
+	}
+}
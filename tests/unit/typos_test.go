@@ -0,0 +1,116 @@
+package unit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/oiahoon/termonaut/internal/analytics"
+	"github.com/oiahoon/termonaut/pkg/models"
+)
+
+func typoHistory(entries map[string]int, exitCode int) []*models.Command {
+	var commands []*models.Command
+	now := time.Now()
+	for command, count := range entries {
+		for i := 0; i < count; i++ {
+			commands = append(commands, &models.Command{
+				Command:   command,
+				ExitCode:  exitCode,
+				Timestamp: now,
+			})
+		}
+	}
+	return commands
+}
+
+func TestTypoAnalyzerCorrections(t *testing.T) {
+	history := typoHistory(map[string]int{
+		"git status":  20,
+		"ls -la":      15,
+		"docker ps":   10,
+		"kubectl get": 5,
+	}, 0)
+
+	tests := []struct {
+		name       string
+		command    string
+		exitCode   int
+		correction string
+	}{
+		{"transposed git", "gti status", 0, "git"},
+		{"transposed ls", "sl -la", 0, "ls"},
+		{"transposed docker", "dokcer ps", 0, "docker"},
+		{"extra letter kubectl", "kubectll get pods", 0, "kubectl"},
+	}
+
+	analyzer := analytics.NewTypoAnalyzer()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			commands := append(typoHistory(map[string]int{tt.command: 1}, tt.exitCode), history...)
+			report := analyzer.AnalyzeTypos(commands)
+
+			if len(report.Corrections) != 1 {
+				t.Fatalf("Expected 1 correction, got %d", len(report.Corrections))
+			}
+			if got := report.Corrections[0].Correction; got != tt.correction {
+				t.Errorf("Expected correction %q, got %q", tt.correction, got)
+			}
+		})
+	}
+}
+
+func TestTypoAnalyzerIgnoresKnownCommands(t *testing.T) {
+	// cd and fd are both valid and one edit apart; neither is a typo
+	commands := typoHistory(map[string]int{
+		"cd src":  30,
+		"fd main": 1,
+		"go test": 10,
+	}, 0)
+
+	report := analytics.NewTypoAnalyzer().AnalyzeTypos(commands)
+	if report.TypoCount != 0 {
+		t.Errorf("Expected no typos, got %d: %+v", report.TypoCount, report.Corrections)
+	}
+}
+
+func TestVocabularyFromCounts(t *testing.T) {
+	analyzer := analytics.NewTypoAnalyzer()
+	counts := map[string]int{"git status": 4, "git push": 2, "ls -la": 3, "  ": 1}
+	commands := typoHistory(counts, 0)
+
+	fromCounts := analyzer.VocabularyFromCounts(counts)
+	built := analyzer.BuildVocabulary(commands)
+	if len(fromCounts) != 2 || fromCounts["git"] != 6 || fromCounts["ls"] != 3 {
+		t.Errorf("VocabularyFromCounts = %v, want git 6 and ls 3", fromCounts)
+	}
+	for base, count := range built {
+		if fromCounts[base] != count {
+			t.Errorf("vocabulary of %s: %d from counts, %d from history", base, fromCounts[base], count)
+		}
+	}
+}
+
+func TestSuggestCorrection(t *testing.T) {
+	analyzer := analytics.NewTypoAnalyzer()
+	vocabulary := analytics.Vocabulary{"git": 20, "npm": 8}
+
+	tests := []struct {
+		command  string
+		expected string
+		ok       bool
+	}{
+		{"gti push origin main", "git push origin main", true},
+		{"nmp install", "npm install", true},
+		{"sudo FOO=1 nmp install", "sudo FOO=1 npm install", true},
+		{"time /usr/bin/gti log", "time /usr/bin/git log", true},
+		{"git status", "", false},
+		{"completelyunknown", "", false},
+	}
+
+	for _, tt := range tests {
+		got, ok := analyzer.SuggestCorrection(tt.command, vocabulary)
+		if ok != tt.ok || got != tt.expected {
+			t.Errorf("SuggestCorrection(%q) = %q, %v; expected %q, %v", tt.command, got, ok, tt.expected, tt.ok)
+		}
+	}
+}
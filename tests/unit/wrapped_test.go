@@ -0,0 +1,199 @@
+package unit
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/oiahoon/termonaut/internal/gamification"
+	"github.com/oiahoon/termonaut/internal/report"
+	"github.com/oiahoon/termonaut/pkg/models"
+)
+
+func wrappedCommands() []*models.Command {
+	var commands []*models.Command
+	add := func(at time.Time, command string, exitCode int, durationMS int64) {
+		commands = append(commands, &models.Command{
+			Command:    command,
+			ExitCode:   exitCode,
+			Timestamp:  at,
+			DurationMS: durationMS,
+		})
+	}
+	day := func(year int, month time.Month, d, hour int) time.Time {
+		return time.Date(year, month, d, hour, 0, 0, 0, time.UTC)
+	}
+
+	// 2023: git and make only
+	add(day(2023, 5, 1, 10), "git status", 0, 0)
+	add(day(2023, 5, 2, 10), "make build", 0, 0)
+	add(day(2023, 5, 2, 11), "make build", 0, 0)
+
+	// 2024: a three-day streak in March, kubectl is new, go build is slow
+	for d := 10; d <= 12; d++ {
+		add(day(2024, 3, d, 23), "git status", 0, 0)
+	}
+	for i := 0; i < 4; i++ {
+		add(day(2024, 3, 11, 22), "go build ./...", 0, 60000)
+	}
+	add(day(2024, 3, 20, 23), "kubectl get pods", 1, 500)
+	add(day(2024, 3, 21, 23), "kubectl get pods", 0, 500)
+
+	return commands
+}
+
+func TestBuildWrapped(t *testing.T) {
+	now := time.Date(2024, 7, 1, 12, 0, 0, 0, time.UTC)
+	achievements := map[string]*gamification.UserAchievement{
+		"first": {
+			Achievement: &gamification.Achievement{ID: "first", Name: "First Steps", Icon: "🚀"},
+			EarnedAt:    time.Date(2024, 3, 10, 23, 0, 0, 0, time.UTC),
+			Completed:   true,
+		},
+		"old": {
+			Achievement: &gamification.Achievement{ID: "old", Name: "Veteran", Icon: "🎖️"},
+			EarnedAt:    time.Date(2023, 5, 1, 10, 0, 0, 0, time.UTC),
+			Completed:   true,
+		},
+	}
+
+	w := report.NewBuilder().BuildWrapped(2024, now, wrappedCommands(), achievements)
+
+	if w.TotalCommands != 9 || w.ActiveDays != 5 {
+		t.Errorf("totals = %d commands on %d days, want 9 on 5", w.TotalCommands, w.ActiveDays)
+	}
+	if !w.End.Equal(now) {
+		t.Errorf("end = %v, want now for the current year", w.End)
+	}
+	if len(w.TopTools) == 0 || w.TopTools[0].Tool != "go" || w.TopTools[0].Count != 4 {
+		t.Errorf("top tools = %+v, want go first with 4", w.TopTools)
+	}
+	if w.BusiestDay.Count != 5 || w.BusiestDay.Date.Day() != 11 {
+		t.Errorf("busiest day = %+v, want March 11 with 5", w.BusiestDay)
+	}
+	if w.LongestStreak != 3 {
+		t.Errorf("longest streak = %d, want 3", w.LongestStreak)
+	}
+	if len(w.TimeSinks) == 0 || w.TimeSinks[0].Command != "go build ./..." {
+		t.Errorf("time sinks = %+v, want go build first", w.TimeSinks)
+	}
+
+	newTools := map[string]bool{}
+	for _, tool := range w.NewTools {
+		newTools[tool.Tool] = true
+	}
+	if !newTools["kubectl"] || !newTools["go"] || newTools["git"] {
+		t.Errorf("new tools = %+v, want go and kubectl but not git", w.NewTools)
+	}
+	if len(w.Achievements) != 1 || w.Achievements[0].Name != "First Steps" {
+		t.Errorf("achievements = %+v, want only First Steps", w.Achievements)
+	}
+}
+
+func TestWrappedPersonality(t *testing.T) {
+	now := time.Date(2024, 7, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		hours []int
+		want  string
+	}{
+		{"evenings", []int{18, 19, 20, 21, 22, 23}, "Night Owl"},
+		{"office hours", []int{9, 10, 11, 12, 13, 14, 15, 16}, "Standard Hours"},
+		{"one late command", []int{23}, "Free Spirit"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var commands []*models.Command
+			// March 4-8, 2024 is Monday to Friday
+			for day := 4; day <= 8; day++ {
+				for _, hour := range tt.hours {
+					commands = append(commands, &models.Command{
+						Command:   "ls",
+						Timestamp: time.Date(2024, 3, day, hour, 0, 0, 0, time.UTC),
+					})
+				}
+			}
+
+			w := report.NewBuilder().BuildWrapped(2024, now, commands, nil)
+			if w.Personality.Name != tt.want {
+				t.Errorf("personality = %q, want %q", w.Personality.Name, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildWrappedEmptyYear(t *testing.T) {
+	now := time.Date(2024, 7, 1, 12, 0, 0, 0, time.UTC)
+	w := report.NewBuilder().BuildWrapped(2022, now, wrappedCommands(), nil)
+
+	if w.TotalCommands != 0 || w.Personality.Name != "Newcomer" {
+		t.Errorf("empty year = %d commands, personality %q", w.TotalCommands, w.Personality.Name)
+	}
+	if w.DaysInYear != 365 {
+		t.Errorf("days in year = %d, want 365", w.DaysInYear)
+	}
+}
+
+func TestWrappedCompareTo(t *testing.T) {
+	now := time.Date(2024, 7, 1, 12, 0, 0, 0, time.UTC)
+	builder := report.NewBuilder()
+	w := builder.BuildWrapped(2024, now, wrappedCommands(), nil)
+	w.CompareTo(builder.BuildWrapped(2023, now, wrappedCommands(), nil))
+
+	c := w.Comparison
+	if c == nil || c.Year != 2023 || c.TotalCommands != 3 {
+		t.Fatalf("comparison = %+v", c)
+	}
+	if c.CommandsChange != 200 {
+		t.Errorf("commands change = %.1f, want 200", c.CommandsChange)
+	}
+	if strings.Join(c.FadingTools, ",") != "make" {
+		t.Errorf("fading tools = %v, want [make]", c.FadingTools)
+	}
+	rising := strings.Join(c.RisingTools, ",")
+	if !strings.Contains(rising, "go") || !strings.Contains(rising, "kubectl") || strings.Contains(rising, "git") {
+		t.Errorf("rising tools = %v, want go and kubectl", c.RisingTools)
+	}
+}
+
+func TestRenderWrapped(t *testing.T) {
+	now := time.Date(2024, 7, 1, 12, 0, 0, 0, time.UTC)
+	commands := append(wrappedCommands(), &models.Command{
+		Command:   "<script>",
+		Timestamp: time.Date(2024, 4, 1, 9, 0, 0, 0, time.UTC),
+	})
+	builder := report.NewBuilder()
+	w := builder.BuildWrapped(2024, now, commands, nil)
+	w.CompareTo(builder.BuildWrapped(2023, now, commands, nil))
+
+	tests := []struct {
+		format report.Format
+		want   []string
+		reject []string
+	}{
+		{report.FormatText, []string{"# 🎁 Termonaut Wrapped 2024", "Terminal Personality:", "`go`", "Compared with 2023", "+200.0%"}, nil},
+		{report.FormatHTML, []string{"<!DOCTYPE html>", "Termonaut Wrapped 2024", "&lt;script&gt;"}, []string{"<script>"}},
+		{report.FormatSVG, []string{"<svg", "</svg>", "vs 2023", "&lt;script&gt;"}, []string{"<script>"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.format), func(t *testing.T) {
+			output, err := report.RenderWrapped(w, tt.format)
+			if err != nil {
+				t.Fatalf("RenderWrapped: %v", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(output, want) {
+					t.Errorf("output missing %q", want)
+				}
+			}
+			for _, reject := range tt.reject {
+				if strings.Contains(output, reject) {
+					t.Errorf("output contains unescaped %q", reject)
+				}
+			}
+		})
+	}
+}